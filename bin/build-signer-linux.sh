@@ -0,0 +1,20 @@
+#!/bin/sh
+
+. ./bin/common.sh
+
+rm -fR dist/release/linux64/stmpoolsigner
+mkdir -p dist/release/linux64/stmpoolsigner
+mkdir -p dist/archives/v$VERSION
+
+echo "Building pool signer (linux64)"
+go_build \
+  dist/release/linux64/stmpoolsigner/stmpoolsigner \
+  ./cmd/stmpoolsigner
+
+ZIPFILE="stmpoolsigner-linux64-$VERSION.tar.gz"
+
+rm -f dist/archives/v$VERSION/$ZIPFILE
+
+cd dist/release/linux64 && tar -czf ../../archives/v$VERSION/$ZIPFILE stmpoolsigner
+
+echo "Built pool signer binaries $VERSION"
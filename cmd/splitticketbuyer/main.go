@@ -56,15 +56,17 @@ func main() {
 
 	logDir := path.Join(cfg.DataDir, "logs")
 	reporter := buyer.NewWriterReporter(buyer.NewLoggerMiddleware(os.Stdout, logDir),
-		cfg.SessionName)
+		cfg.Pool, cfg.SessionName)
 	ctx := context.WithValue(context.Background(), buyer.ReporterCtxKey, reporter)
 	ctx, cancelFunc := context.WithCancel(ctx)
 
 	go buyer.WatchMatcherWaitingList(ctx, cfg.MatcherHost, cfg.MatcherCertFile,
-		reporter)
+		cfg.MatcherClientCertificate(), cfg.ProxyConfig(), reporter)
 
 	err = buyer.BuySplitTicket(ctx, cfg)
-	if err == nil {
+	if err == nil && cfg.DryRun {
+		fmt.Printf("Dry run completed successfully!\n")
+	} else if err == nil {
 		fmt.Printf("Success buying split ticket!\n")
 	}
 
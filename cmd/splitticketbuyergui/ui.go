@@ -267,13 +267,14 @@ func participate(logf logFunc, passphrase, sessionName string,
 	splitResultChan := make(chan error)
 	logDir := path.Join(cfg.DataDir, "logs")
 	reporter := buyer.NewWriterReporter(buyer.NewLoggerMiddleware(logChan, logDir),
-		cfg.SessionName)
+		cfg.Pool, cfg.SessionName)
 	ctx := context.WithValue(context.Background(), buyer.ReporterCtxKey, reporter)
 	ctx, cancel := context.WithCancel(ctx)
 
 	go func() {
 		go buyer.WatchMatcherWaitingList(ctx, cfg.MatcherHost,
-			cfg.MatcherCertFile, reporter)
+			cfg.MatcherCertFile, cfg.MatcherClientCertificate(),
+			cfg.ProxyConfig(), reporter)
 		splitResultChan <- buyer.BuySplitTicket(ctx, cfg)
 	}()
 
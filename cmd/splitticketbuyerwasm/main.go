@@ -206,7 +206,7 @@ func buyerLoop() {
 
 	// FIXME: validate cfg
 
-	reporter := buyer.NewWriterReporter(jsReporter{}, cfg.SessionName)
+	reporter := buyer.NewWriterReporter(jsReporter{}, cfg.Pool, cfg.SessionName)
 	ctx := context.WithValue(context.Background(), buyer.ReporterCtxKey, reporter)
 
 	err := buyer.BuySplitTicket(ctx, &cfg)
@@ -14,6 +14,8 @@ import (
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type jsMatcherClient struct{}
@@ -52,6 +54,11 @@ func (c *jsMatcherClient) BuyerError(ctx context.Context, in *pb.BuyerErrorReque
 	return out, jsGrpcCall(jsObject.Get("matcher"), "buyerError", in, out)
 }
 
+func (c *jsMatcherClient) EstimateWait(ctx context.Context, in *pb.EstimateWaitRequest, opts ...grpc.CallOption) (*pb.EstimateWaitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "wait estimates not yet "+
+		"supported by the js client")
+}
+
 func (c *jsMatcherClient) FetchSpentUtxos(tx *wire.MsgTx) (splitticket.UtxoMap, error) {
 
 	reqOutpoints := make([]interface{}, len(tx.TxIn))
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"syscall/js"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -58,6 +59,15 @@ func (c *jsWalletClient) TicketPrice(ctx context.Context, in *pb.TicketPriceRequ
 	return out, jsGrpcCall(jsObject.Get("wallet"), "ticketPrice", in, out)
 }
 
+func (c *jsWalletClient) PublishTransaction(ctx context.Context, in *pb.PublishTransactionRequest, opts ...grpc.CallOption) (*pb.PublishTransactionResponse, error) {
+	out := new(pb.PublishTransactionResponse)
+	return out, jsGrpcCall(jsObject.Get("wallet"), "publishTransaction", in, out)
+}
+
+func (c *jsWalletClient) UnspentOutputs(ctx context.Context, in *pb.UnspentOutputsRequest) ([]*pb.UnspentOutputResponse, error) {
+	return nil, errors.New("coin control is not supported on the wasm buyer")
+}
+
 func (c *jsWalletClient) MonitorForSessionTransactions(ctx context.Context,
 	splitHash *chainhash.Hash, ticketsHashes []*chainhash.Hash) error {
 
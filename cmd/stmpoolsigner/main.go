@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/poolsigner"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/version"
+)
+
+func main() {
+	cfg, err := poolsigner.LoadConfig()
+	if err != nil {
+		if err == poolsigner.ErrHelpRequested {
+			return
+		} else if err == poolsigner.ErrVersionRequested {
+			fmt.Printf("Split ticket matcher pool signer version %s\n",
+				version.String())
+			return
+		}
+
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	d, err := poolsigner.NewDaemon(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	log.Fatal(d.ListenAndServe())
+}
@@ -0,0 +1,173 @@
+// sweeppoolfees finds the pool fee outputs of split transactions recorded in
+// a dcrstmd sessions dir (by default ~/.dcrstmd/sessions) that were not spent
+// by their tickets (eg. because the ticket was never mined) and sweeps them
+// into a given address. The keys are read from the SplitPoolSignKey or
+// SplitPoolSignXPriv settings of a dcrstmd or stmpoolsigner config file.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/rpcclient"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/poolsigner"
+)
+
+func orPanic(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+type config struct {
+	RPCServer   string  `short:"s" long:"rpcserver" description:"Address of the dcrd daemon"`
+	RPCUser     string  `short:"u" long:"rpcuser" description:"RPC user to connect to dcrd"`
+	RPCPass     string  `short:"P" long:"rpcpass" description:"RPC password to connect to dcrd"`
+	RPCCert     string  `short:"c" long:"rpccert" description:"RPC certificate location"`
+	TestNet     bool    `long:"testnet" description:"Whether to connect to a testnet host"`
+	SimNet      bool    `long:"simnet" description:"Whether to connect to a simnet host"`
+	SessionsDir string  `short:"d" long:"sessionsdir" description:"Path to the sessions dir of dcrstmd"`
+	ConfigFile  string  `short:"C" long:"configfile" description:"Path to the dcrstmd or stmpoolsigner config file with the pool fee keys"`
+	DestAddress string  `long:"destaddress" description:"Address to send the swept funds to"`
+	FeeRate     float64 `long:"feerate" description:"Fee rate (in DCR/KB) of the sweep transaction. If 0, the highest fee rate negotiated in the swept sessions is used"`
+	Publish     bool    `long:"publish" description:"Whether to publish the sweep transaction (otherwise, it is only printed)"`
+}
+
+// keysConfig are the settings read from the config file of dcrstmd or
+// stmpoolsigner.
+type keysConfig struct {
+	SplitPoolSignKey   string `long:"splitpoolsignkey"`
+	SplitPoolSignXPriv string `long:"splitpoolsignxpriv"`
+}
+
+func readConfig() *config {
+	cfg := &config{
+		RPCUser:     "USER",
+		RPCPass:     "PASSWORD",
+		RPCServer:   "",
+		RPCCert:     path.Join(dcrutil.AppDataDir("dcrd", false), "rpc.cert"),
+		SessionsDir: path.Join(dcrutil.AppDataDir("dcrstmd", false), "sessions"),
+		ConfigFile:  path.Join(dcrutil.AppDataDir("dcrstmd", false), "dcrstmd.conf"),
+	}
+
+	parser := flags.NewParser(cfg, flags.Default)
+	_, err := parser.Parse()
+	if err != nil {
+		e, ok := err.(*flags.Error)
+		if ok && e.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
+		fmt.Printf("Command Line Parsing Error: %v\n", err)
+		parser.WriteHelp(os.Stderr)
+		os.Exit(1)
+	}
+
+	if cfg.RPCServer == "" {
+		if cfg.TestNet {
+			cfg.RPCServer = "127.0.0.1:19109"
+		} else if cfg.SimNet {
+			cfg.RPCServer = "127.0.0.1:19556"
+		} else {
+			cfg.RPCServer = "127.0.0.1:9109"
+		}
+	}
+
+	return cfg
+}
+
+func readKeysConfig(fname string) *keysConfig {
+	keysCfg := new(keysConfig)
+	parser := flags.NewParser(keysCfg, flags.IgnoreUnknown)
+	err := flags.NewIniParser(parser).ParseFile(fname)
+	orPanic(err)
+	return keysCfg
+}
+
+func connectToDcrd(cfg *config) (*rpcclient.Client, error) {
+	certs, err := ioutil.ReadFile(cfg.RPCCert)
+	if err != nil {
+		return nil, err
+	}
+	connCfg := &rpcclient.ConnConfig{
+		Host:         cfg.RPCServer,
+		Endpoint:     "ws",
+		User:         cfg.RPCUser,
+		Pass:         cfg.RPCPass,
+		Certificates: certs,
+	}
+	return rpcclient.New(connCfg, nil)
+}
+
+func main() {
+	cfg := readConfig()
+	chainParams := &chaincfg.MainNetParams
+	if cfg.TestNet {
+		chainParams = &chaincfg.TestNet3Params
+	} else if cfg.SimNet {
+		chainParams = &chaincfg.SimNetParams
+	}
+
+	destAddr, err := dcrutil.DecodeAddress(cfg.DestAddress)
+	orPanic(err)
+	if !destAddr.IsForNet(chainParams) {
+		orPanic(fmt.Errorf("destination address is not for the current network"))
+	}
+
+	feeRate, err := dcrutil.NewAmount(cfg.FeeRate)
+	orPanic(err)
+
+	// The index file is not used, given the sweep only uses the key indices
+	// recorded in the sessions.
+	keysCfg := readKeysConfig(cfg.ConfigFile)
+	signer, err := poolsigner.NewSigner(keysCfg.SplitPoolSignKey,
+		keysCfg.SplitPoolSignXPriv, "", chainParams)
+	orPanic(err)
+
+	client, err := connectToDcrd(cfg)
+	orPanic(err)
+
+	log := slog.NewBackend(os.Stderr).Logger("SWEP")
+	stuck, err := poolsigner.FindStuckPoolFeeOutputs(cfg.SessionsDir, log,
+		func(outp *wire.OutPoint) (bool, error) {
+			res, err := client.GetTxOut(&outp.Hash, outp.Index, true)
+			return res != nil, err
+		})
+	orPanic(err)
+
+	if len(stuck) == 0 {
+		fmt.Println("No unspent pool fee outputs found")
+		return
+	}
+
+	var total dcrutil.Amount
+	for _, out := range stuck {
+		fmt.Printf("%s  %s  (key index %d)\n", out.OutPoint,
+			dcrutil.Amount(out.Output.Value), out.KeyIndex)
+		total += dcrutil.Amount(out.Output.Value)
+	}
+	fmt.Printf("Total: %s in %d outputs\n\n", total, len(stuck))
+
+	if feeRate == 0 {
+		feeRate = poolsigner.SweepFeeRate(stuck)
+	}
+
+	tx, err := poolsigner.CreateSweepTx(stuck, signer, destAddr, feeRate)
+	orPanic(err)
+
+	txBytes, err := tx.Bytes()
+	orPanic(err)
+	fmt.Printf("Sweep transaction %s:\n%x\n", tx.TxHash(), txBytes)
+
+	if cfg.Publish {
+		_, err = client.SendRawTransaction(tx, false)
+		orPanic(err)
+		fmt.Println("\nPublished sweep transaction")
+	}
+}
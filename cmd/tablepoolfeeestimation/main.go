@@ -34,6 +34,7 @@ func main() {
 	blockHeight := 315000                       // block height where ticket is purchased
 	ticketPrice := dcrutil.Amount(120 * 1e8)    // ticket price
 	minPartReward, _ := dcrutil.NewAmount(0.05) // how many coins from the reward the minimum participant should receive
+	txFeeRate := splitticket.TxFeeRate          // fee rate (Atoms/KB) of the split and ticket txs
 
 	// from here on, everything is calculated given the above parameters
 
@@ -45,12 +46,13 @@ func main() {
 		maxParts = maxPossibleParts
 	}
 	maxPoolFee := splitticket.SessionPoolFee(maxParts, ticketPrice,
-		blockHeight, poolFeeRate, net)
+		blockHeight, poolFeeRate, txFeeRate, net)
 	minPartPoolFee := minPartAmount * maxPoolFee / ticketPrice
 
 	fmt.Printf("Ticket Price: %s\n", ticketPrice)
 	fmt.Printf("Block Height: %d\n", blockHeight)
 	fmt.Printf("Pool Fee Rate: %.2f%%\n", poolFeeRate)
+	fmt.Printf("Tx Fee Rate: %s/KB\n", txFeeRate)
 	fmt.Printf("Minimum future reward: %s\n", minReward)
 	fmt.Printf("Minimum participation amount: %s\n", minPartAmount)
 	fmt.Printf("Pool fee at minimum participation: %s\n", minPartPoolFee)
@@ -60,12 +62,12 @@ func main() {
 	for p := 1; p <= maxParts; p++ {
 		size := splitticket.TicketSizeEstimate(p)
 
-		ticketFee := splitticket.SessionFeeEstimate(p)
+		ticketFee := splitticket.SessionFeeEstimate(p, txFeeRate)
 
 		poolFee := splitticket.SessionPoolFee(p, ticketPrice,
-			blockHeight, poolFeeRate, net)
+			blockHeight, poolFeeRate, txFeeRate, net)
 
-		partTxFee := splitticket.SessionParticipantFee(p)
+		partTxFee := splitticket.SessionParticipantFee(p, txFeeRate)
 
 		profitMinPart := minPartReward - partTxFee - minPartPoolFee
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"strings"
@@ -12,8 +13,11 @@ import (
 )
 
 type config struct {
-	Host     string `long:"host" description:"Address of the matcher service"`
-	CertFile string `long:"certfile" description:"Path to certificate file when connecting to custom matchers"`
+	Host       string `long:"host" description:"Address of the matcher service"`
+	CertFile   string `long:"certfile" description:"Path to certificate file when connecting to custom matchers"`
+	Proxy      string `long:"proxy" description:"Connect through the SOCKS5 proxy at this address (eg: 127.0.0.1:9050 for Tor). No SRV lookup is done for the matcher host when using a proxy."`
+	ClientCert string `long:"clientcert" description:"Path to the client certificate presented to matchers that require client authentication"`
+	ClientKey  string `long:"clientkey" description:"Path to the private key of the client certificate"`
 }
 
 type stdoutListWatcher struct{}
@@ -31,6 +35,9 @@ func (w *stdoutListWatcher) WaitingListChanged(queues []matcher.WaitingQueue) {
 		for i, a := range q.Amounts {
 			strs[i] = a.String()
 		}
+		if q.Pool != "" {
+			sessName = q.Pool + "/" + sessName
+		}
 		fmt.Printf("Waiting participants (%s): [%s]\n", sessName, strings.Join(strs, ", "))
 	}
 }
@@ -56,9 +63,25 @@ func main() {
 
 	fmt.Println("Starting to watch waiting list")
 
+	var proxyCfg *buyer.ProxyConfig
+	if cfg.Proxy != "" {
+		proxyCfg = &buyer.ProxyConfig{Addr: cfg.Proxy}
+	}
+
+	var clientCert *tls.Certificate
+	if cfg.ClientCert != "" {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			fmt.Printf("Error loading client certificate: %v\n", err)
+			os.Exit(1)
+		}
+		clientCert = &cert
+	}
+
 	ctx := context.Background()
 	err = buyer.WatchMatcherWaitingList(ctx, cfg.Host, cfg.CertFile,
-		&stdoutListWatcher{})
+		clientCert, proxyCfg, &stdoutListWatcher{})
 	if err != nil {
 		panic(err)
 	}
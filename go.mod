@@ -1,6 +1,7 @@
 module github.com/matheusd/dcr-split-ticket-matcher
 
 require (
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
 	github.com/dchest/blake256 v1.0.0
 	github.com/decred/dcrd/blockchain v1.0.2
 	github.com/decred/dcrd/blockchain/stake v1.0.2
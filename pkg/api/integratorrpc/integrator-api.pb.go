@@ -1,18 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: integrator-api.proto
 
-/*
-Package integratorrpc is a generated protocol buffer package.
-
-It is generated from these files:
-	integrator-api.proto
-
-It has these top-level messages:
-	ValidateVoteAddressRequest
-	ValidateVoteAddressResponse
-	ValidatePoolSubsidyAddressRequest
-	ValidatePoolSubsidyAddressResponse
-*/
 package integratorrpc
 
 import proto "github.com/golang/protobuf/proto"
@@ -36,13 +24,35 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type ValidateVoteAddressRequest struct {
-	Address string `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	Address              string   `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ValidateVoteAddressRequest) Reset()                    { *m = ValidateVoteAddressRequest{} }
-func (m *ValidateVoteAddressRequest) String() string            { return proto.CompactTextString(m) }
-func (*ValidateVoteAddressRequest) ProtoMessage()               {}
-func (*ValidateVoteAddressRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+func (m *ValidateVoteAddressRequest) Reset()         { *m = ValidateVoteAddressRequest{} }
+func (m *ValidateVoteAddressRequest) String() string { return proto.CompactTextString(m) }
+func (*ValidateVoteAddressRequest) ProtoMessage()    {}
+func (*ValidateVoteAddressRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{0}
+}
+func (m *ValidateVoteAddressRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateVoteAddressRequest.Unmarshal(m, b)
+}
+func (m *ValidateVoteAddressRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateVoteAddressRequest.Marshal(b, m, deterministic)
+}
+func (dst *ValidateVoteAddressRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateVoteAddressRequest.Merge(dst, src)
+}
+func (m *ValidateVoteAddressRequest) XXX_Size() int {
+	return xxx_messageInfo_ValidateVoteAddressRequest.Size(m)
+}
+func (m *ValidateVoteAddressRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateVoteAddressRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidateVoteAddressRequest proto.InternalMessageInfo
 
 func (m *ValidateVoteAddressRequest) GetAddress() string {
 	if m != nil {
@@ -52,13 +62,35 @@ func (m *ValidateVoteAddressRequest) GetAddress() string {
 }
 
 type ValidateVoteAddressResponse struct {
-	Error string `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ValidateVoteAddressResponse) Reset()         { *m = ValidateVoteAddressResponse{} }
+func (m *ValidateVoteAddressResponse) String() string { return proto.CompactTextString(m) }
+func (*ValidateVoteAddressResponse) ProtoMessage()    {}
+func (*ValidateVoteAddressResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{1}
+}
+func (m *ValidateVoteAddressResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidateVoteAddressResponse.Unmarshal(m, b)
+}
+func (m *ValidateVoteAddressResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidateVoteAddressResponse.Marshal(b, m, deterministic)
+}
+func (dst *ValidateVoteAddressResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidateVoteAddressResponse.Merge(dst, src)
+}
+func (m *ValidateVoteAddressResponse) XXX_Size() int {
+	return xxx_messageInfo_ValidateVoteAddressResponse.Size(m)
+}
+func (m *ValidateVoteAddressResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidateVoteAddressResponse.DiscardUnknown(m)
 }
 
-func (m *ValidateVoteAddressResponse) Reset()                    { *m = ValidateVoteAddressResponse{} }
-func (m *ValidateVoteAddressResponse) String() string            { return proto.CompactTextString(m) }
-func (*ValidateVoteAddressResponse) ProtoMessage()               {}
-func (*ValidateVoteAddressResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+var xxx_messageInfo_ValidateVoteAddressResponse proto.InternalMessageInfo
 
 func (m *ValidateVoteAddressResponse) GetError() string {
 	if m != nil {
@@ -68,15 +100,35 @@ func (m *ValidateVoteAddressResponse) GetError() string {
 }
 
 type ValidatePoolSubsidyAddressRequest struct {
-	Address string `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	Address              string   `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ValidatePoolSubsidyAddressRequest) Reset()         { *m = ValidatePoolSubsidyAddressRequest{} }
 func (m *ValidatePoolSubsidyAddressRequest) String() string { return proto.CompactTextString(m) }
 func (*ValidatePoolSubsidyAddressRequest) ProtoMessage()    {}
 func (*ValidatePoolSubsidyAddressRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{2}
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{2}
+}
+func (m *ValidatePoolSubsidyAddressRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressRequest.Unmarshal(m, b)
+}
+func (m *ValidatePoolSubsidyAddressRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressRequest.Marshal(b, m, deterministic)
 }
+func (dst *ValidatePoolSubsidyAddressRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidatePoolSubsidyAddressRequest.Merge(dst, src)
+}
+func (m *ValidatePoolSubsidyAddressRequest) XXX_Size() int {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressRequest.Size(m)
+}
+func (m *ValidatePoolSubsidyAddressRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidatePoolSubsidyAddressRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidatePoolSubsidyAddressRequest proto.InternalMessageInfo
 
 func (m *ValidatePoolSubsidyAddressRequest) GetAddress() string {
 	if m != nil {
@@ -86,15 +138,35 @@ func (m *ValidatePoolSubsidyAddressRequest) GetAddress() string {
 }
 
 type ValidatePoolSubsidyAddressResponse struct {
-	Error string `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ValidatePoolSubsidyAddressResponse) Reset()         { *m = ValidatePoolSubsidyAddressResponse{} }
 func (m *ValidatePoolSubsidyAddressResponse) String() string { return proto.CompactTextString(m) }
 func (*ValidatePoolSubsidyAddressResponse) ProtoMessage()    {}
 func (*ValidatePoolSubsidyAddressResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{3}
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{3}
+}
+func (m *ValidatePoolSubsidyAddressResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressResponse.Unmarshal(m, b)
+}
+func (m *ValidatePoolSubsidyAddressResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressResponse.Marshal(b, m, deterministic)
+}
+func (dst *ValidatePoolSubsidyAddressResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidatePoolSubsidyAddressResponse.Merge(dst, src)
+}
+func (m *ValidatePoolSubsidyAddressResponse) XXX_Size() int {
+	return xxx_messageInfo_ValidatePoolSubsidyAddressResponse.Size(m)
 }
+func (m *ValidatePoolSubsidyAddressResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidatePoolSubsidyAddressResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidatePoolSubsidyAddressResponse proto.InternalMessageInfo
 
 func (m *ValidatePoolSubsidyAddressResponse) GetError() string {
 	if m != nil {
@@ -103,11 +175,380 @@ func (m *ValidatePoolSubsidyAddressResponse) GetError() string {
 	return ""
 }
 
+type VoteChoice struct {
+	AgendaId             string   `protobuf:"bytes,1,opt,name=agenda_id,json=agendaId" json:"agenda_id,omitempty"`
+	ChoiceId             string   `protobuf:"bytes,2,opt,name=choice_id,json=choiceId" json:"choice_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VoteChoice) Reset()         { *m = VoteChoice{} }
+func (m *VoteChoice) String() string { return proto.CompactTextString(m) }
+func (*VoteChoice) ProtoMessage()    {}
+func (*VoteChoice) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{4}
+}
+func (m *VoteChoice) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VoteChoice.Unmarshal(m, b)
+}
+func (m *VoteChoice) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VoteChoice.Marshal(b, m, deterministic)
+}
+func (dst *VoteChoice) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VoteChoice.Merge(dst, src)
+}
+func (m *VoteChoice) XXX_Size() int {
+	return xxx_messageInfo_VoteChoice.Size(m)
+}
+func (m *VoteChoice) XXX_DiscardUnknown() {
+	xxx_messageInfo_VoteChoice.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VoteChoice proto.InternalMessageInfo
+
+func (m *VoteChoice) GetAgendaId() string {
+	if m != nil {
+		return m.AgendaId
+	}
+	return ""
+}
+
+func (m *VoteChoice) GetChoiceId() string {
+	if m != nil {
+		return m.ChoiceId
+	}
+	return ""
+}
+
+type SetVoteChoicesRequest struct {
+	TicketHash           []byte        `protobuf:"bytes,1,opt,name=ticket_hash,json=ticketHash,proto3" json:"ticket_hash,omitempty"`
+	VoteAddress          string        `protobuf:"bytes,2,opt,name=vote_address,json=voteAddress" json:"vote_address,omitempty"`
+	CommitmentAddress    string        `protobuf:"bytes,3,opt,name=commitment_address,json=commitmentAddress" json:"commitment_address,omitempty"`
+	Choices              []*VoteChoice `protobuf:"bytes,4,rep,name=choices" json:"choices,omitempty"`
+	Signature            []byte        `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *SetVoteChoicesRequest) Reset()         { *m = SetVoteChoicesRequest{} }
+func (m *SetVoteChoicesRequest) String() string { return proto.CompactTextString(m) }
+func (*SetVoteChoicesRequest) ProtoMessage()    {}
+func (*SetVoteChoicesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{5}
+}
+func (m *SetVoteChoicesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetVoteChoicesRequest.Unmarshal(m, b)
+}
+func (m *SetVoteChoicesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetVoteChoicesRequest.Marshal(b, m, deterministic)
+}
+func (dst *SetVoteChoicesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetVoteChoicesRequest.Merge(dst, src)
+}
+func (m *SetVoteChoicesRequest) XXX_Size() int {
+	return xxx_messageInfo_SetVoteChoicesRequest.Size(m)
+}
+func (m *SetVoteChoicesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetVoteChoicesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetVoteChoicesRequest proto.InternalMessageInfo
+
+func (m *SetVoteChoicesRequest) GetTicketHash() []byte {
+	if m != nil {
+		return m.TicketHash
+	}
+	return nil
+}
+
+func (m *SetVoteChoicesRequest) GetVoteAddress() string {
+	if m != nil {
+		return m.VoteAddress
+	}
+	return ""
+}
+
+func (m *SetVoteChoicesRequest) GetCommitmentAddress() string {
+	if m != nil {
+		return m.CommitmentAddress
+	}
+	return ""
+}
+
+func (m *SetVoteChoicesRequest) GetChoices() []*VoteChoice {
+	if m != nil {
+		return m.Choices
+	}
+	return nil
+}
+
+func (m *SetVoteChoicesRequest) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type SetVoteChoicesResponse struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	VoteBits             uint32   `protobuf:"varint,2,opt,name=vote_bits,json=voteBits" json:"vote_bits,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetVoteChoicesResponse) Reset()         { *m = SetVoteChoicesResponse{} }
+func (m *SetVoteChoicesResponse) String() string { return proto.CompactTextString(m) }
+func (*SetVoteChoicesResponse) ProtoMessage()    {}
+func (*SetVoteChoicesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{6}
+}
+func (m *SetVoteChoicesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetVoteChoicesResponse.Unmarshal(m, b)
+}
+func (m *SetVoteChoicesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetVoteChoicesResponse.Marshal(b, m, deterministic)
+}
+func (dst *SetVoteChoicesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetVoteChoicesResponse.Merge(dst, src)
+}
+func (m *SetVoteChoicesResponse) XXX_Size() int {
+	return xxx_messageInfo_SetVoteChoicesResponse.Size(m)
+}
+func (m *SetVoteChoicesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetVoteChoicesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetVoteChoicesResponse proto.InternalMessageInfo
+
+func (m *SetVoteChoicesResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SetVoteChoicesResponse) GetVoteBits() uint32 {
+	if m != nil {
+		return m.VoteBits
+	}
+	return 0
+}
+
+type RegisterTicketRequest struct {
+	Ticket               []byte   `protobuf:"bytes,1,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegisterTicketRequest) Reset()         { *m = RegisterTicketRequest{} }
+func (m *RegisterTicketRequest) String() string { return proto.CompactTextString(m) }
+func (*RegisterTicketRequest) ProtoMessage()    {}
+func (*RegisterTicketRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{7}
+}
+func (m *RegisterTicketRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisterTicketRequest.Unmarshal(m, b)
+}
+func (m *RegisterTicketRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisterTicketRequest.Marshal(b, m, deterministic)
+}
+func (dst *RegisterTicketRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisterTicketRequest.Merge(dst, src)
+}
+func (m *RegisterTicketRequest) XXX_Size() int {
+	return xxx_messageInfo_RegisterTicketRequest.Size(m)
+}
+func (m *RegisterTicketRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisterTicketRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisterTicketRequest proto.InternalMessageInfo
+
+func (m *RegisterTicketRequest) GetTicket() []byte {
+	if m != nil {
+		return m.Ticket
+	}
+	return nil
+}
+
+type RegisterTicketResponse struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegisterTicketResponse) Reset()         { *m = RegisterTicketResponse{} }
+func (m *RegisterTicketResponse) String() string { return proto.CompactTextString(m) }
+func (*RegisterTicketResponse) ProtoMessage()    {}
+func (*RegisterTicketResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{8}
+}
+func (m *RegisterTicketResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegisterTicketResponse.Unmarshal(m, b)
+}
+func (m *RegisterTicketResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegisterTicketResponse.Marshal(b, m, deterministic)
+}
+func (dst *RegisterTicketResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegisterTicketResponse.Merge(dst, src)
+}
+func (m *RegisterTicketResponse) XXX_Size() int {
+	return xxx_messageInfo_RegisterTicketResponse.Size(m)
+}
+func (m *RegisterTicketResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegisterTicketResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegisterTicketResponse proto.InternalMessageInfo
+
+func (m *RegisterTicketResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type TicketVotingStatusRequest struct {
+	TicketHash           []byte   `protobuf:"bytes,1,opt,name=ticket_hash,json=ticketHash,proto3" json:"ticket_hash,omitempty"`
+	VoteAddress          string   `protobuf:"bytes,2,opt,name=vote_address,json=voteAddress" json:"vote_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TicketVotingStatusRequest) Reset()         { *m = TicketVotingStatusRequest{} }
+func (m *TicketVotingStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*TicketVotingStatusRequest) ProtoMessage()    {}
+func (*TicketVotingStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{9}
+}
+func (m *TicketVotingStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TicketVotingStatusRequest.Unmarshal(m, b)
+}
+func (m *TicketVotingStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TicketVotingStatusRequest.Marshal(b, m, deterministic)
+}
+func (dst *TicketVotingStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TicketVotingStatusRequest.Merge(dst, src)
+}
+func (m *TicketVotingStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_TicketVotingStatusRequest.Size(m)
+}
+func (m *TicketVotingStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TicketVotingStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TicketVotingStatusRequest proto.InternalMessageInfo
+
+func (m *TicketVotingStatusRequest) GetTicketHash() []byte {
+	if m != nil {
+		return m.TicketHash
+	}
+	return nil
+}
+
+func (m *TicketVotingStatusRequest) GetVoteAddress() string {
+	if m != nil {
+		return m.VoteAddress
+	}
+	return ""
+}
+
+type TicketVotingStatusResponse struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	Status               string   `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	VoteBits             uint32   `protobuf:"varint,3,opt,name=vote_bits,json=voteBits" json:"vote_bits,omitempty"`
+	VoteVersion          uint32   `protobuf:"varint,4,opt,name=vote_version,json=voteVersion" json:"vote_version,omitempty"`
+	SpentBy              []byte   `protobuf:"bytes,5,opt,name=spent_by,json=spentBy,proto3" json:"spent_by,omitempty"`
+	SpentByHeight        uint32   `protobuf:"varint,6,opt,name=spent_by_height,json=spentByHeight" json:"spent_by_height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TicketVotingStatusResponse) Reset()         { *m = TicketVotingStatusResponse{} }
+func (m *TicketVotingStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*TicketVotingStatusResponse) ProtoMessage()    {}
+func (*TicketVotingStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_integrator_api_5feab3a9e7a15e13, []int{10}
+}
+func (m *TicketVotingStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TicketVotingStatusResponse.Unmarshal(m, b)
+}
+func (m *TicketVotingStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TicketVotingStatusResponse.Marshal(b, m, deterministic)
+}
+func (dst *TicketVotingStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TicketVotingStatusResponse.Merge(dst, src)
+}
+func (m *TicketVotingStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_TicketVotingStatusResponse.Size(m)
+}
+func (m *TicketVotingStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TicketVotingStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TicketVotingStatusResponse proto.InternalMessageInfo
+
+func (m *TicketVotingStatusResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *TicketVotingStatusResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *TicketVotingStatusResponse) GetVoteBits() uint32 {
+	if m != nil {
+		return m.VoteBits
+	}
+	return 0
+}
+
+func (m *TicketVotingStatusResponse) GetVoteVersion() uint32 {
+	if m != nil {
+		return m.VoteVersion
+	}
+	return 0
+}
+
+func (m *TicketVotingStatusResponse) GetSpentBy() []byte {
+	if m != nil {
+		return m.SpentBy
+	}
+	return nil
+}
+
+func (m *TicketVotingStatusResponse) GetSpentByHeight() uint32 {
+	if m != nil {
+		return m.SpentByHeight
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*ValidateVoteAddressRequest)(nil), "integratorrpc.ValidateVoteAddressRequest")
 	proto.RegisterType((*ValidateVoteAddressResponse)(nil), "integratorrpc.ValidateVoteAddressResponse")
 	proto.RegisterType((*ValidatePoolSubsidyAddressRequest)(nil), "integratorrpc.ValidatePoolSubsidyAddressRequest")
 	proto.RegisterType((*ValidatePoolSubsidyAddressResponse)(nil), "integratorrpc.ValidatePoolSubsidyAddressResponse")
+	proto.RegisterType((*VoteChoice)(nil), "integratorrpc.VoteChoice")
+	proto.RegisterType((*SetVoteChoicesRequest)(nil), "integratorrpc.SetVoteChoicesRequest")
+	proto.RegisterType((*SetVoteChoicesResponse)(nil), "integratorrpc.SetVoteChoicesResponse")
+	proto.RegisterType((*RegisterTicketRequest)(nil), "integratorrpc.RegisterTicketRequest")
+	proto.RegisterType((*RegisterTicketResponse)(nil), "integratorrpc.RegisterTicketResponse")
+	proto.RegisterType((*TicketVotingStatusRequest)(nil), "integratorrpc.TicketVotingStatusRequest")
+	proto.RegisterType((*TicketVotingStatusResponse)(nil), "integratorrpc.TicketVotingStatusResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -123,6 +564,9 @@ const _ = grpc.SupportPackageIsVersion4
 type VotePoolIntegratorServiceClient interface {
 	ValidateVoteAddress(ctx context.Context, in *ValidateVoteAddressRequest, opts ...grpc.CallOption) (*ValidateVoteAddressResponse, error)
 	ValidatePoolSubsidyAddress(ctx context.Context, in *ValidatePoolSubsidyAddressRequest, opts ...grpc.CallOption) (*ValidatePoolSubsidyAddressResponse, error)
+	SetVoteChoices(ctx context.Context, in *SetVoteChoicesRequest, opts ...grpc.CallOption) (*SetVoteChoicesResponse, error)
+	RegisterTicket(ctx context.Context, in *RegisterTicketRequest, opts ...grpc.CallOption) (*RegisterTicketResponse, error)
+	TicketVotingStatus(ctx context.Context, in *TicketVotingStatusRequest, opts ...grpc.CallOption) (*TicketVotingStatusResponse, error)
 }
 
 type votePoolIntegratorServiceClient struct {
@@ -151,11 +595,41 @@ func (c *votePoolIntegratorServiceClient) ValidatePoolSubsidyAddress(ctx context
 	return out, nil
 }
 
+func (c *votePoolIntegratorServiceClient) SetVoteChoices(ctx context.Context, in *SetVoteChoicesRequest, opts ...grpc.CallOption) (*SetVoteChoicesResponse, error) {
+	out := new(SetVoteChoicesResponse)
+	err := grpc.Invoke(ctx, "/integratorrpc.VotePoolIntegratorService/SetVoteChoices", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *votePoolIntegratorServiceClient) RegisterTicket(ctx context.Context, in *RegisterTicketRequest, opts ...grpc.CallOption) (*RegisterTicketResponse, error) {
+	out := new(RegisterTicketResponse)
+	err := grpc.Invoke(ctx, "/integratorrpc.VotePoolIntegratorService/RegisterTicket", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *votePoolIntegratorServiceClient) TicketVotingStatus(ctx context.Context, in *TicketVotingStatusRequest, opts ...grpc.CallOption) (*TicketVotingStatusResponse, error) {
+	out := new(TicketVotingStatusResponse)
+	err := grpc.Invoke(ctx, "/integratorrpc.VotePoolIntegratorService/TicketVotingStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for VotePoolIntegratorService service
 
 type VotePoolIntegratorServiceServer interface {
 	ValidateVoteAddress(context.Context, *ValidateVoteAddressRequest) (*ValidateVoteAddressResponse, error)
 	ValidatePoolSubsidyAddress(context.Context, *ValidatePoolSubsidyAddressRequest) (*ValidatePoolSubsidyAddressResponse, error)
+	SetVoteChoices(context.Context, *SetVoteChoicesRequest) (*SetVoteChoicesResponse, error)
+	RegisterTicket(context.Context, *RegisterTicketRequest) (*RegisterTicketResponse, error)
+	TicketVotingStatus(context.Context, *TicketVotingStatusRequest) (*TicketVotingStatusResponse, error)
 }
 
 func RegisterVotePoolIntegratorServiceServer(s *grpc.Server, srv VotePoolIntegratorServiceServer) {
@@ -198,6 +672,60 @@ func _VotePoolIntegratorService_ValidatePoolSubsidyAddress_Handler(srv interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VotePoolIntegratorService_SetVoteChoices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVoteChoicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VotePoolIntegratorServiceServer).SetVoteChoices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/integratorrpc.VotePoolIntegratorService/SetVoteChoices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VotePoolIntegratorServiceServer).SetVoteChoices(ctx, req.(*SetVoteChoicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VotePoolIntegratorService_RegisterTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VotePoolIntegratorServiceServer).RegisterTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/integratorrpc.VotePoolIntegratorService/RegisterTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VotePoolIntegratorServiceServer).RegisterTicket(ctx, req.(*RegisterTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VotePoolIntegratorService_TicketVotingStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TicketVotingStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VotePoolIntegratorServiceServer).TicketVotingStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/integratorrpc.VotePoolIntegratorService/TicketVotingStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VotePoolIntegratorServiceServer).TicketVotingStatus(ctx, req.(*TicketVotingStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _VotePoolIntegratorService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "integratorrpc.VotePoolIntegratorService",
 	HandlerType: (*VotePoolIntegratorServiceServer)(nil),
@@ -210,27 +738,62 @@ var _VotePoolIntegratorService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ValidatePoolSubsidyAddress",
 			Handler:    _VotePoolIntegratorService_ValidatePoolSubsidyAddress_Handler,
 		},
+		{
+			MethodName: "SetVoteChoices",
+			Handler:    _VotePoolIntegratorService_SetVoteChoices_Handler,
+		},
+		{
+			MethodName: "RegisterTicket",
+			Handler:    _VotePoolIntegratorService_RegisterTicket_Handler,
+		},
+		{
+			MethodName: "TicketVotingStatus",
+			Handler:    _VotePoolIntegratorService_TicketVotingStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "integrator-api.proto",
 }
 
-func init() { proto.RegisterFile("integrator-api.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 218 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0xc9, 0xcc, 0x2b, 0x49,
-	0x4d, 0x2f, 0x4a, 0x2c, 0xc9, 0x2f, 0xd2, 0x4d, 0x2c, 0xc8, 0xd4, 0x2b, 0x28, 0xca, 0x2f, 0xc9,
-	0x17, 0xe2, 0x45, 0x88, 0x16, 0x15, 0x24, 0x2b, 0x99, 0x71, 0x49, 0x85, 0x25, 0xe6, 0x64, 0xa6,
-	0x24, 0x96, 0xa4, 0x86, 0xe5, 0x97, 0xa4, 0x3a, 0xa6, 0xa4, 0x14, 0xa5, 0x16, 0x17, 0x07, 0xa5,
-	0x16, 0x96, 0xa6, 0x16, 0x97, 0x08, 0x49, 0x70, 0xb1, 0x27, 0x42, 0x44, 0x24, 0x18, 0x15, 0x18,
-	0x35, 0x38, 0x83, 0x60, 0x5c, 0x25, 0x63, 0x2e, 0x69, 0xac, 0xfa, 0x8a, 0x0b, 0xf2, 0xf3, 0x8a,
-	0x53, 0x85, 0x44, 0xb8, 0x58, 0x53, 0x8b, 0x8a, 0xf2, 0x8b, 0xa0, 0xda, 0x20, 0x1c, 0x25, 0x5b,
-	0x2e, 0x45, 0x98, 0xa6, 0x80, 0xfc, 0xfc, 0x9c, 0xe0, 0xd2, 0xa4, 0xe2, 0xcc, 0x94, 0x4a, 0xa2,
-	0xed, 0xb4, 0xe2, 0x52, 0xc2, 0xa7, 0x1d, 0x9f, 0xd5, 0x46, 0xbd, 0x4c, 0x5c, 0x92, 0x20, 0x87,
-	0x82, 0x34, 0x7a, 0xc2, 0x43, 0x20, 0x38, 0xb5, 0xa8, 0x2c, 0x33, 0x39, 0x55, 0x28, 0x87, 0x4b,
-	0x18, 0x8b, 0x6f, 0x84, 0x34, 0xf5, 0x50, 0x02, 0x4b, 0x0f, 0x77, 0x48, 0x49, 0x69, 0x11, 0xa3,
-	0x14, 0xea, 0xc2, 0x46, 0x46, 0x44, 0xa0, 0x63, 0x7a, 0x44, 0xc8, 0x00, 0x87, 0x51, 0x38, 0x83,
-	0x4c, 0xca, 0x90, 0x04, 0x1d, 0x10, 0x37, 0x24, 0xb1, 0x81, 0x53, 0x83, 0x31, 0x20, 0x00, 0x00,
-	0xff, 0xff, 0xbc, 0x21, 0x69, 0x45, 0x25, 0x02, 0x00, 0x00,
+func init() {
+	proto.RegisterFile("integrator-api.proto", fileDescriptor_integrator_api_5feab3a9e7a15e13)
+}
+
+var fileDescriptor_integrator_api_5feab3a9e7a15e13 = []byte{
+	// 560 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xdd, 0x6a, 0x13, 0x41,
+	0x14, 0x26, 0x26, 0xcd, 0xcf, 0x49, 0xa3, 0x38, 0xb6, 0x61, 0xb3, 0x15, 0x6c, 0x17, 0x2b, 0xa9,
+	0xd0, 0xa8, 0x0d, 0x78, 0x21, 0x78, 0x61, 0x05, 0x69, 0xf0, 0x46, 0x36, 0x92, 0x3b, 0x09, 0x93,
+	0xec, 0xb0, 0x3b, 0x98, 0xec, 0xc4, 0x99, 0x93, 0x40, 0x2e, 0x7d, 0x29, 0xdf, 0xc2, 0x47, 0xf0,
+	0x5d, 0x64, 0x67, 0x66, 0xb3, 0xe4, 0x6f, 0x1b, 0xc1, 0xcb, 0xf3, 0x9d, 0xef, 0x7c, 0x73, 0xe6,
+	0x9b, 0x73, 0x76, 0xe1, 0x84, 0xc7, 0xc8, 0x42, 0x49, 0x51, 0xc8, 0x6b, 0x3a, 0xe3, 0x9d, 0x99,
+	0x14, 0x28, 0x48, 0x23, 0x43, 0xe5, 0x6c, 0xec, 0xbd, 0x05, 0x77, 0x40, 0x27, 0x3c, 0xa0, 0xc8,
+	0x06, 0x02, 0xd9, 0x87, 0x20, 0x90, 0x4c, 0x29, 0x9f, 0xfd, 0x98, 0x33, 0x85, 0xc4, 0x81, 0x0a,
+	0x35, 0x88, 0x53, 0x38, 0x2f, 0xb4, 0x6b, 0x7e, 0x1a, 0x7a, 0x5d, 0x38, 0xdb, 0x59, 0xa7, 0x66,
+	0x22, 0x56, 0x8c, 0x9c, 0xc0, 0x11, 0x93, 0x52, 0x48, 0x5b, 0x66, 0x02, 0xef, 0x3d, 0x5c, 0xa4,
+	0x45, 0x5f, 0x84, 0x98, 0xf4, 0xe7, 0x23, 0xc5, 0x83, 0xe5, 0xc1, 0x67, 0xbe, 0x03, 0x2f, 0xaf,
+	0x3c, 0xf7, 0xe8, 0x4f, 0x00, 0x49, 0x9f, 0x1f, 0x23, 0xc1, 0xc7, 0x8c, 0x9c, 0x41, 0x8d, 0x86,
+	0x2c, 0x0e, 0xe8, 0x90, 0x07, 0x96, 0x57, 0x35, 0x40, 0x2f, 0x48, 0x92, 0x63, 0x4d, 0x4b, 0x92,
+	0x0f, 0x4c, 0xd2, 0x00, 0xbd, 0xc0, 0xfb, 0x53, 0x80, 0xd3, 0x3e, 0xc3, 0x4c, 0x6b, 0xd5, 0xf7,
+	0x33, 0xa8, 0x23, 0x1f, 0x7f, 0x67, 0x38, 0x8c, 0xa8, 0x8a, 0xb4, 0xea, 0xb1, 0x0f, 0x06, 0xba,
+	0xa3, 0x2a, 0x22, 0x17, 0x70, 0xbc, 0x10, 0xc8, 0x86, 0xe9, 0xed, 0x8c, 0x74, 0x7d, 0x91, 0xd9,
+	0x47, 0xae, 0x81, 0x8c, 0xc5, 0x74, 0xca, 0x71, 0xca, 0x62, 0x5c, 0x11, 0x8b, 0x9a, 0xf8, 0x38,
+	0xcb, 0xa4, 0xf4, 0x2e, 0x54, 0x4c, 0x63, 0xca, 0x29, 0x9d, 0x17, 0xdb, 0xf5, 0x9b, 0x56, 0x67,
+	0xed, 0x75, 0x3b, 0x59, 0x9b, 0x7e, 0xca, 0x24, 0x4f, 0xa1, 0xa6, 0x78, 0x18, 0x53, 0x9c, 0x4b,
+	0xe6, 0x1c, 0xe9, 0x2e, 0x33, 0xc0, 0xfb, 0x0c, 0xcd, 0xcd, 0xeb, 0xe5, 0xf9, 0x9a, 0x98, 0xa5,
+	0x2f, 0x35, 0xe2, 0x68, 0x6e, 0xd4, 0xf0, 0xab, 0x09, 0x70, 0xcb, 0x51, 0x79, 0xaf, 0xe0, 0xd4,
+	0x67, 0x21, 0x57, 0xc8, 0xe4, 0x57, 0xed, 0x43, 0xea, 0x55, 0x13, 0xca, 0xc6, 0x18, 0x6b, 0x93,
+	0x8d, 0xbc, 0x0e, 0x34, 0x37, 0x0b, 0x72, 0x5f, 0x75, 0x08, 0x2d, 0xc3, 0x1b, 0x08, 0xe4, 0x71,
+	0xd8, 0x47, 0x8a, 0xf3, 0xff, 0xf9, 0x20, 0xde, 0xef, 0x02, 0xb8, 0xbb, 0x4e, 0xc8, 0xf5, 0xa4,
+	0x09, 0x65, 0xa5, 0x79, 0x56, 0xd1, 0x46, 0xeb, 0x5e, 0x15, 0xd7, 0xbd, 0x5a, 0x35, 0xb3, 0x60,
+	0x52, 0x71, 0x11, 0x3b, 0x25, 0x9d, 0xd7, 0xcd, 0x0c, 0x0c, 0x44, 0x5a, 0x50, 0x55, 0xb3, 0x64,
+	0x30, 0x46, 0x4b, 0xfb, 0x70, 0x15, 0x1d, 0xdf, 0x2e, 0xc9, 0x0b, 0x78, 0x94, 0xa6, 0x86, 0x11,
+	0xe3, 0x61, 0x84, 0x4e, 0x59, 0x0b, 0x34, 0x2c, 0xe3, 0x4e, 0x83, 0x37, 0xbf, 0x4a, 0xd0, 0x1a,
+	0x08, 0xb3, 0x3f, 0xbd, 0xd5, 0xa8, 0xf4, 0x99, 0x5c, 0x24, 0x6b, 0x31, 0x81, 0x27, 0x3b, 0x96,
+	0x9a, 0x5c, 0x6d, 0x4e, 0xd5, 0xde, 0x0f, 0x86, 0xfb, 0xf2, 0x10, 0xaa, 0x35, 0xef, 0x67, 0x21,
+	0xfb, 0xf6, 0x6c, 0xef, 0x33, 0x79, 0xbd, 0x47, 0x6a, 0xef, 0x97, 0xc3, 0x7d, 0xf3, 0x0f, 0x15,
+	0xb6, 0x87, 0x6f, 0xf0, 0x70, 0x7d, 0xdc, 0xc9, 0xf3, 0x0d, 0x91, 0x9d, 0xcb, 0xee, 0x5e, 0xde,
+	0xc3, 0xca, 0xe4, 0xd7, 0xe7, 0x79, 0x4b, 0x7e, 0xe7, 0x7e, 0xb8, 0x97, 0xf7, 0xb0, 0xac, 0x3c,
+	0x07, 0xb2, 0x3d, 0x9c, 0xa4, 0xbd, 0x51, 0xbc, 0x77, 0x43, 0xdc, 0xab, 0x03, 0x98, 0xe6, 0xa8,
+	0x51, 0x59, 0xff, 0x3d, 0xba, 0x7f, 0x07, 0x00, 0xf5, 0x2e, 0x9f, 0x22, 0x55, 0x06, 0x00, 0x00,
 }
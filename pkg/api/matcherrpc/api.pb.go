@@ -1,30 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: api.proto
 
-/*
-Package dcrticketmatcher is a generated protocol buffer package.
-
-It is generated from these files:
-	api.proto
-
-It has these top-level messages:
-	TxOut
-	OutPoint
-	WatchWaitingListRequest
-	WatchWaitingListResponse
-	FindMatchesRequest
-	FindMatchesResponse
-	GenerateTicketRequest
-	GenerateTicketResponse
-	FundTicketRequest
-	FundTicketResponse
-	FundSplitTxRequest
-	FundSplitTxResponse
-	StatusRequest
-	StatusResponse
-	BuyerErrorRequest
-	BuyerErrorResponse
-*/
 package dcrticketmatcher
 
 import proto "github.com/golang/protobuf/proto"
@@ -48,14 +24,36 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type TxOut struct {
-	Value  uint64 `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
-	Script []byte `protobuf:"bytes,2,opt,name=script,proto3" json:"script,omitempty"`
+	Value                uint64   `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
+	Script               []byte   `protobuf:"bytes,2,opt,name=script,proto3" json:"script,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *TxOut) Reset()                    { *m = TxOut{} }
-func (m *TxOut) String() string            { return proto.CompactTextString(m) }
-func (*TxOut) ProtoMessage()               {}
-func (*TxOut) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+func (m *TxOut) Reset()         { *m = TxOut{} }
+func (m *TxOut) String() string { return proto.CompactTextString(m) }
+func (*TxOut) ProtoMessage()    {}
+func (*TxOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{0}
+}
+func (m *TxOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxOut.Unmarshal(m, b)
+}
+func (m *TxOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxOut.Marshal(b, m, deterministic)
+}
+func (dst *TxOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxOut.Merge(dst, src)
+}
+func (m *TxOut) XXX_Size() int {
+	return xxx_messageInfo_TxOut.Size(m)
+}
+func (m *TxOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxOut proto.InternalMessageInfo
 
 func (m *TxOut) GetValue() uint64 {
 	if m != nil {
@@ -72,15 +70,37 @@ func (m *TxOut) GetScript() []byte {
 }
 
 type OutPoint struct {
-	PrevHash  []byte `protobuf:"bytes,1,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
-	PrevIndex int32  `protobuf:"varint,2,opt,name=prev_index,json=prevIndex" json:"prev_index,omitempty"`
-	Tree      int32  `protobuf:"varint,3,opt,name=tree" json:"tree,omitempty"`
+	PrevHash             []byte   `protobuf:"bytes,1,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	PrevIndex            int32    `protobuf:"varint,2,opt,name=prev_index,json=prevIndex" json:"prev_index,omitempty"`
+	Tree                 int32    `protobuf:"varint,3,opt,name=tree" json:"tree,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OutPoint) Reset()         { *m = OutPoint{} }
+func (m *OutPoint) String() string { return proto.CompactTextString(m) }
+func (*OutPoint) ProtoMessage()    {}
+func (*OutPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{1}
+}
+func (m *OutPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OutPoint.Unmarshal(m, b)
+}
+func (m *OutPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OutPoint.Marshal(b, m, deterministic)
+}
+func (dst *OutPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutPoint.Merge(dst, src)
+}
+func (m *OutPoint) XXX_Size() int {
+	return xxx_messageInfo_OutPoint.Size(m)
+}
+func (m *OutPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutPoint.DiscardUnknown(m)
 }
 
-func (m *OutPoint) Reset()                    { *m = OutPoint{} }
-func (m *OutPoint) String() string            { return proto.CompactTextString(m) }
-func (*OutPoint) ProtoMessage()               {}
-func (*OutPoint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+var xxx_messageInfo_OutPoint proto.InternalMessageInfo
 
 func (m *OutPoint) GetPrevHash() []byte {
 	if m != nil {
@@ -104,13 +124,35 @@ func (m *OutPoint) GetTree() int32 {
 }
 
 type WatchWaitingListRequest struct {
-	SendCurrent bool `protobuf:"varint,1,opt,name=send_current,json=sendCurrent" json:"send_current,omitempty"`
+	SendCurrent          bool     `protobuf:"varint,1,opt,name=send_current,json=sendCurrent" json:"send_current,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *WatchWaitingListRequest) Reset()                    { *m = WatchWaitingListRequest{} }
-func (m *WatchWaitingListRequest) String() string            { return proto.CompactTextString(m) }
-func (*WatchWaitingListRequest) ProtoMessage()               {}
-func (*WatchWaitingListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (m *WatchWaitingListRequest) Reset()         { *m = WatchWaitingListRequest{} }
+func (m *WatchWaitingListRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchWaitingListRequest) ProtoMessage()    {}
+func (*WatchWaitingListRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{2}
+}
+func (m *WatchWaitingListRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchWaitingListRequest.Unmarshal(m, b)
+}
+func (m *WatchWaitingListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchWaitingListRequest.Marshal(b, m, deterministic)
+}
+func (dst *WatchWaitingListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchWaitingListRequest.Merge(dst, src)
+}
+func (m *WatchWaitingListRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchWaitingListRequest.Size(m)
+}
+func (m *WatchWaitingListRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchWaitingListRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchWaitingListRequest proto.InternalMessageInfo
 
 func (m *WatchWaitingListRequest) GetSendCurrent() bool {
 	if m != nil {
@@ -120,13 +162,35 @@ func (m *WatchWaitingListRequest) GetSendCurrent() bool {
 }
 
 type WatchWaitingListResponse struct {
-	Queues []*WatchWaitingListResponse_Queue `protobuf:"bytes,1,rep,name=queues" json:"queues,omitempty"`
+	Queues               []*WatchWaitingListResponse_Queue `protobuf:"bytes,1,rep,name=queues" json:"queues,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *WatchWaitingListResponse) Reset()         { *m = WatchWaitingListResponse{} }
+func (m *WatchWaitingListResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchWaitingListResponse) ProtoMessage()    {}
+func (*WatchWaitingListResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{3}
+}
+func (m *WatchWaitingListResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchWaitingListResponse.Unmarshal(m, b)
+}
+func (m *WatchWaitingListResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchWaitingListResponse.Marshal(b, m, deterministic)
+}
+func (dst *WatchWaitingListResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchWaitingListResponse.Merge(dst, src)
+}
+func (m *WatchWaitingListResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchWaitingListResponse.Size(m)
+}
+func (m *WatchWaitingListResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchWaitingListResponse.DiscardUnknown(m)
 }
 
-func (m *WatchWaitingListResponse) Reset()                    { *m = WatchWaitingListResponse{} }
-func (m *WatchWaitingListResponse) String() string            { return proto.CompactTextString(m) }
-func (*WatchWaitingListResponse) ProtoMessage()               {}
-func (*WatchWaitingListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+var xxx_messageInfo_WatchWaitingListResponse proto.InternalMessageInfo
 
 func (m *WatchWaitingListResponse) GetQueues() []*WatchWaitingListResponse_Queue {
 	if m != nil {
@@ -136,17 +200,38 @@ func (m *WatchWaitingListResponse) GetQueues() []*WatchWaitingListResponse_Queue
 }
 
 type WatchWaitingListResponse_Queue struct {
-	Name    string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Amounts []uint64 `protobuf:"varint,2,rep,packed,name=amounts" json:"amounts,omitempty"`
+	Name                 string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Amounts              []uint64 `protobuf:"varint,2,rep,packed,name=amounts" json:"amounts,omitempty"`
+	Pool                 string   `protobuf:"bytes,3,opt,name=pool" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *WatchWaitingListResponse_Queue) Reset()         { *m = WatchWaitingListResponse_Queue{} }
 func (m *WatchWaitingListResponse_Queue) String() string { return proto.CompactTextString(m) }
 func (*WatchWaitingListResponse_Queue) ProtoMessage()    {}
 func (*WatchWaitingListResponse_Queue) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{3, 0}
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{3, 0}
+}
+func (m *WatchWaitingListResponse_Queue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchWaitingListResponse_Queue.Unmarshal(m, b)
+}
+func (m *WatchWaitingListResponse_Queue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchWaitingListResponse_Queue.Marshal(b, m, deterministic)
+}
+func (dst *WatchWaitingListResponse_Queue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchWaitingListResponse_Queue.Merge(dst, src)
+}
+func (m *WatchWaitingListResponse_Queue) XXX_Size() int {
+	return xxx_messageInfo_WatchWaitingListResponse_Queue.Size(m)
+}
+func (m *WatchWaitingListResponse_Queue) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchWaitingListResponse_Queue.DiscardUnknown(m)
 }
 
+var xxx_messageInfo_WatchWaitingListResponse_Queue proto.InternalMessageInfo
+
 func (m *WatchWaitingListResponse_Queue) GetName() string {
 	if m != nil {
 		return m.Name
@@ -161,18 +246,97 @@ func (m *WatchWaitingListResponse_Queue) GetAmounts() []uint64 {
 	return nil
 }
 
+func (m *WatchWaitingListResponse_Queue) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type VoteChoice struct {
+	AgendaId             string   `protobuf:"bytes,1,opt,name=agenda_id,json=agendaId" json:"agenda_id,omitempty"`
+	ChoiceId             string   `protobuf:"bytes,2,opt,name=choice_id,json=choiceId" json:"choice_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VoteChoice) Reset()         { *m = VoteChoice{} }
+func (m *VoteChoice) String() string { return proto.CompactTextString(m) }
+func (*VoteChoice) ProtoMessage()    {}
+func (*VoteChoice) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{4}
+}
+func (m *VoteChoice) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VoteChoice.Unmarshal(m, b)
+}
+func (m *VoteChoice) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VoteChoice.Marshal(b, m, deterministic)
+}
+func (dst *VoteChoice) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VoteChoice.Merge(dst, src)
+}
+func (m *VoteChoice) XXX_Size() int {
+	return xxx_messageInfo_VoteChoice.Size(m)
+}
+func (m *VoteChoice) XXX_DiscardUnknown() {
+	xxx_messageInfo_VoteChoice.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VoteChoice proto.InternalMessageInfo
+
+func (m *VoteChoice) GetAgendaId() string {
+	if m != nil {
+		return m.AgendaId
+	}
+	return ""
+}
+
+func (m *VoteChoice) GetChoiceId() string {
+	if m != nil {
+		return m.ChoiceId
+	}
+	return ""
+}
+
 type FindMatchesRequest struct {
-	ProtocolVersion uint32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
-	Amount          uint64 `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
-	SessionName     string `protobuf:"bytes,3,opt,name=session_name,json=sessionName" json:"session_name,omitempty"`
-	VoteAddress     string `protobuf:"bytes,4,opt,name=vote_address,json=voteAddress" json:"vote_address,omitempty"`
-	PoolAddress     string `protobuf:"bytes,5,opt,name=pool_address,json=poolAddress" json:"pool_address,omitempty"`
+	ProtocolVersion      uint32        `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+	Amount               uint64        `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
+	SessionName          string        `protobuf:"bytes,3,opt,name=session_name,json=sessionName" json:"session_name,omitempty"`
+	VoteAddress          string        `protobuf:"bytes,4,opt,name=vote_address,json=voteAddress" json:"vote_address,omitempty"`
+	PoolAddress          string        `protobuf:"bytes,5,opt,name=pool_address,json=poolAddress" json:"pool_address,omitempty"`
+	VoteChoices          []*VoteChoice `protobuf:"bytes,6,rep,name=vote_choices,json=voteChoices" json:"vote_choices,omitempty"`
+	VoteChoicesSignature []byte        `protobuf:"bytes,7,opt,name=vote_choices_signature,json=voteChoicesSignature,proto3" json:"vote_choices_signature,omitempty"`
+	Pool                 string        `protobuf:"bytes,8,opt,name=pool" json:"pool,omitempty"`
+	JoinMac              []byte        `protobuf:"bytes,9,opt,name=join_mac,json=joinMac,proto3" json:"join_mac,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *FindMatchesRequest) Reset()         { *m = FindMatchesRequest{} }
+func (m *FindMatchesRequest) String() string { return proto.CompactTextString(m) }
+func (*FindMatchesRequest) ProtoMessage()    {}
+func (*FindMatchesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{5}
+}
+func (m *FindMatchesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindMatchesRequest.Unmarshal(m, b)
+}
+func (m *FindMatchesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindMatchesRequest.Marshal(b, m, deterministic)
+}
+func (dst *FindMatchesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindMatchesRequest.Merge(dst, src)
+}
+func (m *FindMatchesRequest) XXX_Size() int {
+	return xxx_messageInfo_FindMatchesRequest.Size(m)
+}
+func (m *FindMatchesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindMatchesRequest.DiscardUnknown(m)
 }
 
-func (m *FindMatchesRequest) Reset()                    { *m = FindMatchesRequest{} }
-func (m *FindMatchesRequest) String() string            { return proto.CompactTextString(m) }
-func (*FindMatchesRequest) ProtoMessage()               {}
-func (*FindMatchesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+var xxx_messageInfo_FindMatchesRequest proto.InternalMessageInfo
 
 func (m *FindMatchesRequest) GetProtocolVersion() uint32 {
 	if m != nil {
@@ -209,22 +373,73 @@ func (m *FindMatchesRequest) GetPoolAddress() string {
 	return ""
 }
 
+func (m *FindMatchesRequest) GetVoteChoices() []*VoteChoice {
+	if m != nil {
+		return m.VoteChoices
+	}
+	return nil
+}
+
+func (m *FindMatchesRequest) GetVoteChoicesSignature() []byte {
+	if m != nil {
+		return m.VoteChoicesSignature
+	}
+	return nil
+}
+
+func (m *FindMatchesRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *FindMatchesRequest) GetJoinMac() []byte {
+	if m != nil {
+		return m.JoinMac
+	}
+	return nil
+}
+
 type FindMatchesResponse struct {
-	SessionId       uint32 `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	Amount          uint64 `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
-	Fee             uint64 `protobuf:"varint,3,opt,name=fee" json:"fee,omitempty"`
-	PoolFee         uint64 `protobuf:"varint,4,opt,name=pool_fee,json=poolFee" json:"pool_fee,omitempty"`
-	MainchainHash   []byte `protobuf:"bytes,5,opt,name=mainchain_hash,json=mainchainHash,proto3" json:"mainchain_hash,omitempty"`
-	MainchainHeight uint32 `protobuf:"varint,6,opt,name=mainchain_height,json=mainchainHeight" json:"mainchain_height,omitempty"`
-	TicketPrice     uint64 `protobuf:"varint,7,opt,name=ticket_price,json=ticketPrice" json:"ticket_price,omitempty"`
-	NbParticipants  uint32 `protobuf:"varint,8,opt,name=nb_participants,json=nbParticipants" json:"nb_participants,omitempty"`
-	SessionToken    []byte `protobuf:"bytes,9,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
-}
-
-func (m *FindMatchesResponse) Reset()                    { *m = FindMatchesResponse{} }
-func (m *FindMatchesResponse) String() string            { return proto.CompactTextString(m) }
-func (*FindMatchesResponse) ProtoMessage()               {}
-func (*FindMatchesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+	SessionId            uint32   `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Amount               uint64   `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
+	Fee                  uint64   `protobuf:"varint,3,opt,name=fee" json:"fee,omitempty"`
+	PoolFee              uint64   `protobuf:"varint,4,opt,name=pool_fee,json=poolFee" json:"pool_fee,omitempty"`
+	MainchainHash        []byte   `protobuf:"bytes,5,opt,name=mainchain_hash,json=mainchainHash,proto3" json:"mainchain_hash,omitempty"`
+	MainchainHeight      uint32   `protobuf:"varint,6,opt,name=mainchain_height,json=mainchainHeight" json:"mainchain_height,omitempty"`
+	TicketPrice          uint64   `protobuf:"varint,7,opt,name=ticket_price,json=ticketPrice" json:"ticket_price,omitempty"`
+	NbParticipants       uint32   `protobuf:"varint,8,opt,name=nb_participants,json=nbParticipants" json:"nb_participants,omitempty"`
+	SessionToken         []byte   `protobuf:"bytes,9,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	FeeRate              uint64   `protobuf:"varint,10,opt,name=fee_rate,json=feeRate" json:"fee_rate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FindMatchesResponse) Reset()         { *m = FindMatchesResponse{} }
+func (m *FindMatchesResponse) String() string { return proto.CompactTextString(m) }
+func (*FindMatchesResponse) ProtoMessage()    {}
+func (*FindMatchesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{6}
+}
+func (m *FindMatchesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindMatchesResponse.Unmarshal(m, b)
+}
+func (m *FindMatchesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindMatchesResponse.Marshal(b, m, deterministic)
+}
+func (dst *FindMatchesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindMatchesResponse.Merge(dst, src)
+}
+func (m *FindMatchesResponse) XXX_Size() int {
+	return xxx_messageInfo_FindMatchesResponse.Size(m)
+}
+func (m *FindMatchesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindMatchesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FindMatchesResponse proto.InternalMessageInfo
 
 func (m *FindMatchesResponse) GetSessionId() uint32 {
 	if m != nil {
@@ -289,20 +504,49 @@ func (m *FindMatchesResponse) GetSessionToken() []byte {
 	return nil
 }
 
+func (m *FindMatchesResponse) GetFeeRate() uint64 {
+	if m != nil {
+		return m.FeeRate
+	}
+	return 0
+}
+
 type GenerateTicketRequest struct {
-	SessionId         uint32      `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	CommitmentAddress string      `protobuf:"bytes,2,opt,name=commitment_address,json=commitmentAddress" json:"commitment_address,omitempty"`
-	SplitTxAddress    string      `protobuf:"bytes,3,opt,name=split_tx_address,json=splitTxAddress" json:"split_tx_address,omitempty"`
-	SplitTxChange     *TxOut      `protobuf:"bytes,4,opt,name=split_tx_change,json=splitTxChange" json:"split_tx_change,omitempty"`
-	SplitTxInputs     []*OutPoint `protobuf:"bytes,5,rep,name=split_tx_inputs,json=splitTxInputs" json:"split_tx_inputs,omitempty"`
-	SecretnbHash      []byte      `protobuf:"bytes,6,opt,name=secretnb_hash,json=secretnbHash,proto3" json:"secretnb_hash,omitempty"`
-	SessionToken      []byte      `protobuf:"bytes,7,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	SessionId            uint32      `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	CommitmentAddress    string      `protobuf:"bytes,2,opt,name=commitment_address,json=commitmentAddress" json:"commitment_address,omitempty"`
+	SplitTxAddress       string      `protobuf:"bytes,3,opt,name=split_tx_address,json=splitTxAddress" json:"split_tx_address,omitempty"`
+	SplitTxChange        *TxOut      `protobuf:"bytes,4,opt,name=split_tx_change,json=splitTxChange" json:"split_tx_change,omitempty"`
+	SplitTxInputs        []*OutPoint `protobuf:"bytes,5,rep,name=split_tx_inputs,json=splitTxInputs" json:"split_tx_inputs,omitempty"`
+	SecretnbHash         []byte      `protobuf:"bytes,6,opt,name=secretnb_hash,json=secretnbHash,proto3" json:"secretnb_hash,omitempty"`
+	SessionToken         []byte      `protobuf:"bytes,7,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
 
-func (m *GenerateTicketRequest) Reset()                    { *m = GenerateTicketRequest{} }
-func (m *GenerateTicketRequest) String() string            { return proto.CompactTextString(m) }
-func (*GenerateTicketRequest) ProtoMessage()               {}
-func (*GenerateTicketRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (m *GenerateTicketRequest) Reset()         { *m = GenerateTicketRequest{} }
+func (m *GenerateTicketRequest) String() string { return proto.CompactTextString(m) }
+func (*GenerateTicketRequest) ProtoMessage()    {}
+func (*GenerateTicketRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{7}
+}
+func (m *GenerateTicketRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GenerateTicketRequest.Unmarshal(m, b)
+}
+func (m *GenerateTicketRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GenerateTicketRequest.Marshal(b, m, deterministic)
+}
+func (dst *GenerateTicketRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GenerateTicketRequest.Merge(dst, src)
+}
+func (m *GenerateTicketRequest) XXX_Size() int {
+	return xxx_messageInfo_GenerateTicketRequest.Size(m)
+}
+func (m *GenerateTicketRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GenerateTicketRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GenerateTicketRequest proto.InternalMessageInfo
 
 func (m *GenerateTicketRequest) GetSessionId() uint32 {
 	if m != nil {
@@ -354,16 +598,38 @@ func (m *GenerateTicketRequest) GetSessionToken() []byte {
 }
 
 type GenerateTicketResponse struct {
-	SplitTx        []byte                                `protobuf:"bytes,1,opt,name=split_tx,json=splitTx,proto3" json:"split_tx,omitempty"`
-	TicketTemplate []byte                                `protobuf:"bytes,2,opt,name=ticket_template,json=ticketTemplate,proto3" json:"ticket_template,omitempty"`
-	Participants   []*GenerateTicketResponse_Participant `protobuf:"bytes,3,rep,name=participants" json:"participants,omitempty"`
-	Index          uint32                                `protobuf:"varint,4,opt,name=index" json:"index,omitempty"`
+	SplitTx              []byte                                `protobuf:"bytes,1,opt,name=split_tx,json=splitTx,proto3" json:"split_tx,omitempty"`
+	TicketTemplate       []byte                                `protobuf:"bytes,2,opt,name=ticket_template,json=ticketTemplate,proto3" json:"ticket_template,omitempty"`
+	Participants         []*GenerateTicketResponse_Participant `protobuf:"bytes,3,rep,name=participants" json:"participants,omitempty"`
+	Index                uint32                                `protobuf:"varint,4,opt,name=index" json:"index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                              `json:"-"`
+	XXX_unrecognized     []byte                                `json:"-"`
+	XXX_sizecache        int32                                 `json:"-"`
 }
 
-func (m *GenerateTicketResponse) Reset()                    { *m = GenerateTicketResponse{} }
-func (m *GenerateTicketResponse) String() string            { return proto.CompactTextString(m) }
-func (*GenerateTicketResponse) ProtoMessage()               {}
-func (*GenerateTicketResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (m *GenerateTicketResponse) Reset()         { *m = GenerateTicketResponse{} }
+func (m *GenerateTicketResponse) String() string { return proto.CompactTextString(m) }
+func (*GenerateTicketResponse) ProtoMessage()    {}
+func (*GenerateTicketResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{8}
+}
+func (m *GenerateTicketResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GenerateTicketResponse.Unmarshal(m, b)
+}
+func (m *GenerateTicketResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GenerateTicketResponse.Marshal(b, m, deterministic)
+}
+func (dst *GenerateTicketResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GenerateTicketResponse.Merge(dst, src)
+}
+func (m *GenerateTicketResponse) XXX_Size() int {
+	return xxx_messageInfo_GenerateTicketResponse.Size(m)
+}
+func (m *GenerateTicketResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GenerateTicketResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GenerateTicketResponse proto.InternalMessageInfo
 
 func (m *GenerateTicketResponse) GetSplitTx() []byte {
 	if m != nil {
@@ -394,18 +660,38 @@ func (m *GenerateTicketResponse) GetIndex() uint32 {
 }
 
 type GenerateTicketResponse_Participant struct {
-	Amount       uint64 `protobuf:"varint,1,opt,name=amount" json:"amount,omitempty"`
-	SecretnbHash []byte `protobuf:"bytes,2,opt,name=secretnb_hash,json=secretnbHash,proto3" json:"secretnb_hash,omitempty"`
-	VotePkScript []byte `protobuf:"bytes,3,opt,name=vote_pk_script,json=votePkScript,proto3" json:"vote_pk_script,omitempty"`
-	PoolPkScript []byte `protobuf:"bytes,4,opt,name=pool_pk_script,json=poolPkScript,proto3" json:"pool_pk_script,omitempty"`
+	Amount               uint64   `protobuf:"varint,1,opt,name=amount" json:"amount,omitempty"`
+	SecretnbHash         []byte   `protobuf:"bytes,2,opt,name=secretnb_hash,json=secretnbHash,proto3" json:"secretnb_hash,omitempty"`
+	VotePkScript         []byte   `protobuf:"bytes,3,opt,name=vote_pk_script,json=votePkScript,proto3" json:"vote_pk_script,omitempty"`
+	PoolPkScript         []byte   `protobuf:"bytes,4,opt,name=pool_pk_script,json=poolPkScript,proto3" json:"pool_pk_script,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GenerateTicketResponse_Participant) Reset()         { *m = GenerateTicketResponse_Participant{} }
 func (m *GenerateTicketResponse_Participant) String() string { return proto.CompactTextString(m) }
 func (*GenerateTicketResponse_Participant) ProtoMessage()    {}
 func (*GenerateTicketResponse_Participant) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{7, 0}
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{8, 0}
+}
+func (m *GenerateTicketResponse_Participant) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GenerateTicketResponse_Participant.Unmarshal(m, b)
 }
+func (m *GenerateTicketResponse_Participant) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GenerateTicketResponse_Participant.Marshal(b, m, deterministic)
+}
+func (dst *GenerateTicketResponse_Participant) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GenerateTicketResponse_Participant.Merge(dst, src)
+}
+func (m *GenerateTicketResponse_Participant) XXX_Size() int {
+	return xxx_messageInfo_GenerateTicketResponse_Participant.Size(m)
+}
+func (m *GenerateTicketResponse_Participant) XXX_DiscardUnknown() {
+	xxx_messageInfo_GenerateTicketResponse_Participant.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GenerateTicketResponse_Participant proto.InternalMessageInfo
 
 func (m *GenerateTicketResponse_Participant) GetAmount() uint64 {
 	if m != nil {
@@ -436,16 +722,38 @@ func (m *GenerateTicketResponse_Participant) GetPoolPkScript() []byte {
 }
 
 type FundTicketRequest struct {
-	SessionId           uint32                                       `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	Tickets             []*FundTicketRequest_FundedParticipantTicket `protobuf:"bytes,2,rep,name=tickets" json:"tickets,omitempty"`
-	RevocationScriptSig []byte                                       `protobuf:"bytes,3,opt,name=revocation_script_sig,json=revocationScriptSig,proto3" json:"revocation_script_sig,omitempty"`
-	SessionToken        []byte                                       `protobuf:"bytes,4,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	SessionId            uint32                                       `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	Tickets              []*FundTicketRequest_FundedParticipantTicket `protobuf:"bytes,2,rep,name=tickets" json:"tickets,omitempty"`
+	RevocationScriptSig  []byte                                       `protobuf:"bytes,3,opt,name=revocation_script_sig,json=revocationScriptSig,proto3" json:"revocation_script_sig,omitempty"`
+	SessionToken         []byte                                       `protobuf:"bytes,4,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                     `json:"-"`
+	XXX_unrecognized     []byte                                       `json:"-"`
+	XXX_sizecache        int32                                        `json:"-"`
 }
 
-func (m *FundTicketRequest) Reset()                    { *m = FundTicketRequest{} }
-func (m *FundTicketRequest) String() string            { return proto.CompactTextString(m) }
-func (*FundTicketRequest) ProtoMessage()               {}
-func (*FundTicketRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (m *FundTicketRequest) Reset()         { *m = FundTicketRequest{} }
+func (m *FundTicketRequest) String() string { return proto.CompactTextString(m) }
+func (*FundTicketRequest) ProtoMessage()    {}
+func (*FundTicketRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{9}
+}
+func (m *FundTicketRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundTicketRequest.Unmarshal(m, b)
+}
+func (m *FundTicketRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundTicketRequest.Marshal(b, m, deterministic)
+}
+func (dst *FundTicketRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundTicketRequest.Merge(dst, src)
+}
+func (m *FundTicketRequest) XXX_Size() int {
+	return xxx_messageInfo_FundTicketRequest.Size(m)
+}
+func (m *FundTicketRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundTicketRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FundTicketRequest proto.InternalMessageInfo
 
 func (m *FundTicketRequest) GetSessionId() uint32 {
 	if m != nil {
@@ -476,18 +784,40 @@ func (m *FundTicketRequest) GetSessionToken() []byte {
 }
 
 type FundTicketRequest_FundedParticipantTicket struct {
-	TicketInputScriptsig []byte `protobuf:"bytes,1,opt,name=ticket_input_scriptsig,json=ticketInputScriptsig,proto3" json:"ticket_input_scriptsig,omitempty"`
+	TicketInputScriptsig []byte   `protobuf:"bytes,1,opt,name=ticket_input_scriptsig,json=ticketInputScriptsig,proto3" json:"ticket_input_scriptsig,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *FundTicketRequest_FundedParticipantTicket) Reset() {
 	*m = FundTicketRequest_FundedParticipantTicket{}
 }
-func (m *FundTicketRequest_FundedParticipantTicket) String() string { return proto.CompactTextString(m) }
-func (*FundTicketRequest_FundedParticipantTicket) ProtoMessage()    {}
+func (m *FundTicketRequest_FundedParticipantTicket) String() string {
+	return proto.CompactTextString(m)
+}
+func (*FundTicketRequest_FundedParticipantTicket) ProtoMessage() {}
 func (*FundTicketRequest_FundedParticipantTicket) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{8, 0}
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{9, 0}
+}
+func (m *FundTicketRequest_FundedParticipantTicket) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundTicketRequest_FundedParticipantTicket.Unmarshal(m, b)
+}
+func (m *FundTicketRequest_FundedParticipantTicket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundTicketRequest_FundedParticipantTicket.Marshal(b, m, deterministic)
+}
+func (dst *FundTicketRequest_FundedParticipantTicket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundTicketRequest_FundedParticipantTicket.Merge(dst, src)
+}
+func (m *FundTicketRequest_FundedParticipantTicket) XXX_Size() int {
+	return xxx_messageInfo_FundTicketRequest_FundedParticipantTicket.Size(m)
+}
+func (m *FundTicketRequest_FundedParticipantTicket) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundTicketRequest_FundedParticipantTicket.DiscardUnknown(m)
 }
 
+var xxx_messageInfo_FundTicketRequest_FundedParticipantTicket proto.InternalMessageInfo
+
 func (m *FundTicketRequest_FundedParticipantTicket) GetTicketInputScriptsig() []byte {
 	if m != nil {
 		return m.TicketInputScriptsig
@@ -496,13 +826,35 @@ func (m *FundTicketRequest_FundedParticipantTicket) GetTicketInputScriptsig() []
 }
 
 type FundTicketResponse struct {
-	Tickets []*FundTicketResponse_FundedParticipantTicket `protobuf:"bytes,1,rep,name=tickets" json:"tickets,omitempty"`
+	Tickets              []*FundTicketResponse_FundedParticipantTicket `protobuf:"bytes,1,rep,name=tickets" json:"tickets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                      `json:"-"`
+	XXX_unrecognized     []byte                                        `json:"-"`
+	XXX_sizecache        int32                                         `json:"-"`
+}
+
+func (m *FundTicketResponse) Reset()         { *m = FundTicketResponse{} }
+func (m *FundTicketResponse) String() string { return proto.CompactTextString(m) }
+func (*FundTicketResponse) ProtoMessage()    {}
+func (*FundTicketResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{10}
+}
+func (m *FundTicketResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundTicketResponse.Unmarshal(m, b)
+}
+func (m *FundTicketResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundTicketResponse.Marshal(b, m, deterministic)
+}
+func (dst *FundTicketResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundTicketResponse.Merge(dst, src)
+}
+func (m *FundTicketResponse) XXX_Size() int {
+	return xxx_messageInfo_FundTicketResponse.Size(m)
+}
+func (m *FundTicketResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundTicketResponse.DiscardUnknown(m)
 }
 
-func (m *FundTicketResponse) Reset()                    { *m = FundTicketResponse{} }
-func (m *FundTicketResponse) String() string            { return proto.CompactTextString(m) }
-func (*FundTicketResponse) ProtoMessage()               {}
-func (*FundTicketResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+var xxx_messageInfo_FundTicketResponse proto.InternalMessageInfo
 
 func (m *FundTicketResponse) GetTickets() []*FundTicketResponse_FundedParticipantTicket {
 	if m != nil {
@@ -512,8 +864,11 @@ func (m *FundTicketResponse) GetTickets() []*FundTicketResponse_FundedParticipan
 }
 
 type FundTicketResponse_FundedParticipantTicket struct {
-	Ticket     []byte `protobuf:"bytes,1,opt,name=ticket,proto3" json:"ticket,omitempty"`
-	Revocation []byte `protobuf:"bytes,2,opt,name=revocation,proto3" json:"revocation,omitempty"`
+	Ticket               []byte   `protobuf:"bytes,1,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	Revocation           []byte   `protobuf:"bytes,2,opt,name=revocation,proto3" json:"revocation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *FundTicketResponse_FundedParticipantTicket) Reset() {
@@ -524,9 +879,26 @@ func (m *FundTicketResponse_FundedParticipantTicket) String() string {
 }
 func (*FundTicketResponse_FundedParticipantTicket) ProtoMessage() {}
 func (*FundTicketResponse_FundedParticipantTicket) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{9, 0}
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{10, 0}
+}
+func (m *FundTicketResponse_FundedParticipantTicket) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundTicketResponse_FundedParticipantTicket.Unmarshal(m, b)
+}
+func (m *FundTicketResponse_FundedParticipantTicket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundTicketResponse_FundedParticipantTicket.Marshal(b, m, deterministic)
+}
+func (dst *FundTicketResponse_FundedParticipantTicket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundTicketResponse_FundedParticipantTicket.Merge(dst, src)
+}
+func (m *FundTicketResponse_FundedParticipantTicket) XXX_Size() int {
+	return xxx_messageInfo_FundTicketResponse_FundedParticipantTicket.Size(m)
+}
+func (m *FundTicketResponse_FundedParticipantTicket) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundTicketResponse_FundedParticipantTicket.DiscardUnknown(m)
 }
 
+var xxx_messageInfo_FundTicketResponse_FundedParticipantTicket proto.InternalMessageInfo
+
 func (m *FundTicketResponse_FundedParticipantTicket) GetTicket() []byte {
 	if m != nil {
 		return m.Ticket
@@ -542,16 +914,38 @@ func (m *FundTicketResponse_FundedParticipantTicket) GetRevocation() []byte {
 }
 
 type FundSplitTxRequest struct {
-	SessionId         uint32   `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	SplitTxScriptsigs [][]byte `protobuf:"bytes,2,rep,name=split_tx_scriptsigs,json=splitTxScriptsigs,proto3" json:"split_tx_scriptsigs,omitempty"`
-	Secretnb          []byte   `protobuf:"bytes,3,opt,name=secretnb,proto3" json:"secretnb,omitempty"`
-	SessionToken      []byte   `protobuf:"bytes,4,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	SessionId            uint32   `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	SplitTxScriptsigs    [][]byte `protobuf:"bytes,2,rep,name=split_tx_scriptsigs,json=splitTxScriptsigs,proto3" json:"split_tx_scriptsigs,omitempty"`
+	Secretnb             []byte   `protobuf:"bytes,3,opt,name=secretnb,proto3" json:"secretnb,omitempty"`
+	SessionToken         []byte   `protobuf:"bytes,4,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *FundSplitTxRequest) Reset()                    { *m = FundSplitTxRequest{} }
-func (m *FundSplitTxRequest) String() string            { return proto.CompactTextString(m) }
-func (*FundSplitTxRequest) ProtoMessage()               {}
-func (*FundSplitTxRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *FundSplitTxRequest) Reset()         { *m = FundSplitTxRequest{} }
+func (m *FundSplitTxRequest) String() string { return proto.CompactTextString(m) }
+func (*FundSplitTxRequest) ProtoMessage()    {}
+func (*FundSplitTxRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{11}
+}
+func (m *FundSplitTxRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundSplitTxRequest.Unmarshal(m, b)
+}
+func (m *FundSplitTxRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundSplitTxRequest.Marshal(b, m, deterministic)
+}
+func (dst *FundSplitTxRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundSplitTxRequest.Merge(dst, src)
+}
+func (m *FundSplitTxRequest) XXX_Size() int {
+	return xxx_messageInfo_FundSplitTxRequest.Size(m)
+}
+func (m *FundSplitTxRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundSplitTxRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FundSplitTxRequest proto.InternalMessageInfo
 
 func (m *FundSplitTxRequest) GetSessionId() uint32 {
 	if m != nil {
@@ -582,14 +976,36 @@ func (m *FundSplitTxRequest) GetSessionToken() []byte {
 }
 
 type FundSplitTxResponse struct {
-	SplitTx       []byte   `protobuf:"bytes,1,opt,name=split_tx,json=splitTx,proto3" json:"split_tx,omitempty"`
-	SecretNumbers [][]byte `protobuf:"bytes,2,rep,name=secret_numbers,json=secretNumbers,proto3" json:"secret_numbers,omitempty"`
+	SplitTx              []byte   `protobuf:"bytes,1,opt,name=split_tx,json=splitTx,proto3" json:"split_tx,omitempty"`
+	SecretNumbers        [][]byte `protobuf:"bytes,2,rep,name=secret_numbers,json=secretNumbers,proto3" json:"secret_numbers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FundSplitTxResponse) Reset()         { *m = FundSplitTxResponse{} }
+func (m *FundSplitTxResponse) String() string { return proto.CompactTextString(m) }
+func (*FundSplitTxResponse) ProtoMessage()    {}
+func (*FundSplitTxResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{12}
+}
+func (m *FundSplitTxResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FundSplitTxResponse.Unmarshal(m, b)
+}
+func (m *FundSplitTxResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FundSplitTxResponse.Marshal(b, m, deterministic)
+}
+func (dst *FundSplitTxResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FundSplitTxResponse.Merge(dst, src)
+}
+func (m *FundSplitTxResponse) XXX_Size() int {
+	return xxx_messageInfo_FundSplitTxResponse.Size(m)
+}
+func (m *FundSplitTxResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FundSplitTxResponse.DiscardUnknown(m)
 }
 
-func (m *FundSplitTxResponse) Reset()                    { *m = FundSplitTxResponse{} }
-func (m *FundSplitTxResponse) String() string            { return proto.CompactTextString(m) }
-func (*FundSplitTxResponse) ProtoMessage()               {}
-func (*FundSplitTxResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+var xxx_messageInfo_FundSplitTxResponse proto.InternalMessageInfo
 
 func (m *FundSplitTxResponse) GetSplitTx() []byte {
 	if m != nil {
@@ -606,23 +1022,88 @@ func (m *FundSplitTxResponse) GetSecretNumbers() [][]byte {
 }
 
 type StatusRequest struct {
+	Pool                 string   `protobuf:"bytes,1,opt,name=pool" json:"pool,omitempty"`
+	SessionName          string   `protobuf:"bytes,2,opt,name=session_name,json=sessionName" json:"session_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
+func (m *StatusRequest) String() string { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()    {}
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{13}
+}
+func (m *StatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusRequest.Unmarshal(m, b)
+}
+func (m *StatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusRequest.Marshal(b, m, deterministic)
+}
+func (dst *StatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusRequest.Merge(dst, src)
+}
+func (m *StatusRequest) XXX_Size() int {
+	return xxx_messageInfo_StatusRequest.Size(m)
+}
+func (m *StatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusRequest proto.InternalMessageInfo
+
+func (m *StatusRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
 }
 
-func (m *StatusRequest) Reset()                    { *m = StatusRequest{} }
-func (m *StatusRequest) String() string            { return proto.CompactTextString(m) }
-func (*StatusRequest) ProtoMessage()               {}
-func (*StatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (m *StatusRequest) GetSessionName() string {
+	if m != nil {
+		return m.SessionName
+	}
+	return ""
+}
 
 type StatusResponse struct {
-	TicketPrice     uint64 `protobuf:"varint,1,opt,name=ticket_price,json=ticketPrice" json:"ticket_price,omitempty"`
-	ProtocolVersion uint32 `protobuf:"varint,2,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
-	MainchainHash   []byte `protobuf:"bytes,3,opt,name=mainchain_hash,json=mainchainHash,proto3" json:"mainchain_hash,omitempty"`
+	TicketPrice               uint64   `protobuf:"varint,1,opt,name=ticket_price,json=ticketPrice" json:"ticket_price,omitempty"`
+	ProtocolVersion           uint32   `protobuf:"varint,2,opt,name=protocol_version,json=protocolVersion" json:"protocol_version,omitempty"`
+	MainchainHash             []byte   `protobuf:"bytes,3,opt,name=mainchain_hash,json=mainchainHash,proto3" json:"mainchain_hash,omitempty"`
+	MainchainHeight           uint32   `protobuf:"varint,4,opt,name=mainchain_height,json=mainchainHeight" json:"mainchain_height,omitempty"`
+	StakeDiffChangeStopWindow int32    `protobuf:"varint,5,opt,name=stake_diff_change_stop_window,json=stakeDiffChangeStopWindow" json:"stake_diff_change_stop_window,omitempty"`
+	NextTicketPrice           uint64   `protobuf:"varint,6,opt,name=next_ticket_price,json=nextTicketPrice" json:"next_ticket_price,omitempty"`
+	FeeRate                   uint64   `protobuf:"varint,7,opt,name=fee_rate,json=feeRate" json:"fee_rate,omitempty"`
+	MaxSessionDuration        uint32   `protobuf:"varint,8,opt,name=max_session_duration,json=maxSessionDuration" json:"max_session_duration,omitempty"`
+	XXX_NoUnkeyedLiteral      struct{} `json:"-"`
+	XXX_unrecognized          []byte   `json:"-"`
+	XXX_sizecache             int32    `json:"-"`
 }
 
-func (m *StatusResponse) Reset()                    { *m = StatusResponse{} }
-func (m *StatusResponse) String() string            { return proto.CompactTextString(m) }
-func (*StatusResponse) ProtoMessage()               {}
-func (*StatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (m *StatusResponse) Reset()         { *m = StatusResponse{} }
+func (m *StatusResponse) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()    {}
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{14}
+}
+func (m *StatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse.Unmarshal(m, b)
+}
+func (m *StatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse.Marshal(b, m, deterministic)
+}
+func (dst *StatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse.Merge(dst, src)
+}
+func (m *StatusResponse) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse.Size(m)
+}
+func (m *StatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse proto.InternalMessageInfo
 
 func (m *StatusResponse) GetTicketPrice() uint64 {
 	if m != nil {
@@ -645,15 +1126,72 @@ func (m *StatusResponse) GetMainchainHash() []byte {
 	return nil
 }
 
+func (m *StatusResponse) GetMainchainHeight() uint32 {
+	if m != nil {
+		return m.MainchainHeight
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetStakeDiffChangeStopWindow() int32 {
+	if m != nil {
+		return m.StakeDiffChangeStopWindow
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetNextTicketPrice() uint64 {
+	if m != nil {
+		return m.NextTicketPrice
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetFeeRate() uint64 {
+	if m != nil {
+		return m.FeeRate
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetMaxSessionDuration() uint32 {
+	if m != nil {
+		return m.MaxSessionDuration
+	}
+	return 0
+}
+
 type BuyerErrorRequest struct {
-	SessionId uint32 `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
-	ErrorMsg  string `protobuf:"bytes,2,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
+	SessionId            uint32   `protobuf:"varint,1,opt,name=session_id,json=sessionId" json:"session_id,omitempty"`
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BuyerErrorRequest) Reset()         { *m = BuyerErrorRequest{} }
+func (m *BuyerErrorRequest) String() string { return proto.CompactTextString(m) }
+func (*BuyerErrorRequest) ProtoMessage()    {}
+func (*BuyerErrorRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{15}
+}
+func (m *BuyerErrorRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BuyerErrorRequest.Unmarshal(m, b)
+}
+func (m *BuyerErrorRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BuyerErrorRequest.Marshal(b, m, deterministic)
+}
+func (dst *BuyerErrorRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BuyerErrorRequest.Merge(dst, src)
+}
+func (m *BuyerErrorRequest) XXX_Size() int {
+	return xxx_messageInfo_BuyerErrorRequest.Size(m)
+}
+func (m *BuyerErrorRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BuyerErrorRequest.DiscardUnknown(m)
 }
 
-func (m *BuyerErrorRequest) Reset()                    { *m = BuyerErrorRequest{} }
-func (m *BuyerErrorRequest) String() string            { return proto.CompactTextString(m) }
-func (*BuyerErrorRequest) ProtoMessage()               {}
-func (*BuyerErrorRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+var xxx_messageInfo_BuyerErrorRequest proto.InternalMessageInfo
 
 func (m *BuyerErrorRequest) GetSessionId() uint32 {
 	if m != nil {
@@ -670,12 +1208,150 @@ func (m *BuyerErrorRequest) GetErrorMsg() string {
 }
 
 type BuyerErrorResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BuyerErrorResponse) Reset()         { *m = BuyerErrorResponse{} }
+func (m *BuyerErrorResponse) String() string { return proto.CompactTextString(m) }
+func (*BuyerErrorResponse) ProtoMessage()    {}
+func (*BuyerErrorResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{16}
+}
+func (m *BuyerErrorResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BuyerErrorResponse.Unmarshal(m, b)
+}
+func (m *BuyerErrorResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BuyerErrorResponse.Marshal(b, m, deterministic)
+}
+func (dst *BuyerErrorResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BuyerErrorResponse.Merge(dst, src)
+}
+func (m *BuyerErrorResponse) XXX_Size() int {
+	return xxx_messageInfo_BuyerErrorResponse.Size(m)
+}
+func (m *BuyerErrorResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BuyerErrorResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BuyerErrorResponse proto.InternalMessageInfo
+
+type EstimateWaitRequest struct {
+	Pool                 string   `protobuf:"bytes,1,opt,name=pool" json:"pool,omitempty"`
+	SessionName          string   `protobuf:"bytes,2,opt,name=session_name,json=sessionName" json:"session_name,omitempty"`
+	Amount               uint64   `protobuf:"varint,3,opt,name=amount" json:"amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EstimateWaitRequest) Reset()         { *m = EstimateWaitRequest{} }
+func (m *EstimateWaitRequest) String() string { return proto.CompactTextString(m) }
+func (*EstimateWaitRequest) ProtoMessage()    {}
+func (*EstimateWaitRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{17}
+}
+func (m *EstimateWaitRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EstimateWaitRequest.Unmarshal(m, b)
+}
+func (m *EstimateWaitRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EstimateWaitRequest.Marshal(b, m, deterministic)
+}
+func (dst *EstimateWaitRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EstimateWaitRequest.Merge(dst, src)
+}
+func (m *EstimateWaitRequest) XXX_Size() int {
+	return xxx_messageInfo_EstimateWaitRequest.Size(m)
+}
+func (m *EstimateWaitRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EstimateWaitRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EstimateWaitRequest proto.InternalMessageInfo
+
+func (m *EstimateWaitRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *EstimateWaitRequest) GetSessionName() string {
+	if m != nil {
+		return m.SessionName
+	}
+	return ""
+}
+
+func (m *EstimateWaitRequest) GetAmount() uint64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+type EstimateWaitResponse struct {
+	MissingAmount        uint64   `protobuf:"varint,1,opt,name=missing_amount,json=missingAmount" json:"missing_amount,omitempty"`
+	AverageAmount        uint64   `protobuf:"varint,2,opt,name=average_amount,json=averageAmount" json:"average_amount,omitempty"`
+	ArrivalIntervalMs    int64    `protobuf:"varint,3,opt,name=arrival_interval_ms,json=arrivalIntervalMs" json:"arrival_interval_ms,omitempty"`
+	ExpectedArrivals     uint32   `protobuf:"varint,4,opt,name=expected_arrivals,json=expectedArrivals" json:"expected_arrivals,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EstimateWaitResponse) Reset()         { *m = EstimateWaitResponse{} }
+func (m *EstimateWaitResponse) String() string { return proto.CompactTextString(m) }
+func (*EstimateWaitResponse) ProtoMessage()    {}
+func (*EstimateWaitResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_d9803d3b7403a1e1, []int{18}
+}
+func (m *EstimateWaitResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EstimateWaitResponse.Unmarshal(m, b)
+}
+func (m *EstimateWaitResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EstimateWaitResponse.Marshal(b, m, deterministic)
+}
+func (dst *EstimateWaitResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EstimateWaitResponse.Merge(dst, src)
+}
+func (m *EstimateWaitResponse) XXX_Size() int {
+	return xxx_messageInfo_EstimateWaitResponse.Size(m)
+}
+func (m *EstimateWaitResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EstimateWaitResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EstimateWaitResponse proto.InternalMessageInfo
+
+func (m *EstimateWaitResponse) GetMissingAmount() uint64 {
+	if m != nil {
+		return m.MissingAmount
+	}
+	return 0
 }
 
-func (m *BuyerErrorResponse) Reset()                    { *m = BuyerErrorResponse{} }
-func (m *BuyerErrorResponse) String() string            { return proto.CompactTextString(m) }
-func (*BuyerErrorResponse) ProtoMessage()               {}
-func (*BuyerErrorResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (m *EstimateWaitResponse) GetAverageAmount() uint64 {
+	if m != nil {
+		return m.AverageAmount
+	}
+	return 0
+}
+
+func (m *EstimateWaitResponse) GetArrivalIntervalMs() int64 {
+	if m != nil {
+		return m.ArrivalIntervalMs
+	}
+	return 0
+}
+
+func (m *EstimateWaitResponse) GetExpectedArrivals() uint32 {
+	if m != nil {
+		return m.ExpectedArrivals
+	}
+	return 0
+}
 
 func init() {
 	proto.RegisterType((*TxOut)(nil), "dcrticketmatcher.TxOut")
@@ -683,6 +1359,7 @@ func init() {
 	proto.RegisterType((*WatchWaitingListRequest)(nil), "dcrticketmatcher.WatchWaitingListRequest")
 	proto.RegisterType((*WatchWaitingListResponse)(nil), "dcrticketmatcher.WatchWaitingListResponse")
 	proto.RegisterType((*WatchWaitingListResponse_Queue)(nil), "dcrticketmatcher.WatchWaitingListResponse.Queue")
+	proto.RegisterType((*VoteChoice)(nil), "dcrticketmatcher.VoteChoice")
 	proto.RegisterType((*FindMatchesRequest)(nil), "dcrticketmatcher.FindMatchesRequest")
 	proto.RegisterType((*FindMatchesResponse)(nil), "dcrticketmatcher.FindMatchesResponse")
 	proto.RegisterType((*GenerateTicketRequest)(nil), "dcrticketmatcher.GenerateTicketRequest")
@@ -698,6 +1375,8 @@ func init() {
 	proto.RegisterType((*StatusResponse)(nil), "dcrticketmatcher.StatusResponse")
 	proto.RegisterType((*BuyerErrorRequest)(nil), "dcrticketmatcher.BuyerErrorRequest")
 	proto.RegisterType((*BuyerErrorResponse)(nil), "dcrticketmatcher.BuyerErrorResponse")
+	proto.RegisterType((*EstimateWaitRequest)(nil), "dcrticketmatcher.EstimateWaitRequest")
+	proto.RegisterType((*EstimateWaitResponse)(nil), "dcrticketmatcher.EstimateWaitResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -718,6 +1397,7 @@ type SplitTicketMatcherServiceClient interface {
 	FundSplitTx(ctx context.Context, in *FundSplitTxRequest, opts ...grpc.CallOption) (*FundSplitTxResponse, error)
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
 	BuyerError(ctx context.Context, in *BuyerErrorRequest, opts ...grpc.CallOption) (*BuyerErrorResponse, error)
+	EstimateWait(ctx context.Context, in *EstimateWaitRequest, opts ...grpc.CallOption) (*EstimateWaitResponse, error)
 }
 
 type splitTicketMatcherServiceClient struct {
@@ -814,6 +1494,15 @@ func (c *splitTicketMatcherServiceClient) BuyerError(ctx context.Context, in *Bu
 	return out, nil
 }
 
+func (c *splitTicketMatcherServiceClient) EstimateWait(ctx context.Context, in *EstimateWaitRequest, opts ...grpc.CallOption) (*EstimateWaitResponse, error) {
+	out := new(EstimateWaitResponse)
+	err := grpc.Invoke(ctx, "/dcrticketmatcher.SplitTicketMatcherService/EstimateWait", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for SplitTicketMatcherService service
 
 type SplitTicketMatcherServiceServer interface {
@@ -824,6 +1513,7 @@ type SplitTicketMatcherServiceServer interface {
 	FundSplitTx(context.Context, *FundSplitTxRequest) (*FundSplitTxResponse, error)
 	Status(context.Context, *StatusRequest) (*StatusResponse, error)
 	BuyerError(context.Context, *BuyerErrorRequest) (*BuyerErrorResponse, error)
+	EstimateWait(context.Context, *EstimateWaitRequest) (*EstimateWaitResponse, error)
 }
 
 func RegisterSplitTicketMatcherServiceServer(s *grpc.Server, srv SplitTicketMatcherServiceServer) {
@@ -959,6 +1649,24 @@ func _SplitTicketMatcherService_BuyerError_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SplitTicketMatcherService_EstimateWait_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateWaitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SplitTicketMatcherServiceServer).EstimateWait(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dcrticketmatcher.SplitTicketMatcherService/EstimateWait",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SplitTicketMatcherServiceServer).EstimateWait(ctx, req.(*EstimateWaitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SplitTicketMatcherService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "dcrticketmatcher.SplitTicketMatcherService",
 	HandlerType: (*SplitTicketMatcherServiceServer)(nil),
@@ -987,6 +1695,10 @@ var _SplitTicketMatcherService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "BuyerError",
 			Handler:    _SplitTicketMatcherService_BuyerError_Handler,
 		},
+		{
+			MethodName: "EstimateWait",
+			Handler:    _SplitTicketMatcherService_EstimateWait_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -998,82 +1710,103 @@ var _SplitTicketMatcherService_serviceDesc = grpc.ServiceDesc{
 	Metadata: "api.proto",
 }
 
-func init() { proto.RegisterFile("api.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 1170 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
-	0x10, 0x86, 0xfe, 0xa5, 0x91, 0x2c, 0xcb, 0x6b, 0xc7, 0xa6, 0x15, 0xa4, 0x75, 0x18, 0x1b, 0x91,
-	0x0b, 0x54, 0x08, 0xdc, 0xe4, 0xd4, 0x00, 0x45, 0x13, 0xd4, 0x8d, 0xd1, 0x26, 0x76, 0x28, 0x37,
-	0x2e, 0x72, 0x21, 0x68, 0x6a, 0x2b, 0x2d, 0x6c, 0x2d, 0x99, 0xe5, 0x52, 0x70, 0x1f, 0xa0, 0xaf,
-	0xd0, 0x4b, 0xaf, 0x79, 0x83, 0x5e, 0x0b, 0xb4, 0x4f, 0xd2, 0x5b, 0xdf, 0xa3, 0xd8, 0xd9, 0xa5,
-	0x44, 0x99, 0x54, 0xad, 0xde, 0xb8, 0xdf, 0xce, 0xce, 0xce, 0x7c, 0xf3, 0xcd, 0x2c, 0xa1, 0xe1,
-	0x85, 0xac, 0x1f, 0x8a, 0x40, 0x06, 0xa4, 0x33, 0xf4, 0x85, 0x64, 0xfe, 0x15, 0x95, 0x13, 0x4f,
-	0xfa, 0x63, 0x2a, 0xec, 0x67, 0x50, 0x39, 0xbf, 0x39, 0x8d, 0x25, 0xd9, 0x82, 0xca, 0xd4, 0xbb,
-	0x8e, 0xa9, 0x55, 0xd8, 0x2b, 0xf4, 0xca, 0x8e, 0x5e, 0x90, 0x6d, 0xa8, 0x46, 0xbe, 0x60, 0xa1,
-	0xb4, 0x8a, 0x7b, 0x85, 0x5e, 0xcb, 0x31, 0x2b, 0xfb, 0x3d, 0xd4, 0x4f, 0x63, 0x79, 0x16, 0x30,
-	0x2e, 0xc9, 0x7d, 0x68, 0x84, 0x82, 0x4e, 0xdd, 0xb1, 0x17, 0x8d, 0xf1, 0x74, 0xcb, 0xa9, 0x2b,
-	0xe0, 0x95, 0x17, 0x8d, 0xc9, 0x03, 0x00, 0xdc, 0x64, 0x7c, 0x48, 0x6f, 0xd0, 0x49, 0xc5, 0x41,
-	0xf3, 0x13, 0x05, 0x10, 0x02, 0x65, 0x29, 0x28, 0xb5, 0x4a, 0xb8, 0x81, 0xdf, 0xf6, 0x73, 0xd8,
-	0xb9, 0x50, 0xd1, 0x5d, 0x78, 0x4c, 0x32, 0x3e, 0xfa, 0x9e, 0x45, 0xd2, 0xa1, 0x1f, 0x62, 0x1a,
-	0x49, 0xf2, 0x10, 0x5a, 0x11, 0xe5, 0x43, 0xd7, 0x8f, 0x85, 0xa0, 0x5c, 0xe2, 0x6d, 0x75, 0xa7,
-	0xa9, 0xb0, 0x97, 0x1a, 0xb2, 0x7f, 0x2b, 0x80, 0x95, 0x3d, 0x1e, 0x85, 0x01, 0x8f, 0x28, 0x79,
-	0x05, 0xd5, 0x0f, 0x31, 0x8d, 0x69, 0x64, 0x15, 0xf6, 0x4a, 0xbd, 0xe6, 0xd1, 0x93, 0xfe, 0x6d,
-	0x42, 0xfa, 0xcb, 0xce, 0xf6, 0xdf, 0xaa, 0x83, 0x8e, 0x39, 0xdf, 0x7d, 0x06, 0x15, 0x04, 0x54,
-	0x06, 0xdc, 0x9b, 0x68, 0xda, 0x1a, 0x0e, 0x7e, 0x13, 0x0b, 0x6a, 0xde, 0x24, 0x88, 0xb9, 0x8c,
-	0xac, 0xe2, 0x5e, 0xa9, 0x57, 0x76, 0x92, 0xa5, 0xfd, 0x57, 0x01, 0xc8, 0x31, 0xe3, 0xc3, 0xd7,
-	0x78, 0x5b, 0x94, 0xe4, 0x75, 0x08, 0x1d, 0x2c, 0x90, 0x1f, 0x5c, 0xbb, 0x53, 0x2a, 0x22, 0x16,
-	0x70, 0x74, 0xb8, 0xe6, 0xac, 0x27, 0xf8, 0x3b, 0x0d, 0xab, 0x8a, 0x68, 0x67, 0x48, 0x66, 0xd9,
-	0x31, 0x2b, 0x4d, 0x4d, 0xa4, 0x4c, 0x5c, 0x8c, 0xa7, 0x84, 0xf1, 0x34, 0x0d, 0xf6, 0x46, 0x85,
-	0xf5, 0x10, 0x5a, 0xd3, 0x40, 0x52, 0xd7, 0x1b, 0x0e, 0x05, 0x8d, 0x22, 0xab, 0xac, 0x4d, 0x14,
-	0xf6, 0xb5, 0x86, 0x94, 0x49, 0x18, 0x04, 0xd7, 0x33, 0x93, 0x8a, 0x36, 0x51, 0x98, 0x31, 0xb1,
-	0xff, 0x28, 0xc2, 0xe6, 0x42, 0x0a, 0x86, 0xdb, 0x07, 0x00, 0x49, 0x00, 0x6c, 0x68, 0xa2, 0x6f,
-	0x18, 0xe4, 0x64, 0xb8, 0x34, 0xee, 0x0e, 0x94, 0x7e, 0x32, 0x02, 0x28, 0x3b, 0xea, 0x93, 0xec,
-	0x42, 0x1d, 0x63, 0x50, 0x70, 0x19, 0xe1, 0x9a, 0x5a, 0x1f, 0x53, 0x4a, 0x0e, 0xa0, 0x3d, 0xf1,
-	0x18, 0xf7, 0xc7, 0x1e, 0xe3, 0x5a, 0x6f, 0x15, 0xd4, 0xdb, 0xda, 0x0c, 0x45, 0xd1, 0x1d, 0x42,
-	0x27, 0x65, 0x46, 0xd9, 0x68, 0x2c, 0xad, 0xaa, 0xa6, 0x73, 0x6e, 0x88, 0xb0, 0x4a, 0x58, 0xd7,
-	0xdf, 0x0d, 0x05, 0xf3, 0xa9, 0x55, 0xc3, 0x0b, 0x9b, 0x1a, 0x3b, 0x53, 0x10, 0x79, 0x0c, 0xeb,
-	0xfc, 0xd2, 0x0d, 0x3d, 0x25, 0x14, 0x16, 0x7a, 0xaa, 0xaa, 0x75, 0x74, 0xd6, 0xe6, 0x97, 0x67,
-	0x29, 0x94, 0x3c, 0x82, 0xb5, 0x84, 0x01, 0x19, 0x5c, 0x51, 0x6e, 0x35, 0x30, 0xb8, 0xa4, 0x2e,
-	0xe7, 0x0a, 0xb3, 0xff, 0x2e, 0xc2, 0xbd, 0x6f, 0x29, 0xa7, 0xc2, 0x93, 0xf4, 0x1c, 0x6f, 0x49,
-	0x44, 0x70, 0x07, 0x81, 0x9f, 0x03, 0xf1, 0x83, 0xc9, 0x84, 0xc9, 0x09, 0xe5, 0x72, 0x56, 0xa0,
-	0x22, 0x16, 0x68, 0x63, 0xbe, 0x93, 0x54, 0xb2, 0x07, 0x9d, 0x28, 0xbc, 0x66, 0xd2, 0x95, 0x37,
-	0x33, 0x63, 0xad, 0x89, 0x36, 0xe2, 0xe7, 0x37, 0x89, 0xe5, 0x57, 0xb0, 0x3e, 0xb3, 0xf4, 0xc7,
-	0x1e, 0x1f, 0x69, 0xda, 0x9b, 0x47, 0x3b, 0xd9, 0xee, 0xc0, 0x59, 0xe1, 0xac, 0x19, 0x0f, 0x2f,
-	0xd1, 0x9a, 0xbc, 0x48, 0x39, 0x60, 0x3c, 0x8c, 0xa5, 0xd2, 0x8d, 0x6a, 0xaf, 0x6e, 0xd6, 0x41,
-	0x32, 0x35, 0x66, 0x3e, 0x4e, 0xf0, 0x80, 0xe6, 0xce, 0x17, 0x54, 0xf2, 0x4b, 0x5d, 0xd8, 0x6a,
-	0xc2, 0x9d, 0x06, 0xb1, 0xae, 0x19, 0x82, 0x6b, 0x39, 0x04, 0xff, 0x53, 0x84, 0xed, 0xdb, 0x04,
-	0x1b, 0x89, 0xee, 0x42, 0x3d, 0x09, 0xd4, 0x0c, 0xaa, 0x9a, 0x89, 0x42, 0x15, 0xd9, 0xe8, 0x40,
-	0xd2, 0x49, 0x78, 0xed, 0x49, 0x6a, 0x26, 0x5e, 0x5b, 0xc3, 0xe7, 0x06, 0x25, 0x3f, 0x42, 0x6b,
-	0x41, 0x0a, 0x25, 0xcc, 0xf4, 0x69, 0x36, 0xd3, 0xfc, 0x18, 0xfa, 0x29, 0xc5, 0x38, 0x0b, 0x9e,
-	0xd4, 0x04, 0xd6, 0x53, 0xb2, 0x8c, 0xa5, 0xd7, 0x8b, 0xee, 0xaf, 0x05, 0x68, 0xa6, 0xce, 0xa4,
-	0xfa, 0xa8, 0xb0, 0xd0, 0x47, 0x19, 0x02, 0x8b, 0x39, 0x04, 0xee, 0x43, 0x1b, 0x27, 0x40, 0x78,
-	0xe5, 0x9a, 0xb1, 0x5e, 0xd2, 0x56, 0x0a, 0x3d, 0xbb, 0x1a, 0x20, 0xa6, 0xac, 0xb0, 0x01, 0xe7,
-	0x56, 0x65, 0x6d, 0xa5, 0xd0, 0xc4, 0xca, 0xfe, 0xbd, 0x08, 0x1b, 0xc7, 0x31, 0x1f, 0xfe, 0x2f,
-	0x11, 0xff, 0x00, 0x35, 0xcd, 0x92, 0x9e, 0x8c, 0xcd, 0xa3, 0x2f, 0xb3, 0xc4, 0x65, 0x9c, 0x22,
-	0x42, 0x87, 0x29, 0x16, 0xcc, 0x76, 0xe2, 0x8b, 0x1c, 0xc1, 0x3d, 0x41, 0xa7, 0x81, 0xef, 0x49,
-	0x75, 0xb1, 0x0e, 0xda, 0x8d, 0xd8, 0xc8, 0xa4, 0xb7, 0x39, 0xdf, 0xd4, 0xc1, 0x0f, 0xd8, 0x28,
-	0x2b, 0xa6, 0x72, 0x56, 0x4c, 0xdd, 0x53, 0xd8, 0x59, 0x72, 0x39, 0x79, 0x0a, 0xdb, 0x46, 0x31,
-	0xa8, 0x79, 0x73, 0xab, 0xba, 0x54, 0x4b, 0x6b, 0x4b, 0xef, 0xa2, 0xbe, 0x07, 0xc9, 0x9e, 0xfd,
-	0xa7, 0x7a, 0x00, 0x52, 0x09, 0x1a, 0x65, 0xbe, 0x9b, 0xf3, 0xa2, 0x5f, 0xa6, 0xe7, 0xff, 0xcd,
-	0x8b, 0x11, 0xd3, 0x5d, 0xc4, 0x74, 0xdf, 0x2e, 0x8f, 0x7f, 0x1b, 0xaa, 0xda, 0xca, 0xc4, 0x6b,
-	0x56, 0xe4, 0x13, 0x80, 0x39, 0x5d, 0x46, 0x45, 0x29, 0xc4, 0xfe, 0x68, 0x32, 0x18, 0xe8, 0xce,
-	0x59, 0xb1, 0xf0, 0x7d, 0xd8, 0x9c, 0xcd, 0x88, 0x19, 0x53, 0x5a, 0x04, 0x2d, 0x67, 0xc3, 0x74,
-	0xe1, 0x8c, 0xa6, 0x88, 0x74, 0xa1, 0x9e, 0x28, 0xd7, 0x14, 0x71, 0xb6, 0x5e, 0xa9, 0x72, 0xf6,
-	0x05, 0x6c, 0x2e, 0x44, 0x79, 0xf7, 0x08, 0x38, 0x80, 0xb6, 0xbe, 0xc2, 0xe5, 0xf1, 0xe4, 0x92,
-	0x8a, 0x24, 0x3a, 0xd3, 0x57, 0x6f, 0x34, 0x68, 0xaf, 0xc3, 0xda, 0x40, 0x7a, 0x32, 0x4e, 0x1e,
-	0x6f, 0xfb, 0x97, 0x02, 0xb4, 0x13, 0xc4, 0xdc, 0x72, 0xfb, 0x55, 0x29, 0x64, 0x5f, 0x95, 0xbc,
-	0x27, 0xbf, 0x98, 0xff, 0xe4, 0x67, 0x5f, 0xbd, 0x52, 0xce, 0xab, 0x67, 0x9f, 0xc2, 0xc6, 0x8b,
-	0xf8, 0x67, 0x2a, 0xbe, 0x11, 0x22, 0x10, 0x2b, 0x96, 0xe5, 0x3e, 0x34, 0xa8, 0x32, 0x77, 0x27,
-	0xd1, 0xc8, 0xbc, 0x25, 0x75, 0x04, 0x5e, 0x47, 0x23, 0x7b, 0x0b, 0x48, 0xda, 0xa1, 0xce, 0xed,
-	0xe8, 0x63, 0x05, 0x76, 0x35, 0xab, 0x98, 0x8d, 0xfe, 0x0d, 0x10, 0x03, 0x2a, 0xa6, 0x2a, 0xad,
-	0x2b, 0xe8, 0xdc, 0xfe, 0x83, 0x22, 0x87, 0xab, 0xfc, 0x65, 0x61, 0xb8, 0xdd, 0xcf, 0x56, 0xff,
-	0x21, 0x7b, 0x52, 0x20, 0xef, 0xa1, 0x99, 0xfa, 0x13, 0x21, 0xfb, 0x39, 0x3d, 0x93, 0xf9, 0xd7,
-	0xea, 0x1e, 0xdc, 0x61, 0x65, 0x4a, 0xe8, 0x43, 0x7b, 0x71, 0x82, 0x93, 0xc7, 0x77, 0xcf, 0x78,
-	0x7d, 0x43, 0x6f, 0xd5, 0xc7, 0x80, 0x5c, 0x00, 0xcc, 0xbb, 0x9a, 0x3c, 0x5a, 0x61, 0x16, 0x76,
-	0xf7, 0x57, 0x19, 0x0c, 0xc8, 0xcc, 0x5c, 0xfd, 0x64, 0xc9, 0xa1, 0xc5, 0x16, 0xce, 0x65, 0x26,
-	0xa7, 0x85, 0xbe, 0x83, 0xaa, 0x96, 0x3b, 0xf9, 0x34, 0x7b, 0x60, 0xa1, 0x35, 0xba, 0x7b, 0xcb,
-	0x0d, 0xe6, 0x0c, 0xcc, 0x35, 0x96, 0xc7, 0x40, 0x46, 0xd2, 0x79, 0x0c, 0x64, 0x65, 0x7a, 0x59,
-	0xc5, 0x2e, 0xfa, 0xe2, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xc6, 0x06, 0xe1, 0x33, 0xfe, 0x0c,
-	0x00, 0x00,
+func init() { proto.RegisterFile("api.proto", fileDescriptor_api_d9803d3b7403a1e1) }
+
+var fileDescriptor_api_d9803d3b7403a1e1 = []byte{
+	// 1517 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0xdd, 0x72, 0x1b, 0x35,
+	0x14, 0x1e, 0xff, 0xc4, 0x76, 0x8e, 0x63, 0x27, 0x51, 0xd2, 0xd4, 0x71, 0x29, 0xa4, 0xdb, 0x84,
+	0xa6, 0x65, 0xc8, 0x74, 0x42, 0xb9, 0xa2, 0x33, 0xd0, 0xbf, 0xd0, 0x0c, 0xa4, 0x49, 0xd7, 0xa1,
+	0x61, 0x3a, 0xc3, 0xec, 0x28, 0xbb, 0x8a, 0x2d, 0x92, 0xfd, 0xe9, 0x4a, 0xeb, 0x9a, 0x6b, 0xde,
+	0x81, 0x27, 0xe0, 0x9e, 0x0b, 0x1e, 0x80, 0xe1, 0x29, 0xb8, 0xe3, 0x0e, 0x6e, 0x78, 0x09, 0x46,
+	0x3a, 0x5a, 0x7b, 0x9d, 0x75, 0x6a, 0x33, 0xdc, 0xad, 0xbe, 0x73, 0x74, 0xa4, 0xf3, 0x9d, 0x4f,
+	0x3a, 0x5a, 0x98, 0xa7, 0x11, 0xdf, 0x89, 0xe2, 0x50, 0x86, 0x64, 0xc9, 0x73, 0x63, 0xc9, 0xdd,
+	0x73, 0x26, 0x7d, 0x2a, 0xdd, 0x1e, 0x8b, 0xad, 0x4f, 0x61, 0xee, 0x78, 0x70, 0x98, 0x48, 0xb2,
+	0x0a, 0x73, 0x7d, 0x7a, 0x91, 0xb0, 0x56, 0x61, 0xa3, 0xb0, 0x5d, 0xb6, 0x71, 0x40, 0xd6, 0xa0,
+	0x22, 0xdc, 0x98, 0x47, 0xb2, 0x55, 0xdc, 0x28, 0x6c, 0x2f, 0xd8, 0x66, 0x64, 0xbd, 0x86, 0xda,
+	0x61, 0x22, 0x8f, 0x42, 0x1e, 0x48, 0x72, 0x03, 0xe6, 0xa3, 0x98, 0xf5, 0x9d, 0x1e, 0x15, 0x3d,
+	0x3d, 0x7b, 0xc1, 0xae, 0x29, 0xe0, 0x39, 0x15, 0x3d, 0x72, 0x13, 0x40, 0x1b, 0x79, 0xe0, 0xb1,
+	0x81, 0x0e, 0x32, 0x67, 0x6b, 0xf7, 0x7d, 0x05, 0x10, 0x02, 0x65, 0x19, 0x33, 0xd6, 0x2a, 0x69,
+	0x83, 0xfe, 0xb6, 0x1e, 0xc2, 0xf5, 0x13, 0xb5, 0xbb, 0x13, 0xca, 0x25, 0x0f, 0xba, 0x5f, 0x73,
+	0x21, 0x6d, 0xf6, 0x26, 0x61, 0x42, 0x92, 0x5b, 0xb0, 0x20, 0x58, 0xe0, 0x39, 0x6e, 0x12, 0xc7,
+	0x2c, 0x90, 0x7a, 0xb5, 0x9a, 0x5d, 0x57, 0xd8, 0x13, 0x84, 0xac, 0x5f, 0x0a, 0xd0, 0xca, 0x4f,
+	0x17, 0x51, 0x18, 0x08, 0x46, 0x9e, 0x43, 0xe5, 0x4d, 0xc2, 0x12, 0x26, 0x5a, 0x85, 0x8d, 0xd2,
+	0x76, 0x7d, 0xf7, 0xfe, 0xce, 0x65, 0x42, 0x76, 0xae, 0x9a, 0xbb, 0xf3, 0x52, 0x4d, 0xb4, 0xcd,
+	0xfc, 0xf6, 0x3e, 0xcc, 0x69, 0x40, 0x65, 0x10, 0x50, 0x1f, 0x69, 0x9b, 0xb7, 0xf5, 0x37, 0x69,
+	0x41, 0x95, 0xfa, 0x61, 0x12, 0x48, 0xd1, 0x2a, 0x6e, 0x94, 0xb6, 0xcb, 0x76, 0x3a, 0x54, 0xde,
+	0x51, 0x18, 0x5e, 0xe8, 0x7c, 0xe7, 0x6d, 0xfd, 0x6d, 0xed, 0x01, 0xbc, 0x0a, 0x25, 0x7b, 0xd2,
+	0x0b, 0xb9, 0xcb, 0x14, 0x9b, 0xb4, 0xcb, 0x02, 0x8f, 0x3a, 0xdc, 0x33, 0x41, 0x6b, 0x08, 0xec,
+	0x7b, 0xca, 0xe8, 0x6a, 0x37, 0x65, 0x2c, 0xa2, 0x11, 0x81, 0x7d, 0xcf, 0xfa, 0xbb, 0x08, 0x64,
+	0x8f, 0x07, 0xde, 0x81, 0xce, 0x44, 0xa4, 0x9c, 0xdd, 0x85, 0x25, 0x5d, 0x7c, 0x37, 0xbc, 0x70,
+	0xfa, 0x2c, 0x16, 0x3c, 0x0c, 0x74, 0xdc, 0x86, 0xbd, 0x98, 0xe2, 0xaf, 0x10, 0x56, 0xd5, 0xc6,
+	0x8d, 0xea, 0xd8, 0x65, 0xdb, 0x8c, 0x90, 0x76, 0xa1, 0x5c, 0x1c, 0x9d, 0x2b, 0xee, 0xbe, 0x6e,
+	0xb0, 0x17, 0x2a, 0xe5, 0x5b, 0xb0, 0xd0, 0x0f, 0x25, 0x73, 0xa8, 0xe7, 0xc5, 0x4c, 0x88, 0x56,
+	0x19, 0x5d, 0x14, 0xf6, 0x08, 0x21, 0xe5, 0xa2, 0xf2, 0x1d, 0xba, 0xcc, 0xa1, 0x8b, 0xc2, 0x52,
+	0x97, 0xcf, 0x4d, 0x14, 0xcc, 0x49, 0xb4, 0x2a, 0xba, 0x4a, 0xef, 0xe5, 0xab, 0x34, 0x22, 0x0c,
+	0xd7, 0xc0, 0x6f, 0x41, 0x1e, 0xc0, 0x5a, 0x36, 0x80, 0x23, 0x78, 0x37, 0xa0, 0x32, 0x89, 0x59,
+	0xab, 0xaa, 0x85, 0xb9, 0x9a, 0x71, 0xee, 0xa4, 0xb6, 0x61, 0x55, 0x6a, 0xa3, 0xaa, 0x90, 0x75,
+	0xa8, 0x7d, 0x1f, 0xf2, 0xc0, 0xf1, 0xa9, 0xdb, 0x9a, 0xd7, 0x73, 0xab, 0x6a, 0x7c, 0x40, 0x5d,
+	0xeb, 0x8f, 0x22, 0xac, 0x8c, 0x11, 0x6d, 0xd4, 0x75, 0x13, 0x20, 0xa5, 0xc9, 0xd4, 0xae, 0x61,
+	0xcf, 0x1b, 0x64, 0xdf, 0xbb, 0x92, 0xdd, 0x25, 0x28, 0x9d, 0x99, 0x23, 0x50, 0xb6, 0xd5, 0xa7,
+	0x5a, 0x5b, 0x33, 0xa5, 0xe0, 0xb2, 0x86, 0xab, 0x6a, 0xbc, 0xc7, 0x18, 0xd9, 0x82, 0xa6, 0x4f,
+	0x79, 0xe0, 0xf6, 0x28, 0x0f, 0xf0, 0xc4, 0xcd, 0xe9, 0xcd, 0x35, 0x86, 0xa8, 0x3e, 0x76, 0x77,
+	0x61, 0x29, 0xe3, 0xc6, 0x78, 0xb7, 0x27, 0x5b, 0x15, 0x2c, 0xfa, 0xc8, 0x51, 0xc3, 0xaa, 0x2c,
+	0xc8, 0xad, 0x13, 0xc5, 0xdc, 0x45, 0xa2, 0xca, 0x76, 0x1d, 0xb1, 0x23, 0x05, 0x91, 0x3b, 0xb0,
+	0x18, 0x9c, 0x3a, 0x11, 0x55, 0x45, 0xe0, 0x11, 0x55, 0xba, 0xae, 0xe9, 0x60, 0xcd, 0xe0, 0xf4,
+	0x28, 0x83, 0x92, 0xdb, 0xd0, 0x48, 0x19, 0x90, 0xe1, 0x39, 0x0b, 0x0c, 0x73, 0xa9, 0x7a, 0x8e,
+	0x15, 0xa6, 0xb2, 0x3b, 0x63, 0xcc, 0x89, 0xa9, 0x64, 0x2d, 0xc0, 0xec, 0xce, 0x18, 0xb3, 0xa9,
+	0x64, 0xd6, 0x9f, 0x45, 0xb8, 0xf6, 0x25, 0x0b, 0x98, 0xb2, 0x1d, 0xeb, 0x0d, 0xa4, 0x2a, 0x9e,
+	0xc2, 0xed, 0xc7, 0x40, 0xdc, 0xd0, 0xf7, 0xb9, 0xf4, 0x59, 0x20, 0x87, 0x0a, 0xc3, 0x13, 0xb2,
+	0x3c, 0xb2, 0xa4, 0x3a, 0xdb, 0x86, 0x25, 0x11, 0x5d, 0x70, 0xe9, 0xc8, 0xc1, 0xd0, 0x19, 0x45,
+	0xdd, 0xd4, 0xf8, 0xf1, 0x60, 0xa4, 0xc8, 0xc5, 0xa1, 0xa7, 0xdb, 0xa3, 0x41, 0x17, 0x2b, 0x52,
+	0xdf, 0xbd, 0x9e, 0x17, 0xa5, 0xbe, 0x48, 0xed, 0x86, 0x89, 0xf0, 0x44, 0x7b, 0x93, 0xc7, 0x99,
+	0x00, 0x3c, 0x88, 0x12, 0xa9, 0x84, 0xaf, 0x54, 0xdd, 0xce, 0x07, 0x48, 0xaf, 0xd4, 0x61, 0x8c,
+	0x7d, 0x3d, 0x01, 0x69, 0x75, 0x63, 0x26, 0x83, 0x53, 0xac, 0x79, 0x25, 0xa5, 0x15, 0x41, 0x5d,
+	0xf2, 0x1c, 0xf7, 0xd5, 0x3c, 0xf7, 0xd6, 0x5f, 0x45, 0x58, 0xbb, 0x4c, 0xb0, 0x51, 0xef, 0x3a,
+	0xd4, 0xd2, 0x8d, 0x9a, 0x5b, 0xbc, 0x6a, 0x76, 0xa1, 0xea, 0x6f, 0x24, 0x22, 0x99, 0x1f, 0x5d,
+	0xa8, 0xc2, 0x61, 0x3b, 0x68, 0x22, 0x7c, 0x6c, 0x50, 0xf2, 0x2d, 0x2c, 0x8c, 0xa9, 0xa4, 0xa4,
+	0x33, 0x7d, 0x90, 0xcf, 0x74, 0xf2, 0x1e, 0x76, 0x32, 0x62, 0xb2, 0xc7, 0x22, 0xa9, 0xf6, 0x84,
+	0x2d, 0xa4, 0xac, 0x4b, 0x8f, 0x83, 0xf6, 0x4f, 0x05, 0xa8, 0x67, 0xe6, 0x64, 0x8e, 0x58, 0x61,
+	0xec, 0x88, 0xe5, 0x08, 0x2c, 0x4e, 0x20, 0x70, 0x13, 0x9a, 0xfa, 0xee, 0x88, 0xce, 0x1d, 0xd3,
+	0xf3, 0x4a, 0xe8, 0xa5, 0xd0, 0xa3, 0xf3, 0x8e, 0xc6, 0x94, 0x97, 0x3e, 0x9b, 0x23, 0xaf, 0x32,
+	0x7a, 0x29, 0x34, 0xf5, 0xb2, 0x7e, 0x2d, 0xc2, 0xf2, 0x5e, 0x12, 0x78, 0xff, 0x49, 0xc4, 0xdf,
+	0x40, 0x15, 0x59, 0xc2, 0xb6, 0x51, 0xdf, 0xfd, 0x2c, 0x4f, 0x5c, 0x2e, 0xa8, 0x46, 0x98, 0x97,
+	0x61, 0xc1, 0x98, 0xd3, 0x58, 0x64, 0x17, 0xae, 0xc5, 0xac, 0x1f, 0xba, 0x54, 0xaa, 0x85, 0x71,
+	0xd3, 0xea, 0x62, 0x34, 0xe9, 0xad, 0x8c, 0x8c, 0xb8, 0xf9, 0x0e, 0xef, 0xe6, 0xc5, 0x54, 0xce,
+	0x8b, 0xa9, 0x7d, 0x08, 0xd7, 0xaf, 0x58, 0x5c, 0xdd, 0xc3, 0x46, 0x31, 0x5a, 0xf3, 0x66, 0x55,
+	0xb5, 0x28, 0x4a, 0x6b, 0x15, 0xad, 0x5a, 0xdf, 0x9d, 0xd4, 0x66, 0xfd, 0x56, 0x00, 0x92, 0x4d,
+	0xd0, 0x28, 0xf3, 0xd5, 0x88, 0x17, 0x6c, 0xdb, 0x0f, 0xdf, 0xcd, 0x8b, 0x11, 0xd3, 0x34, 0x62,
+	0xda, 0x2f, 0xaf, 0xde, 0xff, 0x1a, 0x54, 0xd0, 0xcb, 0xec, 0xd7, 0x8c, 0xc8, 0xfb, 0x00, 0x23,
+	0xba, 0x8c, 0x8a, 0x32, 0x88, 0xf5, 0xb3, 0xc9, 0xa0, 0x83, 0x27, 0x67, 0xc6, 0xc2, 0xef, 0xc0,
+	0xca, 0xf0, 0x8e, 0x18, 0x32, 0x85, 0x22, 0x58, 0xb0, 0x97, 0xcd, 0x29, 0x1c, 0xd2, 0x24, 0x48,
+	0x1b, 0x6a, 0xa9, 0x72, 0x4d, 0x11, 0x87, 0xe3, 0x99, 0x2a, 0x67, 0x9d, 0xc0, 0xca, 0xd8, 0x2e,
+	0xa7, 0x5f, 0x01, 0x5b, 0xd0, 0xc4, 0x25, 0x9c, 0x20, 0xf1, 0x4f, 0x59, 0x9c, 0xee, 0xce, 0x9c,
+	0xab, 0x17, 0x08, 0x5a, 0x7b, 0xd0, 0xe8, 0x48, 0x2a, 0x93, 0xe1, 0xeb, 0x23, 0x6d, 0xad, 0x85,
+	0x4c, 0x6b, 0xbd, 0xfc, 0x9c, 0x28, 0xe6, 0x9e, 0x13, 0xd6, 0x3f, 0x45, 0x68, 0xa6, 0x81, 0xcc,
+	0xe6, 0x2e, 0xf7, 0xa9, 0x42, 0xbe, 0x4f, 0x4d, 0x7a, 0xea, 0x14, 0x27, 0x3f, 0x75, 0xf2, 0x7d,
+	0xb4, 0x34, 0x6b, 0x1f, 0x2d, 0x4f, 0xee, 0xa3, 0x5f, 0xc0, 0x4d, 0x21, 0xe9, 0x39, 0x73, 0x3c,
+	0x7e, 0x76, 0x66, 0x7a, 0x85, 0x23, 0x64, 0x18, 0x39, 0x6f, 0x79, 0xe0, 0x85, 0x6f, 0x75, 0xa3,
+	0x9e, 0xb3, 0xd7, 0xb5, 0xd3, 0x53, 0x7e, 0x76, 0x86, 0x0d, 0xa2, 0x23, 0xc3, 0xe8, 0x44, 0x3b,
+	0x90, 0x7b, 0xb0, 0x1c, 0xb0, 0x81, 0x74, 0xc6, 0xd2, 0xac, 0xe8, 0x34, 0x17, 0x95, 0xe1, 0x38,
+	0x93, 0x6a, 0xb6, 0x89, 0x56, 0xc7, 0x9a, 0x28, 0xb9, 0x0f, 0xab, 0x3e, 0x1d, 0x38, 0x29, 0xc5,
+	0x5e, 0x12, 0xa3, 0x5a, 0xb1, 0x65, 0x13, 0x9f, 0x0e, 0x3a, 0x68, 0x7a, 0x6a, 0x2c, 0xd6, 0x21,
+	0x2c, 0x3f, 0x4e, 0x7e, 0x60, 0xf1, 0xb3, 0x38, 0x0e, 0xe3, 0x19, 0x35, 0x7b, 0x03, 0xe6, 0x99,
+	0x72, 0x77, 0x7c, 0xd1, 0x4d, 0x9f, 0xa2, 0x1a, 0x38, 0x10, 0x5d, 0x6b, 0x15, 0x48, 0x36, 0x20,
+	0x56, 0xd0, 0xf2, 0x60, 0xe5, 0x99, 0x90, 0xdc, 0xa7, 0x92, 0xa9, 0x07, 0xf6, 0xff, 0x93, 0x48,
+	0xe6, 0xae, 0x2f, 0x65, 0xef, 0x7a, 0xeb, 0xf7, 0x02, 0xac, 0x8e, 0x2f, 0x63, 0x04, 0xa4, 0x4a,
+	0xce, 0x85, 0xe0, 0x41, 0xd7, 0x19, 0x6b, 0x12, 0x0d, 0x83, 0x3e, 0xd2, 0xa0, 0x72, 0xa3, 0x7d,
+	0x16, 0xd3, 0x2e, 0x73, 0xc6, 0x9e, 0x6b, 0x0d, 0x83, 0x1a, 0xb7, 0x1d, 0x58, 0xa1, 0x71, 0xcc,
+	0xfb, 0xf4, 0xc2, 0xe1, 0x81, 0x64, 0xb1, 0xfa, 0xf0, 0xf1, 0x15, 0x51, 0xb2, 0x97, 0x8d, 0x69,
+	0xdf, 0x58, 0x0e, 0x04, 0xf9, 0x08, 0x96, 0xd9, 0x20, 0x62, 0xae, 0x64, 0x9e, 0x63, 0xac, 0xc2,
+	0x48, 0x69, 0x29, 0x35, 0x3c, 0x32, 0xf8, 0xee, 0x8f, 0x15, 0x58, 0xc7, 0xc3, 0xa9, 0x4b, 0x8e,
+	0x0f, 0xcd, 0xb8, 0xc3, 0xe2, 0xbe, 0xaa, 0xfd, 0x39, 0x2c, 0x5d, 0xfe, 0x4b, 0x21, 0x77, 0x67,
+	0xf9, 0x93, 0xd1, 0x7c, 0xb7, 0xef, 0xcd, 0xfe, 0xd3, 0x73, 0xbf, 0x40, 0x5e, 0x43, 0x3d, 0xf3,
+	0xd6, 0x25, 0x9b, 0x13, 0xae, 0xde, 0xdc, 0x3f, 0x47, 0x7b, 0x6b, 0x8a, 0x97, 0xa9, 0x88, 0x0b,
+	0xcd, 0xf1, 0x87, 0x00, 0xb9, 0x33, 0xfd, 0xa9, 0x80, 0x2b, 0x6c, 0xcf, 0xfa, 0xa6, 0x20, 0x27,
+	0x00, 0xa3, 0xe6, 0x40, 0x6e, 0xcf, 0xd0, 0x52, 0xdb, 0x9b, 0xb3, 0xf4, 0x17, 0xcd, 0xcc, 0xe8,
+	0x12, 0x25, 0x57, 0x4c, 0x1a, 0xef, 0x04, 0xed, 0xad, 0x29, 0x5e, 0x26, 0xf6, 0x57, 0x50, 0xc1,
+	0xeb, 0x8f, 0x7c, 0x90, 0x9f, 0x30, 0x76, 0xc3, 0xb6, 0x37, 0xae, 0x76, 0x18, 0x31, 0x30, 0x3a,
+	0x8d, 0x93, 0x18, 0xc8, 0x1d, 0xfe, 0xf6, 0xe6, 0xbb, 0x9d, 0x4c, 0xe0, 0xef, 0x60, 0x21, 0x7b,
+	0xd2, 0xc8, 0x84, 0xe4, 0x26, 0x1c, 0xf8, 0xf6, 0x87, 0xd3, 0xdc, 0x30, 0xfc, 0x69, 0x45, 0x5f,
+	0xda, 0x9f, 0xfc, 0x3b, 0x00, 0xc7, 0x05, 0x39, 0xfb, 0xc1, 0x10, 0x00, 0x00,
 }
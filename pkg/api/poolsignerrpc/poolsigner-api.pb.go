@@ -0,0 +1,347 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: poolsigner-api.proto
+
+package poolsignerrpc
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type PoolFeeAddressRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PoolFeeAddressRequest) Reset()         { *m = PoolFeeAddressRequest{} }
+func (m *PoolFeeAddressRequest) String() string { return proto.CompactTextString(m) }
+func (*PoolFeeAddressRequest) ProtoMessage()    {}
+func (*PoolFeeAddressRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_poolsigner_api_6baff4c5876f0bef, []int{0}
+}
+func (m *PoolFeeAddressRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PoolFeeAddressRequest.Unmarshal(m, b)
+}
+func (m *PoolFeeAddressRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PoolFeeAddressRequest.Marshal(b, m, deterministic)
+}
+func (dst *PoolFeeAddressRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PoolFeeAddressRequest.Merge(dst, src)
+}
+func (m *PoolFeeAddressRequest) XXX_Size() int {
+	return xxx_messageInfo_PoolFeeAddressRequest.Size(m)
+}
+func (m *PoolFeeAddressRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PoolFeeAddressRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PoolFeeAddressRequest proto.InternalMessageInfo
+
+type PoolFeeAddressResponse struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	Address              string   `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	KeyIndex             uint32   `protobuf:"varint,3,opt,name=key_index,json=keyIndex" json:"key_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PoolFeeAddressResponse) Reset()         { *m = PoolFeeAddressResponse{} }
+func (m *PoolFeeAddressResponse) String() string { return proto.CompactTextString(m) }
+func (*PoolFeeAddressResponse) ProtoMessage()    {}
+func (*PoolFeeAddressResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_poolsigner_api_6baff4c5876f0bef, []int{1}
+}
+func (m *PoolFeeAddressResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PoolFeeAddressResponse.Unmarshal(m, b)
+}
+func (m *PoolFeeAddressResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PoolFeeAddressResponse.Marshal(b, m, deterministic)
+}
+func (dst *PoolFeeAddressResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PoolFeeAddressResponse.Merge(dst, src)
+}
+func (m *PoolFeeAddressResponse) XXX_Size() int {
+	return xxx_messageInfo_PoolFeeAddressResponse.Size(m)
+}
+func (m *PoolFeeAddressResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PoolFeeAddressResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PoolFeeAddressResponse proto.InternalMessageInfo
+
+func (m *PoolFeeAddressResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *PoolFeeAddressResponse) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PoolFeeAddressResponse) GetKeyIndex() uint32 {
+	if m != nil {
+		return m.KeyIndex
+	}
+	return 0
+}
+
+type SignPoolSplitOutputRequest struct {
+	SplitTx              []byte   `protobuf:"bytes,1,opt,name=split_tx,json=splitTx,proto3" json:"split_tx,omitempty"`
+	Ticket               []byte   `protobuf:"bytes,2,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	KeyIndex             uint32   `protobuf:"varint,3,opt,name=key_index,json=keyIndex" json:"key_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignPoolSplitOutputRequest) Reset()         { *m = SignPoolSplitOutputRequest{} }
+func (m *SignPoolSplitOutputRequest) String() string { return proto.CompactTextString(m) }
+func (*SignPoolSplitOutputRequest) ProtoMessage()    {}
+func (*SignPoolSplitOutputRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_poolsigner_api_6baff4c5876f0bef, []int{2}
+}
+func (m *SignPoolSplitOutputRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignPoolSplitOutputRequest.Unmarshal(m, b)
+}
+func (m *SignPoolSplitOutputRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignPoolSplitOutputRequest.Marshal(b, m, deterministic)
+}
+func (dst *SignPoolSplitOutputRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignPoolSplitOutputRequest.Merge(dst, src)
+}
+func (m *SignPoolSplitOutputRequest) XXX_Size() int {
+	return xxx_messageInfo_SignPoolSplitOutputRequest.Size(m)
+}
+func (m *SignPoolSplitOutputRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignPoolSplitOutputRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignPoolSplitOutputRequest proto.InternalMessageInfo
+
+func (m *SignPoolSplitOutputRequest) GetSplitTx() []byte {
+	if m != nil {
+		return m.SplitTx
+	}
+	return nil
+}
+
+func (m *SignPoolSplitOutputRequest) GetTicket() []byte {
+	if m != nil {
+		return m.Ticket
+	}
+	return nil
+}
+
+func (m *SignPoolSplitOutputRequest) GetKeyIndex() uint32 {
+	if m != nil {
+		return m.KeyIndex
+	}
+	return 0
+}
+
+type SignPoolSplitOutputResponse struct {
+	Error                string   `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	SignatureScript      []byte   `protobuf:"bytes,2,opt,name=signature_script,json=signatureScript,proto3" json:"signature_script,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignPoolSplitOutputResponse) Reset()         { *m = SignPoolSplitOutputResponse{} }
+func (m *SignPoolSplitOutputResponse) String() string { return proto.CompactTextString(m) }
+func (*SignPoolSplitOutputResponse) ProtoMessage()    {}
+func (*SignPoolSplitOutputResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_poolsigner_api_6baff4c5876f0bef, []int{3}
+}
+func (m *SignPoolSplitOutputResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignPoolSplitOutputResponse.Unmarshal(m, b)
+}
+func (m *SignPoolSplitOutputResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignPoolSplitOutputResponse.Marshal(b, m, deterministic)
+}
+func (dst *SignPoolSplitOutputResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignPoolSplitOutputResponse.Merge(dst, src)
+}
+func (m *SignPoolSplitOutputResponse) XXX_Size() int {
+	return xxx_messageInfo_SignPoolSplitOutputResponse.Size(m)
+}
+func (m *SignPoolSplitOutputResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignPoolSplitOutputResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignPoolSplitOutputResponse proto.InternalMessageInfo
+
+func (m *SignPoolSplitOutputResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SignPoolSplitOutputResponse) GetSignatureScript() []byte {
+	if m != nil {
+		return m.SignatureScript
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*PoolFeeAddressRequest)(nil), "poolsignerrpc.PoolFeeAddressRequest")
+	proto.RegisterType((*PoolFeeAddressResponse)(nil), "poolsignerrpc.PoolFeeAddressResponse")
+	proto.RegisterType((*SignPoolSplitOutputRequest)(nil), "poolsignerrpc.SignPoolSplitOutputRequest")
+	proto.RegisterType((*SignPoolSplitOutputResponse)(nil), "poolsignerrpc.SignPoolSplitOutputResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for PoolSignerService service
+
+type PoolSignerServiceClient interface {
+	PoolFeeAddress(ctx context.Context, in *PoolFeeAddressRequest, opts ...grpc.CallOption) (*PoolFeeAddressResponse, error)
+	SignPoolSplitOutput(ctx context.Context, in *SignPoolSplitOutputRequest, opts ...grpc.CallOption) (*SignPoolSplitOutputResponse, error)
+}
+
+type poolSignerServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPoolSignerServiceClient(cc *grpc.ClientConn) PoolSignerServiceClient {
+	return &poolSignerServiceClient{cc}
+}
+
+func (c *poolSignerServiceClient) PoolFeeAddress(ctx context.Context, in *PoolFeeAddressRequest, opts ...grpc.CallOption) (*PoolFeeAddressResponse, error) {
+	out := new(PoolFeeAddressResponse)
+	err := grpc.Invoke(ctx, "/poolsignerrpc.PoolSignerService/PoolFeeAddress", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *poolSignerServiceClient) SignPoolSplitOutput(ctx context.Context, in *SignPoolSplitOutputRequest, opts ...grpc.CallOption) (*SignPoolSplitOutputResponse, error) {
+	out := new(SignPoolSplitOutputResponse)
+	err := grpc.Invoke(ctx, "/poolsignerrpc.PoolSignerService/SignPoolSplitOutput", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for PoolSignerService service
+
+type PoolSignerServiceServer interface {
+	PoolFeeAddress(context.Context, *PoolFeeAddressRequest) (*PoolFeeAddressResponse, error)
+	SignPoolSplitOutput(context.Context, *SignPoolSplitOutputRequest) (*SignPoolSplitOutputResponse, error)
+}
+
+func RegisterPoolSignerServiceServer(s *grpc.Server, srv PoolSignerServiceServer) {
+	s.RegisterService(&_PoolSignerService_serviceDesc, srv)
+}
+
+func _PoolSignerService_PoolFeeAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PoolFeeAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PoolSignerServiceServer).PoolFeeAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/poolsignerrpc.PoolSignerService/PoolFeeAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PoolSignerServiceServer).PoolFeeAddress(ctx, req.(*PoolFeeAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PoolSignerService_SignPoolSplitOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignPoolSplitOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PoolSignerServiceServer).SignPoolSplitOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/poolsignerrpc.PoolSignerService/SignPoolSplitOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PoolSignerServiceServer).SignPoolSplitOutput(ctx, req.(*SignPoolSplitOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PoolSignerService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "poolsignerrpc.PoolSignerService",
+	HandlerType: (*PoolSignerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PoolFeeAddress",
+			Handler:    _PoolSignerService_PoolFeeAddress_Handler,
+		},
+		{
+			MethodName: "SignPoolSplitOutput",
+			Handler:    _PoolSignerService_SignPoolSplitOutput_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "poolsigner-api.proto",
+}
+
+func init() {
+	proto.RegisterFile("poolsigner-api.proto", fileDescriptor_poolsigner_api_6baff4c5876f0bef)
+}
+
+var fileDescriptor_poolsigner_api_6baff4c5876f0bef = []byte{
+	// 291 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xcf, 0x4a, 0xc3, 0x40,
+	0x10, 0xc6, 0x89, 0x62, 0xff, 0x0c, 0xad, 0x7f, 0xd6, 0x5a, 0x63, 0x7a, 0x29, 0x41, 0xa1, 0x15,
+	0xcc, 0x41, 0x9f, 0xc0, 0x8b, 0xe0, 0x49, 0x49, 0xbc, 0x6a, 0x88, 0xc9, 0x50, 0x96, 0x84, 0xec,
+	0x3a, 0xbb, 0x91, 0xf4, 0x6d, 0x7d, 0x14, 0xc9, 0x26, 0x55, 0x5a, 0x62, 0xed, 0xf1, 0xfb, 0x76,
+	0x76, 0x7f, 0x33, 0xdf, 0x2c, 0x8c, 0xa4, 0x10, 0x99, 0xe2, 0x8b, 0x1c, 0xe9, 0x26, 0x92, 0xdc,
+	0x93, 0x24, 0xb4, 0x60, 0xc3, 0x5f, 0x97, 0x64, 0xec, 0x9e, 0xc3, 0xd9, 0xb3, 0x10, 0xd9, 0x03,
+	0xe2, 0x7d, 0x92, 0x10, 0x2a, 0xe5, 0xe3, 0x47, 0x81, 0x4a, 0xbb, 0x08, 0xe3, 0xcd, 0x03, 0x25,
+	0x45, 0xae, 0x90, 0x8d, 0xe0, 0x00, 0x89, 0x04, 0xd9, 0xd6, 0xd4, 0x9a, 0xf5, 0xfd, 0x5a, 0x30,
+	0x1b, 0xba, 0x51, 0x5d, 0x68, 0xef, 0x19, 0x7f, 0x25, 0xd9, 0x04, 0xfa, 0x29, 0x2e, 0x43, 0x9e,
+	0x27, 0x58, 0xda, 0xfb, 0x53, 0x6b, 0x36, 0xf4, 0x7b, 0x29, 0x2e, 0x1f, 0x2b, 0xed, 0x66, 0xe0,
+	0x04, 0x7c, 0x91, 0x57, 0xa8, 0x40, 0x66, 0x5c, 0x3f, 0x15, 0x5a, 0x16, 0xba, 0x69, 0x82, 0x5d,
+	0x40, 0x4f, 0x55, 0x6e, 0xa8, 0x4b, 0x43, 0x1b, 0xf8, 0x5d, 0xa3, 0x5f, 0x4a, 0x36, 0x86, 0x8e,
+	0xe6, 0x71, 0x8a, 0xda, 0xe0, 0x06, 0x7e, 0xa3, 0xb6, 0xd3, 0xde, 0x60, 0xd2, 0x4a, 0xdb, 0x3a,
+	0xd9, 0x1c, 0x8e, 0xab, 0xbc, 0x22, 0x5d, 0x10, 0x86, 0x2a, 0x26, 0x2e, 0x57, 0xcc, 0xa3, 0x1f,
+	0x3f, 0x30, 0xf6, 0xed, 0x97, 0x05, 0x27, 0xe6, 0x71, 0x93, 0x6f, 0x80, 0xf4, 0xc9, 0x63, 0x64,
+	0xaf, 0x70, 0xb8, 0x1e, 0x25, 0xbb, 0xf4, 0xd6, 0xb6, 0xe0, 0xb5, 0xae, 0xc0, 0xb9, 0xfa, 0xa7,
+	0xaa, 0xe9, 0x3a, 0x83, 0xd3, 0x96, 0xa1, 0xd8, 0x7c, 0xe3, 0xf6, 0xdf, 0x31, 0x3b, 0xd7, 0xbb,
+	0x94, 0xd6, 0xb4, 0xf7, 0x8e, 0xf9, 0x46, 0x77, 0xdf, 0x03, 0x00, 0xfd, 0xbb, 0x26, 0xd8, 0x5e,
+	0x02, 0x00, 0x00,
+}
@@ -2,3 +2,4 @@
 
 protoc -I. api.proto --go_out=plugins=grpc:matcherrpc
 protoc -I. integrator-api.proto --go_out=plugins=grpc:integratorrpc
+protoc -I. poolsigner-api.proto --go_out=plugins=grpc:poolsignerrpc
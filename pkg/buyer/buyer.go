@@ -19,6 +19,8 @@ import (
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type buyerSessionParticipant struct {
@@ -42,6 +44,14 @@ type Session struct {
 	TicketPrice  dcrutil.Amount
 	sessionToken []byte
 
+	// feeRate is the fee rate (in Atoms/KB) negotiated with the matcher for
+	// the split and ticket transactions.
+	feeRate dcrutil.Amount
+
+	// completedStage is the last stage of the session that was completed
+	// by the buyer. It's used to resume the session at the correct stage.
+	completedStage Stage
+
 	mainchainHash   *chainhash.Hash
 	mainchainHeight uint32
 	nbParticipants  uint32
@@ -50,6 +60,7 @@ type Session struct {
 
 	voteAddress         dcrutil.Address
 	poolAddress         dcrutil.Address
+	voteChoices         splitticket.VoteChoices
 	splitOutputAddress  dcrutil.Address
 	ticketOutputAddress dcrutil.Address
 	splitChange         *wire.TxOut
@@ -131,17 +142,22 @@ func (session *Session) myTotalAmountIn() dcrutil.Amount {
 }
 
 // Reporter is an interface that must be implemented to report status of a buyer
-// session during its progress.
+// session during its progress. Code outside this package should use an
+// EventReporter to receive the progress as typed events.
 type Reporter interface {
 	reportStage(context.Context, Stage, *Session, *Config)
 	reportMatcherStatus(*pbm.StatusResponse)
+	reportWaitEstimate(est *matcher.WaitEstimate, maxWait time.Duration)
+	reportStakeDiffChange(blocksToChange int32, ticketPrice,
+		expectedTicketPrice, maxAmount dcrutil.Amount)
 	reportSavedSession(string)
 	reportSrvRecordFound(record string)
 	reportSrvLookupError(err error)
 	reportSplitPublished()
-	reportRightTicketPublished()
+	reportRightTicketPublished(ticket *chainhash.Hash)
 	reportWrongTicketPublished(ticket *chainhash.Hash, session *Session)
 	reportBuyingError(err error)
+	reportExternalSignRequest(reqFname, respFname string)
 }
 
 type sessionWaiterResponse struct {
@@ -190,7 +206,26 @@ func buySplitTicket(ctx context.Context, cfg *Config) error {
 		cfg.WalletHost = hosts[0]
 	}
 
-	resp := waitForSession(ctx, cfg)
+	// All connections of a session share the same proxy, so that they share
+	// the same Tor circuit (when using Tor isolation).
+	var err error
+	cfg.sessionProxy, err = cfg.ProxyConfig().newProxy()
+	if err != nil {
+		return err
+	}
+
+	if cfg.ConsolidateUtxos && cfg.ResumeSession == "" {
+		if err := ConsolidateFunds(ctx, cfg); err != nil {
+			return errors.Wrap(err, "error consolidating funds")
+		}
+	}
+
+	var resp sessionWaiterResponse
+	if cfg.ResumeSession != "" {
+		resp = resumeSession(ctx, cfg)
+	} else {
+		resp = waitForSession(ctx, cfg)
+	}
 	if resp.err != nil {
 		return errors.Wrap(resp.err, "error waiting for session")
 	}
@@ -200,7 +235,13 @@ func buySplitTicket(ctx context.Context, cfg *Config) error {
 		resp.wc.close()
 	}()
 
-	ctxBuy, cancelBuy := context.WithTimeout(ctx, time.Second*time.Duration(cfg.MaxTime))
+	// external signers may require manual intervention, so allow for the
+	// additional time needed to sign the transactions.
+	maxTime := cfg.MaxTime
+	if cfg.externalSigner() {
+		maxTime += cfg.SignerTimeout
+	}
+	ctxBuy, cancelBuy := context.WithTimeout(ctx, time.Second*time.Duration(maxTime))
 	reschan2 := make(chan error)
 	go func() { reschan2 <- buySplitTicketInSession(ctxBuy, cfg, resp.mc, resp.wc, resp.session) }()
 
@@ -228,26 +269,10 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 
 	setupCtx, setupCancel := context.WithTimeout(mainCtx, time.Second*60)
 
-	wcc := cfg.WalletConn
-	if wcc == nil {
-		rep.reportStage(setupCtx, StageConnectingToWallet, nil, cfg)
-		wcc, err = connectToWallet(cfg.WalletHost, cfg.WalletCertFile)
-		if err != nil {
-			setupCancel()
-			return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
-				"error trying to connect to wallet")}
-		}
-	}
-	wc := &walletClient{
-		wsvc:        wcc,
-		chainParams: cfg.ChainParams,
-	}
-
-	err = wc.checkNetwork(setupCtx)
+	wc, err := connectToWalletClient(setupCtx, cfg)
 	if err != nil {
 		setupCancel()
-		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
-			"error checking for wallet network")}
+		return sessionWaiterResponse{nil, nil, nil, err}
 	}
 
 	err = wc.testVoteAddress(setupCtx, cfg)
@@ -257,11 +282,13 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 			"error testing buyer vote address")}
 	}
 
-	err = wc.testPassphrase(setupCtx, cfg)
-	if err != nil {
-		setupCancel()
-		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
-			"error testing wallet passphrase")}
+	if !cfg.externalSigner() {
+		err = wc.testPassphrase(setupCtx, cfg)
+		if err != nil {
+			setupCancel()
+			return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
+				"error testing wallet passphrase")}
+		}
 	}
 
 	err = wc.testFunds(setupCtx, cfg)
@@ -271,44 +298,23 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 			"error testing wallet funds")}
 	}
 
-	var dcrd *decredNetwork
-
-	mcc := cfg.MatcherConn
-	if mcc == nil {
-		var utxoProvider utxoMapProvider
-		if !cfg.UtxosFromDcrdata {
-			dcrd, err = connectToDecredNode(cfg.networkCfg())
-			if err != nil {
-				setupCancel()
-				return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
-					"error connecting to dcrd")}
-			}
-			rep.reportStage(setupCtx, StageConnectingToDcrd, nil, cfg)
-			utxoProvider = dcrd.fetchSplitUtxos
-		} else {
-			err = isDcrdataOnline(cfg.DcrdataURL, cfg.ChainParams)
-			if err != nil {
-				setupCancel()
-				return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
-					"error checking if dcrdata is online")}
-			}
-
-			rep.reportStage(setupCtx, StageConnectingToDcrdata, nil, cfg)
-			utxoProvider = utxoProviderForDcrdataURL(cfg.DcrdataURL)
-		}
-
-		rep.reportStage(setupCtx, StageConnectingToMatcher, nil, cfg)
-		mcc, err = connectToMatcherService(setupCtx, cfg.MatcherHost, cfg.MatcherCertFile,
-			utxoProvider)
+	var voteChoices *signedVoteChoices
+	if cfg.VoteChoices != "" {
+		voteChoices, err = wc.signVoteChoices(setupCtx, cfg)
 		if err != nil {
 			setupCancel()
-			return sessionWaiterResponse{nil, nil, nil, errors.Wrapf(err,
-				"error connecting to matcher")}
+			return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
+				"error signing vote choices")}
 		}
 	}
-	mc := &matcherClient{mcc}
 
-	status, err := mc.status(setupCtx)
+	mc, dcrd, err := connectToMatcherClient(setupCtx, cfg)
+	if err != nil {
+		setupCancel()
+		return sessionWaiterResponse{nil, nil, nil, err}
+	}
+
+	status, err := mc.queueStatus(setupCtx, cfg.Pool, cfg.SessionName)
 	if err != nil {
 		setupCancel()
 		return sessionWaiterResponse{nil, nil, nil, errors.Wrapf(err,
@@ -316,6 +322,12 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 	}
 	rep.reportMatcherStatus(status)
 
+	err = cfg.checkMaxSessionDuration(status.MaxSessionDuration)
+	if err != nil {
+		setupCancel()
+		return sessionWaiterResponse{nil, nil, nil, err}
+	}
+
 	maxAmount, err := dcrutil.NewAmount(cfg.MaxAmount)
 	if err != nil {
 		setupCancel()
@@ -331,39 +343,108 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 		maxWaitTime = 60 * 60 * 24 * 365 * 10 // 10 years is plenty :)
 	}
 	waitCtx, waitCancel := context.WithTimeout(mainCtx, time.Second*maxWaitTime)
+	deadline, _ := waitCtx.Deadline()
 
-	walletErrChan := make(chan error)
+	// Errors while checking the wallet, dcrd and sync are sent at most once
+	// per goroutine.
+	waitErrChan := make(chan error, 3)
 	go func() {
 		err := wc.checkWalletWaitingForSession(waitCtx)
 		if err != nil {
-			walletErrChan <- err
+			waitErrChan <- err
 		}
 	}()
 
-	dcrdErrChan := make(chan error)
 	if dcrd != nil {
 		go func() {
 			err := dcrd.checkDcrdWaitingForSession(waitCtx)
 			if err != nil {
-				dcrdErrChan <- err
+				waitErrChan <- err
 			}
 		}()
 	}
 
-	checkSyncChan := make(chan error)
 	go func() {
 		err := checkMatcherWalletBlockchainSync(waitCtx, mc, wc)
 		if err != nil {
-			checkSyncChan <- err
+			waitErrChan <- errors.Wrap(err, "error while checking matcher "+
+				"and wallet sync to the network")
+		}
+	}()
+
+	// The matcher does not accept participants close to a change of stake
+	// difficulty, so participation is suspended during that window and the
+	// buyer registers again after it.
+	for suspended := false; ; suspended = true {
+		if suspended {
+			rep.reportStage(mainCtx, StageFindingMatches, nil, cfg)
+		}
+
+		session, err := participateOutsideStopWindow(waitCtx, mc, wc, cfg,
+			maxAmount, voteChoices, deadline, waitErrChan)
+		if err != nil {
+			waitCancel()
+			return sessionWaiterResponse{nil, nil, nil, err}
+		}
+		if session != nil {
+			waitCancel()
+			rep.reportStage(mainCtx, StageMatchesFound, session, cfg)
+			return sessionWaiterResponse{mc, wc, session, nil}
+		}
+	}
+}
+
+// participateOutsideStopWindow waits until the matcher is outside the stop
+// window around a change of stake difficulty, then registers the buyer as a
+// participant and waits for a session.
+//
+// Returns a nil session and error if the participation was suspended because
+// the matcher entered the stop window before a session started.
+func participateOutsideStopWindow(waitCtx context.Context, mc *matcherClient,
+	wc *walletClient, cfg *Config, maxAmount dcrutil.Amount,
+	voteChoices *signedVoteChoices, deadline time.Time,
+	waitErrChan <-chan error) (*Session, error) {
+
+	err := waitStakeDiffStopWindow(waitCtx, mc, wc, cfg, maxAmount,
+		waitErrChan)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while waiting for stake "+
+			"difficulty change")
+	}
+
+	estimateSupported, err := checkWaitEstimate(waitCtx, mc, cfg, maxAmount,
+		deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	partCtx, partCancel := context.WithCancel(waitCtx)
+	defer partCancel()
+
+	estimateErrChan := make(chan error, 1)
+	if estimateSupported {
+		go func() {
+			err := watchWaitEstimate(partCtx, mc, cfg, deadline)
+			if err != nil {
+				estimateErrChan <- err
+			}
+		}()
+	}
+
+	stopWindowChan := make(chan struct{}, 1)
+	go func() {
+		if watchStakeDiffStopWindow(partCtx, mc, wc, cfg.ChainParams) {
+			stopWindowChan <- struct{}{}
 		}
 	}()
 
-	sessionChan := make(chan *Session)
-	participateErrChan := make(chan error)
+	sessionChan := make(chan *Session, 1)
+	participateErrChan := make(chan error, 1)
 
 	go func() {
-		session, err := mc.participate(waitCtx, maxAmount, cfg.SessionName, cfg.VoteAddress,
-			cfg.PoolAddress, cfg.PoolFeeRate, cfg.ChainParams)
+		session, err := mc.participate(partCtx, maxAmount, cfg.Pool,
+			cfg.SessionName, cfg.SessionKey, cfg.VoteAddress, cfg.PoolAddress,
+			cfg.PoolFeeRate, cfg.maxFeeRate(), voteChoices, cfg.ChainParams)
 		if err != nil {
 			participateErrChan <- err
 		} else {
@@ -373,30 +454,214 @@ func waitForSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse
 
 	select {
 	case <-waitCtx.Done():
-		waitCancel()
-		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(waitCtx.Err(),
-			"timeout while waiting for session in matcher")}
-	case walletErr := <-walletErrChan:
-		waitCancel()
-		return sessionWaiterResponse{nil, nil, nil, walletErr}
-	case dcrdErr := <-dcrdErrChan:
-		waitCancel()
-		return sessionWaiterResponse{nil, nil, nil, dcrdErr}
-	case partErr := <-participateErrChan:
-		waitCancel()
-		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(partErr,
-			"error while waiting to participate in session")}
-	case syncErr := <-checkSyncChan:
-		waitCancel()
-		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(syncErr,
-			"error while checking matcher and wallet sync to the network")}
+		return nil, errors.Wrap(waitCtx.Err(),
+			"timeout while waiting for session in matcher")
+	case waitErr := <-waitErrChan:
+		return nil, waitErr
+	case estimateErr := <-estimateErrChan:
+		return nil, estimateErr
 	case session := <-sessionChan:
-		waitCancel()
-		rep.reportStage(mainCtx, StageMatchesFound, session, cfg)
-		return sessionWaiterResponse{mc, wc, session, nil}
+		return session, nil
+	case partErr := <-participateErrChan:
+		// The matcher rejects participants that arrive inside the stop
+		// window, in which case the buyer waits for it to end.
+		if waitCtx.Err() == nil {
+			w, err := fetchStakeDiffWindow(waitCtx, mc, wc)
+			if err == nil && w.inStopWindow(cfg.ChainParams) {
+				return nil, nil
+			}
+		}
+		return nil, errors.Wrap(partErr,
+			"error while waiting to participate in session")
+	case <-stopWindowChan:
+		partCancel()
+
+		// A session might have started right before the participation was
+		// canceled.
+		select {
+		case session := <-sessionChan:
+			return session, nil
+		case <-participateErrChan:
+			return nil, nil
+		}
+	}
+}
+
+// checkWaitEstimate requests the estimate of the wait for a session from the
+// matcher and reports it. amount is the participation amount of the buyer if
+// it is not yet waiting in the matcher.
+//
+// Returns false if the matcher does not estimate waiting times (or does not
+// estimate them for the selected queue, such as for private queues) and an
+// error if a session is unlikely to start before the deadline (according to the
+// MinMatchProbability of the config).
+func checkWaitEstimate(ctx context.Context, mc *matcherClient, cfg *Config,
+	amount dcrutil.Amount, deadline time.Time) (bool, error) {
+
+	rep := reporterFromContext(ctx)
+
+	estCtx, estCancel := context.WithTimeout(ctx, waitEstimateTimeout)
+	est, err := mc.estimateWait(estCtx, cfg.Pool, cfg.SessionName, amount)
+	estCancel()
+	switch status.Code(err) {
+	case codes.Unimplemented, codes.PermissionDenied:
+		return false, nil
+	}
+	if err != nil {
+		// Estimates are only informative, so the buyer keeps waiting.
+		return true, nil
+	}
+
+	var maxWait time.Duration
+	if cfg.MaxWaitTime > 0 {
+		maxWait = time.Until(deadline)
+	}
+	rep.reportWaitEstimate(est, maxWait)
+
+	if cfg.MinMatchProbability > 0 && maxWait > 0 && est.Known() {
+		prob := est.MatchProbability(maxWait)
+		if prob < cfg.MinMatchProbability {
+			return true, errors.Errorf("session unlikely to start within "+
+				"max wait time (estimated %.0f%% chance)", prob*100)
+		}
+	}
+
+	return true, nil
+}
+
+// watchWaitEstimate periodically checks the estimate of the wait for a session
+// while the buyer is waiting in the matcher, until the context is done or a
+// session becomes unlikely to start before the deadline.
+func watchWaitEstimate(ctx context.Context, mc *matcherClient, cfg *Config,
+	deadline time.Time) error {
+
+	ticker := time.NewTicker(waitEstimateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		supported, err := checkWaitEstimate(ctx, mc, cfg, 0, deadline)
+		if err != nil || !supported {
+			return err
+		}
 	}
 }
 
+// connectToWalletClient opens the connection to the wallet (unless an
+// alternative connection was provided in the config) and checks whether it is
+// running on the expected network.
+func connectToWalletClient(ctx context.Context, cfg *Config) (*walletClient, error) {
+	var err error
+	rep := reporterFromContext(ctx)
+
+	wcc := cfg.WalletConn
+	if wcc == nil {
+		rep.reportStage(ctx, StageConnectingToWallet, nil, cfg)
+		wcc, err = connectToWallet(cfg.WalletHost, cfg.WalletCertFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error trying to connect to wallet")
+		}
+	}
+	wc := &walletClient{
+		wsvc:        wcc,
+		chainParams: cfg.ChainParams,
+	}
+
+	err = wc.checkNetwork(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking for wallet network")
+	}
+
+	return wc, nil
+}
+
+// connectToMatcherClient opens the connection to the matcher (unless an
+// alternative connection was provided in the config), along with the source
+// of utxo information used to validate the split transaction. The returned
+// dcrd connection is nil if utxos are not being fetched from dcrd.
+func connectToMatcherClient(ctx context.Context, cfg *Config) (*matcherClient,
+	*decredNetwork, error) {
+
+	var err error
+	var dcrd *decredNetwork
+	rep := reporterFromContext(ctx)
+
+	mcc := cfg.MatcherConn
+	if mcc == nil {
+		var utxoProvider utxoMapProvider
+		if !cfg.UtxosFromDcrdata {
+			dcrd, err = connectToDecredNode(cfg.networkCfg())
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "error connecting to dcrd")
+			}
+			rep.reportStage(ctx, StageConnectingToDcrd, nil, cfg)
+			utxoProvider = dcrd.fetchSplitUtxos
+		} else {
+			err = isDcrdataOnline(cfg.DcrdataURL, cfg.ChainParams,
+				cfg.sessionProxy)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "error checking if "+
+					"dcrdata is online")
+			}
+
+			rep.reportStage(ctx, StageConnectingToDcrdata, nil, cfg)
+			utxoProvider = utxoProviderForDcrdataURL(cfg.DcrdataURL,
+				cfg.sessionProxy)
+		}
+
+		rep.reportStage(ctx, StageConnectingToMatcher, nil, cfg)
+		mcc, err = connectToMatcherService(ctx, cfg.MatcherHost,
+			cfg.MatcherCertFile, cfg.matcherClientCert, cfg.sessionProxy,
+			utxoProvider)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error connecting to matcher")
+		}
+	}
+
+	return &matcherClient{mcc}, dcrd, nil
+}
+
+// resumeSession loads the in-progress session stored in the file specified
+// in the config and reconnects to the wallet and matcher so that the session
+// can be resumed from its last completed stage.
+func resumeSession(mainCtx context.Context, cfg *Config) sessionWaiterResponse {
+	rep := reporterFromContext(mainCtx)
+
+	session, err := loadPersistedSession(cfg.ResumeSession, cfg)
+	if err != nil {
+		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
+			"error loading session to resume")}
+	}
+
+	err = checkResumedSession(session, cfg)
+	if err != nil {
+		return sessionWaiterResponse{nil, nil, nil, errors.Wrap(err,
+			"error checking session to resume")}
+	}
+
+	setupCtx, setupCancel := context.WithTimeout(mainCtx, time.Second*60)
+	defer setupCancel()
+
+	wc, err := connectToWalletClient(setupCtx, cfg)
+	if err != nil {
+		return sessionWaiterResponse{nil, nil, nil, err}
+	}
+
+	mc, _, err := connectToMatcherClient(setupCtx, cfg)
+	if err != nil {
+		wc.close()
+		return sessionWaiterResponse{nil, nil, nil, err}
+	}
+
+	rep.reportStage(mainCtx, StageMatchesFound, session, cfg)
+	return sessionWaiterResponse{mc, wc, session, nil}
+}
+
 // checkMatcherWalletBlockchainSync checks whether the given matcher and wallet
 // clients are synced to the same height.
 //
@@ -465,50 +730,105 @@ func buySplitTicketInSession(ctx context.Context, cfg *Config, mc *matcherClient
 	rep := reporterFromContext(ctx)
 	var err error
 
-	chainInfo, err := wc.currentChainInfo(ctx)
-	if err != nil {
-		return err
-	}
-	if !chainInfo.bestBlockHash.IsEqual(session.mainchainHash) {
-		return errors.Errorf("mainchain tip of wallet (%s) not the same as "+
-			"matcher (%s)", chainInfo.bestBlockHash, session.mainchainHash)
-	}
-	if chainInfo.bestBlockHeight != session.mainchainHeight {
-		return errors.Errorf("mainchain height of wallet (%d) not the same as "+
-			"matcher (%d)", chainInfo.bestBlockHeight, session.mainchainHeight)
+	// completeStage records that the given stage was completed and saves the
+	// session so that it may be resumed from this point if the buyer is
+	// interrupted.
+	completeStage := func(stage Stage) error {
+		session.completedStage = stage
+		if cfg.DryRun {
+			// Dry run sessions are never funded, so there's nothing to
+			// resume.
+			rep.reportStage(ctx, stage, session, cfg)
+			return nil
+		}
+		if err := persistSession(session, cfg); err != nil {
+			return errors.Wrap(err, "error saving in-progress session")
+		}
+		rep.reportStage(ctx, stage, session, cfg)
+		return nil
 	}
-	if chainInfo.ticketPrice != session.TicketPrice {
-		return errors.Errorf("ticket price of wallet (%s) not the same as "+
-			"matcher (%s)", chainInfo.ticketPrice, session.TicketPrice)
+
+	if session.completedStage < StageOutputsGenerated {
+		chainInfo, err := wc.currentChainInfo(ctx)
+		if err != nil {
+			return err
+		}
+		if !chainInfo.bestBlockHash.IsEqual(session.mainchainHash) {
+			return errors.Errorf("mainchain tip of wallet (%s) not the same as "+
+				"matcher (%s)", chainInfo.bestBlockHash, session.mainchainHash)
+		}
+		if chainInfo.bestBlockHeight != session.mainchainHeight {
+			return errors.Errorf("mainchain height of wallet (%d) not the same as "+
+				"matcher (%d)", chainInfo.bestBlockHeight, session.mainchainHeight)
+		}
+		if chainInfo.ticketPrice != session.TicketPrice {
+			return errors.Errorf("ticket price of wallet (%s) not the same as "+
+				"matcher (%s)", chainInfo.ticketPrice, session.TicketPrice)
+		}
+
+		rep.reportStage(ctx, StageGeneratingOutputs, session, cfg)
+		err = wc.generateOutputs(ctx, session, cfg)
+		if err != nil {
+			return err
+		}
+
+		// The secret number is generated before sending the ticket request,
+		// so that a resumed session sends the same secret hash.
+		session.secretNb = splitticket.RandomSecretNumber()
+		session.secretNbHash = session.secretNb.Hash(session.mainchainHash)
+
+		if err = completeStage(StageOutputsGenerated); err != nil {
+			return err
+		}
 	}
 
-	rep.reportStage(ctx, StageGeneratingOutputs, session, cfg)
-	err = wc.generateOutputs(ctx, session, cfg)
-	if err != nil {
-		return err
+	if session.completedStage < StageTicketGenerated {
+		rep.reportStage(ctx, StageGeneratingTicket, session, cfg)
+		err = retryOnDisconnect(ctx, cfg, func() error {
+			return mc.generateTicket(ctx, session, cfg)
+		})
+		if err != nil {
+			return unreportableError{err}
+		}
+		if err = completeStage(StageTicketGenerated); err != nil {
+			return err
+		}
 	}
-	rep.reportStage(ctx, StageOutputsGenerated, session, cfg)
 
-	rep.reportStage(ctx, StageGeneratingTicket, session, cfg)
-	err = mc.generateTicket(ctx, session, cfg)
-	if err != nil {
-		return unreportableError{err}
+	if session.completedStage < StageTicketSigned {
+		rep.reportStage(ctx, StageSigningTicket, session, cfg)
+		err = wc.signTransactions(ctx, session, cfg)
+		if err != nil {
+			return err
+		}
+		if err = completeStage(StageTicketSigned); err != nil {
+			return err
+		}
 	}
-	rep.reportStage(ctx, StageTicketGenerated, session, cfg)
 
-	rep.reportStage(ctx, StageSigningTicket, session, cfg)
-	err = wc.signTransactions(ctx, session, cfg)
-	if err != nil {
-		return err
+	if session.completedStage < StageTicketFunded {
+		rep.reportStage(ctx, StageFundingTicket, session, cfg)
+		err = retryOnDisconnect(ctx, cfg, func() error {
+			return mc.fundTicket(ctx, session, cfg)
+		})
+		if err != nil {
+			return unreportableError{err}
+		}
+		if err = completeStage(StageTicketFunded); err != nil {
+			return err
+		}
 	}
-	rep.reportStage(ctx, StageTicketSigned, session, cfg)
 
-	rep.reportStage(ctx, StageFundingTicket, session, cfg)
-	err = mc.fundTicket(ctx, session, cfg)
-	if err != nil {
-		return unreportableError{err}
+	if cfg.DryRun {
+		// Stop before sending the split tx signatures, so that the matcher
+		// can't publish the split tx. The other participants of the session
+		// will see it time out, so let the matcher know why.
+		if !cfg.SkipReportErrorsToSvc {
+			mc.sendErrorReport(session.ID, errDryRun)
+		}
+		rep.reportStage(ctx, StageDryRunCompleted, session, cfg)
+		return nil
 	}
-	rep.reportStage(ctx, StageTicketFunded, session, cfg)
 
 	err = wc.monitorSession(ctx, session)
 	if err != nil {
@@ -517,7 +837,9 @@ func buySplitTicketInSession(ctx context.Context, cfg *Config, mc *matcherClient
 	}
 
 	rep.reportStage(ctx, StageFundingSplitTx, session, cfg)
-	err = mc.fundSplitTx(ctx, session, cfg)
+	err = retryOnDisconnect(ctx, cfg, func() error {
+		return mc.fundSplitTx(ctx, session, cfg)
+	})
 	if err != nil {
 		return unreportableError{err}
 	}
@@ -528,6 +850,13 @@ func buySplitTicketInSession(ctx context.Context, cfg *Config, mc *matcherClient
 		return errors.Wrapf(err, "error saving session")
 	}
 
+	// The session data has been saved in its final form, so the in-progress
+	// data is no longer needed.
+	err = removePersistedSession(session, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "error removing in-progress session")
+	}
+
 	if cfg.SkipWaitPublishedTxs {
 		rep.reportStage(ctx, StageSkippedWaiting, session, cfg)
 		rep.reportStage(ctx, StageSessionEndedSuccessfully, session, cfg)
@@ -571,7 +900,7 @@ func waitForPublishedTxs(ctx context.Context, session *Session,
 			if !notifiedTicket && wc.wsvc.PublishedTicketTx() != nil {
 				publishedHash := wc.wsvc.PublishedTicketTx()
 				if expectedTicketHash.IsEqual(publishedHash) {
-					rep.reportRightTicketPublished()
+					rep.reportRightTicketPublished(publishedHash)
 					correctTicket = true
 				} else {
 					rep.reportWrongTicketPublished(publishedHash, session)
@@ -685,6 +1014,7 @@ func saveSession(ctx context.Context, session *Session, cfg *Config) error {
 	out("Commitment Amount = %s (%.2f%%)\n", session.Amount, contribPerc)
 	out("Ticket Fee = %s (total = %s)\n", session.Fee, session.Fee*dcrutil.Amount(session.nbParticipants))
 	out("Pool Fee = %s (total = %s)\n", session.PoolFee, totalPoolFee)
+	out("Negotiated Fee Rate = %s/KB\n", session.feeRate)
 	out("Split Transaction hash = %s\n", splitHash.String())
 	out("Final Ticket Hash = %s\n", ticketHashHex)
 	out("Final Revocation Hash = %s\n", revocationHash.String())
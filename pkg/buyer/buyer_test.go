@@ -0,0 +1,185 @@
+package buyer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/matcherrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// estimateTestMatcher implements the matcher calls needed to estimate the
+// wait for a session. Calling any other matcher function panics.
+type estimateTestMatcher struct {
+	MatcherClientConn
+	resp *pb.EstimateWaitResponse
+	err  error
+	reqs []*pb.EstimateWaitRequest
+}
+
+func (m *estimateTestMatcher) EstimateWait(ctx context.Context, in *pb.EstimateWaitRequest, opts ...grpc.CallOption) (*pb.EstimateWaitResponse, error) {
+	m.reqs = append(m.reqs, in)
+	return m.resp, m.err
+}
+
+func TestCheckWaitEstimate(t *testing.T) {
+	var events []Event
+	rep := NewEventReporter(func(e Event) { events = append(events, e) },
+		"pool", "session")
+	ctx := context.WithValue(context.Background(), ReporterCtxKey, rep)
+
+	// 5 participants arriving every hour are needed.
+	m := &estimateTestMatcher{resp: &pb.EstimateWaitResponse{
+		MissingAmount:     50e8,
+		AverageAmount:     10e8,
+		ArrivalIntervalMs: 3600000,
+		ExpectedArrivals:  5,
+	}}
+	mc := &matcherClient{client: m}
+	cfg := &Config{Pool: "pool", SessionName: "session", MaxWaitTime: 600}
+	deadline := time.Now().Add(10 * time.Minute)
+
+	supported, err := checkWaitEstimate(ctx, mc, cfg, 10e8, deadline)
+	if !supported || err != nil {
+		t.Fatalf("unexpected result %v %v", supported, err)
+	}
+	if len(m.reqs) != 1 || m.reqs[0].Amount != 10e8 ||
+		m.reqs[0].SessionName != "session" || m.reqs[0].Pool != "pool" {
+		t.Fatalf("unexpected estimate requests %v", m.reqs)
+	}
+	e, is := events[0].(*WaitEstimateEvent)
+	if !is || e.Estimate.ExpectedWait() != 5*time.Hour ||
+		e.MaxWait <= 0 || e.MaxWait > 10*time.Minute {
+		t.Fatalf("unexpected wait estimate event %#v", events[0])
+	}
+
+	// The buyer gives up when a session is unlikely to start in time.
+	cfg.MinMatchProbability = 0.5
+	if _, err = checkWaitEstimate(ctx, mc, cfg, 0, deadline); err == nil {
+		t.Fatalf("buyer did not give up on unlikely session")
+	}
+
+	// But not when waiting without a time limit.
+	cfg.MaxWaitTime = 0
+	if _, err = checkWaitEstimate(ctx, mc, cfg, 0, deadline); err != nil {
+		t.Fatalf("unexpected error without max wait time: %v", err)
+	}
+
+	// Failures to estimate do not abort waiting.
+	m.err = status.Error(codes.Unavailable, "boom")
+	supported, err = checkWaitEstimate(ctx, mc, cfg, 0, deadline)
+	if !supported || err != nil {
+		t.Fatalf("unexpected result on failure %v %v", supported, err)
+	}
+
+	// Matchers that do not estimate waiting times are detected.
+	m.err = status.Error(codes.Unimplemented, "unknown method")
+	supported, err = checkWaitEstimate(ctx, mc, cfg, 0, deadline)
+	if supported || err != nil {
+		t.Fatalf("unexpected result for old matcher %v %v", supported, err)
+	}
+
+	// As are private queues, which are not estimated.
+	m.err = status.Error(codes.PermissionDenied, "private queue")
+	supported, err = checkWaitEstimate(ctx, mc, cfg, 0, deadline)
+	if supported || err != nil {
+		t.Fatalf("unexpected result for private queue %v %v", supported, err)
+	}
+}
+
+// statusTestMatcher implements the matcher status call. Calling any other
+// matcher function panics.
+type statusTestMatcher struct {
+	MatcherClientConn
+	resp *pb.StatusResponse
+}
+
+func (m *statusTestMatcher) Status(ctx context.Context, in *pb.StatusRequest, opts ...grpc.CallOption) (*pb.StatusResponse, error) {
+	return m.resp, nil
+}
+
+func TestStakeDiffWindow(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	m := &statusTestMatcher{resp: &pb.StatusResponse{
+		TicketPrice:               100e8,
+		NextTicketPrice:           120e8,
+		StakeDiffChangeStopWindow: 5,
+	}}
+	mc := &matcherClient{client: m}
+
+	tests := []struct {
+		height   uint32
+		inWindow bool
+		blocks   int32
+		expected dcrutil.Amount
+	}{
+		{14350, false, 0, 100e8},
+		{14398, true, 2, 120e8},
+		{14400, true, 0, 100e8},
+		{14401, true, 0, 100e8},
+		{14405, false, 0, 100e8},
+	}
+
+	for _, tc := range tests {
+		m.resp.MainchainHeight = tc.height
+		w, err := fetchStakeDiffWindow(context.Background(), mc, nil)
+		if err != nil {
+			t.Fatalf("unexpected error fetching window: %v", err)
+		}
+		if w.inStopWindow(params) != tc.inWindow {
+			t.Errorf("unexpected stop window at height %d", tc.height)
+		}
+		if w.blocksToChange(params) != tc.blocks {
+			t.Errorf("unexpected blocks to change at height %d: %d",
+				tc.height, w.blocksToChange(params))
+		}
+		if w.expectedTicketPrice(params) != tc.expected {
+			t.Errorf("unexpected expected ticket price at height %d: %s",
+				tc.height, w.expectedTicketPrice(params))
+		}
+	}
+
+	// Without an estimate of the next price, the current one is expected.
+	m.resp.MainchainHeight = 14398
+	m.resp.NextTicketPrice = 0
+	w, _ := fetchStakeDiffWindow(context.Background(), mc, nil)
+	if w.expectedTicketPrice(params) != 100e8 {
+		t.Fatalf("unexpected expected ticket price without estimate: %s",
+			w.expectedTicketPrice(params))
+	}
+
+	shares := []struct {
+		maxAmount, price dcrutil.Amount
+		share            float64
+	}{{30e8, 120e8, 0.25}, {150e8, 120e8, 1}, {30e8, 0, 0}}
+	for _, tc := range shares {
+		if share := ticketPriceShare(tc.maxAmount, tc.price); share != tc.share {
+			t.Errorf("unexpected share %f of %s for %s", share, tc.price,
+				tc.maxAmount)
+		}
+	}
+
+	// Buyers outside the window continue immediately.
+	m.resp.MainchainHeight = 14350
+	cfg := &Config{ChainParams: params}
+	err := waitStakeDiffStopWindow(context.Background(), mc, nil, cfg, 10e8,
+		nil)
+	if err != nil {
+		t.Fatalf("unexpected error outside the window: %v", err)
+	}
+
+	// Buyers inside the window wait until it ends.
+	m.resp.MainchainHeight = 14400
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	err = waitStakeDiffStopWindow(ctx, mc, nil, cfg, 10e8, nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error inside the window: %v", err)
+	}
+}
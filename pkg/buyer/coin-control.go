@@ -0,0 +1,289 @@
+package buyer
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	pb "github.com/decred/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrwallet/wallet/txrules"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// InputSelectionWallet lets the wallet choose the inputs of the split tx.
+	InputSelectionWallet = "wallet"
+
+	// InputSelectionFewest selects the largest utxos first, such that the
+	// split tx uses as few inputs as possible.
+	InputSelectionFewest = "fewest"
+
+	// InputSelectionOldest selects the utxos that were received first.
+	InputSelectionOldest = "oldest"
+)
+
+// splitInputCandidate is a wallet utxo that may be used as input of the split
+// tx.
+type splitInputCandidate struct {
+	outpoint    wire.OutPoint
+	amount      dcrutil.Amount
+	receiveTime int64
+}
+
+// parseOutPoint parses an outpoint in the format txhash:index. Only outpoints
+// in the regular tx tree are supported.
+func parseOutPoint(s string) (wire.OutPoint, error) {
+	var outp wire.OutPoint
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return outp, errors.Errorf("outpoint %s not in the format txhash:index", s)
+	}
+
+	hash, err := chainhash.NewHashFromStr(parts[0])
+	if err != nil {
+		return outp, errors.Wrapf(err, "invalid hash of outpoint %s", s)
+	}
+
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return outp, errors.Wrapf(err, "invalid index of outpoint %s", s)
+	}
+
+	outp.Hash = *hash
+	outp.Index = uint32(index)
+	outp.Tree = wire.TxTreeRegular
+	return outp, nil
+}
+
+// parseOutPoints parses a list of outpoints into a set.
+func parseOutPoints(list []string) (map[wire.OutPoint]struct{}, error) {
+	res := make(map[wire.OutPoint]struct{}, len(list))
+	for _, s := range list {
+		outp, err := parseOutPoint(s)
+		if err != nil {
+			return nil, err
+		}
+		res[outp] = struct{}{}
+	}
+	return res, nil
+}
+
+// coinControl returns true if the inputs of the split tx should be selected by
+// the buyer instead of being chosen by the wallet.
+func (cfg *Config) coinControl() bool {
+	return len(cfg.SplitInputs) > 0 || len(cfg.ExcludeSplitInputs) > 0 ||
+		(cfg.InputSelection != "" && cfg.InputSelection != InputSelectionWallet)
+}
+
+// validateCoinControl checks whether the coin control options of the config
+// are valid.
+func (cfg *Config) validateCoinControl() error {
+	switch cfg.InputSelection {
+	case "", InputSelectionWallet, InputSelectionFewest, InputSelectionOldest:
+	default:
+		return errors.Errorf("invalid InputSelection %s", cfg.InputSelection)
+	}
+
+	if len(cfg.SplitInputs) > splitticket.MaximumSplitInputs {
+		return errors.Errorf("cannot specify more than %d SplitInputs",
+			splitticket.MaximumSplitInputs)
+	}
+
+	pinned, err := parseOutPoints(cfg.SplitInputs)
+	if err != nil {
+		return errors.Wrap(err, "invalid SplitInputs")
+	}
+
+	excluded, err := parseOutPoints(cfg.ExcludeSplitInputs)
+	if err != nil {
+		return errors.Wrap(err, "invalid ExcludeSplitInputs")
+	}
+
+	if cfg.ConsolidateUtxos && len(pinned) > 0 {
+		return errors.New("cannot specify both ConsolidateUtxos and " +
+			"SplitInputs")
+	}
+
+	// Consolidating publishes a transaction, which a dry run must not do.
+	if cfg.ConsolidateUtxos && cfg.DryRun {
+		return errors.New("cannot specify both ConsolidateUtxos and DryRun")
+	}
+
+	for outp := range pinned {
+		if _, has := excluded[outp]; has {
+			return errors.Errorf("outpoint %s specified in both SplitInputs "+
+				"and ExcludeSplitInputs", outp)
+		}
+	}
+
+	return nil
+}
+
+// listSplitInputCandidates returns the utxos of the source account that may be
+// used as inputs of the split tx: p2pkh outputs of the regular tx tree with at
+// least the minimum number of confirmations.
+func (wc *walletClient) listSplitInputCandidates(ctx context.Context,
+	cfg *Config) ([]splitInputCandidate, error) {
+
+	req := &pb.UnspentOutputsRequest{
+		Account:               cfg.SourceAccount,
+		TargetAmount:          dcrutil.MaxAmount,
+		RequiredConfirmations: splitticket.MinimumSplitInputConfirms,
+	}
+	utxos, err := wc.wsvc.UnspentOutputs(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing wallet utxos")
+	}
+
+	res := make([]splitInputCandidate, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.Tree != int32(wire.TxTreeRegular) {
+			continue
+		}
+
+		class := txscript.GetScriptClass(txscript.DefaultScriptVersion,
+			utxo.PkScript)
+		if class != txscript.PubKeyHashTy {
+			continue
+		}
+
+		hash, err := chainhash.NewHash(utxo.TransactionHash)
+		if err != nil {
+			return nil, errors.Wrap(err, "wallet returned invalid utxo hash")
+		}
+
+		res = append(res, splitInputCandidate{
+			outpoint:    *wire.NewOutPoint(hash, utxo.OutputIndex, wire.TxTreeRegular),
+			amount:      dcrutil.Amount(utxo.Amount),
+			receiveTime: utxo.ReceiveTime,
+		})
+	}
+
+	return res, nil
+}
+
+// selectSplitInputs chooses which of the candidate utxos to use as inputs of
+// the split tx according to the coin control options of the config, such that
+// they pay for the target amount plus the participant's split tx fee. Returns
+// the selected inputs and the resulting change amount (0 if the change would
+// be dust and no change output should be added).
+func selectSplitInputs(candidates []splitInputCandidate, cfg *Config,
+	target dcrutil.Amount, nbParticipants int, feeRate dcrutil.Amount,
+	changeScriptSize int) ([]splitInputCandidate, dcrutil.Amount, error) {
+
+	pinned, err := parseOutPoints(cfg.SplitInputs)
+	if err != nil {
+		return nil, 0, err
+	}
+	excluded, err := parseOutPoints(cfg.ExcludeSplitInputs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	available := make([]splitInputCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if _, has := excluded[c.outpoint]; has {
+			continue
+		}
+		if _, has := pinned[c.outpoint]; len(pinned) > 0 && !has {
+			continue
+		}
+		available = append(available, c)
+	}
+
+	if len(available) < len(pinned) {
+		return nil, 0, errors.Errorf("not all specified split inputs are "+
+			"spendable wallet p2pkh utxos with at least %d confirmations",
+			splitticket.MinimumSplitInputConfirms)
+	}
+
+	switch cfg.InputSelection {
+	case InputSelectionFewest:
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].amount > available[j].amount
+		})
+	case InputSelectionOldest:
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].receiveTime < available[j].receiveTime
+		})
+	}
+
+	// change returns the change amount when using the given number of inputs
+	// with the given total amount. Dust change is not added to the split tx.
+	change := func(nbInputs int, total dcrutil.Amount) (dcrutil.Amount, bool) {
+		fee := splitticket.SplitTxParticipantFee(nbParticipants, nbInputs,
+			true, feeRate)
+		c := total - target - fee
+		if c > 0 && !txrules.IsDustAmount(c, changeScriptSize, feeRate) {
+			return c, true
+		}
+
+		fee = splitticket.SplitTxParticipantFee(nbParticipants, nbInputs,
+			false, feeRate)
+		return 0, total >= target+fee
+	}
+
+	var total dcrutil.Amount
+	for i, c := range available {
+		if i >= splitticket.MaximumSplitInputs {
+			return nil, 0, errors.Errorf("participation requires more than "+
+				"%d split inputs; consolidate the wallet utxos",
+				splitticket.MaximumSplitInputs)
+		}
+
+		total += c.amount
+
+		// pinned inputs are always used in full.
+		if len(pinned) > 0 && i < len(available)-1 {
+			continue
+		}
+
+		if changeAmount, enough := change(i+1, total); enough {
+			return available[:i+1], changeAmount, nil
+		}
+	}
+
+	return nil, 0, errors.Errorf("not enough funds in the selected utxos "+
+		"(%s) to participate with %s", total, target)
+}
+
+// selectSplitTxInputs fills the split inputs and change of the session with
+// the wallet utxos selected according to the coin control options of the
+// config.
+func (wc *walletClient) selectSplitTxInputs(ctx context.Context,
+	session *Session, cfg *Config) error {
+
+	candidates, err := wc.listSplitInputCandidates(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	target := session.Amount + session.Fee + session.PoolFee
+	inputs, change, err := selectSplitInputs(candidates, cfg, target,
+		int(session.nbParticipants), session.feeRate,
+		len(session.splitChange.PkScript))
+	if err != nil {
+		return err
+	}
+
+	if change > 0 {
+		session.splitChange.Value = int64(change)
+	} else {
+		session.splitChange = nil
+	}
+
+	session.splitInputs = make([]*wire.TxIn, len(inputs))
+	for i, in := range inputs {
+		outp := in.outpoint
+		session.splitInputs[i] = wire.NewTxIn(&outp, wire.NullValueIn, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package buyer
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+)
+
+func testCandidates() []splitInputCandidate {
+	// amounts (in DCR) and receive times of the test utxos, in the order
+	// returned by the wallet.
+	data := []struct {
+		amount      float64
+		receiveTime int64
+	}{
+		{3, 30}, {10, 20}, {2, 10}, {5, 40},
+	}
+
+	res := make([]splitInputCandidate, len(data))
+	for i, d := range data {
+		amount, _ := dcrutil.NewAmount(d.amount)
+		res[i] = splitInputCandidate{
+			outpoint:    wire.OutPoint{Hash: chainhash.Hash{byte(i + 1)}},
+			amount:      amount,
+			receiveTime: d.receiveTime,
+		}
+	}
+	return res
+}
+
+func TestSelectSplitInputs(t *testing.T) {
+	candidates := testCandidates()
+	target, _ := dcrutil.NewAmount(4)
+	feeRate := splitticket.TxFeeRate
+	outpStr := func(i int) string { return candidates[i].outpoint.Hash.String() + ":0" }
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		expected  []int
+		expectErr bool
+	}{
+		{"wallet order", Config{}, []int{0, 1}, false},
+		{"fewest", Config{InputSelection: InputSelectionFewest}, []int{1}, false},
+		{"oldest", Config{InputSelection: InputSelectionOldest}, []int{2, 1}, false},
+		{"excluded", Config{InputSelection: InputSelectionFewest,
+			ExcludeSplitInputs: []string{outpStr(1)}}, []int{3}, false},
+		{"pinned", Config{SplitInputs: []string{outpStr(0), outpStr(2)}},
+			[]int{0, 2}, false},
+		{"pinned not enough", Config{SplitInputs: []string{outpStr(0)}},
+			nil, true},
+		{"pinned not available", Config{SplitInputs: []string{
+			chainhash.Hash{0xff}.String() + ":0"}}, nil, true},
+		{"not enough after exclusion", Config{ExcludeSplitInputs: []string{
+			outpStr(0), outpStr(1), outpStr(3)}}, nil, true},
+	}
+
+	for _, tc := range tests {
+		inputs, change, err := selectSplitInputs(candidates, &tc.cfg, target,
+			2, feeRate, 25)
+		if tc.expectErr {
+			if err == nil {
+				t.Fatalf("%s: expected error but got none", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if len(inputs) != len(tc.expected) {
+			t.Fatalf("%s: unexpected number of inputs %d", tc.name, len(inputs))
+		}
+		var total dcrutil.Amount
+		for i, in := range inputs {
+			if in.outpoint != candidates[tc.expected[i]].outpoint {
+				t.Fatalf("%s: unexpected input at index %d", tc.name, i)
+			}
+			total += in.amount
+		}
+
+		fee := splitticket.SplitTxParticipantFee(2, len(inputs), true, feeRate)
+		if change != total-target-fee {
+			t.Fatalf("%s: unexpected change %s", tc.name, change)
+		}
+	}
+}
+
+func TestSelectSplitInputsMaxInputs(t *testing.T) {
+	candidates := make([]splitInputCandidate, splitticket.MaximumSplitInputs+1)
+	for i := range candidates {
+		candidates[i] = splitInputCandidate{
+			outpoint: wire.OutPoint{Index: uint32(i)},
+			amount:   1e6,
+		}
+	}
+
+	target := dcrutil.Amount(1e6 * splitticket.MaximumSplitInputs)
+	_, _, err := selectSplitInputs(candidates, &Config{}, target, 1,
+		splitticket.TxFeeRate, 25)
+	if err == nil {
+		t.Fatalf("selected more than the maximum number of split inputs")
+	}
+}
+
+func TestValidateCoinControl(t *testing.T) {
+	outp := chainhash.Hash{0x01}.String() + ":1"
+
+	valid := []Config{
+		{},
+		{InputSelection: InputSelectionOldest, SplitInputs: []string{outp}},
+		{ConsolidateUtxos: true},
+		{DryRun: true},
+	}
+	for i, cfg := range valid {
+		if err := cfg.validateCoinControl(); err != nil {
+			t.Fatalf("unexpected error on valid config %d: %v", i, err)
+		}
+	}
+
+	invalid := []Config{
+		{InputSelection: "random"},
+		{SplitInputs: []string{"xxxx:1"}},
+		{ExcludeSplitInputs: []string{chainhash.Hash{}.String()}},
+		{SplitInputs: []string{outp}, ExcludeSplitInputs: []string{outp}},
+		{ConsolidateUtxos: true, DryRun: true},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.validateCoinControl(); err == nil {
+			t.Fatalf("invalid config %d did not return an error", i)
+		}
+	}
+}
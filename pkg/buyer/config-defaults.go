@@ -23,10 +23,54 @@ MaxAmount = 0.0
 # session.
 UtxosFromDcrdata = 0
 
+# Vote preferences (agenda:choice pairs separated by commas) that the voting
+# pool should use when this participant is selected as the voter of the ticket.
+# VoteChoices = sdiffalgorithm:yes,lnsupport:yes
+
 # Pool subsidy fee rate (as a percentage). The buyer stops the session if the
 # the service attempt to use a rate higher than this.
 PoolFeeRate = 5.0
 
+# Coin control for the inputs of the split transaction. SplitInputs pins the
+# given outpoints (txhash:index) as the only inputs to use, ExcludeSplitInputs
+# prevents the given outpoints from being used. Both may be specified multiple
+# times. InputSelection may be "wallet" (let the wallet choose), "fewest" (use
+# the largest utxos first) or "oldest" (use the oldest utxos first).
+# SplitInputs =
+# ExcludeSplitInputs =
+# InputSelection = wallet
+
+# How to sign the session transactions: "wallet" (the connected wallet),
+# "file" (export the unsigned transactions to SignerDir and wait for the signed
+# transactions, for air-gapped signing) or "remote" (a remote dcrwallet, whose
+# passphrase is read from Pass). SignerTimeout is the additional time (in
+# seconds) allowed for the session when using the file or remote signers. The
+# buyer refuses matcher queues whose sessions may last less than that.
+# SignerType = wallet
+# SignerDir = ~/.splitticketbuyer/signer
+# SignerHost =
+# SignerCertFile =
+# SignerTimeout = 300
+
+# Consolidate the utxos of the source account before participating if more
+# than the maximum number of split tx inputs would be needed. The buyer waits
+# for the confirmation of the consolidation tx and then starts the session.
+# ConsolidateUtxos = 0
+
+# Maximum fee rate (in DCR/KB) to pay for the split and ticket transactions.
+# Sessions where the matcher requests a higher fee rate are refused. If 0, the
+# default (0.01 DCR/KB) is used.
+# MaxFeeRate = 0.01
+
+# Give up waiting for a session before MaxWaitTime when the matcher estimates
+# that the probability (between 0 and 1) of a session starting in time is
+# lower than this. 0 waits for the whole MaxWaitTime.
+# MinMatchProbability = 0
+
+# Maximum amount of time (in seconds) to keep trying to reconnect to the
+# matcher if the connection drops during a session. 0 disables reconnecting.
+# ReconnectTimeout = 20
+
 # Address of the matcher daemon.
 # Voting Pools that provide the split ticket matching service can usually be
 # used just by specifying its host (eg: stake.myexamplepool.com).
@@ -36,6 +80,11 @@ MatcherHost =
 # Location of the matcher rpc.cert file when connecting to a custom matcher.
 # MatcherCertFile = ~/.splitticketbuyer/matcher.cert
 
+# Client certificate and key presented to matchers that require clients to
+# authenticate with their own certificates.
+# MatcherClientCert = ~/.splitticketbuyer/client.cert
+# MatcherClientKey = ~/.splitticketbuyer/client.key
+
 # 1 = TestNet, 0 = MainNet
 TestNet = 0
 
@@ -2,6 +2,7 @@ package buyer
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,7 +11,9 @@ import (
 	"strings"
 
 	"github.com/go-ini/ini"
+	intnet "github.com/matheusd/dcr-split-ticket-matcher/pkg/buyer/internal/net"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 
 	"github.com/decred/dcrd/chaincfg"
@@ -42,33 +45,56 @@ var (
 // Config stores the configuration needed to perform a single ticket split
 // session as a buyer.
 type Config struct {
-	ConfigFile            string  `short:"C" long:"configfile" description:"Path to config file"`
-	WalletCertFile        string  `long:"wallet.certfile" description:"Path Wallet rpc.cert file"`
-	WalletHost            string  `long:"wallet.host" description:"Address of the wallet. Use 127.0.0.1:0 to try and automatically locate the running wallet on localhost."`
-	Pass                  string  `short:"P" long:"pass" description:"Passphrase to unlock the wallet"`
-	MatcherHost           string  `long:"matcher.host" description:"Address of the matcher host"`
-	MaxAmount             float64 `long:"maxamount" description:"Maximum participation amount"`
-	SourceAccount         uint32  `long:"sourceaccount" description:"Source account of funds for purchase"`
-	SStxFeeLimits         uint16  `long:"sstxfeelimits" description:"Fee limit allowance for sstx purchases"`
-	VoteAddress           string  `long:"voteaddress" description:"Voting address of the stakepool"`
-	PoolAddress           string  `long:"pooladdress" description:"Pool fee address of the stakepool"`
-	PoolFeeRate           float64 `long:"poolfeerate" description:"Pool fee rate (percentage) that the given pool has advertised as using"`
-	TestNet               bool    `long:"testnet" description:"Whether this is connecting to a testnet wallet/matcher service"`
-	SimNet                bool    `long:"simnet" description:"Whether this is connecting to a simnet wallet/matcher service"`
-	MaxTime               int     `long:"maxtime" description:"Maximum amount of time (in seconds) to wait for the completion of the split buy"`
-	MaxWaitTime           int     `long:"maxwaittime" description:"Maximum amount of time (in seconds) to wait until a new split ticket session is initiated"`
-	DataDir               string  `long:"datadir" description:"Directory where session data files are stored"`
-	MatcherCertFile       string  `long:"matchercertfile" description:"Location of the certificate file for connecting to the grpc matcher service"`
-	SessionName           string  `long:"sessionname" description:"Name of the session to connect to. Leave blank to connect to the public matching session."`
-	DcrdHost              string  `long:"dcrdhost" description:"Host of the dcrd daemon"`
-	DcrdUser              string  `long:"dcrduser" description:"Username of the dcrd daemon"`
-	DcrdPass              string  `long:"dcrpass" description:"Password of the dcrd daemon"`
-	DcrdCert              string  `long:"dcrdcert" description:"Location of the certificate for the dcrd daemon"`
-	SkipWaitPublishedTxs  bool    `long:"skipwaitpublishedtxs" description:"If specified, the session ends immediately after the last step, without waiting for the matcher to publish the transactions."`
-	ShowVersion           bool    `long:"version" description:"Show version and quit"`
-	SkipReportErrorsToSvc bool    `long:"skipreporterrorstosvc" description:"Skip sending buyer errors that happen during the session to the service"`
-	UtxosFromDcrdata      bool    `long:"utxosfromdcrdata" description:"Fetch utxo information of other participants from dcrdata instead of dcrd"`
-	DcrdataURL            string  `long:"dcrdataurl" description:"URL to use when connecting to dcrdata. Uses the default dcrdata URL for the given network if left empty"`
+	ConfigFile            string   `short:"C" long:"configfile" description:"Path to config file"`
+	WalletCertFile        string   `long:"wallet.certfile" description:"Path Wallet rpc.cert file"`
+	WalletHost            string   `long:"wallet.host" description:"Address of the wallet. Use 127.0.0.1:0 to try and automatically locate the running wallet on localhost."`
+	Pass                  string   `short:"P" long:"pass" description:"Passphrase to unlock the wallet"`
+	MatcherHost           string   `long:"matcher.host" description:"Address of the matcher host"`
+	MaxAmount             float64  `long:"maxamount" description:"Maximum participation amount"`
+	SourceAccount         uint32   `long:"sourceaccount" description:"Source account of funds for purchase"`
+	SStxFeeLimits         uint16   `long:"sstxfeelimits" description:"Fee limit allowance for sstx purchases"`
+	VoteAddress           string   `long:"voteaddress" description:"Voting address of the stakepool"`
+	PoolAddress           string   `long:"pooladdress" description:"Pool fee address of the stakepool"`
+	PoolFeeRate           float64  `long:"poolfeerate" description:"Pool fee rate (percentage) that the given pool has advertised as using"`
+	TestNet               bool     `long:"testnet" description:"Whether this is connecting to a testnet wallet/matcher service"`
+	SimNet                bool     `long:"simnet" description:"Whether this is connecting to a simnet wallet/matcher service"`
+	MaxTime               int      `long:"maxtime" description:"Maximum amount of time (in seconds) to wait for the completion of the split buy"`
+	MaxWaitTime           int      `long:"maxwaittime" description:"Maximum amount of time (in seconds) to wait until a new split ticket session is initiated"`
+	MinMatchProbability   float64  `long:"minmatchprobability" description:"Give up waiting for a session when the probability (estimated by the matcher, between 0 and 1) of a session starting within the remaining MaxWaitTime drops below this value. 0 never gives up before MaxWaitTime."`
+	DataDir               string   `long:"datadir" description:"Directory where session data files are stored"`
+	MatcherCertFile       string   `long:"matchercertfile" description:"Location of the certificate file for connecting to the grpc matcher service"`
+	MatcherClientCert     string   `long:"matcherclientcert" description:"Location of the client certificate presented to matchers that require clients to authenticate (mutual TLS)"`
+	MatcherClientKey      string   `long:"matcherclientkey" description:"Location of the private key of the MatcherClientCert"`
+	SessionName           string   `long:"sessionname" description:"Name of the session to connect to. Leave blank to connect to the public matching session."`
+	SessionKey            string   `long:"sessionkey" description:"Join password or pre-shared key of the session, for sessions that require one"`
+	Pool                  string   `long:"pool" description:"Name of the voting pool (as configured on the matcher) to buy the ticket for. Leave blank to use the default pool of the matcher."`
+	DcrdHost              string   `long:"dcrdhost" description:"Host of the dcrd daemon"`
+	DcrdUser              string   `long:"dcrduser" description:"Username of the dcrd daemon"`
+	DcrdPass              string   `long:"dcrpass" description:"Password of the dcrd daemon"`
+	DcrdCert              string   `long:"dcrdcert" description:"Location of the certificate for the dcrd daemon"`
+	SkipWaitPublishedTxs  bool     `long:"skipwaitpublishedtxs" description:"If specified, the session ends immediately after the last step, without waiting for the matcher to publish the transactions."`
+	ShowVersion           bool     `long:"version" description:"Show version and quit"`
+	SkipReportErrorsToSvc bool     `long:"skipreporterrorstosvc" description:"Skip sending buyer errors that happen during the session to the service"`
+	UtxosFromDcrdata      bool     `long:"utxosfromdcrdata" description:"Fetch utxo information of other participants from dcrdata instead of dcrd"`
+	DcrdataURL            string   `long:"dcrdataurl" description:"URL to use when connecting to dcrdata. Uses the default dcrdata URL for the given network if left empty"`
+	VoteChoices           string   `long:"votechoices" description:"Comma-separated list of agenda:choice vote preferences to request from the voting pool if this participant is selected as the voter"`
+	MaxFeeRate            float64  `long:"maxfeerate" description:"Maximum fee rate (in DCR/KB) for the split and ticket transactions that the buyer accepts to pay. The buyer does not participate in sessions with a higher fee rate. If 0, defaults to 0.01 DCR/KB."`
+	ReconnectTimeout      int      `long:"reconnecttimeout" description:"Maximum amount of time (in seconds) to keep trying to reconnect to the matcher if the connection drops during a session. 0 disables reconnecting."`
+	ResumeSession         string   `long:"resumesession" description:"Path to an in-progress session file (stored in the inprogress dir of the data dir) to resume instead of starting a new session"`
+	SplitInputs           []string `long:"splitinput" description:"Outpoint (txhash:index) of a wallet utxo to use as input of the split transaction. May be specified multiple times. When specified, only the given utxos are used."`
+	ExcludeSplitInputs    []string `long:"excludesplitinput" description:"Outpoint (txhash:index) of a wallet utxo that must not be used as input of the split transaction. May be specified multiple times."`
+	SignerType            string   `long:"signer" description:"How to sign the session transactions: wallet (the connected wallet), file (export the transactions to SignerDir and wait for the signed transactions, for air-gapped signing) or remote (a remote dcrwallet instance, using the passphrase from Pass)"`
+	SignerDir             string   `long:"signer.dir" description:"Directory where the transactions are exported to and the signed transactions are read from when using the file signer"`
+	SignerHost            string   `long:"signer.host" description:"Address of the remote wallet when using the remote signer"`
+	SignerCertFile        string   `long:"signer.certfile" description:"Path to the rpc.cert file of the remote wallet when using the remote signer"`
+	SignerTimeout         int      `long:"signer.timeout" description:"Additional amount of time (in seconds) allowed for the session to complete when signing with the file or remote signers"`
+	ConsolidateUtxos      bool     `long:"consolidateutxos" description:"If participating requires more than the maximum number of split transaction inputs, consolidate the utxos of the source account and wait for the confirmation of the consolidation transaction before starting the session"`
+	InputSelection        string   `long:"inputselection" description:"How to select the inputs of the split transaction: wallet (let the wallet choose), fewest (use the largest utxos first) or oldest (use the oldest utxos first)"`
+	DryRun                bool     `long:"dryrun" description:"Rehearse the purchase: run the session up to (but not including) sending the split transaction signatures to the matcher and report the resulting fees and voter chance. No funds are spent. Cannot be used with ConsolidateUtxos."`
+	Proxy                 string   `long:"proxy" description:"Connect to the matcher, dcrd and dcrdata through the SOCKS5 proxy at this address (eg: 127.0.0.1:9050 for Tor). Required for .onion hosts. No SRV lookup is done for the matcher host when using a proxy."`
+	ProxyUser             string   `long:"proxyuser" description:"Username for the proxy server"`
+	ProxyPass             string   `long:"proxypass" description:"Password for the proxy server"`
+	TorIsolation          bool     `long:"torisolation" description:"Use random proxy credentials for each session, so that Tor uses separate circuits for each session (stream isolation)"`
 
 	Passphrase  []byte
 	ChainParams *chaincfg.Params
@@ -83,16 +109,32 @@ type Config struct {
 	// the buyer will relay all network-related calls to this object.
 	MatcherConn MatcherClientConn
 
+	// Signer is an alternative way of signing the transactions of the buyer.
+	// When specified, it is used instead of the signer selected by
+	// SignerType.
+	Signer Signer
+
 	// SaveSessionWriter is an alternative way of saving session data of
 	// completed sessions. If specified, it will be used instead of directly
 	// saving to a file.
 	SaveSessionWriter SessionWriter
+
+	// sessionProxy is the proxy used for the connections of the current
+	// session. It's nil when connecting directly.
+	sessionProxy *intnet.Proxy
+
+	// matcherClientCert is the loaded MatcherClientCert/MatcherClientKey
+	// pair.
+	matcherClientCert *tls.Certificate
 }
 
 // ReadPassphrase reads the passphrase from stdin (if needed), fills the
 // PassPhrase field and clears the Pass field
 func (cfg *Config) ReadPassphrase() error {
-	if cfg.Pass == "" {
+	if cfg.Pass == "" && cfg.SignerType == SignerFile {
+		// the file signer does not require a passphrase
+		return nil
+	} else if cfg.Pass == "" {
 		return ErrEmptyPassword
 	} else if cfg.Pass == "-" {
 		pass, err := passFromStdin()
@@ -139,6 +181,37 @@ func (cfg *Config) Validate() error {
 		return missing("MaxAmount")
 	}
 
+	if _, err := splitticket.ParseVoteChoices(cfg.VoteChoices); err != nil {
+		return errors.Wrap(err, "invalid VoteChoices")
+	}
+
+	if cfg.MinMatchProbability < 0 || cfg.MinMatchProbability > 1 {
+		return errors.New("MinMatchProbability must be between 0 and 1")
+	}
+
+	if maxFeeRate, err := dcrutil.NewAmount(cfg.MaxFeeRate); err != nil {
+		return errors.Wrap(err, "invalid MaxFeeRate")
+	} else if maxFeeRate != 0 && maxFeeRate < splitticket.MinTxFeeRate {
+		return errors.Errorf("MaxFeeRate cannot be less than %s",
+			splitticket.MinTxFeeRate)
+	}
+
+	if err := cfg.validateCoinControl(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateSigner(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateProxy(); err != nil {
+		return err
+	}
+
+	if err := cfg.loadMatcherClientCert(); err != nil {
+		return err
+	}
+
 	if cfg.DataDir == "" {
 		return missing("DataDir")
 	}
@@ -237,6 +310,11 @@ func LoadConfig() (*Config, error) {
 		DataDir:              defaultDataDir,
 		SkipWaitPublishedTxs: false,
 		PoolFeeRate:          5.0,
+		MaxFeeRate:           defaultMaxFeeRate.ToCoin(),
+		ReconnectTimeout:     20,
+		InputSelection:       InputSelectionWallet,
+		SignerType:           SignerWallet,
+		SignerTimeout:        300,
 	}
 
 	parser := flags.NewParser(cfg, flags.Default)
@@ -274,19 +352,78 @@ func LoadConfig() (*Config, error) {
 		cfg.MatcherCertFile = util.CleanAndExpandPath(cfg.MatcherCertFile)
 	}
 
+	if cfg.MatcherClientCert != "" {
+		cfg.MatcherClientCert = util.CleanAndExpandPath(cfg.MatcherClientCert)
+	}
+
+	if cfg.MatcherClientKey != "" {
+		cfg.MatcherClientKey = util.CleanAndExpandPath(cfg.MatcherClientKey)
+	}
+
 	if cfg.DataDir != "" {
 		cfg.DataDir = util.CleanAndExpandPath(cfg.DataDir)
 	}
 
+	if cfg.ResumeSession != "" {
+		cfg.ResumeSession = util.CleanAndExpandPath(cfg.ResumeSession)
+	}
+
+	if cfg.SignerDir != "" {
+		cfg.SignerDir = util.CleanAndExpandPath(cfg.SignerDir)
+	}
+
+	if cfg.SignerCertFile != "" {
+		cfg.SignerCertFile = util.CleanAndExpandPath(cfg.SignerCertFile)
+	}
+
 	return cfg, nil
 }
 
+// maxFeeRate returns the maximum fee rate (in Atoms/KB) the buyer accepts to
+// pay. If not specified, defaultMaxFeeRate is used.
+func (cfg *Config) maxFeeRate() dcrutil.Amount {
+	maxFeeRate, _ := dcrutil.NewAmount(cfg.MaxFeeRate)
+	if maxFeeRate == 0 {
+		return defaultMaxFeeRate
+	}
+	return maxFeeRate
+}
+
+// loadMatcherClientCert loads the client certificate used to authenticate to
+// the matcher, if one was specified.
+func (cfg *Config) loadMatcherClientCert() error {
+	if cfg.MatcherClientCert == "" && cfg.MatcherClientKey == "" {
+		cfg.matcherClientCert = nil
+		return nil
+	}
+	if cfg.MatcherClientCert == "" || cfg.MatcherClientKey == "" {
+		return errors.New("specify either both or neither of " +
+			"MatcherClientCert and MatcherClientKey")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.MatcherClientCert,
+		cfg.MatcherClientKey)
+	if err != nil {
+		return errors.Wrap(err, "error loading matcher client certificate")
+	}
+	cfg.matcherClientCert = &cert
+	return nil
+}
+
+// MatcherClientCertificate returns the client certificate presented to the
+// matcher or nil if none was specified. Only available after the config is
+// validated.
+func (cfg *Config) MatcherClientCertificate() *tls.Certificate {
+	return cfg.matcherClientCert
+}
+
 func (cfg *Config) networkCfg() *decredNetworkConfig {
 	return &decredNetworkConfig{
 		Host:     cfg.DcrdHost,
 		User:     cfg.DcrdUser,
 		Pass:     cfg.DcrdPass,
 		CertFile: cfg.DcrdCert,
+		proxy:    cfg.sessionProxy,
 	}
 }
 
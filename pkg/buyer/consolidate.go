@@ -0,0 +1,326 @@
+package buyer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	pb "github.com/decred/dcrwallet/rpc/walletrpc"
+	"github.com/decred/dcrwallet/wallet/txrules"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// consolidateMaxInputs is the maximum number of utxos spent by a single
+	// consolidation transaction, so that it stays below the maximum standard
+	// transaction size.
+	consolidateMaxInputs = 500
+
+	// consolidateCheckInterval is how often the wallet is queried for the
+	// confirmation of the consolidation transaction.
+	consolidateCheckInterval = 15 * time.Second
+)
+
+// tooManySplitInputsError is the error returned when participating in a
+// session requires more inputs into the split tx than allowed.
+type tooManySplitInputsError int
+
+func (e tooManySplitInputsError) Error() string {
+	return fmt.Sprintf("number of utxos to send into split tx (%d) larger "+
+		"than maximum allowed (%d)", int(e), splitticket.MaximumSplitInputs)
+}
+
+// ConsolidateFunds checks whether participating in a session with the
+// configured maximum amount requires more than the maximum allowed number of
+// split tx inputs and if so, consolidates the utxos of the source account
+// into a single output, waiting until it has enough confirmations to be used
+// in a split tx.
+//
+// Returns nil without performing any action if the funds do not need to be
+// consolidated.
+func ConsolidateFunds(ctx context.Context, cfg *Config) error {
+	rep := reporterFromContext(ctx)
+
+	if len(cfg.SplitInputs) > 0 {
+		return errors.New("cannot consolidate funds when the split inputs " +
+			"are specified")
+	}
+
+	if cfg.DryRun {
+		return errors.New("cannot consolidate funds in a dry run")
+	}
+
+	wc, err := connectToWalletClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.WalletConn == nil {
+		defer wc.close()
+	}
+
+	err = wc.testFunds(ctx, cfg)
+	if _, tooMany := errors.Cause(err).(tooManySplitInputsError); !tooMany {
+		return err
+	}
+
+	feeRate, err := consolidationFeeRate(ctx, cfg)
+	if err != nil {
+		return errors.Wrap(err, "error obtaining consolidation fee rate")
+	}
+
+	rep.reportStage(ctx, StageConsolidatingFunds, nil, cfg)
+	outp, err := wc.consolidateUtxos(ctx, cfg, feeRate)
+	if err != nil {
+		return errors.Wrap(err, "error consolidating utxos")
+	}
+
+	rep.reportStage(ctx, StageWaitingConsolidation, nil, cfg)
+	err = wc.waitConfirmedUtxo(ctx, cfg, outp)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for consolidation tx")
+	}
+
+	// The wallet may still pick too many utxos to fund the split tx, so the
+	// funds are checked again before proceeding.
+	err = wc.testFunds(ctx, cfg)
+	if err != nil {
+		return errors.Wrap(err, "error testing funds after consolidation")
+	}
+
+	rep.reportStage(ctx, StageFundsConsolidated, nil, cfg)
+	return nil
+}
+
+// consolidationFeeRate returns the fee rate (in Atoms/KB) of the consolidation
+// tx. This is the fee rate the matcher currently uses for new sessions,
+// limited to the maximum fee rate the buyer accepts to pay. Matchers that do
+// not report their fee rate use splitticket.TxFeeRate, as in their sessions.
+func consolidationFeeRate(ctx context.Context, cfg *Config) (dcrutil.Amount,
+	error) {
+
+	mc, dcrd, err := connectToMatcherClient(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.MatcherConn == nil {
+		defer mc.close()
+	}
+	if dcrd != nil {
+		defer dcrd.client.Shutdown()
+	}
+
+	status, err := mc.status(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "error getting status from matcher")
+	}
+
+	feeRate := dcrutil.Amount(status.FeeRate)
+	if feeRate == 0 {
+		feeRate = splitticket.TxFeeRate
+	}
+	if maxFeeRate := cfg.maxFeeRate(); feeRate > maxFeeRate {
+		feeRate = maxFeeRate
+	}
+	return feeRate, nil
+}
+
+// consolidationFee returns the fee of a consolidation tx with the given number
+// of inputs at the given fee rate (in Atoms/KB).
+func consolidationFee(nbInputs int, feeRate dcrutil.Amount) dcrutil.Amount {
+	size := 12 + 3 + 3 + 3 + // tx header + varints
+		nbInputs*splitticket.SplitTxInputSize + splitticket.SplitTxOutputSize
+	return txrules.FeeForSerializeSize(feeRate, size)
+}
+
+// consolidationTarget returns the amount needed to participate in a session
+// with the configured maximum amount, funding the split tx with the maximum
+// allowed number of inputs.
+func consolidationTarget(cfg *Config) (dcrutil.Amount, error) {
+	amount, err := dcrutil.NewAmount(cfg.MaxAmount)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error decoding maxAmount")
+	}
+
+	feeRate := cfg.maxFeeRate()
+	return amount + splitticket.SessionFeeEstimate(1, feeRate) +
+		splitticket.SplitTxParticipantFee(1, splitticket.MaximumSplitInputs,
+			true, feeRate), nil
+}
+
+// selectConsolidationUtxos returns the smallest of the given utxos (sorted by
+// increasing amount) that need to be consolidated, such that the consolidated
+// output and the largest remaining utxos can fund the target amount within
+// the maximum number of split tx inputs.
+func selectConsolidationUtxos(utxos []splitInputCandidate,
+	target, feeRate dcrutil.Amount) ([]splitInputCandidate, error) {
+
+	var consolidated dcrutil.Amount
+	for i, utxo := range utxos {
+		consolidated += utxo.amount
+		nb := i + 1
+		if nb > consolidateMaxInputs {
+			break
+		}
+		if nb < 2 {
+			continue
+		}
+
+		total := consolidated - consolidationFee(nb, feeRate)
+		remaining := utxos[nb:]
+		if len(remaining) > splitticket.MaximumSplitInputs-1 {
+			remaining = remaining[len(remaining)-
+				(splitticket.MaximumSplitInputs-1):]
+		}
+		for _, r := range remaining {
+			total += r.amount
+		}
+		if total >= target {
+			return utxos[:nb], nil
+		}
+	}
+
+	return nil, errors.Errorf("consolidating up to %d utxos does not allow "+
+		"participating with the maximum amount within %d split inputs",
+		consolidateMaxInputs, splitticket.MaximumSplitInputs)
+}
+
+// consolidateUtxos creates, signs and publishes a transaction that spends the
+// smallest utxos of the source account usable as split tx inputs into a single
+// output, paying the given fee rate (in Atoms/KB). Only as many utxos as
+// needed to participate with the configured maximum amount within the maximum
+// number of split tx inputs are spent. Returns the outpoint of the
+// consolidated output.
+func (wc *walletClient) consolidateUtxos(ctx context.Context,
+	cfg *Config, feeRate dcrutil.Amount) (*wire.OutPoint, error) {
+
+	candidates, err := wc.listSplitInputCandidates(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := parseOutPoints(cfg.ExcludeSplitInputs)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]splitInputCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if _, has := excluded[c.outpoint]; !has {
+			utxos = append(utxos, c)
+		}
+	}
+	if len(utxos) < 2 {
+		return nil, errors.New("not enough utxos to consolidate")
+	}
+
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].amount < utxos[j].amount
+	})
+	target, err := consolidationTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+	utxos, err = selectConsolidationUtxos(utxos, target, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := wc.wsvc.NextAddress(ctx, &pb.NextAddressRequest{
+		Account:   cfg.SourceAccount,
+		GapPolicy: pb.NextAddressRequest_GAP_POLICY_WRAP,
+		Kind:      pb.NextAddressRequest_BIP0044_INTERNAL,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error obtaining consolidation address")
+	}
+	addr, err := dcrutil.DecodeAddress(resp.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding consolidation address")
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating consolidation pkscript")
+	}
+
+	tx := wire.NewMsgTx()
+	var total dcrutil.Amount
+	for _, utxo := range utxos {
+		outp := utxo.outpoint
+		tx.AddTxIn(wire.NewTxIn(&outp, int64(utxo.amount), nil))
+		total += utxo.amount
+	}
+
+	fee := consolidationFee(len(utxos), feeRate)
+	if txrules.IsDustAmount(total-fee, len(pkScript), feeRate) {
+		return nil, errors.Errorf("consolidated amount (%s) is dust",
+			total-fee)
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(total-fee), pkScript))
+
+	bts, err := tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "error serializing consolidation tx")
+	}
+
+	signResp, err := cfg.signer(wc).SignTransactions(ctx, &pb.SignTransactionsRequest{
+		Transactions: []*pb.SignTransactionsRequest_UnsignedTransaction{
+			{SerializedTransaction: bts},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing consolidation tx")
+	}
+	if len(signResp.Transactions) != 1 {
+		return nil, errors.Errorf("wallet signed a different number of "+
+			"transactions (%d) than expected (1)", len(signResp.Transactions))
+	}
+	if len(signResp.Transactions[0].UnsignedInputIndexes) > 0 {
+		return nil, errors.New("wallet did not sign all inputs of the " +
+			"consolidation tx")
+	}
+
+	pubResp, err := wc.wsvc.PublishTransaction(ctx, &pb.PublishTransactionRequest{
+		SignedTransaction: signResp.Transactions[0].Transaction,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error publishing consolidation tx")
+	}
+
+	hash, err := chainhash.NewHash(pubResp.TransactionHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "wallet returned invalid tx hash")
+	}
+
+	return wire.NewOutPoint(hash, 0, wire.TxTreeRegular), nil
+}
+
+// waitConfirmedUtxo waits until the given outpoint is a utxo of the source
+// account that can be used as input of a split tx.
+func (wc *walletClient) waitConfirmedUtxo(ctx context.Context, cfg *Config,
+	outp *wire.OutPoint) error {
+
+	for {
+		candidates, err := wc.listSplitInputCandidates(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range candidates {
+			if c.outpoint == *outp {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(consolidateCheckInterval):
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package buyer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	pb "github.com/decred/dcrwallet/rpc/walletrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"google.golang.org/grpc"
+)
+
+// consolidateTestWallet implements the wallet calls needed to consolidate
+// utxos. Calling any other wallet function panics.
+type consolidateTestWallet struct {
+	WalletClientConn
+	t         *testing.T
+	utxos     []*pb.UnspentOutputResponse
+	published *wire.MsgTx
+}
+
+func (w *consolidateTestWallet) UnspentOutputs(ctx context.Context, in *pb.UnspentOutputsRequest) ([]*pb.UnspentOutputResponse, error) {
+	return w.utxos, nil
+}
+
+func (w *consolidateTestWallet) NextAddress(ctx context.Context, in *pb.NextAddressRequest, opts ...grpc.CallOption) (*pb.NextAddressResponse, error) {
+	if in.Kind != pb.NextAddressRequest_BIP0044_INTERNAL {
+		w.t.Fatalf("consolidation should use an internal address")
+	}
+	return &pb.NextAddressResponse{Address: testAddress(w.t, 0xaa).String()}, nil
+}
+
+func (w *consolidateTestWallet) SignTransactions(ctx context.Context, in *pb.SignTransactionsRequest, opts ...grpc.CallOption) (*pb.SignTransactionsResponse, error) {
+	signed := &pb.SignTransactionsResponse_SignedTransaction{
+		Transaction: in.Transactions[0].SerializedTransaction,
+	}
+	return &pb.SignTransactionsResponse{
+		Transactions: []*pb.SignTransactionsResponse_SignedTransaction{signed},
+	}, nil
+}
+
+func (w *consolidateTestWallet) PublishTransaction(ctx context.Context, in *pb.PublishTransactionRequest, opts ...grpc.CallOption) (*pb.PublishTransactionResponse, error) {
+	tx := wire.NewMsgTx()
+	if err := tx.FromBytes(in.SignedTransaction); err != nil {
+		w.t.Fatalf("error decoding published tx: %v", err)
+	}
+	w.published = tx
+	hash := tx.TxHash()
+
+	// simulate the consolidated output being confirmed.
+	w.utxos = []*pb.UnspentOutputResponse{{
+		TransactionHash: hash[:],
+		Amount:          tx.TxOut[0].Value,
+		PkScript:        tx.TxOut[0].PkScript,
+	}}
+	return &pb.PublishTransactionResponse{TransactionHash: hash[:]}, nil
+}
+
+func TestConsolidateUtxos(t *testing.T) {
+	script, _ := txscript.PayToAddrScript(testAddress(t, 0x01))
+	nbUtxos := splitticket.MaximumSplitInputs * 2
+	w := &consolidateTestWallet{t: t}
+	for i := 0; i < nbUtxos; i++ {
+		hash := chainhash.Hash{byte(i)}
+		w.utxos = append(w.utxos, &pb.UnspentOutputResponse{
+			TransactionHash: hash[:],
+			Amount:          1e7,
+			PkScript:        script,
+		})
+	}
+
+	// an excluded utxo is not consolidated.
+	excluded := chainhash.Hash{0x00}
+	cfg := &Config{
+		ChainParams:        &chaincfg.TestNet3Params,
+		ExcludeSplitInputs: []string{excluded.String() + ":0"},
+		MaxAmount:          10,
+	}
+	wc := &walletClient{wsvc: w, chainParams: cfg.ChainParams}
+
+	// the utxos can't be consolidated into enough funds.
+	feeRate := dcrutil.Amount(2e5)
+	_, err := wc.consolidateUtxos(context.Background(), cfg, feeRate)
+	if err == nil || w.published != nil {
+		t.Fatalf("consolidated utxos that can't fund the session")
+	}
+
+	cfg.MaxAmount = 2.5
+	outp, err := wc.consolidateUtxos(context.Background(), cfg, feeRate)
+	if err != nil {
+		t.Fatalf("unexpected error consolidating utxos: %v", err)
+	}
+
+	tx := w.published
+	if tx == nil || outp.Hash != tx.TxHash() || outp.Index != 0 {
+		t.Fatalf("consolidation tx not published")
+	}
+	if len(tx.TxIn) < 2 || len(tx.TxIn) >= nbUtxos-1 || len(tx.TxOut) != 1 {
+		t.Fatalf("unexpected consolidation tx format (%d inputs, %d outputs)",
+			len(tx.TxIn), len(tx.TxOut))
+	}
+
+	// only the utxos needed to fund the session within the maximum number of
+	// split inputs are consolidated.
+	target, _ := consolidationTarget(cfg)
+	remaining := int64(splitticket.MaximumSplitInputs-1) * 1e7
+	if tx.TxOut[0].Value+remaining < int64(target) {
+		t.Fatalf("consolidated output does not allow funding the session")
+	}
+	if tx.TxOut[0].Value+remaining-1e7 >= int64(target) {
+		t.Fatalf("consolidated more utxos than needed")
+	}
+	for _, in := range tx.TxIn {
+		if in.PreviousOutPoint.Hash == excluded {
+			t.Fatalf("excluded utxo consolidated")
+		}
+	}
+
+	fee := int64(len(tx.TxIn))*1e7 - tx.TxOut[0].Value
+	signedSize := tx.SerializeSize() + len(tx.TxIn)*(1+73+1+33)
+	minFee := int64(signedSize) * int64(feeRate) / 1000
+	if fee < minFee || fee > minFee*101/100 {
+		t.Fatalf("unexpected consolidation fee %d (min %d)", fee, minFee)
+	}
+
+	err = wc.waitConfirmedUtxo(context.Background(), cfg, outp)
+	if err != nil {
+		t.Fatalf("unexpected error waiting for consolidation: %v", err)
+	}
+}
@@ -1,5 +1,11 @@
 package buyer
 
+import (
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+)
+
 // Stage represents a single stage of the full ticket buying process.
 type Stage int32
 type reporterCtxKey int
@@ -7,6 +13,26 @@ type reporterCtxKey int
 const (
 	// ReporterCtxKey is the key to use when passing a reporter via context
 	ReporterCtxKey = reporterCtxKey(1)
+
+	// waitEstimateInterval is the interval between requests for estimates
+	// of the wait for a session while the buyer is waiting in the matcher.
+	waitEstimateInterval = time.Minute
+
+	// waitEstimateTimeout is the maximum time to wait for the matcher to
+	// reply to a request for a wait estimate.
+	waitEstimateTimeout = 30 * time.Second
+
+	// stakeDiffCheckInterval is the interval between checks of whether the
+	// matcher is in the stop window around a change of stake difficulty.
+	stakeDiffCheckInterval = 30 * time.Second
+
+	// defaultStakeDiffChangeStopWindow is the stop window assumed for
+	// matchers that don't report it.
+	defaultStakeDiffChangeStopWindow = 5
+
+	// defaultMaxFeeRate is the maximum fee rate (in Atoms/KB) accepted by
+	// the buyer when MaxFeeRate is not specified (0.01 DCR/KB).
+	defaultMaxFeeRate dcrutil.Amount = 1e6
 )
 
 // Following are the various stages the buyer can be in. They may not
@@ -40,4 +66,9 @@ const (
 	StageSkippedWaiting
 	StageWaitingPublishedTxs
 	StageSessionEndedSuccessfully
+	StageConsolidatingFunds
+	StageWaitingConsolidation
+	StageFundsConsolidated
+	StageDryRunCompleted
+	StageWaitingStakeDiffChange
 )
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"time"
 
+	intnet "github.com/matheusd/dcr-split-ticket-matcher/pkg/buyer/internal/net"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 
 	"github.com/decred/dcrd/rpcclient"
@@ -17,6 +18,8 @@ type decredNetworkConfig struct {
 	User     string
 	Pass     string
 	CertFile string
+
+	proxy *intnet.Proxy
 }
 
 type decredNetwork struct {
@@ -40,6 +43,11 @@ func connectToDecredNode(cfg *decredNetworkConfig) (*decredNetwork, error) {
 		Certificates:         certs,
 		DisableAutoReconnect: true,
 	}
+	if cfg.proxy != nil {
+		connCfg.Proxy = cfg.proxy.Addr
+		connCfg.ProxyUser = cfg.proxy.User
+		connCfg.ProxyPass = cfg.proxy.Pass
+	}
 
 	ntfsHandler := &rpcclient.NotificationHandlers{}
 
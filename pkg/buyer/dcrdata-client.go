@@ -5,25 +5,38 @@ import (
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/wire"
 	dcrdatatypes "github.com/decred/dcrdata/api/types"
+	intnet "github.com/matheusd/dcr-split-ticket-matcher/pkg/buyer/internal/net"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 	"net/http"
 	"time"
 )
 
+// dcrdataClient returns the http client used to query dcrdata, connecting
+// through the given proxy (if not nil).
+func dcrdataClient(proxy *intnet.Proxy) *http.Client {
+	if proxy != nil {
+		return proxy.HTTPClient(time.Second * 10)
+	}
+	return &http.Client{Timeout: time.Second * 10}
+}
+
 // utxoProviderForDcrdataURL returns a UtxoMapProvider function that fetches
 // utxo information from the given dcrdata URL.
-func utxoProviderForDcrdataURL(dcrdataURL string) utxoMapProvider {
+func utxoProviderForDcrdataURL(dcrdataURL string, proxy *intnet.Proxy) utxoMapProvider {
+	client := dcrdataClient(proxy)
 	return func(tx *wire.MsgTx) (splitticket.UtxoMap, error) {
-		return splitticket.UtxoMapFromDcrdata(dcrdataURL, tx)
+		return splitticket.UtxoMapFromDcrdataClient(client, dcrdataURL, tx)
 	}
 }
 
 // isDcrdataOnline checks whether there is a dcrdata online at the given URL and
 // that it is for the given network. Returns nil if successful or an error.
-func isDcrdataOnline(dcrdataURL string, chainParams *chaincfg.Params) error {
+func isDcrdataOnline(dcrdataURL string, chainParams *chaincfg.Params,
+	proxy *intnet.Proxy) error {
+
 	url := dcrdataURL + "/api/status"
-	client := http.Client{Timeout: time.Second * 10}
+	client := dcrdataClient(proxy)
 	urlResp, err := client.Get(url)
 	if err != nil {
 		return errors.Wrap(err, "error during GET /api/status call")
@@ -0,0 +1,80 @@
+package buyer
+
+import (
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/pkg/errors"
+)
+
+// errDryRun is reported to the matcher when a dry run session stops before
+// funding the split transaction.
+var errDryRun = errors.New("buyer dry run: not funding the split transaction")
+
+// DryRunResult is the outcome a buyer would have had if a dry run session had
+// been completed.
+type DryRunResult struct {
+	// Contribution is the amount the buyer would have contributed to the
+	// ticket.
+	Contribution dcrutil.Amount
+
+	// TicketPrice is the price of the ticket of the session.
+	TicketPrice dcrutil.Amount
+
+	// ContributionPercent is the share of the ticket price contributed by the
+	// buyer, as a percentage (eg: 5.0 = 5%).
+	ContributionPercent float64
+
+	// VoterChance is the probability (between 0 and 1) that the buyer would
+	// have been selected as the voter of the ticket.
+	VoterChance float64
+
+	// SplitFee is the share of the split tx fee paid by the buyer.
+	SplitFee dcrutil.Amount
+
+	// TicketFee is the share of the ticket fee paid by the buyer.
+	TicketFee dcrutil.Amount
+
+	// PoolFee is the share of the pool fee paid by the buyer.
+	PoolFee dcrutil.Amount
+}
+
+// TotalFees returns the sum of all fees the buyer would have paid.
+func (r *DryRunResult) TotalFees() dcrutil.Amount {
+	return r.SplitFee + r.TicketFee + r.PoolFee
+}
+
+// dryRunResult calculates the outcome of the session, given that the ticket
+// has already been generated by the matcher.
+func (session *Session) dryRunResult() *DryRunResult {
+	res := &DryRunResult{
+		Contribution: session.Amount,
+		TicketPrice:  session.TicketPrice,
+		TicketFee:    session.Fee,
+		PoolFee:      session.PoolFee,
+	}
+
+	if session.TicketPrice > 0 {
+		res.ContributionPercent = float64(session.Amount) /
+			float64(session.TicketPrice) * 100
+	}
+
+	var total dcrutil.Amount
+	for _, amount := range session.amounts() {
+		total += amount
+	}
+	if total > 0 {
+		res.VoterChance = float64(session.Amount) / float64(total)
+	}
+
+	// Whatever is left out of our inputs after the change and the split
+	// output that funds our part of the ticket is our share of the split fee.
+	splitFee := session.myTotalAmountIn() - session.Amount - session.Fee -
+		session.PoolFee
+	if session.splitChange != nil {
+		splitFee -= dcrutil.Amount(session.splitChange.Value)
+	}
+	if splitFee > 0 {
+		res.SplitFee = splitFee
+	}
+
+	return res
+}
@@ -0,0 +1,43 @@
+package buyer
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+)
+
+func TestDryRunResult(t *testing.T) {
+	outp := wire.OutPoint{Hash: chainhash.Hash{0x01}}
+	session := &Session{
+		Amount:      dcrutil.Amount(25e8),
+		Fee:         dcrutil.Amount(1e5),
+		PoolFee:     dcrutil.Amount(5e7),
+		TicketPrice: dcrutil.Amount(100e8),
+		participants: []buyerSessionParticipant{
+			{amount: 25e8}, {amount: 50e8}, {amount: 25e8},
+		},
+		splitInputs: []*wire.TxIn{wire.NewTxIn(&outp, wire.NullValueIn, nil)},
+		splitTxUtxoMap: splitticket.UtxoMap{
+			outp: splitticket.UtxoEntry{Value: dcrutil.Amount(30e8)},
+		},
+		splitChange: wire.NewTxOut(int64(dcrutil.Amount(30e8-25e8-1e5-5e7-3e4)), nil),
+	}
+
+	res := session.dryRunResult()
+	if res.ContributionPercent != 25 {
+		t.Fatalf("unexpected contribution percentage %f",
+			res.ContributionPercent)
+	}
+	if res.VoterChance != 0.25 {
+		t.Fatalf("unexpected voter chance %f", res.VoterChance)
+	}
+	if res.SplitFee != 3e4 {
+		t.Fatalf("unexpected split fee %s", res.SplitFee)
+	}
+	if res.TotalFees() != 3e4+1e5+5e7 {
+		t.Fatalf("unexpected total fees %s", res.TotalFees())
+	}
+}
@@ -0,0 +1,447 @@
+package buyer
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/matcherrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Event is a typed notification of the progress of a split ticket purchase.
+// Subscribers should switch on the concrete type of the event, which is one of
+// the *Event types of this package.
+type Event interface {
+	buyerEvent()
+}
+
+// SessionSnapshot is a copy of the public information of a buyer session at
+// the time an event was generated. Fields that are not yet known at the
+// reported stage hold their zero value.
+type SessionSnapshot struct {
+	ID              matcher.ParticipantID
+	Amount          dcrutil.Amount
+	Fee             dcrutil.Amount
+	PoolFee         dcrutil.Amount
+	TicketPrice     dcrutil.Amount
+	FeeRate         dcrutil.Amount
+	MainchainHash   *chainhash.Hash
+	MainchainHeight uint32
+	NbParticipants  uint32
+	MyIndex         uint32
+	Amounts         []dcrutil.Amount
+
+	VoteAddress         string
+	PoolAddress         string
+	SplitOutputAddress  string
+	TicketOutputAddress string
+
+	SplitTxHash    *chainhash.Hash
+	TicketHash     *chainhash.Hash
+	RevocationHash *chainhash.Hash
+
+	VoterIndex   int
+	SelectedCoin dcrutil.Amount
+}
+
+// encodeAddress returns the encoded address or an empty string if the address
+// is nil.
+func encodeAddress(addr dcrutil.Address) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.EncodeAddress()
+}
+
+// Snapshot returns a copy of the public information of the session.
+func (session *Session) Snapshot() *SessionSnapshot {
+	snap := &SessionSnapshot{
+		ID:                  session.ID,
+		Amount:              session.Amount,
+		Fee:                 session.Fee,
+		PoolFee:             session.PoolFee,
+		TicketPrice:         session.TicketPrice,
+		FeeRate:             session.feeRate,
+		MainchainHeight:     session.mainchainHeight,
+		NbParticipants:      session.nbParticipants,
+		MyIndex:             session.myIndex,
+		Amounts:             session.amounts(),
+		VoteAddress:         encodeAddress(session.voteAddress),
+		PoolAddress:         encodeAddress(session.poolAddress),
+		SplitOutputAddress:  encodeAddress(session.splitOutputAddress),
+		TicketOutputAddress: encodeAddress(session.ticketOutputAddress),
+		VoterIndex:          session.voterIndex,
+		SelectedCoin:        session.selectedCoin,
+	}
+
+	if session.mainchainHash != nil {
+		hash := *session.mainchainHash
+		snap.MainchainHash = &hash
+	}
+	if session.fundedSplitTx != nil {
+		hash := session.fundedSplitTx.TxHash()
+		snap.SplitTxHash = &hash
+	} else if session.splitTx != nil {
+		hash := session.splitTx.TxHash()
+		snap.SplitTxHash = &hash
+	}
+	if session.selectedTicket != nil {
+		hash := session.selectedTicket.TxHash()
+		snap.TicketHash = &hash
+	}
+	if session.selectedRevocation != nil {
+		hash := session.selectedRevocation.TxHash()
+		snap.RevocationHash = &hash
+	}
+
+	return snap
+}
+
+// StageEvent is generated when the buyer enters a new stage. Session is nil
+// on stages that happen outside of a matched session (eg: while connecting to
+// the services or finding matches). DryRun is only filled on the
+// StageDryRunCompleted stage.
+type StageEvent struct {
+	Stage   Stage
+	Pool    string
+	Name    string
+	Session *SessionSnapshot
+	DryRun  *DryRunResult
+}
+
+// MatcherStatusEvent is generated after the status of the matcher is queried.
+type MatcherStatusEvent struct {
+	TicketPrice     dcrutil.Amount
+	ProtocolVersion uint32
+}
+
+// WaitingListEvent is generated when the waiting queues of the matcher change.
+// Only the queues watched by the reporter are included.
+type WaitingListEvent struct {
+	Queues []matcher.WaitingQueue
+}
+
+// WaitEstimateEvent is generated when the matcher estimates how long the buyer
+// will wait for a session. MaxWait is the remaining time the buyer is willing
+// to wait (zero if unlimited), so Estimate.MatchProbability(MaxWait) is the
+// chance of a session starting in time.
+type WaitEstimateEvent struct {
+	Estimate matcher.WaitEstimate
+	MaxWait  time.Duration
+}
+
+// StakeDiffChangeEvent is generated when the buyer suspends its participation
+// in the matcher around a change of stake difficulty and again when it resumes
+// participating. BlocksToChange is the number of blocks until the change (zero
+// if it already happened) and ExpectedTicketPrice is the price estimated after
+// the change. TicketShare is the fraction (between 0 and 1) of
+// ExpectedTicketPrice covered by MaxAmount or zero if no price is known.
+//
+// The comparison is informational only: the buyer resumes participating with
+// MaxAmount regardless of the new ticket price.
+type StakeDiffChangeEvent struct {
+	BlocksToChange      int32
+	TicketPrice         dcrutil.Amount
+	ExpectedTicketPrice dcrutil.Amount
+	MaxAmount           dcrutil.Amount
+	TicketShare         float64
+}
+
+// SavedSessionEvent is generated after the session is saved.
+type SavedSessionEvent struct {
+	Filename string
+}
+
+// SrvRecordEvent is generated after the SRV record of the matcher is looked
+// up. Err is filled if the lookup failed.
+type SrvRecordEvent struct {
+	Record string
+	Err    error
+}
+
+// ExternalSignRequestEvent is generated when transactions are exported for
+// signing by an external signer.
+type ExternalSignRequestEvent struct {
+	RequestFilename  string
+	ResponseFilename string
+}
+
+// SplitPublishedEvent is generated when the split transaction is seen on the
+// network.
+type SplitPublishedEvent struct{}
+
+// TicketPublishedEvent is generated when a ticket spending the split
+// transaction is seen on the network. If Published is different than Expected,
+// the matcher published a different ticket than the one negotiated during the
+// session and should be considered compromised.
+type TicketPublishedEvent struct {
+	Expected  chainhash.Hash
+	Published chainhash.Hash
+}
+
+// Correct returns true if the published ticket is the expected one.
+func (e *TicketPublishedEvent) Correct() bool {
+	return e.Expected == e.Published
+}
+
+// ErrorEvent is generated when buying the split ticket fails. Code classifies
+// the error: errors returned by the matcher keep their grpc code, canceled or
+// expired contexts are respectively reported as codes.Canceled and
+// codes.DeadlineExceeded and all other errors are codes.Unknown.
+type ErrorEvent struct {
+	Err  error
+	Code codes.Code
+}
+
+func (*StageEvent) buyerEvent()               {}
+func (*MatcherStatusEvent) buyerEvent()       {}
+func (*WaitingListEvent) buyerEvent()         {}
+func (*WaitEstimateEvent) buyerEvent()        {}
+func (*StakeDiffChangeEvent) buyerEvent()     {}
+func (*SavedSessionEvent) buyerEvent()        {}
+func (*SrvRecordEvent) buyerEvent()           {}
+func (*ExternalSignRequestEvent) buyerEvent() {}
+func (*SplitPublishedEvent) buyerEvent()      {}
+func (*TicketPublishedEvent) buyerEvent()     {}
+func (*ErrorEvent) buyerEvent()               {}
+
+// ErrorCode returns the code used to classify the given buyer error on error
+// events.
+func ErrorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	cause := errors.Cause(err)
+	if e, is := cause.(unreportableError); is {
+		cause = errors.Cause(e.e)
+	}
+	switch cause {
+	case context.Canceled:
+		return codes.Canceled
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	}
+
+	return status.Code(cause)
+}
+
+// EventReporter is a Reporter that generates typed events for the progress of
+// the purchase. The handler is called synchronously from the goroutine that
+// generated the event, which may be any of the goroutines of the buyer (eg:
+// the ones watching the wait estimate and the stake difficulty while waiting
+// for a session) or the one running WatchMatcherWaitingList. Therefore the
+// handler must be safe for concurrent use and should not block.
+//
+// EventReporter also fulfills the waiting list watcher interface, so it can be
+// used with WatchMatcherWaitingList.
+type EventReporter struct {
+	handler     func(Event)
+	pool        string
+	sessionName string
+}
+
+// NewEventReporter returns a reporter that calls the handler for every event
+// of the purchase. Only changes to the waiting queue of the given pool and
+// session name are reported.
+func NewEventReporter(handler func(Event), pool, sessionName string) *EventReporter {
+	return &EventReporter{
+		handler:     handler,
+		pool:        pool,
+		sessionName: encodeSessionName(sessionName),
+	}
+}
+
+// NewChanEventReporter returns a reporter that sends the events of the
+// purchase to the given channel. The reporter blocks until the events are
+// received, so the channel must be consumed (or buffered) for the duration of
+// the purchase.
+func NewChanEventReporter(c chan<- Event, pool, sessionName string) *EventReporter {
+	return NewEventReporter(func(e Event) { c <- e }, pool, sessionName)
+}
+
+// WaitingListChanged fulfills waitingListWatcher by generating a waiting list
+// event with the watched queue.
+func (rep *EventReporter) WaitingListChanged(queues []matcher.WaitingQueue) {
+	var watched []matcher.WaitingQueue
+	for _, q := range queues {
+		if q.Pool == rep.pool && q.Name == rep.sessionName {
+			watched = append(watched, q)
+		}
+	}
+	rep.handler(&WaitingListEvent{Queues: watched})
+}
+
+func (rep *EventReporter) reportStage(ctx context.Context, stage Stage, session *Session, cfg *Config) {
+	e := &StageEvent{Stage: stage}
+	if cfg != nil {
+		e.Pool = cfg.Pool
+		e.Name = cfg.SessionName
+	}
+	if session != nil {
+		e.Session = session.Snapshot()
+		if stage == StageDryRunCompleted {
+			e.DryRun = session.dryRunResult()
+		}
+	}
+	rep.handler(e)
+}
+
+func (rep *EventReporter) reportMatcherStatus(status *pb.StatusResponse) {
+	rep.handler(&MatcherStatusEvent{
+		TicketPrice:     dcrutil.Amount(status.TicketPrice),
+		ProtocolVersion: status.ProtocolVersion,
+	})
+}
+
+func (rep *EventReporter) reportWaitEstimate(est *matcher.WaitEstimate,
+	maxWait time.Duration) {
+
+	rep.handler(&WaitEstimateEvent{Estimate: *est, MaxWait: maxWait})
+}
+
+func (rep *EventReporter) reportStakeDiffChange(blocksToChange int32,
+	ticketPrice, expectedTicketPrice, maxAmount dcrutil.Amount) {
+
+	rep.handler(&StakeDiffChangeEvent{
+		BlocksToChange:      blocksToChange,
+		TicketPrice:         ticketPrice,
+		ExpectedTicketPrice: expectedTicketPrice,
+		MaxAmount:           maxAmount,
+		TicketShare:         ticketPriceShare(maxAmount, expectedTicketPrice),
+	})
+}
+
+func (rep *EventReporter) reportSavedSession(fname string) {
+	rep.handler(&SavedSessionEvent{Filename: fname})
+}
+
+func (rep *EventReporter) reportSrvRecordFound(record string) {
+	rep.handler(&SrvRecordEvent{Record: record})
+}
+
+func (rep *EventReporter) reportSrvLookupError(err error) {
+	rep.handler(&SrvRecordEvent{Err: err})
+}
+
+func (rep *EventReporter) reportSplitPublished() {
+	rep.handler(&SplitPublishedEvent{})
+}
+
+func (rep *EventReporter) reportRightTicketPublished(ticket *chainhash.Hash) {
+	rep.handler(&TicketPublishedEvent{Expected: *ticket, Published: *ticket})
+}
+
+func (rep *EventReporter) reportWrongTicketPublished(ticket *chainhash.Hash, session *Session) {
+	rep.handler(&TicketPublishedEvent{
+		Expected:  session.selectedTicket.TxHash(),
+		Published: *ticket,
+	})
+}
+
+func (rep *EventReporter) reportBuyingError(err error) {
+	rep.handler(&ErrorEvent{Err: err, Code: ErrorCode(err)})
+}
+
+func (rep *EventReporter) reportExternalSignRequest(reqFname, respFname string) {
+	rep.handler(&ExternalSignRequestEvent{
+		RequestFilename:  reqFname,
+		ResponseFilename: respFname,
+	})
+}
+
+// MultiReporter sends the progress of the purchase to several reporters (eg:
+// a WriterReporter for the log and an EventReporter for a dashboard).
+type MultiReporter []Reporter
+
+// WaitingListChanged fulfills waitingListWatcher by forwarding the changes to
+// the reporters that watch the waiting list.
+func (reps MultiReporter) WaitingListChanged(queues []matcher.WaitingQueue) {
+	for _, rep := range reps {
+		if w, is := rep.(waitingListWatcher); is {
+			w.WaitingListChanged(queues)
+		}
+	}
+}
+
+func (reps MultiReporter) reportStage(ctx context.Context, stage Stage, session *Session, cfg *Config) {
+	for _, rep := range reps {
+		rep.reportStage(ctx, stage, session, cfg)
+	}
+}
+
+func (reps MultiReporter) reportMatcherStatus(status *pb.StatusResponse) {
+	for _, rep := range reps {
+		rep.reportMatcherStatus(status)
+	}
+}
+
+func (reps MultiReporter) reportWaitEstimate(est *matcher.WaitEstimate,
+	maxWait time.Duration) {
+
+	for _, rep := range reps {
+		rep.reportWaitEstimate(est, maxWait)
+	}
+}
+
+func (reps MultiReporter) reportStakeDiffChange(blocksToChange int32,
+	ticketPrice, expectedTicketPrice, maxAmount dcrutil.Amount) {
+
+	for _, rep := range reps {
+		rep.reportStakeDiffChange(blocksToChange, ticketPrice,
+			expectedTicketPrice, maxAmount)
+	}
+}
+
+func (reps MultiReporter) reportSavedSession(fname string) {
+	for _, rep := range reps {
+		rep.reportSavedSession(fname)
+	}
+}
+
+func (reps MultiReporter) reportSrvRecordFound(record string) {
+	for _, rep := range reps {
+		rep.reportSrvRecordFound(record)
+	}
+}
+
+func (reps MultiReporter) reportSrvLookupError(err error) {
+	for _, rep := range reps {
+		rep.reportSrvLookupError(err)
+	}
+}
+
+func (reps MultiReporter) reportSplitPublished() {
+	for _, rep := range reps {
+		rep.reportSplitPublished()
+	}
+}
+
+func (reps MultiReporter) reportRightTicketPublished(ticket *chainhash.Hash) {
+	for _, rep := range reps {
+		rep.reportRightTicketPublished(ticket)
+	}
+}
+
+func (reps MultiReporter) reportWrongTicketPublished(ticket *chainhash.Hash, session *Session) {
+	for _, rep := range reps {
+		rep.reportWrongTicketPublished(ticket, session)
+	}
+}
+
+func (reps MultiReporter) reportBuyingError(err error) {
+	for _, rep := range reps {
+		rep.reportBuyingError(err)
+	}
+}
+
+func (reps MultiReporter) reportExternalSignRequest(reqFname, respFname string) {
+	for _, rep := range reps {
+		rep.reportExternalSignRequest(reqFname, respFname)
+	}
+}
@@ -0,0 +1,118 @@
+package buyer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEventReporter(t *testing.T) {
+	var events []Event
+	rep := NewEventReporter(func(e Event) { events = append(events, e) },
+		"pool", "session")
+	var _ Reporter = rep
+	var _ waitingListWatcher = rep
+
+	ticket := wire.NewMsgTx()
+	ticket.AddTxOut(wire.NewTxOut(10, nil))
+	session := &Session{
+		ID:             1,
+		Amount:         dcrutil.Amount(1e8),
+		nbParticipants: 3,
+		myIndex:        2,
+		selectedTicket: ticket,
+	}
+	cfg := &Config{Pool: "pool", SessionName: "session"}
+	rep.reportStage(context.Background(), StageTicketGenerated, session, cfg)
+
+	rep.WaitingListChanged([]matcher.WaitingQueue{
+		{Pool: "pool", Name: encodeSessionName("other")},
+		{Pool: "pool", Name: encodeSessionName("session"),
+			Amounts: []dcrutil.Amount{1, 2}},
+	})
+
+	wrongHash := chainhash.Hash{0x01}
+	rep.reportWrongTicketPublished(&wrongHash, session)
+	rep.reportBuyingError(errors.Wrap(context.Canceled, "aborted"))
+	rep.reportStakeDiffChange(3, 100e8, 120e8, 30e8)
+
+	if len(events) != 5 {
+		t.Fatalf("unexpected number of events: %d", len(events))
+	}
+
+	stage, is := events[0].(*StageEvent)
+	if !is || stage.Stage != StageTicketGenerated || stage.Pool != "pool" ||
+		stage.Session == nil {
+		t.Fatalf("unexpected stage event %#v", events[0])
+	}
+	expectedHash := ticket.TxHash()
+	if stage.Session.ID != 1 || stage.Session.Amount != 1e8 ||
+		stage.Session.NbParticipants != 3 || stage.Session.MyIndex != 2 ||
+		*stage.Session.TicketHash != expectedHash {
+		t.Fatalf("unexpected session snapshot %#v", stage.Session)
+	}
+
+	wl, is := events[1].(*WaitingListEvent)
+	if !is || len(wl.Queues) != 1 || len(wl.Queues[0].Amounts) != 2 {
+		t.Fatalf("unexpected waiting list event %#v", events[1])
+	}
+
+	published, is := events[2].(*TicketPublishedEvent)
+	if !is || published.Correct() || published.Expected != expectedHash ||
+		published.Published != wrongHash {
+		t.Fatalf("unexpected ticket published event %#v", events[2])
+	}
+
+	errEvent, is := events[3].(*ErrorEvent)
+	if !is || errEvent.Code != codes.Canceled {
+		t.Fatalf("unexpected error event %#v", events[3])
+	}
+
+	sdiff, is := events[4].(*StakeDiffChangeEvent)
+	if !is || sdiff.BlocksToChange != 3 || sdiff.TicketShare != 0.25 {
+		t.Fatalf("unexpected stake diff change event %#v", events[4])
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{nil, codes.OK},
+		{errors.New("boom"), codes.Unknown},
+		{errors.Wrap(context.DeadlineExceeded, "timeout"), codes.DeadlineExceeded},
+		{status.Error(codes.FailedPrecondition, "wrong"), codes.FailedPrecondition},
+		{unreportableError{errors.Wrap(
+			status.Error(codes.PermissionDenied, "key"), "join")},
+			codes.PermissionDenied},
+	}
+
+	for i, tc := range tests {
+		if code := ErrorCode(tc.err); code != tc.code {
+			t.Fatalf("test %d: expected code %s, got %s", i, tc.code, code)
+		}
+	}
+}
+
+func TestMultiReporter(t *testing.T) {
+	var nb1, nb2 int
+	rep := MultiReporter{
+		NewEventReporter(func(Event) { nb1++ }, "", "s"),
+		NullReporter{},
+		NewEventReporter(func(Event) { nb2++ }, "", "s"),
+	}
+
+	rep.reportSplitPublished()
+	rep.WaitingListChanged(nil)
+	if nb1 != 2 || nb2 != 2 {
+		t.Fatalf("events not forwarded to all reporters (%d, %d)", nb1, nb2)
+	}
+}
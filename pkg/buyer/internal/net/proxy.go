@@ -0,0 +1,69 @@
+package net
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+	"github.com/pkg/errors"
+)
+
+// Proxy dials outbound connections through a SOCKS5 proxy.
+type Proxy struct {
+	Addr string
+	User string
+	Pass string
+}
+
+// NewProxy returns a proxy for the SOCKS5 server at the given address. If
+// isolate is true, the given credentials are replaced by random ones, so that
+// Tor uses different circuits for the connections made with this proxy than
+// for any other connection (stream isolation).
+func NewProxy(addr, user, pass string, isolate bool) (*Proxy, error) {
+	if isolate {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, errors.Wrap(err, "error generating proxy isolation "+
+				"credentials")
+		}
+		user = hex.EncodeToString(b[:8])
+		pass = hex.EncodeToString(b[8:])
+	}
+
+	return &Proxy{Addr: addr, User: user, Pass: pass}, nil
+}
+
+func (p *Proxy) socks() *socks.Proxy {
+	return &socks.Proxy{Addr: p.Addr, Username: p.User, Password: p.Pass}
+}
+
+// Dial connects to the given address through the proxy.
+func (p *Proxy) Dial(network, addr string) (net.Conn, error) {
+	return p.socks().Dial(network, addr)
+}
+
+// DialTimeout connects to the given tcp address through the proxy. It has the
+// signature required by grpc.WithDialer.
+func (p *Proxy) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	return p.socks().DialTimeout("tcp", addr, timeout)
+}
+
+// HTTPClient returns an http client that performs its requests through the
+// proxy.
+func (p *Proxy) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Dial: p.Dial},
+	}
+}
+
+// IsOnionHost returns true if the given host (with an optional port) is a Tor
+// onion service.
+func IsOnionHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(RemoveHostPort(host)), ".")
+	return strings.HasSuffix(host, ".onion")
+}
@@ -0,0 +1,51 @@
+package net
+
+import (
+	"testing"
+)
+
+func TestIsOnionHost(t *testing.T) {
+	tests := []struct {
+		host string
+		res  bool
+	}{
+		{"expyuzz4wqqyqhjn.onion", true},
+		{"expyuzz4wqqyqhjn.onion:8475", true},
+		{"EXPYUZZ4WQQYQHJN.ONION.:8475", true},
+		{"matcher.example.com:8475", false},
+		{"onion.example.com", false},
+		{"127.0.0.1:9050", false},
+	}
+
+	for _, tc := range tests {
+		if res := IsOnionHost(tc.host); res != tc.res {
+			t.Fatalf("IsOnionHost(%q) returned %v, expected %v", tc.host,
+				res, tc.res)
+		}
+	}
+}
+
+func TestNewProxyIsolation(t *testing.T) {
+	p, err := NewProxy("127.0.0.1:9050", "user", "pass", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.User != "user" || p.Pass != "pass" {
+		t.Fatalf("proxy without isolation changed the credentials")
+	}
+
+	p1, err := NewProxy("127.0.0.1:9050", "user", "pass", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := NewProxy("127.0.0.1:9050", "user", "pass", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.User == "user" || p1.User == "" || p1.Pass == "" {
+		t.Fatalf("isolated proxy did not generate credentials")
+	}
+	if p1.User == p2.User || p1.Pass == p2.Pass {
+		t.Fatalf("isolated proxies share credentials")
+	}
+}
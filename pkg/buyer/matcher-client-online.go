@@ -3,6 +3,8 @@ package buyer
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"time"
 
 	"github.com/decred/dcrd/wire"
@@ -32,43 +34,77 @@ type onlineMatcherClient struct {
 	utxoProvider utxoMapProvider
 }
 
-// connectToMatcherService tries to connect to the given matcher host and to a
-// dcrd daemon, given the provided config options.
-func connectToMatcherService(ctx context.Context, matcherHost string,
-	certFile string, utxoProvider utxoMapProvider) (MatcherClientConn, error) {
+// matcherCredentials returns the transport credentials for connecting to the
+// given matcher host. If certFile is specified, the matcher must use the
+// (usually self signed) certificate in it. If clientCert is specified, it is
+// presented to matchers that require clients to authenticate.
+func matcherCredentials(matcherHost, certFile string,
+	clientCert *tls.Certificate) (credentials.TransportCredentials, error) {
 
-	rep := reporterFromContext(ctx)
+	tlsCfg := &tls.Config{
+		ServerName: intnet.RemoveHostPort(matcherHost),
+	}
 
-	matcherHost, isSrv, err := intnet.DetermineMatcherHost(matcherHost)
-	if err != nil {
-		rep.reportSrvLookupError(err)
+	if certFile != "" {
+		pem, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating credentials")
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("error creating credentials: no " +
+				"certificates found in matcher cert file")
+		}
+		tlsCfg.ServerName = "localhost"
 	}
-	if isSrv {
-		rep.reportSrvRecordFound(matcherHost)
+
+	if clientCert != nil {
+		tlsCfg.Certificates = []tls.Certificate{*clientCert}
 	}
 
-	var opt grpc.DialOption
-	var creds credentials.TransportCredentials
+	return credentials.NewTLS(tlsCfg), nil
+}
 
-	if certFile != "" {
-		creds, err = credentials.NewClientTLSFromFile(certFile, "localhost")
+// connectToMatcherService tries to connect to the given matcher host and to a
+// dcrd daemon, given the provided config options.
+func connectToMatcherService(ctx context.Context, matcherHost string,
+	certFile string, clientCert *tls.Certificate, proxy *intnet.Proxy,
+	utxoProvider utxoMapProvider) (MatcherClientConn, error) {
+
+	var err error
+	rep := reporterFromContext(ctx)
+
+	// The SRV lookup uses the system resolver, so skip it when connecting
+	// through a proxy (or to an onion service, which can't have SRV records)
+	// to avoid leaking the matcher host to the local DNS.
+	if proxy == nil && !intnet.IsOnionHost(matcherHost) {
+		var isSrv bool
+		matcherHost, isSrv, err = intnet.DetermineMatcherHost(matcherHost)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error creating credentials")
+			rep.reportSrvLookupError(err)
 		}
-	} else {
-		tlsCfg := &tls.Config{
-			ServerName: intnet.RemoveHostPort(matcherHost),
+		if isSrv {
+			rep.reportSrvRecordFound(matcherHost)
 		}
-		creds = credentials.NewTLS(tlsCfg)
 	}
-	opt = grpc.WithTransportCredentials(creds)
+
+	creds, err := matcherCredentials(matcherHost, certFile, clientCert)
+	if err != nil {
+		return nil, err
+	}
+	opt := grpc.WithTransportCredentials(creds)
 	optKeepAlive := grpc.WithKeepaliveParams(keepalive.ClientParameters{
 		Time:                5 * time.Minute,
 		Timeout:             20 * time.Second,
 		PermitWithoutStream: true,
 	})
 
-	conn, err := grpc.Dial(matcherHost, opt, optKeepAlive)
+	opts := []grpc.DialOption{opt, optKeepAlive}
+	if proxy != nil {
+		opts = append(opts, grpc.WithDialer(proxy.DialTimeout))
+	}
+
+	conn, err := grpc.Dial(matcherHost, opts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error connecting to matcher host")
 	}
@@ -111,6 +147,10 @@ func (c *onlineMatcherClient) BuyerError(ctx context.Context, in *pb.BuyerErrorR
 	return c.client.BuyerError(ctx, in, opts...)
 }
 
+func (c *onlineMatcherClient) EstimateWait(ctx context.Context, in *pb.EstimateWaitRequest, opts ...grpc.CallOption) (*pb.EstimateWaitResponse, error) {
+	return c.client.EstimateWait(ctx, in, opts...)
+}
+
 func (c *onlineMatcherClient) FetchSpentUtxos(msg *wire.MsgTx) (splitticket.UtxoMap, error) {
 	return c.utxoProvider(msg)
 }
@@ -17,6 +17,16 @@ import (
 	"github.com/decred/dcrd/txscript"
 	"github.com/decred/dcrd/wire"
 	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/matcherrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// minReconnectDelay and maxReconnectDelay are the bounds of the delay
+	// between attempts of repeating a matcher call after the connection to
+	// the matcher was lost.
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 8 * time.Second
 )
 
 // MatcherClientConn is an interface defining the functions needed on a remote
@@ -42,17 +52,68 @@ func (mc *matcherClient) status(ctx context.Context) (*pb.StatusResponse, error)
 	return mc.client.Status(ctx, req)
 }
 
+// queueStatus returns the status of the matcher, including the limits of the
+// sessions of the given queue.
+func (mc *matcherClient) queueStatus(ctx context.Context, pool,
+	sessionName string) (*pb.StatusResponse, error) {
+
+	req := &pb.StatusRequest{Pool: pool, SessionName: sessionName}
+	return mc.client.Status(ctx, req)
+}
+
+// estimateWait returns the estimate of how long a participant with the given
+// amount waits for a session on the given queue. The amount should be zero if
+// the buyer is already waiting on the queue.
+func (mc *matcherClient) estimateWait(ctx context.Context, pool,
+	sessionName string, amount dcrutil.Amount) (*matcher.WaitEstimate, error) {
+
+	req := &pb.EstimateWaitRequest{
+		Pool:        pool,
+		SessionName: sessionName,
+		Amount:      uint64(amount),
+	}
+	resp, err := mc.client.EstimateWait(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &matcher.WaitEstimate{
+		MissingAmount:    dcrutil.Amount(resp.MissingAmount),
+		AverageAmount:    dcrutil.Amount(resp.AverageAmount),
+		ArrivalInterval:  time.Duration(resp.ArrivalIntervalMs) * time.Millisecond,
+		ExpectedArrivals: int(resp.ExpectedArrivals),
+	}, nil
+}
+
 func (mc *matcherClient) participate(ctx context.Context, maxAmount dcrutil.Amount,
-	sessionName string, voteAddress, poolAddress string, poolFeeRate float64,
+	pool, sessionName, sessionKey string, voteAddress, poolAddress string,
+	poolFeeRate float64, maxFeeRate dcrutil.Amount, voteChoices *signedVoteChoices,
 	chainParams *chaincfg.Params) (*Session, error) {
 	req := &pb.FindMatchesRequest{
 		Amount:          uint64(maxAmount),
 		SessionName:     sessionName,
+		Pool:            pool,
 		ProtocolVersion: version.ProtocolVersion,
 		VoteAddress:     voteAddress,
 		PoolAddress:     poolAddress,
 	}
 
+	if sessionKey != "" {
+		req.JoinMac = matcher.JoinQueueMAC([]byte(sessionKey), pool,
+			sessionName, uint64(maxAmount), voteAddress, poolAddress)
+	}
+
+	if voteChoices != nil {
+		req.VoteChoicesSignature = voteChoices.signature
+		req.VoteChoices = make([]*pb.VoteChoice, len(voteChoices.choices))
+		for i, c := range voteChoices.choices {
+			req.VoteChoices[i] = &pb.VoteChoice{
+				AgendaId: c.AgendaID,
+				ChoiceId: c.ChoiceID,
+			}
+		}
+	}
+
 	resp, err := mc.client.FindMatches(ctx, req)
 	if err != nil {
 		return nil, err
@@ -73,11 +134,33 @@ func (mc *matcherClient) participate(ctx context.Context, maxAmount dcrutil.Amou
 		mainchainHeight: resp.MainchainHeight,
 		nbParticipants:  resp.NbParticipants,
 		sessionToken:    resp.SessionToken,
+		feeRate:         dcrutil.Amount(resp.FeeRate),
+	}
+
+	if sess.feeRate == 0 {
+		// matchers that do not negotiate the fee rate use the default one.
+		sess.feeRate = splitticket.TxFeeRate
+	}
+	if sess.feeRate < splitticket.MinTxFeeRate {
+		return nil, errors.Errorf("matcher requested fee rate (%s/KB) lower "+
+			"than the minimum relay fee rate (%s/KB)", sess.feeRate,
+			splitticket.MinTxFeeRate)
+	}
+	if sess.feeRate > maxFeeRate {
+		return nil, errors.Errorf("matcher requested fee rate (%s/KB) higher "+
+			"than the maximum allowed (%s/KB)", sess.feeRate, maxFeeRate)
+	}
+
+	if voteChoices != nil {
+		// the vote choices were signed by the ticket commitment address, so
+		// it must be the one used in the ticket.
+		sess.voteChoices = voteChoices.choices
+		sess.ticketOutputAddress = voteChoices.commitmentAddress
 	}
 
 	err = splitticket.CheckParticipantSessionPoolFee(int(sess.nbParticipants),
 		sess.TicketPrice, sess.Amount, sess.PoolFee, sess.Fee,
-		int(sess.mainchainHeight), poolFeeRate, chainParams)
+		int(sess.mainchainHeight), poolFeeRate, sess.feeRate, chainParams)
 	if err != nil {
 		return nil, errors.Wrap(err, "matcher requested wrong pool fee amount")
 	}
@@ -107,8 +190,6 @@ func (mc *matcherClient) generateTicket(ctx context.Context, session *Session, c
 		}
 	}
 
-	session.secretNb = splitticket.RandomSecretNumber()
-	session.secretNbHash = session.secretNb.Hash(session.mainchainHash)
 	session.voteAddress = voteAddr
 	session.poolAddress = poolAddr
 
@@ -318,13 +399,15 @@ func (mc *matcherClient) fundTicket(ctx context.Context, session *Session, cfg *
 			return errors.Wrapf(err, "error checking validity of ticket of part %d", i)
 		}
 
-		err = splitticket.CheckSignedTicket(splitTx, ticket, cfg.ChainParams)
+		err = splitticket.CheckSignedTicket(splitTx, ticket, session.feeRate,
+			cfg.ChainParams)
 		if err != nil {
 			return errors.Wrapf(err, "error checking validity of signatures "+
 				"of ticket of part %d", i)
 		}
 
-		if err = splitticket.CheckRevocation(ticket, revocation, cfg.ChainParams); err != nil {
+		if err = splitticket.CheckRevocation(ticket, revocation,
+			session.feeRate, cfg.ChainParams); err != nil {
 			return errors.Wrapf(err, "error checking validity of revocation of part %d", i)
 		}
 
@@ -378,7 +461,7 @@ func (mc *matcherClient) fundSplitTx(ctx context.Context, session *Session, cfg
 	}
 
 	err = splitticket.CheckSignedSplit(fundedSplit, session.splitTxUtxoMap,
-		cfg.ChainParams)
+		session.feeRate, cfg.ChainParams)
 	if err != nil {
 		return err
 	}
@@ -415,6 +498,44 @@ func (mc *matcherClient) fundSplitTx(ctx context.Context, session *Session, cfg
 	return nil
 }
 
+// retryOnDisconnect performs the given matcher call, repeating it with an
+// exponential backoff while it fails due to the matcher being unreachable (eg:
+// the connection dropped and is being reestablished).
+//
+// The matcher accepts the same call being repeated within a session, as long
+// as it is authenticated by the session token and carries the same data, so
+// the call must send exactly the same request in every attempt.
+func retryOnDisconnect(ctx context.Context, cfg *Config, call func() error) error {
+	timeout := time.Duration(cfg.ReconnectTimeout) * time.Second
+	delay := minReconnectDelay
+	var deadline time.Time
+
+	for {
+		err := call()
+		if err == nil || status.Code(err) != codes.Unavailable || timeout <= 0 {
+			return err
+		}
+
+		if deadline.IsZero() {
+			deadline = time.Now().Add(timeout)
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return errors.Wrap(err, "timeout trying to reconnect to matcher")
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
 // sendErrorReport sends the given error to the matcher. It ignores all errors,
 // given that any error triggered here (eg: connection error, etc) is
 // unreportable anyway or may have been caused by the original error.
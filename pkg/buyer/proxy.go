@@ -0,0 +1,64 @@
+package buyer
+
+import (
+	"net/url"
+
+	intnet "github.com/matheusd/dcr-split-ticket-matcher/pkg/buyer/internal/net"
+	"github.com/pkg/errors"
+)
+
+// ProxyConfig is the configuration of the SOCKS5 proxy used for the outbound
+// connections of the buyer (matcher, waiting list, dcrd and dcrdata).
+type ProxyConfig struct {
+	Addr string
+	User string
+	Pass string
+
+	// TorIsolation makes every session use random proxy credentials, so that
+	// Tor uses different circuits for each session.
+	TorIsolation bool
+}
+
+// ProxyConfig returns the configuration of the proxy of the buyer or nil if
+// connections are made directly.
+func (cfg *Config) ProxyConfig() *ProxyConfig {
+	if cfg.Proxy == "" {
+		return nil
+	}
+
+	return &ProxyConfig{
+		Addr:         cfg.Proxy,
+		User:         cfg.ProxyUser,
+		Pass:         cfg.ProxyPass,
+		TorIsolation: cfg.TorIsolation,
+	}
+}
+
+// newProxy returns the proxy for a new set of connections, or nil if the
+// connections are made directly. When using Tor isolation, every call returns
+// a proxy with different credentials.
+func (pcfg *ProxyConfig) newProxy() (*intnet.Proxy, error) {
+	if pcfg == nil {
+		return nil, nil
+	}
+
+	return intnet.NewProxy(pcfg.Addr, pcfg.User, pcfg.Pass, pcfg.TorIsolation)
+}
+
+// validateProxy checks whether the proxy config options are consistent.
+func (cfg *Config) validateProxy() error {
+	if cfg.Proxy == "" {
+		if cfg.TorIsolation {
+			return errors.New("torisolation requires a proxy")
+		}
+		if intnet.IsOnionHost(cfg.MatcherHost) {
+			return errors.New("onion matcher hosts require a proxy")
+		}
+		u, err := url.Parse(cfg.DcrdataURL)
+		if cfg.UtxosFromDcrdata && err == nil && intnet.IsOnionHost(u.Host) {
+			return errors.New("onion dcrdata hosts require a proxy")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,503 @@
+package buyer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// inProgressSessionsDir is the subdir of the data dir where sessions that
+// have not yet completed are stored.
+const inProgressSessionsDir = "inprogress"
+
+// storedOutPoint is the serialized version of an outpoint.
+type storedOutPoint struct {
+	Hash  string `json:"hash"`
+	Index uint32 `json:"index"`
+	Tree  int8   `json:"tree"`
+}
+
+type storedSplitInput struct {
+	OutPoint  storedOutPoint `json:"outpoint"`
+	SigScript string         `json:"sigscript,omitempty"`
+}
+
+type storedUtxo struct {
+	OutPoint      storedOutPoint `json:"outpoint"`
+	PkScript      string         `json:"pkscript"`
+	Value         int64          `json:"value"`
+	Version       uint16         `json:"version"`
+	Confirmations int64          `json:"confirmations"`
+}
+
+type storedParticipant struct {
+	SecretHash   string `json:"secret_hash"`
+	VotePkScript string `json:"vote_pkscript"`
+	PoolPkScript string `json:"pool_pkscript"`
+	Amount       int64  `json:"amount"`
+	VoteAddress  string `json:"vote_address"`
+	Ticket       string `json:"ticket,omitempty"`
+	Revocation   string `json:"revocation,omitempty"`
+}
+
+// storedSession is the serialized version of an in-progress session. It
+// stores everything needed to resume the session from the last completed
+// stage, including the secret number and the session token.
+type storedSession struct {
+	ID              uint32 `json:"id"`
+	Amount          int64  `json:"amount"`
+	Fee             int64  `json:"fee"`
+	PoolFee         int64  `json:"pool_fee"`
+	TicketPrice     int64  `json:"ticket_price"`
+	FeeRate         int64  `json:"fee_rate"`
+	SessionToken    string `json:"session_token"`
+	CompletedStage  Stage  `json:"completed_stage"`
+	MainchainHash   string `json:"mainchain_hash"`
+	MainchainHeight uint32 `json:"mainchain_height"`
+	NbParticipants  uint32 `json:"nb_participants"`
+	SecretNb        string `json:"secret_nb,omitempty"`
+	VoteChoices     string `json:"vote_choices,omitempty"`
+	MyIndex         uint32 `json:"my_index"`
+
+	SplitOutputAddress  string `json:"split_output_address,omitempty"`
+	TicketOutputAddress string `json:"ticket_output_address,omitempty"`
+	VoteAddress         string `json:"vote_address,omitempty"`
+	PoolAddress         string `json:"pool_address,omitempty"`
+	SplitChangeScript   string `json:"split_change_script,omitempty"`
+	SplitChangeValue    int64  `json:"split_change_value,omitempty"`
+	SplitChangeVersion  uint16 `json:"split_change_version,omitempty"`
+
+	SplitInputs  []storedSplitInput  `json:"split_inputs,omitempty"`
+	Participants []storedParticipant `json:"participants,omitempty"`
+	SplitUtxos   []storedUtxo        `json:"split_utxos,omitempty"`
+
+	TicketTemplate      string   `json:"ticket_template,omitempty"`
+	SplitTx             string   `json:"split_tx,omitempty"`
+	TicketsScriptSig    []string `json:"tickets_scriptsig,omitempty"`
+	RevocationScriptSig string   `json:"revocation_scriptsig,omitempty"`
+}
+
+func encodeTx(tx *wire.MsgTx) (string, error) {
+	if tx == nil {
+		return "", nil
+	}
+	b, err := tx.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func decodeTx(s string) (*wire.MsgTx, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx()
+	if err = tx.FromBytes(b); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func encodeAddr(addr dcrutil.Address) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.EncodeAddress()
+}
+
+func decodeAddr(s string, chainParams *chaincfg.Params) (dcrutil.Address, error) {
+	if s == "" {
+		return nil, nil
+	}
+	addr, err := dcrutil.DecodeAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsForNet(chainParams) {
+		return nil, errors.Errorf("address %s is not for the current "+
+			"network", s)
+	}
+	return addr, nil
+}
+
+func encodeOutPoint(outp wire.OutPoint) storedOutPoint {
+	return storedOutPoint{
+		Hash:  outp.Hash.String(),
+		Index: outp.Index,
+		Tree:  outp.Tree,
+	}
+}
+
+func decodeOutPoint(outp storedOutPoint) (wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(outp.Hash)
+	if err != nil {
+		return wire.OutPoint{}, err
+	}
+	return *wire.NewOutPoint(hash, outp.Index, outp.Tree), nil
+}
+
+// toStored converts the session into its serializable version.
+func (session *Session) toStored() (*storedSession, error) {
+	var err error
+
+	s := &storedSession{
+		ID:                  uint32(session.ID),
+		Amount:              int64(session.Amount),
+		Fee:                 int64(session.Fee),
+		PoolFee:             int64(session.PoolFee),
+		TicketPrice:         int64(session.TicketPrice),
+		FeeRate:             int64(session.feeRate),
+		SessionToken:        hex.EncodeToString(session.sessionToken),
+		CompletedStage:      session.completedStage,
+		MainchainHash:       session.mainchainHash.String(),
+		MainchainHeight:     session.mainchainHeight,
+		NbParticipants:      session.nbParticipants,
+		MyIndex:             session.myIndex,
+		SplitOutputAddress:  encodeAddr(session.splitOutputAddress),
+		TicketOutputAddress: encodeAddr(session.ticketOutputAddress),
+		VoteAddress:         encodeAddr(session.voteAddress),
+		PoolAddress:         encodeAddr(session.poolAddress),
+		RevocationScriptSig: hex.EncodeToString(session.revocationScriptSig),
+	}
+
+	if session.secretNb != nil {
+		s.SecretNb = hex.EncodeToString(session.secretNb)
+	}
+
+	if len(session.voteChoices) > 0 {
+		s.VoteChoices = session.voteChoices.String()
+	}
+
+	if session.splitChange != nil {
+		s.SplitChangeScript = hex.EncodeToString(session.splitChange.PkScript)
+		s.SplitChangeValue = session.splitChange.Value
+		s.SplitChangeVersion = session.splitChange.Version
+	}
+
+	for _, in := range session.splitInputs {
+		s.SplitInputs = append(s.SplitInputs, storedSplitInput{
+			OutPoint:  encodeOutPoint(in.PreviousOutPoint),
+			SigScript: hex.EncodeToString(in.SignatureScript),
+		})
+	}
+
+	for i, p := range session.participants {
+		sp := storedParticipant{
+			SecretHash:   hex.EncodeToString(p.secretHash[:]),
+			VotePkScript: hex.EncodeToString(p.votePkScript),
+			PoolPkScript: hex.EncodeToString(p.poolPkScript),
+			Amount:       int64(p.amount),
+			VoteAddress:  encodeAddr(p.voteAddress),
+		}
+		if sp.Ticket, err = encodeTx(p.ticket); err != nil {
+			return nil, errors.Wrapf(err, "error encoding ticket of "+
+				"participant %d", i)
+		}
+		if sp.Revocation, err = encodeTx(p.revocation); err != nil {
+			return nil, errors.Wrapf(err, "error encoding revocation of "+
+				"participant %d", i)
+		}
+		s.Participants = append(s.Participants, sp)
+	}
+
+	for outp, entry := range session.splitTxUtxoMap {
+		s.SplitUtxos = append(s.SplitUtxos, storedUtxo{
+			OutPoint:      encodeOutPoint(outp),
+			PkScript:      hex.EncodeToString(entry.PkScript),
+			Value:         int64(entry.Value),
+			Version:       entry.Version,
+			Confirmations: entry.Confirmations,
+		})
+	}
+
+	if s.TicketTemplate, err = encodeTx(session.ticketTemplate); err != nil {
+		return nil, errors.Wrap(err, "error encoding ticket template")
+	}
+	if s.SplitTx, err = encodeTx(session.splitTx); err != nil {
+		return nil, errors.Wrap(err, "error encoding split tx")
+	}
+
+	for _, sig := range session.ticketsScriptSig {
+		s.TicketsScriptSig = append(s.TicketsScriptSig, hex.EncodeToString(sig))
+	}
+
+	return s, nil
+}
+
+// toSession rebuilds a session from its serialized version.
+func (s *storedSession) toSession(chainParams *chaincfg.Params) (*Session, error) {
+	var err error
+
+	session := &Session{
+		ID:              matcher.ParticipantID(s.ID),
+		Amount:          dcrutil.Amount(s.Amount),
+		Fee:             dcrutil.Amount(s.Fee),
+		PoolFee:         dcrutil.Amount(s.PoolFee),
+		TicketPrice:     dcrutil.Amount(s.TicketPrice),
+		feeRate:         dcrutil.Amount(s.FeeRate),
+		completedStage:  s.CompletedStage,
+		mainchainHeight: s.MainchainHeight,
+		nbParticipants:  s.NbParticipants,
+		myIndex:         s.MyIndex,
+	}
+
+	if session.sessionToken, err = hex.DecodeString(s.SessionToken); err != nil {
+		return nil, errors.Wrap(err, "error decoding session token")
+	}
+
+	if session.mainchainHash, err = chainhash.NewHashFromStr(s.MainchainHash); err != nil {
+		return nil, errors.Wrap(err, "error decoding mainchain hash")
+	}
+
+	if s.SecretNb != "" {
+		secretNb, err := hex.DecodeString(s.SecretNb)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding secret number")
+		}
+		session.secretNb = splitticket.SecretNumber(secretNb)
+		session.secretNbHash = session.secretNb.Hash(session.mainchainHash)
+	}
+
+	if s.VoteChoices != "" {
+		session.voteChoices, err = splitticket.ParseVoteChoices(s.VoteChoices)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding vote choices")
+		}
+	}
+
+	session.splitOutputAddress, err = decodeAddr(s.SplitOutputAddress, chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding split output address")
+	}
+	session.ticketOutputAddress, err = decodeAddr(s.TicketOutputAddress, chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding ticket output address")
+	}
+	session.voteAddress, err = decodeAddr(s.VoteAddress, chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding vote address")
+	}
+	session.poolAddress, err = decodeAddr(s.PoolAddress, chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding pool address")
+	}
+
+	if s.SplitChangeScript != "" {
+		script, err := hex.DecodeString(s.SplitChangeScript)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding split change script")
+		}
+		session.splitChange = wire.NewTxOut(s.SplitChangeValue, script)
+		session.splitChange.Version = s.SplitChangeVersion
+	}
+
+	for i, in := range s.SplitInputs {
+		outp, err := decodeOutPoint(in.OutPoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding split input %d", i)
+		}
+		sigScript, err := hex.DecodeString(in.SigScript)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding sigscript of split "+
+				"input %d", i)
+		}
+		if len(sigScript) == 0 {
+			sigScript = nil
+		}
+		session.splitInputs = append(session.splitInputs,
+			wire.NewTxIn(&outp, wire.NullValueIn, sigScript))
+	}
+
+	for i, sp := range s.Participants {
+		var p buyerSessionParticipant
+		secretHash, err := hex.DecodeString(sp.SecretHash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding secret hash of "+
+				"participant %d", i)
+		}
+		copy(p.secretHash[:], secretHash)
+		if p.votePkScript, err = hex.DecodeString(sp.VotePkScript); err != nil {
+			return nil, errors.Wrapf(err, "error decoding vote pkscript of "+
+				"participant %d", i)
+		}
+		if p.poolPkScript, err = hex.DecodeString(sp.PoolPkScript); err != nil {
+			return nil, errors.Wrapf(err, "error decoding pool pkscript of "+
+				"participant %d", i)
+		}
+		p.amount = dcrutil.Amount(sp.Amount)
+		if p.voteAddress, err = decodeAddr(sp.VoteAddress, chainParams); err != nil {
+			return nil, errors.Wrapf(err, "error decoding vote address of "+
+				"participant %d", i)
+		}
+		if p.ticket, err = decodeTx(sp.Ticket); err != nil {
+			return nil, errors.Wrapf(err, "error decoding ticket of "+
+				"participant %d", i)
+		}
+		if p.revocation, err = decodeTx(sp.Revocation); err != nil {
+			return nil, errors.Wrapf(err, "error decoding revocation of "+
+				"participant %d", i)
+		}
+		session.participants = append(session.participants, p)
+	}
+
+	if len(s.SplitUtxos) > 0 {
+		session.splitTxUtxoMap = make(splitticket.UtxoMap, len(s.SplitUtxos))
+	}
+	for i, u := range s.SplitUtxos {
+		outp, err := decodeOutPoint(u.OutPoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding split utxo %d", i)
+		}
+		pkScript, err := hex.DecodeString(u.PkScript)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding pkscript of split "+
+				"utxo %d", i)
+		}
+		session.splitTxUtxoMap[outp] = splitticket.UtxoEntry{
+			PkScript:      pkScript,
+			Value:         dcrutil.Amount(u.Value),
+			Version:       u.Version,
+			Confirmations: u.Confirmations,
+		}
+	}
+
+	if session.ticketTemplate, err = decodeTx(s.TicketTemplate); err != nil {
+		return nil, errors.Wrap(err, "error decoding ticket template")
+	}
+	if session.splitTx, err = decodeTx(s.SplitTx); err != nil {
+		return nil, errors.Wrap(err, "error decoding split tx")
+	}
+
+	for i, sig := range s.TicketsScriptSig {
+		b, err := hex.DecodeString(sig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding ticket sigscript %d", i)
+		}
+		session.ticketsScriptSig = append(session.ticketsScriptSig, b)
+	}
+
+	if session.revocationScriptSig, err = hex.DecodeString(s.RevocationScriptSig); err != nil {
+		return nil, errors.Wrap(err, "error decoding revocation sigscript")
+	}
+	if len(session.revocationScriptSig) == 0 {
+		session.revocationScriptSig = nil
+	}
+
+	return session, nil
+}
+
+// inProgressSessionFile returns the name of the file used to store the given
+// session while it's in progress.
+func inProgressSessionFile(cfg *Config, session *Session) string {
+	return filepath.Join(cfg.DataDir, inProgressSessionsDir,
+		session.ID.String()+".json")
+}
+
+// persistSession saves the current state of the in-progress session to the
+// data dir, so that it can be resumed if the buyer is interrupted.
+func persistSession(session *Session, cfg *Config) error {
+	s, err := session.toStored()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding session")
+	}
+
+	fname := inProgressSessionFile(cfg, session)
+	err = os.MkdirAll(filepath.Dir(fname), 0700)
+	if err != nil {
+		return errors.Wrap(err, "error creating in-progress sessions dir")
+	}
+
+	// write to a temp file and rename it so that a crash while writing does
+	// not corrupt the previously saved state.
+	tmpName := fname + ".tmp"
+	err = ioutil.WriteFile(tmpName, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error writing session file")
+	}
+
+	return os.Rename(tmpName, fname)
+}
+
+// removePersistedSession removes the stored state of a session that has been
+// completed.
+func removePersistedSession(session *Session, cfg *Config) error {
+	err := os.Remove(inProgressSessionFile(cfg, session))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// loadPersistedSession loads a session previously stored by persistSession.
+func loadPersistedSession(fname string, cfg *Config) (*Session, error) {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading session file")
+	}
+
+	s := new(storedSession)
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding session file")
+	}
+
+	return s.toSession(cfg.ChainParams)
+}
+
+// checkResumedSession verifies that a session loaded to be resumed pays to the
+// vote and pool addresses of the config. Sessions stored before the ticket was
+// generated are checked once it is generated again.
+func checkResumedSession(session *Session, cfg *Config) error {
+	if session.voteAddress != nil &&
+		session.voteAddress.EncodeAddress() != cfg.VoteAddress {
+		return errors.Errorf("vote address of session (%s) different than "+
+			"the configured one (%s)", session.voteAddress, cfg.VoteAddress)
+	}
+	if session.poolAddress != nil &&
+		session.poolAddress.EncodeAddress() != cfg.PoolAddress {
+		return errors.Errorf("pool address of session (%s) different than "+
+			"the configured one (%s)", session.poolAddress, cfg.PoolAddress)
+	}
+
+	if len(session.participants) == 0 {
+		return nil
+	}
+	if session.voteAddress == nil || session.poolAddress == nil {
+		return errors.Errorf("session file does not store the vote and pool " +
+			"addresses of the ticket")
+	}
+	if int(session.myIndex) >= len(session.participants) {
+		return errors.Errorf("invalid participant index %d", session.myIndex)
+	}
+
+	myPart := session.participants[session.myIndex]
+	err := splitticket.CheckTicketScriptMatchAddresses(session.voteAddress,
+		session.poolAddress, myPart.votePkScript, myPart.poolPkScript,
+		cfg.ChainParams)
+	if err != nil {
+		return errors.Wrap(err, "error checking the vote/pool scripts of my "+
+			"ticket")
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package buyer
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testAddress(t *testing.T, b byte) dcrutil.Address {
+	var hash [20]byte
+	hash[0] = b
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:], &chaincfg.TestNet3Params, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func testSession(t *testing.T) *Session {
+	mainchainHash := chainhash.Hash{0x01, 0x02}
+	changeScript, _ := txscript.PayToAddrScript(testAddress(t, 0x03))
+	outp := wire.OutPoint{Hash: chainhash.Hash{0x04}, Index: 1}
+
+	splitTx := wire.NewMsgTx()
+	splitTx.AddTxIn(wire.NewTxIn(&outp, wire.NullValueIn, nil))
+	splitTx.AddTxOut(wire.NewTxOut(10, changeScript))
+	ticket := splitTx.Copy()
+	ticket.TxIn[0].SignatureScript = []byte{0x05}
+
+	secretNb := splitticket.RandomSecretNumber()
+	return &Session{
+		ID:                  0x00010002,
+		Amount:              10e8,
+		Fee:                 1e5,
+		PoolFee:             5e6,
+		TicketPrice:         100e8,
+		feeRate:             splitticket.TxFeeRate,
+		sessionToken:        []byte{0x06, 0x07},
+		completedStage:      StageTicketFunded,
+		mainchainHash:       &mainchainHash,
+		mainchainHeight:     1000,
+		nbParticipants:      1,
+		secretNb:            secretNb,
+		secretNbHash:        secretNb.Hash(&mainchainHash),
+		splitOutputAddress:  testAddress(t, 0x08),
+		ticketOutputAddress: testAddress(t, 0x09),
+		voteAddress:         testAddress(t, 0x10),
+		poolAddress:         testAddress(t, 0x11),
+		splitChange:         wire.NewTxOut(20, changeScript),
+		splitInputs: []*wire.TxIn{
+			wire.NewTxIn(&outp, wire.NullValueIn, []byte{0x0a}),
+		},
+		participants: []buyerSessionParticipant{{
+			secretHash:   secretNb.Hash(&mainchainHash),
+			votePkScript: []byte{0x0b},
+			poolPkScript: []byte{0x0c},
+			amount:       10e8,
+			voteAddress:  testAddress(t, 0x0d),
+			ticket:       ticket,
+			revocation:   splitTx,
+		}},
+		splitTxUtxoMap: splitticket.UtxoMap{
+			outp: splitticket.UtxoEntry{
+				PkScript:      changeScript,
+				Value:         30e8,
+				Confirmations: 10,
+			},
+		},
+		ticketTemplate:      ticket,
+		splitTx:             splitTx,
+		ticketsScriptSig:    [][]byte{{0x0e}},
+		revocationScriptSig: []byte{0x0f},
+	}
+}
+
+func TestPersistSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buyer-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &Config{DataDir: dir, ChainParams: &chaincfg.TestNet3Params}
+	session := testSession(t)
+
+	if err := persistSession(session, cfg); err != nil {
+		t.Fatalf("error persisting session: %v", err)
+	}
+
+	fname := filepath.Join(dir, inProgressSessionsDir, "0001.0002.json")
+	loaded, err := loadPersistedSession(fname, cfg)
+	if err != nil {
+		t.Fatalf("error loading session: %v", err)
+	}
+
+	if !bytes.Equal(loaded.secretNb, session.secretNb) ||
+		!bytes.Equal(loaded.sessionToken, session.sessionToken) ||
+		loaded.completedStage != session.completedStage {
+		t.Fatalf("loaded session has different resume information")
+	}
+
+	// Transactions may decode empty scripts as non-nil slices, so compare
+	// the serialized versions of the sessions.
+	orig, err := session.toStored()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.toStored()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orig, got) {
+		t.Fatalf("loaded session different than persisted")
+	}
+
+	// Loading a session of a different network fails.
+	cfg.ChainParams = &chaincfg.MainNetParams
+	if _, err = loadPersistedSession(fname, cfg); err == nil {
+		t.Fatalf("loaded session of a different network")
+	}
+
+	if err := removePersistedSession(session, cfg); err != nil {
+		t.Fatalf("error removing session: %v", err)
+	}
+	if _, err := os.Stat(fname); !os.IsNotExist(err) {
+		t.Fatalf("session file not removed")
+	}
+}
+
+func TestCheckResumedSession(t *testing.T) {
+	voteAddr, poolAddr := testAddress(t, 0x10), testAddress(t, 0x11)
+	votePkScript, err := txscript.PayToSStx(voteAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolPkScript, err := txscript.GenerateSStxAddrPush(poolAddr, 1e8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := testSession(t)
+	session.participants[0].votePkScript = votePkScript
+	session.participants[0].poolPkScript = poolPkScript
+	cfg := &Config{
+		VoteAddress: voteAddr.EncodeAddress(),
+		PoolAddress: poolAddr.EncodeAddress(),
+		ChainParams: &chaincfg.TestNet3Params,
+	}
+	if err := checkResumedSession(session, cfg); err != nil {
+		t.Fatalf("unexpected error checking session: %v", err)
+	}
+
+	// Sessions of different addresses are not resumed.
+	cfg.VoteAddress = testAddress(t, 0x12).EncodeAddress()
+	if err := checkResumedSession(session, cfg); err == nil {
+		t.Fatalf("resumed session with a different vote address")
+	}
+	cfg.VoteAddress = voteAddr.EncodeAddress()
+	cfg.PoolAddress = testAddress(t, 0x12).EncodeAddress()
+	if err := checkResumedSession(session, cfg); err == nil {
+		t.Fatalf("resumed session with a different pool address")
+	}
+	cfg.PoolAddress = poolAddr.EncodeAddress()
+
+	// Neither are sessions with a ticket paying to other addresses.
+	session.voteAddress = testAddress(t, 0x12)
+	cfg.VoteAddress = session.voteAddress.EncodeAddress()
+	if err := checkResumedSession(session, cfg); err == nil {
+		t.Fatalf("resumed session with a ticket of different addresses")
+	}
+
+	// Nor sessions with a ticket but without the stored addresses.
+	session.voteAddress, session.poolAddress = nil, nil
+	if err := checkResumedSession(session, cfg); err == nil {
+		t.Fatalf("resumed session without stored addresses")
+	}
+}
+
+func TestRetryOnDisconnect(t *testing.T) {
+	cfg := &Config{ReconnectTimeout: 10}
+	unavailable := status.Error(codes.Unavailable, "transport is closing")
+
+	// Calls are repeated while the matcher is unavailable.
+	calls := 0
+	err := retryOnDisconnect(context.Background(), cfg, func() error {
+		calls++
+		if calls < 3 {
+			return unavailable
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("unexpected number of calls %d", calls)
+	}
+
+	// Other errors are returned immediately.
+	calls = 0
+	aborted := status.Error(codes.Aborted, "session expired")
+	err = retryOnDisconnect(context.Background(), cfg, func() error {
+		calls++
+		return aborted
+	})
+	if err != aborted || calls != 1 {
+		t.Fatalf("unexpected result (%v) after %d calls", err, calls)
+	}
+
+	// Reconnecting gives up after the timeout.
+	cfg.ReconnectTimeout = 1
+	err = retryOnDisconnect(context.Background(), cfg, func() error {
+		return unavailable
+	})
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("timeout")) {
+		t.Fatalf("unexpected error after reconnect timeout: %v", err)
+	}
+}
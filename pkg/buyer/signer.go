@@ -0,0 +1,277 @@
+package buyer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/decred/dcrwallet/rpc/walletrpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignerWallet signs the session transactions with the connected wallet.
+	SignerWallet = "wallet"
+
+	// SignerFile signs the session transactions by exporting them to a file
+	// and waiting for a file with the signed transactions, generated by an
+	// external (possibly air-gapped) wallet.
+	SignerFile = "file"
+
+	// SignerRemote signs the session transactions by sending them to a
+	// remote wallet over grpc.
+	SignerRemote = "remote"
+
+	// fileSignerCheckInterval is how often the file signer checks whether the
+	// signed transactions file has been created.
+	fileSignerCheckInterval = time.Second
+)
+
+// Signer is the interface for objects that sign the transactions of a split
+// ticket session (and the transactions created by the buyer, such as utxo
+// consolidations) on behalf of the buyer.
+type Signer interface {
+	// SignTransactions signs the given transactions. The request follows the
+	// format of dcrwallet's SignTransactions call (without the passphrase)
+	// and the response must include the signed transactions in the same order
+	// as the request.
+	SignTransactions(ctx context.Context, req *pb.SignTransactionsRequest) (
+		*pb.SignTransactionsResponse, error)
+}
+
+// walletSigner signs transactions using a dcrwallet grpc connection.
+type walletSigner struct {
+	wsvc       WalletClientConn
+	passphrase []byte
+}
+
+func (s *walletSigner) SignTransactions(ctx context.Context,
+	req *pb.SignTransactionsRequest) (*pb.SignTransactionsResponse, error) {
+
+	signReq := *req
+	signReq.Passphrase = s.passphrase
+	return s.wsvc.SignTransactions(ctx, &signReq)
+}
+
+// remoteSigner signs transactions by connecting to a remote dcrwallet
+// instance over grpc. The connection is only kept open while signing.
+type remoteSigner struct {
+	host       string
+	certFile   string
+	passphrase []byte
+}
+
+func (s *remoteSigner) SignTransactions(ctx context.Context,
+	req *pb.SignTransactionsRequest) (*pb.SignTransactionsResponse, error) {
+
+	wsvc, err := connectToWallet(s.host, s.certFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to remote signer")
+	}
+	defer wsvc.Close()
+
+	signer := &walletSigner{wsvc: wsvc, passphrase: s.passphrase}
+	return signer.SignTransactions(ctx, req)
+}
+
+// fileSignRequestScript is a previous output script needed to sign a
+// transaction, as exported by the file signer.
+type fileSignRequestScript struct {
+	TransactionHash string `json:"transaction_hash"`
+	OutputIndex     uint32 `json:"output_index"`
+	Tree            int32  `json:"tree"`
+	PkScript        string `json:"pk_script"`
+}
+
+// fileSignRequest is the content of the file with the transactions to be
+// signed externally. Transactions are hex encoded.
+type fileSignRequest struct {
+	Transactions      []string                `json:"transactions"`
+	AdditionalScripts []fileSignRequestScript `json:"additional_scripts"`
+}
+
+// fileSignResponse is the content of the file with the externally signed
+// transactions, in the same order as the request. Transactions are hex
+// encoded.
+type fileSignResponse struct {
+	Transactions []string `json:"transactions"`
+}
+
+// fileSigner signs transactions by writing them to a file and waiting for an
+// external wallet to write the signed transactions to a corresponding file.
+type fileSigner struct {
+	dir string
+}
+
+// fileNames returns the names of the request and response files for the given
+// request. Files are named after the hash of the transactions, so that signing
+// the same transactions again (eg: after a resumed session) uses the same
+// files.
+func (s *fileSigner) fileNames(req *fileSignRequest) (string, string) {
+	hasher := sha256.New()
+	for _, tx := range req.Transactions {
+		hasher.Write([]byte(tx))
+	}
+	id := hex.EncodeToString(hasher.Sum(nil)[:8])
+	return filepath.Join(s.dir, "unsigned-"+id+".json"),
+		filepath.Join(s.dir, "signed-"+id+".json")
+}
+
+func (s *fileSigner) SignTransactions(ctx context.Context,
+	req *pb.SignTransactionsRequest) (*pb.SignTransactionsResponse, error) {
+
+	rep := reporterFromContext(ctx)
+
+	fileReq := &fileSignRequest{
+		Transactions: make([]string, len(req.Transactions)),
+		AdditionalScripts: make([]fileSignRequestScript,
+			len(req.AdditionalScripts)),
+	}
+	for i, tx := range req.Transactions {
+		fileReq.Transactions[i] = hex.EncodeToString(tx.SerializedTransaction)
+	}
+	for i, script := range req.AdditionalScripts {
+		fileReq.AdditionalScripts[i] = fileSignRequestScript{
+			TransactionHash: hex.EncodeToString(script.TransactionHash),
+			OutputIndex:     script.OutputIndex,
+			Tree:            script.Tree,
+			PkScript:        hex.EncodeToString(script.PkScript),
+		}
+	}
+
+	reqFname, respFname := s.fileNames(fileReq)
+	data, err := json.MarshalIndent(fileReq, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding sign request")
+	}
+	if err = os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating signer dir")
+	}
+	if err = ioutil.WriteFile(reqFname, data, 0600); err != nil {
+		return nil, errors.Wrap(err, "error writing sign request file")
+	}
+	rep.reportExternalSignRequest(reqFname, respFname)
+
+	// The signed txs file may be read while the external wallet is still
+	// writing it, so a file that can't be decoded is only an error if it
+	// stays that way until the context is done.
+	fileResp := new(fileSignResponse)
+	var decodeErr error
+	for {
+		data, err = ioutil.ReadFile(respFname)
+		if err == nil {
+			decodeErr = json.Unmarshal(data, fileResp)
+			if decodeErr == nil {
+				break
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "error reading signed txs file")
+		}
+
+		select {
+		case <-ctx.Done():
+			if decodeErr != nil {
+				return nil, errors.Wrap(decodeErr, "error decoding signed "+
+					"txs file")
+			}
+			return nil, ctx.Err()
+		case <-time.After(fileSignerCheckInterval):
+		}
+	}
+
+	resp := &pb.SignTransactionsResponse{
+		Transactions: make([]*pb.SignTransactionsResponse_SignedTransaction,
+			len(fileResp.Transactions)),
+	}
+	for i, tx := range fileResp.Transactions {
+		bts, err := hex.DecodeString(tx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding signed tx %d", i)
+		}
+		resp.Transactions[i] = &pb.SignTransactionsResponse_SignedTransaction{
+			Transaction: bts,
+		}
+	}
+
+	os.Remove(reqFname)
+	os.Remove(respFname)
+
+	return resp, nil
+}
+
+// externalSigner returns true if the transactions are not signed by the
+// connected wallet.
+func (cfg *Config) externalSigner() bool {
+	return cfg.Signer != nil ||
+		(cfg.SignerType != "" && cfg.SignerType != SignerWallet)
+}
+
+// checkMaxSessionDuration checks whether the sessions of the matcher, which
+// last at most the given number of seconds, allow for the time needed to sign
+// with the configured signer. Matchers that do not report the duration of
+// their sessions (maxDuration == 0) are assumed to allow it.
+func (cfg *Config) checkMaxSessionDuration(maxDuration uint32) error {
+	if !cfg.externalSigner() || maxDuration == 0 {
+		return nil
+	}
+
+	if int64(maxDuration) < int64(cfg.SignerTimeout) {
+		return errors.Errorf("sessions of the matcher last at most %d "+
+			"seconds, less than the time allowed for the external signer "+
+			"(SignerTimeout = %d seconds)", maxDuration, cfg.SignerTimeout)
+	}
+
+	return nil
+}
+
+// validateSigner checks whether the signer options of the config are valid.
+func (cfg *Config) validateSigner() error {
+	switch cfg.SignerType {
+	case "", SignerWallet:
+	case SignerFile:
+		if cfg.SignerDir == "" {
+			return missingConfigParameterError("SignerDir")
+		}
+	case SignerRemote:
+		if cfg.SignerHost == "" {
+			return missingConfigParameterError("SignerHost")
+		}
+		if cfg.SignerCertFile == "" {
+			return missingConfigParameterError("SignerCertFile")
+		}
+	default:
+		return errors.Errorf("invalid SignerType %s", cfg.SignerType)
+	}
+
+	if cfg.externalSigner() && cfg.VoteChoices != "" {
+		return errors.New("VoteChoices can only be signed by the wallet signer")
+	}
+
+	return nil
+}
+
+// signer returns the signer to use for the transactions of the buyer, given
+// the connected wallet.
+func (cfg *Config) signer(wc *walletClient) Signer {
+	if cfg.Signer != nil {
+		return cfg.Signer
+	}
+
+	switch cfg.SignerType {
+	case SignerFile:
+		return &fileSigner{dir: cfg.SignerDir}
+	case SignerRemote:
+		return &remoteSigner{
+			host:       cfg.SignerHost,
+			certFile:   cfg.SignerCertFile,
+			passphrase: cfg.Passphrase,
+		}
+	default:
+		return &walletSigner{wsvc: wc.wsvc, passphrase: cfg.Passphrase}
+	}
+}
@@ -0,0 +1,141 @@
+package buyer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrwallet/rpc/walletrpc"
+)
+
+func TestFileSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buyer-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	req := &pb.SignTransactionsRequest{
+		Transactions: []*pb.SignTransactionsRequest_UnsignedTransaction{
+			{SerializedTransaction: []byte{0x01, 0x02}},
+			{SerializedTransaction: []byte{0x03}},
+		},
+		AdditionalScripts: []*pb.SignTransactionsRequest_AdditionalScript{{
+			TransactionHash: []byte{0x04},
+			OutputIndex:     1,
+			PkScript:        []byte{0x05},
+		}},
+	}
+	signed := [][]byte{{0x01, 0x02, 0xaa}, {0x03, 0xbb}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type result struct {
+		resp *pb.SignTransactionsResponse
+		err  error
+	}
+	c := make(chan result)
+	go func() {
+		signer := &fileSigner{dir: dir}
+		resp, err := signer.SignTransactions(ctx, req)
+		c <- result{resp, err}
+	}()
+
+	// wait for the request file and act as the external wallet.
+	var reqFname string
+	for reqFname == "" {
+		files, _ := filepath.Glob(filepath.Join(dir, "unsigned-*.json"))
+		if len(files) > 0 {
+			reqFname = files[0]
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for sign request file")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	data, err := ioutil.ReadFile(reqFname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileReq := new(fileSignRequest)
+	if err = json.Unmarshal(data, fileReq); err != nil {
+		t.Fatalf("error decoding sign request: %v", err)
+	}
+	if len(fileReq.Transactions) != 2 || fileReq.Transactions[0] != "0102" ||
+		len(fileReq.AdditionalScripts) != 1 ||
+		fileReq.AdditionalScripts[0].PkScript != "05" {
+		t.Fatalf("unexpected sign request %v", fileReq)
+	}
+
+	fileResp := &fileSignResponse{Transactions: []string{
+		hex.EncodeToString(signed[0]), hex.EncodeToString(signed[1]),
+	}}
+	data, _ = json.Marshal(fileResp)
+	respFname := filepath.Join(dir, "signed-"+
+		filepath.Base(reqFname)[len("unsigned-"):])
+
+	// a partially written file is not an error while there's time left to
+	// finish writing it.
+	if err = ioutil.WriteFile(respFname, data[:len(data)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-c:
+		t.Fatalf("signer returned on partial file (err: %v)", res.err)
+	case <-time.After(2 * fileSignerCheckInterval):
+	}
+
+	if err = ioutil.WriteFile(respFname, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := <-c
+	if res.err != nil {
+		t.Fatalf("unexpected error signing: %v", res.err)
+	}
+	if len(res.resp.Transactions) != len(signed) {
+		t.Fatalf("unexpected number of signed txs %d", len(res.resp.Transactions))
+	}
+	for i, tx := range res.resp.Transactions {
+		if !bytes.Equal(tx.Transaction, signed[i]) {
+			t.Fatalf("unexpected signed tx %d", i)
+		}
+	}
+
+	if _, err = os.Stat(reqFname); !os.IsNotExist(err) {
+		t.Fatalf("sign request file not removed")
+	}
+}
+
+func TestCheckMaxSessionDuration(t *testing.T) {
+	tests := []struct {
+		cfg         Config
+		maxDuration uint32
+		valid       bool
+	}{
+		{Config{SignerTimeout: 300}, 30, true},
+		{Config{SignerType: SignerFile, SignerTimeout: 300}, 0, true},
+		{Config{SignerType: SignerFile, SignerTimeout: 300}, 600, true},
+		{Config{SignerType: SignerFile, SignerTimeout: 300}, 30, false},
+		{Config{SignerType: SignerRemote, SignerTimeout: 20}, 30, true},
+	}
+
+	for i, tc := range tests {
+		err := tc.cfg.checkMaxSessionDuration(tc.maxDuration)
+		if tc.valid && err != nil {
+			t.Fatalf("unexpected error on test %d: %v", i, err)
+		} else if !tc.valid && err == nil {
+			t.Fatalf("test %d did not return an error", i)
+		}
+	}
+}
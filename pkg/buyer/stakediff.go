@@ -0,0 +1,157 @@
+package buyer
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// stakeDiffWindow is the position of the chain (as seen by the matcher)
+// relative to the changes of stake difficulty.
+type stakeDiffWindow struct {
+	height          uint32
+	stopWindow      int32
+	ticketPrice     dcrutil.Amount
+	nextTicketPrice dcrutil.Amount
+}
+
+// inStopWindow returns true if the matcher does not accept participants at
+// the current height, due to its proximity to a change of stake difficulty.
+func (w *stakeDiffWindow) inStopWindow(params *chaincfg.Params) bool {
+	return splitticket.StakeDiffChangeDistance(w.height, params) < w.stopWindow
+}
+
+// blocksToChange returns the number of blocks until the stake difficulty
+// changes, if the change is within the stop window. It returns zero if the
+// change already happened.
+func (w *stakeDiffWindow) blocksToChange(params *chaincfg.Params) int32 {
+	blocks := splitticket.BlocksToStakeDiffChange(w.height, params)
+	if blocks > w.stopWindow {
+		return 0
+	}
+	return blocks
+}
+
+// expectedTicketPrice returns the ticket price expected after the change of
+// stake difficulty of the current stop window. The current price is returned
+// if the change already happened or no estimate is available.
+func (w *stakeDiffWindow) expectedTicketPrice(params *chaincfg.Params) dcrutil.Amount {
+	if w.blocksToChange(params) == 0 || w.nextTicketPrice == 0 {
+		return w.ticketPrice
+	}
+	return w.nextTicketPrice
+}
+
+// ticketPriceShare returns the fraction (between 0 and 1) of the given ticket
+// price covered by maxAmount or zero if the ticket price is unknown.
+func ticketPriceShare(maxAmount, ticketPrice dcrutil.Amount) float64 {
+	if ticketPrice <= 0 {
+		return 0
+	}
+	if maxAmount >= ticketPrice {
+		return 1
+	}
+	return float64(maxAmount) / float64(ticketPrice)
+}
+
+// fetchStakeDiffWindow returns the current position of the matcher relative
+// to the changes of stake difficulty.
+func fetchStakeDiffWindow(ctx context.Context, mc *matcherClient,
+	wc *walletClient) (*stakeDiffWindow, error) {
+
+	status, err := mc.status(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking status of matcher")
+	}
+
+	w := &stakeDiffWindow{
+		height:          status.MainchainHeight,
+		stopWindow:      status.StakeDiffChangeStopWindow,
+		ticketPrice:     dcrutil.Amount(status.TicketPrice),
+		nextTicketPrice: dcrutil.Amount(status.NextTicketPrice),
+	}
+
+	if w.height == 0 {
+		// Older matchers don't report their height or stop window, so use
+		// the height of the wallet and the default window.
+		chainInfo, err := wc.currentChainInfo(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "error checking wallet chain info")
+		}
+		w.height = chainInfo.bestBlockHeight
+		w.stopWindow = defaultStakeDiffChangeStopWindow
+	}
+
+	return w, nil
+}
+
+// waitStakeDiffStopWindow blocks while the matcher is in the stop window
+// around a change of stake difficulty, during which it doesn't accept
+// participants. It returns immediately if the matcher is outside the window.
+//
+// Errors received from errChan while waiting are returned.
+func waitStakeDiffStopWindow(ctx context.Context, mc *matcherClient,
+	wc *walletClient, cfg *Config, maxAmount dcrutil.Amount,
+	errChan <-chan error) error {
+
+	rep := reporterFromContext(ctx)
+
+	// Failing to fetch the window is not fatal, given that the matcher
+	// rejects participants inside it anyway.
+	w, err := fetchStakeDiffWindow(ctx, mc, wc)
+	if err != nil || !w.inStopWindow(cfg.ChainParams) {
+		return nil
+	}
+
+	rep.reportStakeDiffChange(w.blocksToChange(cfg.ChainParams),
+		w.ticketPrice, w.expectedTicketPrice(cfg.ChainParams), maxAmount)
+	rep.reportStage(ctx, StageWaitingStakeDiffChange, nil, cfg)
+
+	ticker := time.NewTicker(stakeDiffCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errChan:
+			return err
+		case <-ticker.C:
+		}
+
+		// Failures to fetch the window are retried on the next check.
+		w, err = fetchStakeDiffWindow(ctx, mc, wc)
+		if err == nil && !w.inStopWindow(cfg.ChainParams) {
+			rep.reportStakeDiffChange(0, w.ticketPrice, w.ticketPrice,
+				maxAmount)
+			return nil
+		}
+	}
+}
+
+// watchStakeDiffStopWindow periodically checks whether the matcher entered the
+// stop window around a change of stake difficulty. It returns true once the
+// matcher is in the window or false if the context is done first.
+func watchStakeDiffStopWindow(ctx context.Context, mc *matcherClient,
+	wc *walletClient, params *chaincfg.Params) bool {
+
+	ticker := time.NewTicker(stakeDiffCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
+		w, err := fetchStakeDiffWindow(ctx, mc, wc)
+		if err == nil && w.inStopWindow(params) {
+			return true
+		}
+	}
+}
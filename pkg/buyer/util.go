@@ -62,16 +62,19 @@ func (lm *LoggerMiddleware) Write(p []byte) (n int, err error) {
 // messages to a writer
 type WriterReporter struct {
 	w                io.Writer
+	pool             string
 	sessionName      string
 	lastWaitListLine string
 }
 
-// NewWriterReporter returns a reporter that writes into stdout
-func NewWriterReporter(w io.Writer, sessionName string) *WriterReporter {
+// NewWriterReporter returns a reporter that writes into stdout. Only changes to
+// the waiting queue of the given pool and session name are reported.
+func NewWriterReporter(w io.Writer, pool, sessionName string) *WriterReporter {
 	sessionHash := sha256.Sum256([]byte(sessionName))
 	sessionName = hex.EncodeToString(sessionHash[:])
 	return &WriterReporter{
 		w:           w,
+		pool:        pool,
 		sessionName: sessionName,
 	}
 }
@@ -79,7 +82,7 @@ func NewWriterReporter(w io.Writer, sessionName string) *WriterReporter {
 // WaitingListChanged fulfills waitingListWatcher by outputting the changes
 func (rep *WriterReporter) WaitingListChanged(queues []matcher.WaitingQueue) {
 	for _, q := range queues {
-		if q.Name != rep.sessionName {
+		if q.Pool != rep.pool || q.Name != rep.sessionName {
 			continue
 		}
 		strs := make([]string, len(q.Amounts))
@@ -105,6 +108,55 @@ func (rep *WriterReporter) reportMatcherStatus(status *pb.StatusResponse) {
 	fmt.Fprintf(rep.w, "Matcher ticket price: %s\n", price)
 }
 
+func (rep *WriterReporter) reportWaitEstimate(est *matcher.WaitEstimate,
+	maxWait time.Duration) {
+
+	switch {
+	case est.MissingAmount == 0:
+		fmt.Fprintf(rep.w, "Estimated wait for a session: none\n")
+	case !est.Known():
+		fmt.Fprintf(rep.w, "Not enough recent participants to estimate the "+
+			"wait for a session (%s missing)\n", est.MissingAmount)
+	case maxWait > 0:
+		fmt.Fprintf(rep.w, "Estimated wait for a session: %s (%d more "+
+			"participants needed, %.0f%% chance within %s)\n",
+			est.ExpectedWait().Round(time.Second), est.ExpectedArrivals,
+			est.MatchProbability(maxWait)*100, maxWait.Round(time.Second))
+	default:
+		fmt.Fprintf(rep.w, "Estimated wait for a session: %s (%d more "+
+			"participants needed)\n", est.ExpectedWait().Round(time.Second),
+			est.ExpectedArrivals)
+	}
+}
+
+func (rep *WriterReporter) reportStakeDiffChange(blocksToChange int32,
+	ticketPrice, expectedTicketPrice, maxAmount dcrutil.Amount) {
+
+	if blocksToChange > 0 {
+		fmt.Fprintf(rep.w, "Stake difficulty changes in %d blocks. Expected "+
+			"ticket price: %s (currently %s)\n", blocksToChange,
+			expectedTicketPrice, ticketPrice)
+	} else {
+		fmt.Fprintf(rep.w, "Stake difficulty changed. Ticket price: %s\n",
+			ticketPrice)
+	}
+
+	switch share := ticketPriceShare(maxAmount, expectedTicketPrice); {
+	case share == 0:
+	case share == 1:
+		fmt.Fprintf(rep.w, "Max amount (%s) covers the whole ticket price\n",
+			maxAmount)
+	default:
+		fmt.Fprintf(rep.w, "Max amount (%s) is %.2f%% of the ticket price\n",
+			maxAmount, share*100)
+	}
+}
+
+func (rep *WriterReporter) reportExternalSignRequest(reqFname, respFname string) {
+	fmt.Fprintf(rep.w, "Transactions to sign exported to %s\n", reqFname)
+	fmt.Fprintf(rep.w, "Waiting for the signed transactions at %s\n", respFname)
+}
+
 func (rep *WriterReporter) reportSrvRecordFound(record string) {
 	fmt.Fprintf(rep.w, "Found SRV record to use host %s\n", record)
 }
@@ -117,7 +169,7 @@ func (rep *WriterReporter) reportSplitPublished() {
 	fmt.Fprintf(rep.w, "Split tx published in the network\n")
 }
 
-func (rep *WriterReporter) reportRightTicketPublished() {
+func (rep *WriterReporter) reportRightTicketPublished(ticket *chainhash.Hash) {
 	fmt.Fprintf(rep.w, "Correct ticket published in the network\n")
 }
 
@@ -153,8 +205,13 @@ func (rep *WriterReporter) reportStage(ctx context.Context, stage Stage, session
 		return
 	case StageFindingMatches:
 		encSessName := encodeSessionName(cfg.SessionName)[:10]
-		out("Finding peers to split ticket buy in session '%s' (%s)\n",
-			cfg.SessionName, encSessName)
+		if cfg.Pool != "" {
+			out("Finding peers to split ticket buy in session '%s' (%s) of "+
+				"pool '%s'\n", cfg.SessionName, encSessName, cfg.Pool)
+		} else {
+			out("Finding peers to split ticket buy in session '%s' (%s)\n",
+				cfg.SessionName, encSessName)
+		}
 		return
 	case StageConnectingToWallet:
 		out("Connecting to wallet %s\n", cfg.WalletHost)
@@ -165,6 +222,21 @@ func (rep *WriterReporter) reportStage(ctx context.Context, stage Stage, session
 	case StageConnectingToDcrdata:
 		out("Verified dcrdata online %s\n", cfg.DcrdataURL)
 		return
+	case StageConsolidatingFunds:
+		out("Too many utxos needed to participate. Consolidating funds of "+
+			"account %d\n", cfg.SourceAccount)
+		return
+	case StageWaitingConsolidation:
+		out("Waiting for %d confirmations of the consolidation tx\n",
+			splitticket.MinimumSplitInputConfirms)
+		return
+	case StageFundsConsolidated:
+		out("Funds consolidated\n")
+		return
+	case StageWaitingStakeDiffChange:
+		out("Matcher not accepting participants around the stake difficulty " +
+			"change. Waiting to register again\n")
+		return
 	}
 
 	// from here on, all stages need a session
@@ -287,6 +359,18 @@ func (rep *WriterReporter) reportStage(ctx context.Context, stage Stage, session
 		out("Split tx hash: %s\n", session.fundedSplitTx.TxHash())
 		out("Ticket Hash: %s\n", session.selectedTicket.TxHash())
 
+	case StageDryRunCompleted:
+		res := session.dryRunResult()
+		out("Dry run completed. The split tx was not funded and no funds " +
+			"were spent.\n")
+		out("Contribution: %s (%.2f%% of the ticket price of %s)\n",
+			res.Contribution, res.ContributionPercent, res.TicketPrice)
+		out("Voter chance: %.2f%%\n", res.VoterChance*100)
+		out("Split tx fee: %s\n", res.SplitFee)
+		out("Ticket tx fee: %s\n", res.TicketFee)
+		out("Pool fee: %s\n", res.PoolFee)
+		out("Total fees: %s\n", res.TotalFees())
+
 	default:
 		out("Unknown stage: %d\n", stage)
 	}
@@ -298,14 +382,18 @@ type NullReporter struct{}
 
 func (rep NullReporter) reportStage(ctx context.Context, stage Stage, session *Session, cfg *Config) {
 }
+func (rep NullReporter) reportStakeDiffChange(int32, dcrutil.Amount, dcrutil.Amount, dcrutil.Amount) {
+}
 func (rep NullReporter) reportMatcherStatus(status *pb.StatusResponse)                       {}
+func (rep NullReporter) reportWaitEstimate(*matcher.WaitEstimate, time.Duration)             {}
 func (rep NullReporter) reportSavedSession(string)                                           {}
 func (rep NullReporter) reportSrvRecordFound(record string)                                  {}
 func (rep NullReporter) reportSrvLookupError(err error)                                      {}
 func (rep NullReporter) reportSplitPublished()                                               {}
-func (rep NullReporter) reportRightTicketPublished()                                         {}
+func (rep NullReporter) reportRightTicketPublished(ticket *chainhash.Hash)                   {}
 func (rep NullReporter) reportWrongTicketPublished(ticket *chainhash.Hash, session *Session) {}
 func (rep NullReporter) reportBuyingError(err error)                                         {}
+func (rep NullReporter) reportExternalSignRequest(reqFname, respFname string)                {}
 
 func reporterFromContext(ctx context.Context) Reporter {
 	val := ctx.Value(ReporterCtxKey)
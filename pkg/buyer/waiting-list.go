@@ -10,7 +10,6 @@ import (
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -23,30 +22,34 @@ type waitingListWatcher interface {
 // an error.
 // Whenever the waiting list changes, the changesChan receives a list with the
 // current queues.
+//
+// If proxyCfg is not nil, the connection to the matcher is made through the
+// proxy (with its own credentials when using Tor isolation) and no SRV lookup
+// is done for the matcher host. If clientCert is
+// not nil, it is presented to matchers that require client certificates.
 func WatchMatcherWaitingList(ctx context.Context, matcherHost string,
-	certFile string, watcher waitingListWatcher) error {
+	certFile string, clientCert *tls.Certificate, proxyCfg *ProxyConfig,
+	watcher waitingListWatcher) error {
 
-	matcherHost, _, err := intnet.DetermineMatcherHost(matcherHost)
+	proxy, err := proxyCfg.newProxy()
 	if err != nil {
-		return errors.Wrap(err, "error determining matcher host to connect "+
-			"to when watching lists")
+		return err
 	}
 
-	var opt grpc.DialOption
-	var creds credentials.TransportCredentials
-
-	if certFile != "" {
-		creds, err = credentials.NewClientTLSFromFile(certFile, "localhost")
+	// Don't leak the matcher host to the local DNS when using a proxy.
+	if proxy == nil && !intnet.IsOnionHost(matcherHost) {
+		matcherHost, _, err = intnet.DetermineMatcherHost(matcherHost)
 		if err != nil {
-			return errors.Wrapf(err, "error creating credentials")
-		}
-	} else {
-		tlsCfg := &tls.Config{
-			ServerName: intnet.RemoveHostPort(matcherHost),
+			return errors.Wrap(err, "error determining matcher host to "+
+				"connect to when watching lists")
 		}
-		creds = credentials.NewTLS(tlsCfg)
 	}
-	opt = grpc.WithTransportCredentials(creds)
+
+	creds, err := matcherCredentials(matcherHost, certFile, clientCert)
+	if err != nil {
+		return err
+	}
+	opt := grpc.WithTransportCredentials(creds)
 
 	optKeepAlive := grpc.WithKeepaliveParams(keepalive.ClientParameters{
 		Time:                5 * time.Minute,
@@ -57,7 +60,12 @@ func WatchMatcherWaitingList(ctx context.Context, matcherHost string,
 	dialCtx, cancelDialCtx := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelDialCtx()
 
-	conn, err := grpc.DialContext(dialCtx, matcherHost, opt, optKeepAlive)
+	opts := []grpc.DialOption{opt, optKeepAlive}
+	if proxy != nil {
+		opts = append(opts, grpc.WithDialer(proxy.DialTimeout))
+	}
+
+	conn, err := grpc.DialContext(dialCtx, matcherHost, opts...)
 	if err != nil {
 		return err
 	}
@@ -80,6 +88,7 @@ func WatchMatcherWaitingList(ctx context.Context, matcherHost string,
 		queues := make([]matcher.WaitingQueue, len(resp.Queues))
 		for i, q := range resp.Queues {
 			queues[i] = matcher.WaitingQueue{
+				Pool:    q.Pool,
 				Name:    q.Name,
 				Amounts: uint64sToAmounts(q.Amounts),
 			}
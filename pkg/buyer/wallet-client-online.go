@@ -2,6 +2,7 @@ package buyer
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"time"
 
@@ -91,6 +92,28 @@ func (c *onlineWalletClient) TicketPrice(ctx context.Context, in *pb.TicketPrice
 	return c.wsvc.TicketPrice(ctx, in, opts...)
 }
 
+func (c *onlineWalletClient) PublishTransaction(ctx context.Context, in *pb.PublishTransactionRequest, opts ...grpc.CallOption) (*pb.PublishTransactionResponse, error) {
+	return c.wsvc.PublishTransaction(ctx, in, opts...)
+}
+
+func (c *onlineWalletClient) UnspentOutputs(ctx context.Context, in *pb.UnspentOutputsRequest) ([]*pb.UnspentOutputResponse, error) {
+	stream, err := c.wsvc.UnspentOutputs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*pb.UnspentOutputResponse
+	for {
+		utxo, err := stream.Recv()
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, utxo)
+	}
+}
+
 func (c *onlineWalletClient) MonitorForSessionTransactions(ctx context.Context,
 	splitHash *chainhash.Hash, ticketsHashes []*chainhash.Hash) error {
 
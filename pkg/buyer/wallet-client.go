@@ -31,6 +31,8 @@ type WalletClientConn interface {
 	SignMessage(ctx context.Context, in *pb.SignMessageRequest, opts ...grpc.CallOption) (*pb.SignMessageResponse, error)
 	BestBlock(ctx context.Context, in *pb.BestBlockRequest, opts ...grpc.CallOption) (*pb.BestBlockResponse, error)
 	TicketPrice(ctx context.Context, in *pb.TicketPriceRequest, opts ...grpc.CallOption) (*pb.TicketPriceResponse, error)
+	UnspentOutputs(ctx context.Context, in *pb.UnspentOutputsRequest) ([]*pb.UnspentOutputResponse, error)
+	PublishTransaction(ctx context.Context, in *pb.PublishTransactionRequest, opts ...grpc.CallOption) (*pb.PublishTransactionResponse, error)
 	MonitorForSessionTransactions(ctx context.Context, splitTxHash *chainhash.Hash, ticketsHashes []*chainhash.Hash) error
 	PublishedSplitTx() bool
 	PublishedTicketTx() *chainhash.Hash
@@ -138,16 +140,21 @@ func (wc *walletClient) generateOutputAddresses(ctx context.Context, session *Se
 	}
 
 	// vvvvvvvv ticket output vvvvvvvv
+	// When vote choices were sent, the commitment address was generated
+	// prior to the session (to sign them), so reuse it.
 	rep.reportStage(ctx, StageGenerateTicketCommitmentAddr, session, cfg)
-	resp, err = wc.wsvc.NextAddress(ctx, req)
-	if err != nil {
-		return nil, nil, nil, errors.Wrapf(err, "error obtaining next address "+
-			"for ticket commitment output")
-	}
-	ticketOut, err = dcrutil.DecodeAddress(resp.Address)
-	if err != nil {
-		return nil, nil, nil, errors.Wrapf(err, "error decoding address for "+
-			"ticket commitment output")
+	ticketOut = session.ticketOutputAddress
+	if ticketOut == nil {
+		resp, err = wc.wsvc.NextAddress(ctx, req)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "error obtaining next "+
+				"address for ticket commitment output")
+		}
+		ticketOut, err = dcrutil.DecodeAddress(resp.Address)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "error decoding address "+
+				"for ticket commitment output")
+		}
 	}
 
 	// vvvvv split change vvvv
@@ -170,42 +177,23 @@ func (wc *walletClient) generateSplitTxInputs(ctx context.Context, session *Sess
 
 	rep := reporterFromContext(ctx)
 
-	outputs := make([]*pb.ConstructTransactionRequest_Output, 3)
-
-	// add the output for the voter lottery commitment, so the wallet accounts
-	// for that when calculating the amount of input.
-	// FIXME: currently double paying, as all participants are accounting for
-	// this. This should be of size ceil(33/nbParts)
-	nullData := bytes.Repeat([]byte{0x00}, splitticket.LotteryCommitmentHashSize)
-	script := append([]byte{txscript.OP_RETURN, txscript.OP_DATA_32}, nullData...)
-	outputs[0] = &pb.ConstructTransactionRequest_Output{
-		Amount: 0,
-		Destination: &pb.ConstructTransactionRequest_OutputDestination{
-			Script: script,
-		},
-	}
-
-	// add the output for my ticket commitment
-	outputs[1] = &pb.ConstructTransactionRequest_Output{
-		Amount: int64(session.Amount + session.Fee),
+	rep.reportStage(ctx, StageGenerateSplitInputs, session, cfg)
+	if cfg.coinControl() {
+		return wc.selectSplitTxInputs(ctx, session, cfg)
+	}
+
+	// Request a single output for our ticket commitment, but funded with
+	// enough to also pay for our share of the pool fee and of the fee for the
+	// outputs common to all participants (which appear only once in the split
+	// tx). The wallet then accounts for the fee of our own inputs and outputs.
+	commonFee := splitticket.SplitTxCommonFeeShare(int(session.nbParticipants),
+		session.feeRate)
+	outputs := []*pb.ConstructTransactionRequest_Output{{
+		Amount: int64(session.Amount + session.Fee + session.PoolFee + commonFee),
 		Destination: &pb.ConstructTransactionRequest_OutputDestination{
 			Address: session.splitOutputAddress.String(),
 		},
-	}
-
-	// add a dummy pool fee output, so that we account for our share of the pool fee
-	// when grabbing funds for the split tx
-	zeroed := [20]byte{}
-	addrZeroed, err := dcrutil.NewAddressPubKeyHash(zeroed[:], cfg.ChainParams, 0)
-	if err != nil {
-		return err
-	}
-	outputs[2] = &pb.ConstructTransactionRequest_Output{
-		Amount: int64(session.PoolFee),
-		Destination: &pb.ConstructTransactionRequest_OutputDestination{
-			Address: addrZeroed.String(),
-		},
-	}
+	}}
 
 	splitChangeDest := &pb.ConstructTransactionRequest_OutputDestination{
 		Script:        session.splitChange.PkScript,
@@ -213,14 +201,13 @@ func (wc *walletClient) generateSplitTxInputs(ctx context.Context, session *Sess
 	}
 
 	req := &pb.ConstructTransactionRequest{
-		FeePerKb:              int32(splitticket.TxFeeRate),
+		FeePerKb:              int32(session.feeRate),
 		RequiredConfirmations: splitticket.MinimumSplitInputConfirms,
 		SourceAccount:         cfg.SourceAccount,
 		NonChangeOutputs:      outputs,
 		ChangeDestination:     splitChangeDest,
 	}
 
-	rep.reportStage(ctx, StageGenerateSplitInputs, session, cfg)
 	resp, err := wc.wsvc.ConstructTransaction(ctx, req)
 	if err != nil {
 		return err
@@ -232,13 +219,29 @@ func (wc *walletClient) generateSplitTxInputs(ctx context.Context, session *Sess
 		return err
 	}
 
+	if len(tx.TxIn) > splitticket.MaximumSplitInputs {
+		return errors.Errorf("wallet selected more than %d split inputs; "+
+			"consolidate the wallet utxos", splitticket.MaximumSplitInputs)
+	}
+
 	if resp.ChangeIndex > -1 {
 		out := tx.TxOut[resp.ChangeIndex]
 		if !bytes.Equal(out.PkScript, splitChangeDest.Script) {
 			return errors.Errorf("wallet changed split change pkscript")
 		}
 
-		session.splitChange.Value = out.Value
+		// The wallet accounted for the full tx header, so recalculate the
+		// change based on the fee we actually need to pay.
+		splitFee := splitticket.SplitTxParticipantFee(
+			int(session.nbParticipants), len(tx.TxIn), true, session.feeRate)
+		change := dcrutil.Amount(resp.TotalPreviousOutputAmount) -
+			session.Amount - session.Fee - session.PoolFee - splitFee
+		if change < dcrutil.Amount(out.Value) {
+			return errors.Errorf("wallet selected inputs (%s) not enough to "+
+				"pay for split tx fee (%s)",
+				dcrutil.Amount(resp.TotalPreviousOutputAmount), splitFee)
+		}
+		session.splitChange.Value = int64(change)
 	} else {
 		session.splitChange = nil
 	}
@@ -332,7 +335,7 @@ func (wc *walletClient) prepareRevocationForSigning(session *Session) (
 	ticketHash := myTicket.TxHash()
 
 	revocation, err := splitticket.CreateUnsignedRevocation(&ticketHash, myTicket,
-		splitticket.RevocationFeeRate(wc.chainParams))
+		splitticket.RevocationFeeRate(session.feeRate, wc.chainParams))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -462,9 +465,7 @@ func (wc *walletClient) processSignedSplit(
 }
 
 func (wc *walletClient) signTransactions(ctx context.Context, session *Session, cfg *Config) error {
-	req := &pb.SignTransactionsRequest{
-		Passphrase: cfg.Passphrase,
-	}
+	req := &pb.SignTransactionsRequest{}
 
 	tickets, ticketsAddScripts, err := wc.prepareTicketsForSigning(session)
 	if err != nil {
@@ -487,7 +488,7 @@ func (wc *walletClient) signTransactions(ctx context.Context, session *Session,
 	req.AdditionalScripts = append(req.AdditionalScripts, revocationAddScripts...)
 	req.AdditionalScripts = append(req.AdditionalScripts, ticketsAddScripts...)
 
-	resp, err := wc.wsvc.SignTransactions(ctx, req)
+	resp, err := cfg.signer(wc).SignTransactions(ctx, req)
 	if err != nil {
 		return errors.Wrapf(err, "error signing transactions")
 	}
@@ -565,6 +566,63 @@ func (wc *walletClient) testPassphrase(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
+// signedVoteChoices stores the vote choices of the buyer, signed by the
+// address that will be used as ticket commitment.
+type signedVoteChoices struct {
+	choices           splitticket.VoteChoices
+	commitmentAddress dcrutil.Address
+	signature         []byte
+}
+
+// signVoteChoices generates the address that will be used as ticket
+// commitment output and signs the configured vote choices with it, so that
+// the voting pool may verify the choices were sent by the ticket owner.
+func (wc *walletClient) signVoteChoices(ctx context.Context, cfg *Config) (
+	*signedVoteChoices, error) {
+
+	choices, err := splitticket.ParseVoteChoices(cfg.VoteChoices)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing vote choices")
+	}
+
+	voteAddr, err := dcrutil.DecodeAddress(cfg.VoteAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding vote address")
+	}
+
+	resp, err := wc.wsvc.NextAddress(ctx, &pb.NextAddressRequest{
+		Account:   cfg.SourceAccount,
+		GapPolicy: pb.NextAddressRequest_GAP_POLICY_WRAP,
+		Kind:      pb.NextAddressRequest_BIP0044_EXTERNAL,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error obtaining next address for "+
+			"ticket commitment output")
+	}
+
+	commitAddr, err := dcrutil.DecodeAddress(resp.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding address for ticket "+
+			"commitment output")
+	}
+
+	req := &pb.SignMessageRequest{
+		Address:    resp.Address,
+		Message:    splitticket.VoteChoicesSignMessage(choices, voteAddr),
+		Passphrase: cfg.Passphrase,
+	}
+	signResp, err := wc.wsvc.SignMessage(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing vote choices")
+	}
+
+	return &signedVoteChoices{
+		choices:           choices,
+		commitmentAddress: commitAddr,
+		signature:         signResp.Signature,
+	}, nil
+}
+
 // testFunds tests whether the wallet has sufficient funds to contribute the
 // specified maxAmount into a split ticket session.
 //
@@ -585,7 +643,7 @@ func (wc *walletClient) testFunds(ctx context.Context, cfg *Config) error {
 
 	// split the total amount in 3 parts, one for each simulated output and add
 	// the ticket fee
-	ticketFee := splitticket.SessionFeeEstimate(1)
+	ticketFee := splitticket.SessionFeeEstimate(1, cfg.maxFeeRate())
 	poolFee := amount / 3
 	changeAmount := poolFee
 	amount = amount - poolFee - changeAmount + ticketFee
@@ -632,7 +690,7 @@ func (wc *walletClient) testFunds(ctx context.Context, cfg *Config) error {
 	}
 
 	req := &pb.ConstructTransactionRequest{
-		FeePerKb:              int32(splitticket.TxFeeRate),
+		FeePerKb:              int32(cfg.maxFeeRate()),
 		RequiredConfirmations: splitticket.MinimumSplitInputConfirms,
 		SourceAccount:         cfg.SourceAccount,
 		NonChangeOutputs:      outputs,
@@ -676,9 +734,7 @@ func (wc *walletClient) testFunds(ctx context.Context, cfg *Config) error {
 		return errors.Wrapf(err, "error unserializing response tx")
 	}
 	if len(tx.TxIn) > splitticket.MaximumSplitInputs {
-		return errors.Errorf("number of utxos to send into split tx (%d) "+
-			"larger than maximum allowed (%d)", len(tx.TxIn),
-			splitticket.MaximumSplitInputs)
+		return tooManySplitInputsError(len(tx.TxIn))
 	}
 
 	return nil
@@ -0,0 +1,481 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme"
+	"github.com/pkg/errors"
+)
+
+const (
+	// acmeRenewBefore is how long before expiring certificates are renewed.
+	acmeRenewBefore = 30 * 24 * time.Hour
+
+	// acmeRetryDelay is the initial delay before retrying to obtain a
+	// certificate after a failure. It doubles after each failure, up to
+	// acmeMaxRetryDelay.
+	acmeRetryDelay    = time.Minute
+	acmeMaxRetryDelay = 6 * time.Hour
+
+	// acmeObtainTimeout is the maximum time an attempt to obtain a
+	// certificate may take, so that a CA that never finishes validating the
+	// challenges doesn't stop further attempts.
+	acmeObtainTimeout = 10 * time.Minute
+
+	acmeAccountKeyFilename = "account.key"
+	acmeCertFilename       = "cert.pem"
+)
+
+// acmeConfig is the configuration of the ACME certificate manager.
+type acmeConfig struct {
+	Domains       []string
+	DirectoryURL  string
+	DirectoryCert string
+	Email         string
+	CacheDir      string
+	HTTPBindAddr  string
+	Log           slog.Logger
+
+	// Overridable by tests.
+	renewBefore   time.Duration
+	retryDelay    time.Duration
+	obtainTimeout time.Duration
+	httpClient    *http.Client
+}
+
+// acmeManager obtains and renews the TLS certificate of the services of the
+// matcher from an ACME CA (eg: Let's Encrypt).
+//
+// The certificate is served by the GetCertificate hook of the tls configs of
+// the listeners, so renewed certificates are used on new connections without
+// restarting the listeners or dropping established connections. Until a
+// certificate is obtained (and for clients that request a server name not
+// covered by it), the fallback certificate of the tls config is used.
+type acmeManager struct {
+	cfg    *acmeConfig
+	log    slog.Logger
+	client *acme.Client
+
+	mtx        sync.Mutex
+	cert       *tls.Certificate
+	alpnCerts  map[string]*tls.Certificate
+	httpTokens map[string]string
+}
+
+// newACMEManager returns a manager for the given config, loading (or
+// creating) the account key and the previously obtained certificate from the
+// cache dir.
+func newACMEManager(cfg *acmeConfig) (*acmeManager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("no domains specified for acme certificates")
+	}
+	if cfg.renewBefore == 0 {
+		cfg.renewBefore = acmeRenewBefore
+	}
+	if cfg.retryDelay == 0 {
+		cfg.retryDelay = acmeRetryDelay
+	}
+	if cfg.obtainTimeout == 0 {
+		cfg.obtainTimeout = acmeObtainTimeout
+	}
+
+	if err := os.MkdirAll(cfg.CacheDir, 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating acme cache dir")
+	}
+
+	key, err := loadACMEAccountKey(filepath.Join(cfg.CacheDir,
+		acmeAccountKeyFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cfg.httpClient
+	if httpClient == nil {
+		httpClient, err = acmeHTTPClient(cfg.DirectoryCert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m := &acmeManager{
+		cfg: cfg,
+		log: cfg.Log,
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: cfg.DirectoryURL,
+			HTTPClient:   httpClient,
+		},
+		alpnCerts:  make(map[string]*tls.Certificate),
+		httpTokens: make(map[string]string),
+	}
+	if cfg.Email != "" {
+		m.client.Contact = []string{"mailto:" + cfg.Email}
+	}
+
+	m.cert, err = m.loadCachedCert()
+	if err != nil {
+		m.log.Warnf("Ignoring cached acme certificate: %v", err)
+	} else if m.cert != nil {
+		m.log.Infof("Loaded acme certificate valid until %s",
+			m.cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return m, nil
+}
+
+// acmeHTTPClient returns the client used to connect to the ACME directory,
+// optionally trusting the CA in the given file (for test CAs such as pebble).
+func acmeHTTPClient(caFile string) (*http.Client, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if caFile == "" {
+		return client, nil
+	}
+
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading acme directory cert")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in acme directory " +
+			"cert file")
+	}
+	client.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+	return client, nil
+}
+
+// writeFileAtomic writes the file by renaming a temporary file, so that
+// readers never see a partially written file.
+func writeFileAtomic(fname string, data []byte) error {
+	tmp := fname + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
+
+// loadACMEAccountKey loads the account key from the given file, creating it
+// if it does not exist.
+func loadACMEAccountKey(fname string) (*ecdsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(fname)
+	if os.IsNotExist(err) {
+		var key *ecdsa.PrivateKey
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		var der []byte
+		der, err = x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		b = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		if err = writeFileAtomic(fname, b); err != nil {
+			return nil, errors.Wrap(err, "error writing acme account key")
+		}
+		return key, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error reading acme account key")
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.Errorf("invalid acme account key file %s", fname)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing acme account key")
+	}
+	return key, nil
+}
+
+// loadCachedCert loads the certificate previously obtained for the domains.
+// It returns nil if there is no usable cached certificate.
+func (m *acmeManager) loadCachedCert() (*tls.Certificate, error) {
+	fname := filepath.Join(m.cfg.CacheDir, acmeCertFilename)
+	if _, err := os.Stat(fname); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(fname, fname)
+	if err != nil {
+		return nil, err
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range m.cfg.Domains {
+		if err = cert.Leaf.VerifyHostname(d); err != nil {
+			return nil, errors.Wrap(err, "cached certificate does not match "+
+				"the configured domains")
+		}
+	}
+	if time.Now().After(cert.Leaf.NotAfter) {
+		return nil, errors.New("cached certificate expired")
+	}
+
+	return &cert, nil
+}
+
+// certificate returns the current certificate (if any).
+func (m *acmeManager) certificate() *tls.Certificate {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.cert
+}
+
+// configureTLS configures the given tls config (of a listener) to use the
+// certificates of the manager. The existing certificates of the config are
+// used as fallback.
+func (m *acmeManager) configureTLS(cfg *tls.Config) {
+	cfg.GetCertificate = m.getCertificate
+	cfg.GetConfigForClient = m.getConfigForClient
+}
+
+// getCertificate fulfills tls.Config.GetCertificate. It returns nil (so that
+// the fallback certificate is used) while no certificate has been obtained
+// or if the client requested a server name not covered by the certificate
+// (eg: buyers connecting with the self signed matcher certificate).
+func (m *acmeManager) getCertificate(hello *tls.ClientHelloInfo) (
+	*tls.Certificate, error) {
+
+	cert := m.certificate()
+	if cert == nil {
+		return nil, nil
+	}
+	if hello.ServerName != "" &&
+		cert.Leaf.VerifyHostname(hello.ServerName) != nil {
+		return nil, nil
+	}
+	return cert, nil
+}
+
+// getConfigForClient fulfills tls.Config.GetConfigForClient by answering
+// tls-alpn-01 challenges. Other connections use the original config.
+func (m *acmeManager) getConfigForClient(hello *tls.ClientHelloInfo) (
+	*tls.Config, error) {
+
+	if len(hello.SupportedProtos) != 1 ||
+		hello.SupportedProtos[0] != acme.ALPNProto {
+		return nil, nil
+	}
+
+	m.mtx.Lock()
+	cert := m.alpnCerts[strings.ToLower(hello.ServerName)]
+	m.mtx.Unlock()
+	if cert == nil {
+		return nil, errors.Errorf("no tls-alpn-01 challenge for %s",
+			hello.ServerName)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{acme.ALPNProto},
+	}, nil
+}
+
+// ServeHTTP answers http-01 challenges.
+func (m *acmeManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, acme.HTTP01ChallengePrefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, acme.HTTP01ChallengePrefix)
+	m.mtx.Lock()
+	keyAuth, ok := m.httpTokens[token]
+	m.mtx.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
+
+// ChallengeType fulfills acme.Solver. The http-01 challenge is used when the
+// http challenge server is enabled and tls-alpn-01 otherwise.
+func (m *acmeManager) ChallengeType() string {
+	if m.cfg.HTTPBindAddr != "" {
+		return acme.ChallengeHTTP01
+	}
+	return acme.ChallengeTLSALPN01
+}
+
+// Present fulfills acme.Solver.
+func (m *acmeManager) Present(domain, token, keyAuth string) error {
+	if m.cfg.HTTPBindAddr != "" {
+		m.mtx.Lock()
+		m.httpTokens[token] = keyAuth
+		m.mtx.Unlock()
+		return nil
+	}
+
+	cert, err := acme.TLSALPN01Certificate(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+	m.mtx.Lock()
+	m.alpnCerts[strings.ToLower(domain)] = cert
+	m.mtx.Unlock()
+	return nil
+}
+
+// CleanUp fulfills acme.Solver.
+func (m *acmeManager) CleanUp(domain, token string) {
+	m.mtx.Lock()
+	delete(m.httpTokens, token)
+	delete(m.alpnCerts, strings.ToLower(domain))
+	m.mtx.Unlock()
+}
+
+// obtain requests a new certificate for the domains, stores it in the cache
+// dir and starts using it.
+func (m *acmeManager) obtain(ctx context.Context) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: m.cfg.Domains[0]},
+			DNSNames: m.cfg.Domains,
+		}, key)
+	if err != nil {
+		return errors.Wrap(err, "error creating certificate request")
+	}
+
+	chain, err := m.client.ObtainCertificate(ctx, m.cfg.Domains, csr, m)
+	if err != nil {
+		return err
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return errors.Wrap(err, "error parsing obtained certificate")
+	}
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.X.Cmp(key.X) != 0 || pub.Y.Cmp(key.Y) != 0 {
+		return errors.New("obtained certificate is for a different key")
+	}
+	cert := &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	for _, c := range chain {
+		data = append(data, pem.EncodeToMemory(&pem.Block{
+			Type: "CERTIFICATE", Bytes: c})...)
+	}
+	err = writeFileAtomic(filepath.Join(m.cfg.CacheDir, acmeCertFilename),
+		data)
+	if err != nil {
+		// The certificate is still used until the next restart.
+		m.log.Errorf("Error caching acme certificate: %v", err)
+	}
+
+	m.mtx.Lock()
+	m.cert = cert
+	m.mtx.Unlock()
+
+	m.log.Infof("Obtained acme certificate for %s valid until %s",
+		strings.Join(m.cfg.Domains, ", "), leaf.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+// renewAt returns when the current certificate should be renewed.
+func (m *acmeManager) renewAt() time.Time {
+	cert := m.certificate()
+	if cert == nil {
+		return time.Now()
+	}
+
+	// Short lived certificates are renewed after two thirds of their
+	// lifetime.
+	renewBefore := m.cfg.renewBefore
+	lifetime := cert.Leaf.NotAfter.Sub(cert.Leaf.NotBefore)
+	if lifetime/3 < renewBefore {
+		renewBefore = lifetime / 3
+	}
+	return cert.Leaf.NotAfter.Add(-renewBefore)
+}
+
+// run obtains the certificate (if needed) and renews it until the context is
+// done. Failures (including attempts that take longer than the obtain
+// timeout) are retried with an exponential backoff.
+func (m *acmeManager) run(ctx context.Context) {
+	if m.cfg.HTTPBindAddr != "" {
+		srv := &http.Server{Addr: m.cfg.HTTPBindAddr, Handler: m}
+		go func() {
+			<-ctx.Done()
+			srv.Shutdown(context.Background())
+		}()
+		go func() {
+			m.log.Infof("Answering acme http-01 challenges on %s",
+				m.cfg.HTTPBindAddr)
+			err := srv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				m.log.Errorf("Error running acme http challenge server: %v",
+					err)
+			}
+		}()
+	}
+
+	retryDelay := m.cfg.retryDelay
+	wait := time.Until(m.renewAt())
+	for {
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		obtainCtx, cancel := context.WithTimeout(ctx, m.cfg.obtainTimeout)
+		err := m.obtain(obtainCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			m.log.Errorf("Error obtaining acme certificate (retrying in "+
+				"%s): %v", retryDelay, err)
+			wait = retryDelay
+			retryDelay *= 2
+			if retryDelay > acmeMaxRetryDelay {
+				retryDelay = acmeMaxRetryDelay
+			}
+			continue
+		}
+
+		retryDelay = m.cfg.retryDelay
+		wait = time.Until(m.renewAt())
+		if wait < retryDelay {
+			wait = retryDelay
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package daemon
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme/acmetest"
+	"github.com/pkg/errors"
+)
+
+// newTestACMEManager returns a manager that obtains certificates from a test
+// ACME server.
+func newTestACMEManager(t *testing.T, srv *acmetest.Server, cacheDir,
+	httpBindAddr string) *acmeManager {
+
+	m, err := newACMEManager(&acmeConfig{
+		Domains:      []string{"matcher.example.com"},
+		DirectoryURL: srv.DirectoryURL(),
+		CacheDir:     cacheDir,
+		HTTPBindAddr: httpBindAddr,
+		Log:          slog.Disabled,
+		retryDelay:   20 * time.Millisecond,
+		httpClient:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating acme manager: %v", err)
+	}
+	m.client.PollInterval = 10 * time.Millisecond
+	return m
+}
+
+// servedCert returns the certificate served by the given tls config to a
+// client connecting to the server name.
+func servedCert(t *testing.T, cfg *tls.Config, serverName string) *x509.Certificate {
+	hello := &tls.ClientHelloInfo{ServerName: serverName}
+	if cfg.GetConfigForClient != nil {
+		c, err := cfg.GetConfigForClient(hello)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != nil {
+			cfg = c
+		}
+	}
+
+	var cert *tls.Certificate
+	if cfg.GetCertificate != nil {
+		var err error
+		cert, err = cfg.GetCertificate(hello)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cert == nil {
+		cert = &cfg.Certificates[0]
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
+
+func TestACMEManagerHTTP01(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var m *acmeManager
+	srv, err := acmetest.NewServer(func(typ, domain, token, keyAuth string) error {
+		return acmetest.ValidateHTTP01(m, domain, token, keyAuth)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	m = newTestACMEManager(t, srv, dir, "127.0.0.1:0")
+	if m.ChallengeType() != acme.ChallengeHTTP01 {
+		t.Fatalf("unexpected challenge type %s", m.ChallengeType())
+	}
+
+	fallback := newTestCert(t, "localhost", false, nil)
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{fallback.cert.Raw},
+		PrivateKey:  fallback.key,
+	}}}
+	m.configureTLS(tlsCfg)
+
+	// The fallback certificate is used until a certificate is obtained.
+	if cert := servedCert(t, tlsCfg, "matcher.example.com"); !cert.Equal(fallback.cert) {
+		t.Fatalf("fallback cert not served before obtaining the acme cert")
+	}
+
+	if err = m.obtain(context.Background()); err != nil {
+		t.Fatalf("unexpected error obtaining cert: %v", err)
+	}
+	cert := servedCert(t, tlsCfg, "matcher.example.com")
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "matcher.example.com",
+		Roots: srv.Roots()})
+	if err != nil {
+		t.Fatalf("served cert not valid: %v", err)
+	}
+
+	// Clients connecting with the self signed certificate still get it.
+	if cert := servedCert(t, tlsCfg, "localhost"); !cert.Equal(fallback.cert) {
+		t.Fatalf("fallback cert not served for other server names")
+	}
+
+	// A new manager uses the cached certificate and account.
+	m2 := newTestACMEManager(t, srv, dir, "127.0.0.1:0")
+	if m2.certificate() == nil || !m2.certificate().Leaf.Equal(cert) {
+		t.Fatalf("cached certificate not loaded")
+	}
+	if m2.client.Key.X.Cmp(m.client.Key.X) != 0 {
+		t.Fatalf("cached account key not loaded")
+	}
+	if !m2.renewAt().After(time.Now()) {
+		t.Fatalf("cached certificate scheduled for immediate renewal")
+	}
+	if srv.Issued() != 1 {
+		t.Fatalf("unexpected number of issued certs %d", srv.Issued())
+	}
+}
+
+func TestACMEManagerTLSALPN01(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Use the tls config of the grpc service when it requires client
+	// certificates, to ensure challenges are answered anyway.
+	ca := newTestCert(t, "ca", true, nil)
+	caFile := dir + "/ca.pem"
+	if err = ioutil.WriteFile(caFile, ca.pem(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := newClientAuthorizer(caFile, "", slog.Disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback := newTestCert(t, "localhost", false, nil)
+	tlsCfg := auth.tlsConfig(&tls.Certificate{
+		Certificate: [][]byte{fallback.cert.Raw},
+		PrivateKey:  fallback.key,
+	})
+
+	srv, err := acmetest.NewServer(func(typ, domain, token, keyAuth string) error {
+		return acmetest.ValidateTLSALPN01(tlsCfg, domain, keyAuth)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	m := newTestACMEManager(t, srv, dir+"/acme", "")
+	if m.ChallengeType() != acme.ChallengeTLSALPN01 {
+		t.Fatalf("unexpected challenge type %s", m.ChallengeType())
+	}
+	m.configureTLS(tlsCfg)
+
+	if err = m.obtain(context.Background()); err != nil {
+		t.Fatalf("unexpected error obtaining cert: %v", err)
+	}
+	if len(m.alpnCerts) != 0 {
+		t.Fatalf("challenge certs not cleaned up")
+	}
+	cert := servedCert(t, tlsCfg, "matcher.example.com")
+	if !cert.Equal(m.certificate().Leaf) {
+		t.Fatalf("acme cert not served")
+	}
+}
+
+func TestACMEManagerRenewal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var m *acmeManager
+	srv, err := acmetest.NewServer(func(typ, domain, token, keyAuth string) error {
+		return acmetest.ValidateHTTP01(m, domain, token, keyAuth)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	// Certificates that are immediately due for renewal.
+	srv.CertLifetime = time.Second
+	m = newTestACMEManager(t, srv, dir, "127.0.0.1:0")
+	tlsCfg := new(tls.Config)
+	m.configureTLS(tlsCfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.run(ctx)
+		close(done)
+	}()
+
+	var first *x509.Certificate
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if m.certificate() != nil {
+			cert := servedCert(t, tlsCfg, "matcher.example.com")
+			if first == nil {
+				first = cert
+			} else if !cert.Equal(first) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if first == nil || srv.Issued() < 2 {
+		t.Fatalf("certificate not renewed (issued %d)", srv.Issued())
+	}
+	if cert := servedCert(t, tlsCfg, "matcher.example.com"); cert.Equal(first) {
+		t.Fatalf("renewed certificate not served")
+	}
+}
+
+func TestACMEManagerObtainTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The first validation never finishes, as if the CA were stuck.
+	var m *acmeManager
+	var validations int32
+	release := make(chan struct{})
+	srv, err := acmetest.NewServer(func(typ, domain, token, keyAuth string) error {
+		if atomic.AddInt32(&validations, 1) == 1 {
+			<-release
+			return errors.New("released")
+		}
+		return acmetest.ValidateHTTP01(m, domain, token, keyAuth)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	defer close(release)
+
+	m = newTestACMEManager(t, srv, dir, "127.0.0.1:0")
+	m.cfg.obtainTimeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.certificate() == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if m.certificate() == nil {
+		t.Fatalf("certificate not obtained after a stuck attempt")
+	}
+}
@@ -0,0 +1,263 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/pkg/errors"
+)
+
+// clientAuthReloadInterval is the minimum interval between checks for changes
+// of the client CA and allowlist files.
+const clientAuthReloadInterval = 10 * time.Second
+
+// clientAuthorizer authorizes the TLS certificates of clients of the matcher
+// (mutual TLS), either by the CA that signed them or by their fingerprint.
+// Authorized certificates are mapped to an identity, used for logging and for
+// restricting who may join pre-declared queues.
+//
+// The CA and allowlist files are reloaded whenever they change, so clients
+// can be added and removed without restarting the daemon.
+type clientAuthorizer struct {
+	caFile        string
+	allowlistFile string
+	log           slog.Logger
+
+	mtx         sync.Mutex
+	lastCheck   time.Time
+	caModTime   time.Time
+	listModTime time.Time
+	cas         *x509.CertPool
+	allowlist   map[[sha256.Size]byte]string
+}
+
+// newClientAuthorizer returns an authorizer for the given client CA and
+// allowlist files. Either file may be empty, but not both.
+func newClientAuthorizer(caFile, allowlistFile string, log slog.Logger) (
+	*clientAuthorizer, error) {
+
+	if caFile == "" && allowlistFile == "" {
+		return nil, errors.New("client authorization requires either a " +
+			"client CA file or a client allowlist file")
+	}
+
+	a := &clientAuthorizer{
+		caFile:        caFile,
+		allowlistFile: allowlistFile,
+		log:           log,
+	}
+	if err := a.reload(true); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// modTime returns the modification time of the given file.
+func modTime(fname string) (time.Time, error) {
+	fi, err := os.Stat(fname)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// reload reloads the files that changed since they were last loaded. Unless
+// force is true, files are checked at most once per
+// clientAuthReloadInterval. Must be called with the mutex unlocked.
+func (a *clientAuthorizer) reload(force bool) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := time.Now()
+	if !force && now.Sub(a.lastCheck) < clientAuthReloadInterval {
+		return nil
+	}
+	a.lastCheck = now
+
+	if a.caFile != "" {
+		mod, err := modTime(a.caFile)
+		if err != nil {
+			return errors.Wrap(err, "error checking client CA file")
+		}
+		if force || !mod.Equal(a.caModTime) {
+			cas, err := loadClientCAs(a.caFile)
+			if err != nil {
+				return err
+			}
+			a.cas, a.caModTime = cas, mod
+			if !force {
+				a.log.Infof("Reloaded client CA file %s", a.caFile)
+			}
+		}
+	}
+
+	if a.allowlistFile != "" {
+		mod, err := modTime(a.allowlistFile)
+		if err != nil {
+			return errors.Wrap(err, "error checking client allowlist file")
+		}
+		if force || !mod.Equal(a.listModTime) {
+			allowlist, err := loadClientAllowlist(a.allowlistFile)
+			if err != nil {
+				return err
+			}
+			a.allowlist, a.listModTime = allowlist, mod
+			if !force {
+				a.log.Infof("Reloaded client allowlist %s (%d clients)",
+					a.allowlistFile, len(allowlist))
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyPeerCertificate is used as the tls.Config.VerifyPeerCertificate of
+// the grpc service. It accepts the client if its certificate is in the
+// allowlist or if it was signed by one of the client CAs.
+func (a *clientAuthorizer) verifyPeerCertificate(rawCerts [][]byte,
+	_ [][]*x509.Certificate) error {
+
+	if len(rawCerts) == 0 {
+		return errors.New("client did not provide a certificate")
+	}
+
+	if err := a.reload(false); err != nil {
+		// Keep using the previously loaded files.
+		a.log.Errorf("Error reloading client authorization files: %v", err)
+	}
+
+	a.mtx.Lock()
+	cas := a.cas
+	_, allowed := a.allowlist[sha256.Sum256(rawCerts[0])]
+	a.mtx.Unlock()
+
+	if allowed {
+		return nil
+	}
+
+	if cas == nil {
+		return errors.New("client certificate not in allowlist")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return errors.Wrap(err, "error parsing client certificate")
+		}
+		certs[i] = cert
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         cas,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return errors.Wrap(err, "error verifying client certificate")
+	}
+
+	return nil
+}
+
+// identity returns the identity of the client with the given (authorized)
+// certificate: its name in the allowlist, the common name of its subject or
+// its fingerprint, in that order.
+func (a *clientAuthorizer) identity(cert *x509.Certificate) string {
+	fp := sha256.Sum256(cert.Raw)
+
+	a.mtx.Lock()
+	name, has := a.allowlist[fp]
+	a.mtx.Unlock()
+
+	switch {
+	case has:
+		return name
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	default:
+		return hex.EncodeToString(fp[:])
+	}
+}
+
+// tlsConfig returns the tls config of the grpc service, requiring clients to
+// present an authorized certificate.
+func (a *clientAuthorizer) tlsConfig(cert *tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates:          []tls.Certificate{*cert},
+		ClientAuth:            tls.RequireAnyClientCert,
+		VerifyPeerCertificate: a.verifyPeerCertificate,
+	}
+}
+
+// loadClientCAs loads the certificates of the CAs that sign the certificates
+// of authorized clients.
+func loadClientCAs(fname string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading client CA file")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in client CA file %s",
+			fname)
+	}
+
+	return pool, nil
+}
+
+// loadClientAllowlist loads the allowlist of client certificates. Each line of
+// the file has the SHA-256 fingerprint of a certificate (hex encoded,
+// optionally separated by colons) followed by the identity of the client.
+// Empty lines and lines starting with # are ignored.
+func loadClientAllowlist(fname string) (map[[sha256.Size]byte]string, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening client allowlist file")
+	}
+	defer f.Close()
+
+	allowlist := make(map[[sha256.Size]byte]string)
+	scanner := bufio.NewScanner(f)
+	for lineNb := 1; scanner.Scan(); lineNb++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("line %d of client allowlist %s does "+
+				"not have a fingerprint and identity", lineNb, fname)
+		}
+
+		fpBytes, err := hex.DecodeString(strings.Replace(fields[0], ":", "",
+			-1))
+		if err != nil || len(fpBytes) != sha256.Size {
+			return nil, errors.Errorf("invalid fingerprint on line %d of "+
+				"client allowlist %s", lineNb, fname)
+		}
+		var fp [sha256.Size]byte
+		copy(fp[:], fpBytes)
+		allowlist[fp] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading client allowlist file")
+	}
+
+	return allowlist, nil
+}
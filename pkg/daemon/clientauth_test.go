@@ -0,0 +1,212 @@
+package daemon
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+)
+
+// testCert is a certificate generated for client authorization tests.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func (c *testCert) fingerprint() string {
+	fp := sha256.Sum256(c.cert.Raw)
+	return hex.EncodeToString(fp[:])
+}
+
+func (c *testCert) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+		Bytes: c.cert.Raw})
+}
+
+// newTestCert generates a certificate with the given common name, signed by
+// parent (or self-signed if parent is nil).
+func newTestCert(t *testing.T, cn string, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	signerCert, signerKey := tmpl, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert,
+		&key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{cert: cert, key: key}
+}
+
+func TestClientAuthorizerAllowlist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-clientauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	alice := newTestCert(t, "alice-cn", false, nil)
+	bob := newTestCert(t, "bob-cn", false, nil)
+
+	// Colon separated fingerprints are also accepted.
+	var bobFp string
+	for i, c := range bob.fingerprint() {
+		if i > 0 && i%2 == 0 {
+			bobFp += ":"
+		}
+		bobFp += string(c)
+	}
+
+	fname := filepath.Join(dir, "allowlist")
+	content := "# authorized clients\n\n" + alice.fingerprint() + " alice\n"
+	if err = ioutil.WriteFile(fname, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := newClientAuthorizer("", fname, slog.Disabled)
+	if err != nil {
+		t.Fatalf("unexpected error loading allowlist: %v", err)
+	}
+
+	if err = auth.verifyPeerCertificate([][]byte{alice.cert.Raw}, nil); err != nil {
+		t.Fatalf("unexpected error verifying allowed cert: %v", err)
+	}
+	if id := auth.identity(alice.cert); id != "alice" {
+		t.Fatalf("unexpected identity %s", id)
+	}
+	if err = auth.verifyPeerCertificate([][]byte{bob.cert.Raw}, nil); err == nil {
+		t.Fatalf("cert not in the allowlist was accepted")
+	}
+	if err = auth.verifyPeerCertificate(nil, nil); err == nil {
+		t.Fatalf("missing cert was accepted")
+	}
+
+	// Add bob to the allowlist and force the file to be checked again.
+	content += bobFp + " bob\n"
+	if err = ioutil.WriteFile(fname, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err = os.Chtimes(fname, future, future); err != nil {
+		t.Fatal(err)
+	}
+	auth.lastCheck = time.Time{}
+
+	if err = auth.verifyPeerCertificate([][]byte{bob.cert.Raw}, nil); err != nil {
+		t.Fatalf("unexpected error verifying cert after reload: %v", err)
+	}
+	if id := auth.identity(bob.cert); id != "bob" {
+		t.Fatalf("unexpected identity %s", id)
+	}
+
+	// An invalid allowlist keeps the previously loaded one.
+	if err = ioutil.WriteFile(fname, []byte("xxxx bob\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future = future.Add(time.Minute)
+	if err = os.Chtimes(fname, future, future); err != nil {
+		t.Fatal(err)
+	}
+	auth.lastCheck = time.Time{}
+	if err = auth.verifyPeerCertificate([][]byte{bob.cert.Raw}, nil); err != nil {
+		t.Fatalf("unexpected error verifying cert after failed reload: %v", err)
+	}
+
+	if _, err = newClientAuthorizer("", fname, slog.Disabled); err == nil {
+		t.Fatalf("invalid allowlist was loaded")
+	}
+	if _, err = newClientAuthorizer("", "", slog.Disabled); err == nil {
+		t.Fatalf("authorizer without files was created")
+	}
+}
+
+func TestClientAuthorizerCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-clientauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "test ca", true, nil)
+	intermediate := newTestCert(t, "test intermediate", true, ca)
+	carol := newTestCert(t, "carol", false, ca)
+	dave := newTestCert(t, "dave", false, intermediate)
+	mallory := newTestCert(t, "mallory", false, nil)
+
+	fname := filepath.Join(dir, "clientca.pem")
+	if err = ioutil.WriteFile(fname, ca.pem(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := newClientAuthorizer(fname, "", slog.Disabled)
+	if err != nil {
+		t.Fatalf("unexpected error loading client CA: %v", err)
+	}
+
+	if err = auth.verifyPeerCertificate([][]byte{carol.cert.Raw}, nil); err != nil {
+		t.Fatalf("unexpected error verifying cert signed by CA: %v", err)
+	}
+	if id := auth.identity(carol.cert); id != "carol" {
+		t.Fatalf("unexpected identity %s", id)
+	}
+
+	chain := [][]byte{dave.cert.Raw, intermediate.cert.Raw}
+	if err = auth.verifyPeerCertificate(chain, nil); err != nil {
+		t.Fatalf("unexpected error verifying cert signed by intermediate: %v",
+			err)
+	}
+	if err = auth.verifyPeerCertificate(chain[:1], nil); err == nil {
+		t.Fatalf("cert without its intermediate was accepted")
+	}
+	if err = auth.verifyPeerCertificate([][]byte{mallory.cert.Raw}, nil); err == nil {
+		t.Fatalf("cert not signed by CA was accepted")
+	}
+
+	if err = ioutil.WriteFile(fname, []byte("not a cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newClientAuthorizer(fname, "", slog.Disabled); err == nil {
+		t.Fatalf("invalid client CA file was loaded")
+	}
+}
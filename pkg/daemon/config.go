@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 
@@ -32,12 +33,14 @@ type Config struct {
 
 	Port                  int    `long:"port" description:"Port to run the service on"`
 	WaitingListWSBindAddr string `long:"waitinglistwsbindaddr" description:"Address to bind the waiting list watcher websocket server. Empty disables this service"`
+	GatewayBindAddr       string `long:"gatewaybindaddr" description:"Address to bind the gRPC-Web and JSON/REST gateway of the matcher service (for browser clients). Empty disables this service"`
 	LogLevel              slog.Level
 	LogLevelName          string `long:"loglevel" description:"Log Level (CRITICAL, ERROR, WARNING, INFO, DEBUG, TRACE)"`
 	LogDir                string `long:"logdir" description:"Location to save log files."`
 	KeyFile               string `long:"keyfile" description:"Location of the rpc.key file (private key for the TLS certificate)."`
 	CertFile              string `long:"certfile" description:"Location of the rpc.cert file (TLS certificate)."`
 	SplitPoolSignKey      string `long:"splitpoolsignkey" description:"WIF private key for signing the split -> ticket intermediate pool fee txo"`
+	SplitPoolSignXPriv    string `long:"splitpoolsignxpriv" description:"Extended private key from which a different key is derived for the split -> ticket intermediate pool fee txo of each session. Takes precedence over splitpoolsignkey."`
 	DataDir               string `long:"datadir" description:"Dir where session and other data will be saved"`
 	ShowVersion           bool   `long:"version" description:"Show version and quit"`
 
@@ -58,19 +61,50 @@ type Config struct {
 	StakeDiffChangeStopWindow   int32         `long:"stakediffchangestopwindow" description:"Stop the matching service when the the stake change is closer than this number of blocks"`
 	PublishTransactions         bool          `long:"publishtransactions" description:"Whether to actually publish transactions of successful sessions"`
 	ValidateVoteAddressOnWallet bool          `long:"validatevoteaddressonwallet" description:"Whether to validate the vote addresses of participants on the wallet"`
-	PoolSubsidyWalletMasterPub  string        `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. If empty, pool fee addresses are not validated. Append a :[index] to generate addresses up to the provided index (default: 10000)."`
+	PoolSubsidyWalletMasterPub  []string      `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. If empty, pool fee addresses are not validated. May be specified multiple times. Append a :[index] to accept addresses up to the provided index regardless of the gap limit."`
+	PoolSubsidyGapLimit         uint32        `long:"poolsubsidygaplimit" description:"Number of pool fee addresses accepted past the highest index already seen for each PoolSubsidyWalletMasterPub"`
 	PoolFee                     float64       `long:"poolfee" description:"Pool fee as a percentage (eg: 5.0 = 5%)"`
+	MinFeeRate                  float64       `long:"minfeerate" description:"Minimum fee rate (in DCR/KB) used for the split and ticket transactions of sessions"`
+	MaxFeeRate                  float64       `long:"maxfeerate" description:"Maximum fee rate (in DCR/KB) used for the split and ticket transactions of sessions, regardless of the network fee estimate"`
 
 	StakepooldIntegratorHost string `long:"stakepooldintegratorhost" description:"Host to connect to for stakepoold validation"`
 	StakepooldIntegratorCert string `long:"stakepooldintegratorcert" description:"Certificate to use when connecting the stakepool integrator host"`
 
+	PoolConfigFiles  []string `long:"poolconfig" description:"Config file of an additional voting pool served by the matcher, selected by buyers by its name. May be specified multiple times."`
+	QueueConfigFiles []string `long:"queueconfig" description:"Config file of a named queue with its own participation rules (amounts, participants, session duration, vote addresses and join key). May be specified multiple times."`
+
+	PoolSignerHost       string `long:"poolsignerhost" description:"Host of a pool signer daemon (stmpoolsigner) used to sign the split -> ticket intermediate pool fee txo instead of SplitPoolSignKey"`
+	PoolSignerCert       string `long:"poolsignercert" description:"Certificate to use when connecting to the pool signer host"`
+	PoolSignerClientKey  string `long:"poolsignerclientkey" description:"Location of the private key used to authenticate to the pool signer host. Created if it does not exist."`
+	PoolSignerClientCert string `long:"poolsignerclientcert" description:"Location of the certificate used to authenticate to the pool signer host. Add it to the ClientCAFile of the pool signer."`
+
+	TrustedProxy  []string `long:"trustedproxy" description:"IP address or CIDR network of a reverse proxy (eg: nginx or a load balancer) trusted to report the original address of clients. May be specified multiple times."`
+	ProxyProtocol bool     `long:"proxyprotocol" description:"Read the PROXY protocol (v1 or v2) header of connections to the grpc service from trusted proxies"`
+
+	ClientCAFile    string `long:"clientcafile" description:"Require clients of the grpc service to present a TLS certificate signed by one of the CAs in this file. Reloaded when changed."`
+	ClientAllowlist string `long:"clientallowlist" description:"Require clients of the grpc service to present a TLS certificate with a SHA-256 fingerprint listed in this file (one '<fingerprint> <identity>' per line). Reloaded when changed."`
+
+	ACMEDomain        []string `long:"acmedomain" description:"Obtain the TLS certificate of the grpc and waiting list websocket services for this domain from an ACME CA (eg: Let's Encrypt). May be specified multiple times."`
+	ACMEDirectory     string   `long:"acmedirectory" description:"Directory URL of the ACME CA"`
+	ACMEDirectoryCert string   `long:"acmedirectorycert" description:"CA certificate used to validate the TLS certificate of the ACME directory (eg: of a test CA such as pebble)"`
+	ACMEEmail         string   `long:"acmeemail" description:"Contact email of the account with the ACME CA"`
+	ACMECacheDir      string   `long:"acmecachedir" description:"Dir where the ACME account key and certificate are stored. Defaults to the acme dir inside DataDir."`
+	ACMEHTTPBindAddr  string   `long:"acmehttpbindaddr" description:"Address (usually :80) to answer http-01 challenges. If empty, tls-alpn-01 challenges are answered by the grpc and websocket services, one of which must be reachable on port 443 of the domains."`
+
 	AllowPublicSession bool `long:"allowpublicsession" description:"Whether to allow sessions with an empty name (public sessions) in the matcher."`
 
 	KeepAliveTime    time.Duration `long:"keepalivetime" description:"Time duration between server-requested pings to individual clients to see if they are still online"`
 	KeepAliveTimeout time.Duration `long:"keepalivetimeout" description:"Time duration to wait for a reply after a keepalive ping has been sent"`
 
+	DisconnectGracePeriod time.Duration `long:"disconnectgraceperiod" description:"Time duration to wait for a participant that disconnected during a session to reconnect before canceling the session"`
+
 	SuccessfulSessionCmd string `long:"successfulsessioncmd" description:"An executable to be executed after a successful session is completed. It will receive the ticket hash as first argument."`
 
+	WebhookURLs        []string `long:"webhookurl" description:"URL to POST json notifications of session events to. May be specified multiple times."`
+	WebhookSecret      string   `long:"webhooksecret" description:"Secret used to sign webhook payloads (HMAC-SHA256, sent in the X-Dcrstmd-Signature header). Empty disables signing."`
+	WebhookMaxAttempts int      `long:"webhookmaxattempts" description:"Maximum number of attempts to deliver each webhook notification before giving up"`
+	EventsFile         string   `long:"eventsfile" description:"File where session events are appended as json lines. Empty disables the event stream file."`
+
 	logBackend *slog.Backend
 }
 
@@ -114,11 +148,16 @@ func LoadConfig() (*Config, error) {
 		KeyFile:  filepath.Join(defaultDataDir, "rpc.key"),
 		CertFile: filepath.Join(defaultDataDir, "rpc.cert"),
 
+		ACMEDirectory: acme.LetsEncryptURL,
+
 		DcrdHost: "localhost:19109",
 		DcrdUser: "USER",
 		DcrdPass: "PASSWORD",
 		DcrdCert: filepath.Join(dcrutil.AppDataDir("dcrd", false), "rpc.cert"),
 
+		PoolSignerClientKey:  filepath.Join(defaultDataDir, "poolsigner-client.key"),
+		PoolSignerClientCert: filepath.Join(defaultDataDir, "poolsigner-client.cert"),
+
 		DcrwHost: "localhost:19110",
 		DcrwUser: "USER",
 		DcrwPass: "PASSWORD",
@@ -129,11 +168,18 @@ func LoadConfig() (*Config, error) {
 		PublishTransactions:         false,
 		AllowPublicSession:          false,
 		ValidateVoteAddressOnWallet: false,
-		PoolSubsidyWalletMasterPub:  "",
+		PoolSubsidyWalletMasterPub:  nil,
+		PoolSubsidyGapLimit:         util.DefaultPoolAddrGapLimit,
 		PoolFee:                     splitticket.MaxPoolFeeRateMainnet,
+		MinFeeRate:                  splitticket.TxFeeRate.ToCoin(),
+		MaxFeeRate:                  0.01,
 
 		KeepAliveTime:    60 * time.Second,
 		KeepAliveTimeout: 5 * time.Second,
+
+		DisconnectGracePeriod: 10 * time.Second,
+
+		WebhookMaxAttempts: 10,
 	}
 
 	parser := flags.NewParser(cfg, flags.Default)
@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
 	"github.com/decred/slog"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -20,6 +22,8 @@ import (
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/poolintegrator"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/poolsigner"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/version"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -36,6 +40,11 @@ type Daemon struct {
 	dcrd         *decredNetwork
 	grpcListener net.Listener
 	waitlistSvc  *waitlistWebsocketService
+	gatewaySvc   *gatewayService
+	integrators  map[string]*poolintegrator.Client
+	notifier     *notifier
+	clientAuth   *clientAuthorizer
+	acme         *acmeManager
 }
 
 // NewDaemon returns a new daemon instance and prepares it to listen to
@@ -56,9 +65,36 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		return nil, errors.New("Cannot use pool fee less than 0.1%")
 	}
 
+	minFeeRate, err := dcrutil.NewAmount(cfg.MinFeeRate)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid minimum fee rate")
+	}
+	maxFeeRate, err := dcrutil.NewAmount(cfg.MaxFeeRate)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid maximum fee rate")
+	}
+	if minFeeRate < splitticket.MinTxFeeRate {
+		return nil, errors.Errorf("Cannot use minimum fee rate less than %s/KB",
+			splitticket.MinTxFeeRate)
+	}
+	if maxFeeRate < minFeeRate {
+		return nil, errors.New("Maximum fee rate cannot be less than the " +
+			"minimum fee rate")
+	}
+
+	trusted, err := parseTrustedProxies(cfg.TrustedProxy)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ProxyProtocol && len(trusted) == 0 {
+		return nil, errors.New("proxyprotocol requires at least one " +
+			"trustedproxy")
+	}
+
 	d := &Daemon{
-		cfg: cfg,
-		log: cfg.logger("DAEM"),
+		cfg:         cfg,
+		log:         cfg.logger("DAEM"),
+		integrators: make(map[string]*poolintegrator.Client),
 	}
 
 	d.log.Criticalf("Starting dcrstmd version %s", version.String())
@@ -69,6 +105,8 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		CertFile:    cfg.DcrdCert,
 		User:        cfg.DcrdUser,
 		Log:         cfg.logger("DCRD"),
+		MinFeeRate:  minFeeRate,
+		MaxFeeRate:  maxFeeRate,
 		chainParams: chainParams,
 	}
 	dcrd, err := connectToDecredNode(dcfg)
@@ -108,6 +146,37 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		d.rpcKeys = cert
 	}
 
+	if cfg.ClientCAFile != "" || cfg.ClientAllowlist != "" {
+		d.clientAuth, err = newClientAuthorizer(cfg.ClientCAFile,
+			cfg.ClientAllowlist, cfg.logger("AUTH"))
+		if err != nil {
+			return nil, err
+		}
+		d.log.Infof("Requiring client certificates on the grpc service")
+	}
+
+	if len(cfg.ACMEDomain) > 0 {
+		cacheDir := cfg.ACMECacheDir
+		if cacheDir == "" {
+			cacheDir = filepath.Join(cfg.DataDir, "acme")
+		}
+		acfg := &acmeConfig{
+			Domains:       cfg.ACMEDomain,
+			DirectoryURL:  cfg.ACMEDirectory,
+			DirectoryCert: cfg.ACMEDirectoryCert,
+			Email:         cfg.ACMEEmail,
+			CacheDir:      cacheDir,
+			HTTPBindAddr:  cfg.ACMEHTTPBindAddr,
+			Log:           cfg.logger("ACME"),
+		}
+		d.acme, err = newACMEManager(acfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "error initializing acme")
+		}
+		d.log.Infof("Using TLS certificates for %s from %s",
+			strings.Join(cfg.ACMEDomain, ", "), cfg.ACMEDirectory)
+	}
+
 	minAmount, err := dcrutil.NewAmount(cfg.MinAmount)
 	if err != nil {
 		panic(err)
@@ -132,6 +201,7 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "error initializing sakepoold integrator client")
 		}
+		d.integrators[matcher.DefaultPoolName] = stakepooldIntegrator
 	}
 
 	var voteAddrValidator matcher.VoteAddressValidationProvider
@@ -147,14 +217,18 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 	}
 
 	var poolAddrValidator matcher.PoolAddressValidationProvider
-	if cfg.PoolSubsidyWalletMasterPub != "" {
+	if len(cfg.PoolSubsidyWalletMasterPub) > 0 {
 		poolAddrValidator, err = util.NewMasterPubPoolAddrValidator(
-			cfg.PoolSubsidyWalletMasterPub, chainParams)
+			cfg.PoolSubsidyWalletMasterPub, cfg.PoolSubsidyGapLimit,
+			filepath.Join(cfg.DataDir, util.PoolAddrIndexFilename), d.log,
+			chainParams)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error deriving pool subsidy "+
 				"addresses from masterpubkey")
 		}
-		d.log.Infof("Validating pool subsidy addresses with masterPubKey")
+		d.log.Infof("Validating pool subsidy addresses with %d masterPubKey(s) "+
+			"(gap limit %d)", len(cfg.PoolSubsidyWalletMasterPub),
+			cfg.PoolSubsidyGapLimit)
 	} else if stakepooldIntegrator != nil {
 		poolAddrValidator = stakepooldIntegrator
 		d.log.Info("Using stakepoold integrator to validate pool subsidy addresses")
@@ -163,17 +237,55 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		d.log.Infof("Not validating pool subsidy addresses")
 	}
 
-	poolSigner, err := newPrivateKeySplitPoolSigner(cfg.SplitPoolSignKey,
-		chainParams)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error decoding private key for split "+
-			"pool signing")
+	var poolSigner matcher.SignPoolSplitOutputProvider
+	if cfg.PoolSignerHost != "" {
+		var clientCert *tls.Certificate
+		clientCert, err = util.LoadRPCKeyPair(cfg.PoolSignerClientKey,
+			cfg.PoolSignerClientCert)
+		if err == util.ErrKeyPairCreated {
+			d.log.Criticalf("Generated pool signer client key (%s) and cert "+
+				"(%s) files. Add the cert to the ClientCAFile of the pool "+
+				"signer", cfg.PoolSignerClientKey, cfg.PoolSignerClientCert)
+		} else if err != nil {
+			return nil, errors.Wrap(err, "error loading pool signer client "+
+				"key pair")
+		}
+
+		poolSigner, err = poolsigner.NewClient(cfg.PoolSignerHost,
+			cfg.PoolSignerCert, clientCert, chainParams)
+		if err != nil {
+			return nil, errors.Wrap(err, "error initializing pool signer client")
+		}
+		d.log.Infof("Using pool signer at %s to sign pool fee inputs",
+			cfg.PoolSignerHost)
+	} else if cfg.SplitPoolSignXPriv != "" {
+		var hdSigner *poolsigner.HDKeySigner
+		hdSigner, err = poolsigner.NewHDKeySigner(cfg.SplitPoolSignXPriv,
+			filepath.Join(cfg.DataDir, poolsigner.KeyIndexFilename), chainParams)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding extended private key "+
+				"for split pool signing")
+		}
+		poolSigner = hdSigner
+		d.log.Infof("Deriving pool fee addresses from extended key (next "+
+			"index %d)", hdSigner.NextIndex())
+	} else {
+		var keySigner *poolsigner.KeySigner
+		keySigner, err = poolsigner.NewKeySigner(cfg.SplitPoolSignKey,
+			chainParams)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding private key for split "+
+				"pool signing")
+		}
+		poolSigner = keySigner
+		d.log.Infof("Using address %s to move pool fee funds from split to ticket",
+			keySigner.Address().EncodeAddress())
 	}
-	d.log.Infof("Using address %s to move pool fee funds from split to ticket",
-		poolSigner.address.EncodeAddress())
 
 	d.log.Infof("Using keepalive timeout of %s / %s", cfg.KeepAliveTime,
 		cfg.KeepAliveTimeout)
+	d.log.Infof("Waiting %s for disconnected participants to reconnect",
+		cfg.DisconnectGracePeriod)
 
 	mcfg := &matcher.Config{
 		MinAmount:                 uint64(minAmount),
@@ -189,16 +301,86 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		StakeDiffChangeStopWindow: cfg.StakeDiffChangeStopWindow,
 		PublishTransactions:       cfg.PublishTransactions,
 		SessionDataDir:            filepath.Join(cfg.DataDir, "sessions"),
+		DisconnectGracePeriod:     cfg.DisconnectGracePeriod,
+	}
+
+	for _, fname := range cfg.PoolConfigFiles {
+		var pcfg *PoolConfig
+		pcfg, err = loadPoolConfig(fname)
+		if err != nil {
+			return nil, err
+		}
+		var pool *matcher.Pool
+		pool, err = d.newPool(pcfg, chainParams)
+		if err != nil {
+			return nil, err
+		}
+		mcfg.Pools = append(mcfg.Pools, pool)
+	}
+
+	for _, fname := range cfg.QueueConfigFiles {
+		var qcfg *matcher.QueueConfig
+		qcfg, err = loadQueueConfig(fname, chainParams)
+		if err != nil {
+			return nil, err
+		}
+		if len(qcfg.AllowedClients) > 0 && d.clientAuth == nil {
+			return nil, errors.Errorf("queue config file %s restricts the "+
+				"allowed clients but neither clientcafile nor "+
+				"clientallowlist were specified", fname)
+		}
+		mcfg.Queues = append(mcfg.Queues, qcfg)
+		d.log.Infof("Declared queue %s of pool '%s' (private: %v)",
+			encodeQueueName(qcfg.Name)[:10], qcfg.Pool, len(qcfg.JoinKey) > 0)
+	}
+
+	if len(d.integrators) > 0 && cfg.PublishTransactions {
+		d.log.Infof("Registering tickets with stakepoold integrators")
+	} else if len(d.integrators) > 0 {
+		d.log.Infof("Forwarding vote choices to stakepoold integrators")
+	}
+
+	if len(cfg.WebhookURLs) > 0 || cfg.EventsFile != "" {
+		if cfg.WebhookMaxAttempts < 1 {
+			return nil, errors.New("webhookmaxattempts must be at least 1")
+		}
+		ncfg := &notifierConfig{
+			WebhookURLs: cfg.WebhookURLs,
+			Secret:      []byte(cfg.WebhookSecret),
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			RetryDelay:  5 * time.Second,
+			OutboxDir:   filepath.Join(cfg.DataDir, "outbox"),
+			EventsFile:  cfg.EventsFile,
+			Log:         cfg.logger("NTFN"),
+		}
+		d.notifier, err = newNotifier(ncfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "error initializing notifier")
+		}
+		d.log.Infof("Sending session notifications to %d webhooks",
+			len(cfg.WebhookURLs))
+		if cfg.WebhookSecret == "" && len(cfg.WebhookURLs) > 0 {
+			d.log.Warnf("Webhook payloads will not be signed")
+		}
+
+		mcfg.SessionStartedNtfn = d.onSessionStartedNtfn
+		mcfg.SessionFailedNtfn = d.onSessionFailedNtfn
+		mcfg.PublishFailedNtfn = d.onPublishFailedNtfn
 	}
-	if cfg.SuccessfulSessionCmd != "" {
+
+	if cfg.SuccessfulSessionCmd != "" || len(d.integrators) > 0 ||
+		d.notifier != nil {
 		mcfg.SuccessfulSesssionNtfn = d.onSuccessfulSessionNtfn
 	}
-	d.matcher = matcher.NewMatcher(mcfg)
+	d.matcher, err = matcher.NewMatcher(mcfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing matcher")
+	}
 
 	if cfg.WaitingListWSBindAddr != "" {
 		var wssvc *waitlistWebsocketService
 		wssvc, err = newWaitlistWebsocketService(cfg.WaitingListWSBindAddr,
-			d.matcher, cfg.logger("WLWS"))
+			d.matcher, trusted, cfg.logger("WLWS"))
 		if err != nil {
 			return nil, errors.Wrapf(err, "error starting waitlist "+
 				"websocket service")
@@ -210,22 +392,132 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		d.log.Info("Skipping start of websocket waiting list service")
 	}
 
+	if cfg.GatewayBindAddr != "" {
+		d.gatewaySvc, err = newGatewayService(cfg.GatewayBindAddr, trusted,
+			cfg.logger("GTWY"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error starting gateway service")
+		}
+		d.log.Criticalf("gRPC-Web/REST gateway listening on %s",
+			cfg.GatewayBindAddr)
+	}
+
 	intf := fmt.Sprintf(":%d", cfg.Port)
 	lis, err := net.Listen("tcp", intf)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error listening on interface %s", intf)
 	}
+	if cfg.ProxyProtocol {
+		lis = &proxyProtoListener{Listener: lis, trusted: trusted}
+		d.log.Infof("Reading PROXY protocol headers from trusted proxies")
+	}
 	d.grpcListener = lis
 	d.log.Criticalf("GRPC service listening on %s", intf)
 
 	return d, nil
 }
 
-func (daemon *Daemon) onSuccessfulSessionNtfn(ticketHash chainhash.Hash) {
-	if daemon.cfg.SuccessfulSessionCmd == "" {
+func (daemon *Daemon) onSessionStartedNtfn(sess *matcher.Session) {
+	daemon.notifier.notify(newSessionEvent(eventSessionStarted, sess))
+}
+
+func (daemon *Daemon) onSessionFailedNtfn(sess *matcher.Session, err error) {
+	e := newSessionEvent(eventSessionFailed, sess)
+	e.Reason = err.Error()
+	daemon.notifier.notify(e)
+}
+
+func (daemon *Daemon) onPublishFailedNtfn(sess *matcher.Session, ticket,
+	splitTx *wire.MsgTx, err error) {
+
+	e := newSessionEvent(eventPublishFailed, sess)
+	e.Reason = err.Error()
+	e.setTransactions(ticket, splitTx)
+	daemon.notifier.notify(e)
+}
+
+func (daemon *Daemon) onSuccessfulSessionNtfn(sess *matcher.Session, ticket,
+	splitTx *wire.MsgTx) {
+
+	ticketHash := ticket.TxHash()
+
+	if daemon.notifier != nil {
+		e := newSessionEvent(eventSessionSucceeded, sess)
+		e.setTransactions(ticket, splitTx)
+		daemon.notifier.notify(e)
+	}
+
+	integrator := daemon.integrators[sess.Pool]
+	if integrator != nil && daemon.cfg.PublishTransactions {
+		daemon.registerTicketWithPool(integrator, sess, ticket)
+	} else if integrator != nil {
+		// The ticket is not published by the matcher, so it can't be
+		// registered yet, but the pool may still use the voter's choices
+		// once the ticket is published by other means.
+		daemon.forwardVoteChoices(integrator, sess, &ticketHash)
+	}
+
+	if daemon.cfg.SuccessfulSessionCmd != "" {
+		daemon.runSuccessfulSessionCmd(ticketHash)
+	}
+}
+
+// registerTicketWithPool registers the ticket of a successful session with the
+// voting pool of the session (through its stakepoold integrator), sets its
+// vote bits according to the selected voter's choices and logs the resulting
+// voting status.
+func (daemon *Daemon) registerTicketWithPool(integrator *poolintegrator.Client,
+	sess *matcher.Session, ticket *wire.MsgTx) {
+
+	ticketHash := ticket.TxHash()
+	voter := sess.Participants[sess.VoterIndex]
+
+	err := integrator.RegisterTicket(ticket)
+	if err != nil {
+		daemon.log.Errorf("Error registering ticket %s with voting pool: %v",
+			ticketHash, err)
 		return
 	}
 
+	daemon.forwardVoteChoices(integrator, sess, &ticketHash)
+
+	status, err := integrator.TicketVotingStatus(&ticketHash,
+		voter.VoteAddress)
+	if err != nil {
+		daemon.log.Errorf("Error fetching voting status of ticket %s: %v",
+			ticketHash, err)
+		return
+	}
+
+	daemon.log.Infof("Ticket %s registered with voting pool (status %s, "+
+		"vote version %d, vote bits %.4x)", ticketHash, status.Status,
+		status.VoteVersion, status.VoteBits)
+}
+
+// forwardVoteChoices sends the vote choices of the selected voter of a
+// successful session to the voting pool of the session, so that it sets the
+// vote bits of the ticket accordingly.
+func (daemon *Daemon) forwardVoteChoices(integrator *poolintegrator.Client,
+	sess *matcher.Session, ticketHash *chainhash.Hash) {
+
+	if len(sess.VoteChoices) == 0 {
+		return
+	}
+
+	voter := sess.Participants[sess.VoterIndex]
+	voteBits, err := integrator.SetVoteChoices(ticketHash, voter.VoteAddress,
+		voter.CommitmentAddress, sess.VoteChoices, voter.VoteChoicesSignature)
+	if err != nil {
+		daemon.log.Errorf("Error setting vote choices of ticket %s: %v",
+			ticketHash, err)
+		return
+	}
+
+	daemon.log.Infof("Set vote choices %s (vote bits %.4x) of ticket %s",
+		sess.VoteChoices, voteBits, ticketHash)
+}
+
+func (daemon *Daemon) runSuccessfulSessionCmd(ticketHash chainhash.Hash) {
 	daemon.log.Debugf("Running cmd after successful session: '%s %s'",
 		daemon.cfg.SuccessfulSessionCmd, ticketHash.String())
 	cmd := exec.Command(daemon.cfg.SuccessfulSessionCmd, ticketHash.String())
@@ -254,10 +546,22 @@ func (daemon *Daemon) Run(serverCtx context.Context) error {
 	}
 	go daemon.matcher.Run(serverCtx)
 	go daemon.dcrd.run(serverCtx)
+	if daemon.notifier != nil {
+		go daemon.notifier.run(serverCtx)
+	}
+
+	if daemon.acme != nil {
+		go daemon.acme.run(serverCtx)
+	}
 
 	if daemon.waitlistSvc != nil {
-		go daemon.waitlistSvc.run(serverCtx, daemon.cfg.CertFile,
-			daemon.cfg.KeyFile)
+		wsTLSCfg := &tls.Config{
+			Certificates: []tls.Certificate{*daemon.rpcKeys},
+		}
+		if daemon.acme != nil {
+			daemon.acme.configureTLS(wsTLSCfg)
+		}
+		go daemon.waitlistSvc.run(serverCtx, wsTLSCfg)
 	}
 
 	keepAlive := keepalive.ServerParameters{
@@ -269,14 +573,28 @@ func (daemon *Daemon) Run(serverCtx context.Context) error {
 		PermitWithoutStream: true,
 	}
 
-	creds := credentials.NewServerTLSFromCert(daemon.rpcKeys)
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{*daemon.rpcKeys}}
+	if daemon.clientAuth != nil {
+		tlsCfg = daemon.clientAuth.tlsConfig(daemon.rpcKeys)
+	}
+	if daemon.acme != nil {
+		daemon.acme.configureTLS(tlsCfg)
+	}
+	creds := credentials.NewTLS(tlsCfg)
 	server := grpc.NewServer(grpc.Creds(creds), grpc.KeepaliveParams(keepAlive),
-		grpc.KeepaliveEnforcementPolicy(keepAlivePolicy))
+		grpc.KeepaliveEnforcementPolicy(keepAlivePolicy),
+		grpc.StatsHandler(connTracker{}))
 
 	svc := NewSplitTicketMatcherService(daemon.matcher, daemon.dcrd,
 		daemon.cfg.AllowPublicSession, daemon.cfg.logger("MSVC"))
+	svc.clientAuth = daemon.clientAuth
 	pb.RegisterSplitTicketMatcherServiceServer(server, svc)
 
+	if daemon.gatewaySvc != nil {
+		daemon.gatewaySvc.setService(svc)
+		go daemon.gatewaySvc.run(serverCtx, tlsCfg)
+	}
+
 	daemon.log.Criticalf("Running daemon on pid %d", os.Getpid())
 
 	go func() {
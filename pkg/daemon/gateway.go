@@ -0,0 +1,563 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/matcherrpc"
+)
+
+const (
+	// gatewayServiceName is the full name of the grpc service, used as the
+	// path prefix of gRPC-Web calls.
+	gatewayServiceName = "dcrticketmatcher.SplitTicketMatcherService"
+
+	// gatewayRESTPrefix is the path prefix of the JSON/REST calls.
+	gatewayRESTPrefix = "/v1/"
+
+	// gatewayMaxRequestSize is the maximum size of the request bodies. This is
+	// the same as the default maximum message size of grpc servers.
+	gatewayMaxRequestSize = 4 * 1024 * 1024
+
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// grpcWebTrailerFlag is the flag of the frame with the trailers of a
+	// gRPC-Web response.
+	grpcWebTrailerFlag = 0x80
+)
+
+// gatewayMethod is a method of the matcher service callable through the
+// gateway. Exactly one of call or stream is specified.
+type gatewayMethod struct {
+	newRequest func() proto.Message
+	call       func(ctx context.Context, req proto.Message) (proto.Message, error)
+	stream     func(ctx context.Context, req proto.Message,
+		send func(proto.Message) error) error
+
+	// allowGET is true for methods that can be called on the REST mapping
+	// with a GET request, with the fields of the request as query parameters.
+	allowGET bool
+}
+
+func gatewayMethods(svc pb.SplitTicketMatcherServiceServer) map[string]*gatewayMethod {
+	return map[string]*gatewayMethod{
+		"FindMatches": {
+			newRequest: func() proto.Message { return new(pb.FindMatchesRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.FindMatches(ctx, req.(*pb.FindMatchesRequest))
+			},
+		},
+		"GenerateTicket": {
+			newRequest: func() proto.Message { return new(pb.GenerateTicketRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.GenerateTicket(ctx, req.(*pb.GenerateTicketRequest))
+			},
+		},
+		"FundTicket": {
+			newRequest: func() proto.Message { return new(pb.FundTicketRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.FundTicket(ctx, req.(*pb.FundTicketRequest))
+			},
+		},
+		"FundSplitTx": {
+			newRequest: func() proto.Message { return new(pb.FundSplitTxRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.FundSplitTx(ctx, req.(*pb.FundSplitTxRequest))
+			},
+		},
+		"Status": {
+			newRequest: func() proto.Message { return new(pb.StatusRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.Status(ctx, req.(*pb.StatusRequest))
+			},
+			allowGET: true,
+		},
+		"BuyerError": {
+			newRequest: func() proto.Message { return new(pb.BuyerErrorRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.BuyerError(ctx, req.(*pb.BuyerErrorRequest))
+			},
+		},
+		"EstimateWait": {
+			newRequest: func() proto.Message { return new(pb.EstimateWaitRequest) },
+			call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return svc.EstimateWait(ctx, req.(*pb.EstimateWaitRequest))
+			},
+			allowGET: true,
+		},
+		"WatchWaitingList": {
+			newRequest: func() proto.Message { return new(pb.WatchWaitingListRequest) },
+			stream: func(ctx context.Context, req proto.Message,
+				send func(proto.Message) error) error {
+
+				stream := &gatewayWatchWaitingListStream{ctx: ctx, send: send}
+				return svc.WatchWaitingList(req.(*pb.WatchWaitingListRequest),
+					stream)
+			},
+			allowGET: true,
+		},
+	}
+}
+
+// gatewayWatchWaitingListStream fulfills
+// pb.SplitTicketMatcherService_WatchWaitingListServer for calls performed
+// through the gateway.
+type gatewayWatchWaitingListStream struct {
+	ctx  context.Context
+	send func(proto.Message) error
+}
+
+func (s *gatewayWatchWaitingListStream) Send(m *pb.WatchWaitingListResponse) error {
+	return s.send(m)
+}
+
+func (s *gatewayWatchWaitingListStream) SetHeader(metadata.MD) error  { return nil }
+func (s *gatewayWatchWaitingListStream) SendHeader(metadata.MD) error { return nil }
+func (s *gatewayWatchWaitingListStream) SetTrailer(metadata.MD)       {}
+func (s *gatewayWatchWaitingListStream) Context() context.Context     { return s.ctx }
+func (s *gatewayWatchWaitingListStream) RecvMsg(m interface{}) error  { return io.EOF }
+
+func (s *gatewayWatchWaitingListStream) SendMsg(m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return errors.Errorf("unexpected message type %T", m)
+	}
+	return s.send(msg)
+}
+
+// gatewayAddr is the address of a client of the gateway, as seen by the
+// matcher service.
+type gatewayAddr string
+
+func (a gatewayAddr) Network() string { return "tcp" }
+func (a gatewayAddr) String() string  { return string(a) }
+
+// gatewayService serves the matcher service to clients that can't use native
+// grpc (such as browsers), through gRPC-Web and through a JSON/REST mapping of
+// the service methods.
+type gatewayService struct {
+	log      slog.Logger
+	server   *http.Server
+	listener net.Listener
+	trusted  trustedProxies
+	methods  map[string]*gatewayMethod
+}
+
+func newGatewayService(bindAddr string, trusted trustedProxies,
+	log slog.Logger) (*gatewayService, error) {
+
+	ln, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error binding gateway service to "+
+			"address %s", bindAddr)
+	}
+
+	gw := &gatewayService{
+		log:      log,
+		listener: ln,
+		trusted:  trusted,
+	}
+	gw.server = &http.Server{
+		Addr:              bindAddr,
+		Handler:           gw,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return gw, nil
+}
+
+// setService sets the matcher service called by the gateway.
+func (gw *gatewayService) setService(svc pb.SplitTicketMatcherServiceServer) {
+	gw.methods = gatewayMethods(svc)
+}
+
+// ServeHTTP fulfills http.Handler.
+func (gw *gatewayService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The gateway is meant to be used by web pages hosted anywhere.
+	hdr := w.Header()
+	hdr.Set("Access-Control-Allow-Origin", "*")
+	hdr.Set("Access-Control-Expose-Headers", "grpc-status, grpc-message")
+	if r.Method == http.MethodOptions {
+		hdr.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		hdr.Set("Access-Control-Allow-Headers", "content-type, x-grpc-web, "+
+			"x-user-agent, grpc-timeout")
+		hdr.Set("Access-Control-Max-Age", "86400")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/"+gatewayServiceName+"/") {
+		gw.serveGRPCWeb(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, gatewayRESTPrefix) {
+		gw.serveREST(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// callContext returns the context for a call of the matcher service performed
+// by the given request, with the (grpc) peer information expected by the
+// service.
+func (gw *gatewayService) callContext(r *http.Request) context.Context {
+	pr := &peer.Peer{Addr: gatewayAddr("[gw]" + gw.trusted.clientAddr(r))}
+	if r.TLS != nil {
+		pr.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	return peer.NewContext(r.Context(), pr)
+}
+
+// call performs the call of the given method. Responses are sent through the
+// send function, which is called once for unary methods.
+func (gw *gatewayService) call(ctx context.Context, method *gatewayMethod,
+	req proto.Message, send func(proto.Message) error) error {
+
+	if method.stream != nil {
+		return method.stream(ctx, req, send)
+	}
+
+	resp, err := method.call(ctx, req)
+	if err != nil {
+		return err
+	}
+	return send(resp)
+}
+
+// serveGRPCWeb serves a gRPC-Web call.
+func (gw *gatewayService) serveGRPCWeb(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	isText := strings.HasPrefix(contentType, grpcWebTextContentType)
+	if r.Method != http.MethodPost ||
+		!strings.HasPrefix(contentType, grpcWebContentType) {
+
+		http.Error(w, "invalid gRPC-Web request", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if isText {
+		w.Header().Set("Content-Type", grpcWebTextContentType+"+proto")
+	} else {
+		w.Header().Set("Content-Type", grpcWebContentType+"+proto")
+	}
+	flusher, _ := w.(http.Flusher)
+
+	// Frames of -text responses are base64 encoded individually, so that
+	// they can be decoded as soon as they are received.
+	writeFrame := func(flag byte, data []byte) error {
+		frame := make([]byte, 5+len(data))
+		frame[0] = flag
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+		copy(frame[5:], data)
+		if isText {
+			frame = []byte(base64.StdEncoding.EncodeToString(frame))
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	writeStatus := func(err error) {
+		st := status.Convert(err)
+		trailers := fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\n",
+			st.Code(), encodeGRPCMessage(st.Message()))
+		writeFrame(grpcWebTrailerFlag, []byte(trailers))
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/"+gatewayServiceName+"/")
+	method, has := gw.methods[name]
+	if !has {
+		writeStatus(status.Errorf(codes.Unimplemented, "unknown method %s",
+			name))
+		return
+	}
+
+	req := method.newRequest()
+	if err := readGRPCWebRequest(r, isText, req); err != nil {
+		writeStatus(status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	ctx := gw.callContext(r)
+	if timeout, ok := parseGRPCTimeout(r.Header.Get("grpc-timeout")); ok {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := gw.call(ctx, method, req, func(resp proto.Message) error {
+		b, err := proto.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		return writeFrame(0, b)
+	})
+	if r.Context().Err() != nil {
+		// Client is gone.
+		return
+	}
+	if err != nil {
+		gw.log.Debugf("Error in gRPC-Web call %s: %v", name, err)
+	}
+	writeStatus(err)
+}
+
+// readGRPCWebRequest reads the message of a gRPC-Web request.
+func readGRPCWebRequest(r *http.Request, isText bool, req proto.Message) error {
+	var body io.Reader = http.MaxBytesReader(nil, r.Body, gatewayMaxRequestSize)
+	if isText {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return errors.Wrap(err, "error reading request")
+	}
+	if len(b) < 5 || b[0] != 0 {
+		return errors.New("invalid request frame")
+	}
+	size := binary.BigEndian.Uint32(b[1:])
+	if uint32(len(b)-5) < size {
+		return errors.New("truncated request frame")
+	}
+
+	return proto.Unmarshal(b[5:5+size], req)
+}
+
+// encodeGRPCMessage percent-encodes a status message for the grpc-message
+// trailer.
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// parseGRPCTimeout parses the value of a grpc-timeout header.
+func parseGRPCTimeout(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, has := units[s[len(s)-1]]
+	if !has {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return time.Duration(v) * unit, true
+}
+
+// restError is the body of the responses of failed REST calls.
+type restError struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+// restStreamResult is the body of each message sent by the REST mapping of
+// streaming methods. Messages are separated by newlines.
+type restStreamResult struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *restError      `json:"error,omitempty"`
+}
+
+// httpStatusFromCode returns the http status corresponding to a grpc status
+// code.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// readRESTRequest reads the request of a REST call, from the JSON body of POST
+// requests or the query parameters of GET requests.
+func readRESTRequest(r *http.Request, req proto.Message) error {
+
+	unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+
+	if r.Method == http.MethodGet {
+		// Query values that are valid JSON (numbers and booleans) are used
+		// as is, while others are used as strings.
+		fields := make(map[string]json.RawMessage, len(r.URL.Query()))
+		for k, vs := range r.URL.Query() {
+			v := vs[len(vs)-1]
+			var raw json.RawMessage
+			if json.Unmarshal([]byte(v), &raw) == nil &&
+				!strings.HasPrefix(v, "{") && !strings.HasPrefix(v, "[") {
+				fields[k] = raw
+			} else {
+				fields[k], _ = json.Marshal(v)
+			}
+		}
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		return unmarshaler.Unmarshal(bytes.NewReader(b), req)
+	}
+
+	b, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body,
+		gatewayMaxRequestSize))
+	if err != nil {
+		return errors.Wrap(err, "error reading request")
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+	return unmarshaler.Unmarshal(bytes.NewReader(b), req)
+}
+
+// serveREST serves a call of the JSON/REST mapping of the matcher service.
+func (gw *gatewayService) serveREST(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	writeError := func(err error) {
+		st := status.Convert(err)
+		w.WriteHeader(httpStatusFromCode(st.Code()))
+		json.NewEncoder(w).Encode(&restError{st.Code(), st.Message()})
+	}
+
+	// Methods are mapped to paths with their names in lower camel case (eg:
+	// /v1/findMatches).
+	name := strings.TrimPrefix(r.URL.Path, gatewayRESTPrefix)
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	method, has := gw.methods[name]
+	if !has {
+		writeError(status.Errorf(codes.NotFound, "unknown method %s", name))
+		return
+	}
+	if r.Method != http.MethodPost && !(r.Method == http.MethodGet &&
+		method.allowGET) {
+
+		writeError(status.Errorf(codes.Unimplemented, "method %s not "+
+			"allowed for %s", r.Method, name))
+		return
+	}
+
+	req := method.newRequest()
+	if err := readRESTRequest(r, req); err != nil {
+		writeError(status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	marshaler := jsonpb.Marshaler{EmitDefaults: true}
+	ctx := gw.callContext(r)
+
+	if method.stream == nil {
+		var resp proto.Message
+		err := gw.call(ctx, method, req, func(m proto.Message) error {
+			resp = m
+			return nil
+		})
+		if err != nil {
+			writeError(err)
+			return
+		}
+		marshaler.Marshal(w, resp)
+		return
+	}
+
+	// Responses of streaming calls are sent as they are generated, one JSON
+	// object per line.
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	err := gw.call(ctx, method, req, func(m proto.Message) error {
+		s, err := marshaler.MarshalToString(m)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(&restStreamResult{Result: json.RawMessage(s)})
+		if err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		st := status.Convert(err)
+		enc.Encode(&restStreamResult{Error: &restError{st.Code(),
+			st.Message()}})
+	}
+}
+
+func (gw *gatewayService) run(serverCtx context.Context, tlsCfg *tls.Config) error {
+	go func() {
+		<-serverCtx.Done()
+
+		// Close (instead of shutting down) the server, so that streaming
+		// calls are canceled.
+		gw.server.Close()
+	}()
+
+	gw.server.TLSConfig = tlsCfg
+	return gw.server.ServeTLS(gw.listener, "", "")
+}
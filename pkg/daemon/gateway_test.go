@@ -0,0 +1,356 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/version"
+
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/matcherrpc"
+)
+
+type testPoolSigner struct{}
+
+func (s testPoolSigner) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	return testAddress(0xfe), 0, nil
+}
+
+func (s testPoolSigner) SignPoolSplitOutput(split, ticket *wire.MsgTx, keyIndex uint32) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+// newTestGateway returns an httptest server for a gateway of a matcher service
+// that allows public sessions.
+func newTestGateway(t *testing.T, ctx context.Context) *httptest.Server {
+	m, err := matcher.NewMatcher(&matcher.Config{
+		MinAmount:                1e8,
+		NetworkProvider:          testNetwork{},
+		SignPoolSplitOutProvider: testPoolSigner{},
+		VoteAddrValidator:        matcher.InsecurePoolAddressesValidator{},
+		PoolAddrValidator:        matcher.InsecurePoolAddressesValidator{},
+		Log:                      slog.Disabled,
+		SessionLog:               slog.Disabled,
+		ChainParams:              &chaincfg.TestNet3Params,
+		MaxSessionDuration:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go m.Run(ctx)
+
+	gw, err := newGatewayService("127.0.0.1:0", nil, slog.Disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw.listener.Close()
+	gw.setService(NewSplitTicketMatcherService(m, testNetwork{}, true,
+		slog.Disabled))
+
+	return httptest.NewServer(gw)
+}
+
+// grpcWebCall performs a gRPC-Web call and returns the data frames and the
+// trailers of the response.
+func grpcWebCall(t *testing.T, srv *httptest.Server, method string,
+	req proto.Message, text bool) ([][]byte, string) {
+
+	b, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := make([]byte, 5+len(b))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(b)))
+	copy(body[5:], b)
+
+	contentType := grpcWebContentType + "+proto"
+	if text {
+		contentType = grpcWebTextContentType
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+
+	resp, err := http.Post(srv.URL+"/"+gatewayServiceName+"/"+method,
+		contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected http status %d", resp.StatusCode)
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each frame of -text responses is base64 encoded individually.
+	var frames []byte
+	if text {
+		for len(respBody) > 0 {
+			n := bytes.IndexByte(respBody, '=')
+			for n > -1 && n+1 < len(respBody) && respBody[n+1] == '=' {
+				n++
+			}
+			if n == -1 {
+				n = len(respBody) - 1
+			}
+			dec, err := base64.StdEncoding.DecodeString(string(respBody[:n+1]))
+			if err != nil {
+				t.Fatalf("invalid base64 response: %v", err)
+			}
+			frames = append(frames, dec...)
+			respBody = respBody[n+1:]
+		}
+	} else {
+		frames = respBody
+	}
+
+	var data [][]byte
+	for len(frames) >= 5 {
+		size := binary.BigEndian.Uint32(frames[1:])
+		payload := frames[5 : 5+size]
+		if frames[0] == grpcWebTrailerFlag {
+			return data, string(payload)
+		}
+		data = append(data, payload)
+		frames = frames[5+size:]
+	}
+	t.Fatalf("response without trailers")
+	return nil, ""
+}
+
+func TestGatewayGRPCWeb(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newTestGateway(t, ctx)
+	defer srv.Close()
+
+	for _, text := range []bool{false, true} {
+		data, trailers := grpcWebCall(t, srv, "Status", &pb.StatusRequest{},
+			text)
+		if !strings.Contains(trailers, "grpc-status: 0\r\n") {
+			t.Fatalf("unexpected trailers %q", trailers)
+		}
+		if len(data) != 1 {
+			t.Fatalf("unexpected number of responses %d", len(data))
+		}
+		var resp pb.StatusResponse
+		if err := proto.Unmarshal(data[0], &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.TicketPrice != 100e8 {
+			t.Fatalf("unexpected ticket price %d", resp.TicketPrice)
+		}
+	}
+
+	// Errors are returned in the trailers.
+	data, trailers := grpcWebCall(t, srv, "FindMatches",
+		&pb.FindMatchesRequest{ProtocolVersion: 0xffff}, false)
+	if len(data) != 0 || !strings.Contains(trailers, "grpc-status: 9\r\n") {
+		t.Fatalf("unexpected response to invalid request %q", trailers)
+	}
+	_, trailers = grpcWebCall(t, srv, "Unknown", &pb.StatusRequest{}, false)
+	if !strings.Contains(trailers, "grpc-status: 12\r\n") {
+		t.Fatalf("unexpected response to unknown method %q", trailers)
+	}
+}
+
+func TestGatewayGRPCWebStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newTestGateway(t, ctx)
+	defer srv.Close()
+
+	b, _ := proto.Marshal(&pb.WatchWaitingListRequest{SendCurrent: true})
+	body := make([]byte, 5+len(b))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(b)))
+	copy(body[5:], b)
+	resp, err := http.Post(srv.URL+"/"+gatewayServiceName+"/WatchWaitingList",
+		grpcWebContentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The current (empty) list of queues is sent without ending the call.
+	var hdr [5]byte
+	if _, err = io.ReadFull(resp.Body, hdr[:]); err != nil {
+		t.Fatalf("unexpected error reading stream: %v", err)
+	}
+	if hdr[0] != 0 {
+		t.Fatalf("unexpected frame flag %x", hdr[0])
+	}
+}
+
+func TestGatewayREST(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newTestGateway(t, ctx)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status pb.StatusResponse
+	err = jsonpb.Unmarshal(resp.Body, &status)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("unexpected error decoding status: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || status.TicketPrice != 100e8 ||
+		status.MainchainHeight != 1000 {
+		t.Fatalf("unexpected status response %d %v", resp.StatusCode, status)
+	}
+
+	// Wait estimates may be requested with query parameters.
+	resp, err = http.Get(srv.URL + "/v1/estimateWait?amount=1000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var est pb.EstimateWaitResponse
+	err = jsonpb.Unmarshal(resp.Body, &est)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("unexpected error decoding estimate: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || est.MissingAmount <= 90e8 {
+		t.Fatalf("unexpected estimate response %d %v", resp.StatusCode, est)
+	}
+
+	// Errors are mapped to http status codes.
+	resp, err = http.Post(srv.URL+"/v1/findMatches", "application/json",
+		strings.NewReader(`{"protocolVersion": 65535}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restErr restError
+	json.NewDecoder(resp.Body).Decode(&restErr)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || restErr.Code != 9 {
+		t.Fatalf("unexpected error response %d %v", resp.StatusCode, restErr)
+	}
+
+	// Participants calling through the gateway are matched in a session.
+	results := make(chan *pb.FindMatchesResponse, 2)
+	for i := byte(1); i <= 2; i++ {
+		req, _ := (&jsonpb.Marshaler{}).MarshalToString(&pb.FindMatchesRequest{
+			Amount:          60e8,
+			VoteAddress:     testAddress(i).EncodeAddress(),
+			PoolAddress:     testAddress(i + 0x10).EncodeAddress(),
+			ProtocolVersion: version.ProtocolVersion,
+		})
+		go func() {
+			resp, err := http.Post(srv.URL+"/v1/findMatches",
+				"application/json", strings.NewReader(req))
+			if err != nil {
+				t.Error(err)
+				results <- nil
+				return
+			}
+			defer resp.Body.Close()
+			var res pb.FindMatchesResponse
+			if err := jsonpb.Unmarshal(resp.Body, &res); err != nil {
+				t.Errorf("unexpected error decoding response: %v", err)
+				results <- nil
+				return
+			}
+			results <- &res
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case res := <-results:
+			if res == nil || res.NbParticipants != 2 ||
+				len(res.SessionToken) == 0 {
+				t.Fatalf("unexpected find matches response %v", res)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for session")
+		}
+	}
+}
+
+func TestGatewayRESTStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newTestGateway(t, ctx)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/watchWaitingList?sendCurrent=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("unexpected error reading stream: %v", err)
+	}
+	var res restStreamResult
+	if err := json.Unmarshal(line, &res); err != nil || res.Result == nil {
+		t.Fatalf("unexpected stream message %s", line)
+	}
+}
+
+func TestGatewayCORS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newTestGateway(t, ctx)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodOptions,
+		srv.URL+"/"+gatewayServiceName+"/Status", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"),
+			"x-grpc-web") {
+		t.Fatalf("unexpected preflight response headers %v", resp.Header)
+	}
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"10S", 10 * time.Second, true},
+		{"250m", 250 * time.Millisecond, true},
+		{"1H", time.Hour, true},
+		{"10", 0, false},
+		{"xS", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := parseGRPCTimeout(tc.s)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("unexpected result for %q: %s %v", tc.s, got, ok)
+		}
+	}
+}
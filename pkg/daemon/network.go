@@ -1,9 +1,12 @@
 package daemon
 
 import (
+	"encoding/json"
 	"github.com/decred/slog"
 	"io/ioutil"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/decred/dcrd/chaincfg"
@@ -16,29 +19,58 @@ import (
 	"golang.org/x/net/context"
 )
 
+// feeEstimateTargetConfs is the number of blocks within which transactions
+// of a session should be mined, when estimating the session fee rate.
+const feeEstimateTargetConfs = 2
+
 type decredNetworkConfig struct {
 	Host        string
 	User        string
 	Pass        string
 	CertFile    string
 	Log         slog.Logger
+	MinFeeRate  dcrutil.Amount
+	MaxFeeRate  dcrutil.Amount
 	chainParams *chaincfg.Params
 }
 
 type decredNetwork struct {
-	client      *rpcclient.Client
+	client *rpcclient.Client
+	log    slog.Logger
+
+	// mtx protects the chain state and estimates below. They are updated by
+	// the dcrd notification handlers and by the refresh goroutine, and read
+	// concurrently by the matcher.
+	mtx         sync.Mutex
 	blockHeight uint32
 	blockHash   chainhash.Hash
-	log         slog.Logger
 	ticketPrice uint64
+	feeRate     dcrutil.Amount
+
+	// nextTicketPrice is the expected ticket price of the next stake
+	// difficulty window. It is zero when not available.
+	nextTicketPrice uint64
+
+	// refreshNeeded is signalled by the notification handlers when the
+	// chain state and estimates need to be refreshed from dcrd. The refresh
+	// is done in a separate goroutine, given that the handlers run on the
+	// goroutine that reads dcrd replies, so they can't make calls to it.
+	refreshNeeded chan struct{}
+
+	minFeeRate  dcrutil.Amount
+	maxFeeRate  dcrutil.Amount
 	chainParams *chaincfg.Params
 }
 
 func connectToDecredNode(cfg *decredNetworkConfig) (*decredNetwork, error) {
 
 	net := &decredNetwork{
-		log:         cfg.Log,
-		chainParams: cfg.chainParams,
+		log:           cfg.Log,
+		minFeeRate:    cfg.MinFeeRate,
+		maxFeeRate:    cfg.MaxFeeRate,
+		feeRate:       cfg.MinFeeRate,
+		chainParams:   cfg.chainParams,
+		refreshNeeded: make(chan struct{}, 1),
 	}
 
 	// Connect to local dcrd RPC server using websockets.
@@ -79,7 +111,9 @@ func connectToDecredNode(cfg *decredNetworkConfig) (*decredNetwork, error) {
 		return nil, err
 	}
 
-	net.log.Criticalf("Connected to the decred network. Height=%d StakeDiff=%s", net.blockHeight, dcrutil.Amount(net.ticketPrice))
+	net.log.Criticalf("Connected to the decred network. Height=%d StakeDiff=%s FeeRate=%s/KB",
+		net.CurrentBlockHeight(), dcrutil.Amount(net.CurrentTicketPrice()),
+		net.CurrentFeeRate())
 
 	return net, nil
 }
@@ -88,6 +122,8 @@ func (net *decredNetwork) run(serverCtx context.Context) {
 	var err error
 	ticker := time.NewTicker(30 * time.Second)
 
+	go net.refreshOnNotifications(serverCtx)
+
 	for {
 		err = nil
 
@@ -131,7 +167,35 @@ func (net *decredNetwork) run(serverCtx context.Context) {
 		}
 
 		net.log.Infof("Reconnected and updated best block to %d StakeDiff %s",
-			net.blockHeight, dcrutil.Amount(net.ticketPrice))
+			net.CurrentBlockHeight(), dcrutil.Amount(net.CurrentTicketPrice()))
+	}
+}
+
+// refreshOnNotifications refreshes the chain state and estimates from dcrd
+// whenever the notification handlers request it, until the context is done.
+func (net *decredNetwork) refreshOnNotifications(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-net.refreshNeeded:
+		}
+
+		err := net.updateFromBestBlock()
+		if err != nil {
+			net.log.Errorf("Error updating best block: %v", err)
+		}
+	}
+}
+
+// requestRefresh requests that the refresh goroutine updates the chain state
+// and estimates. It never blocks, so it is safe to call from the notification
+// handlers.
+func (net *decredNetwork) requestRefresh() {
+	select {
+	case net.refreshNeeded <- struct{}{}:
+	default:
+		// A refresh is already pending.
 	}
 }
 
@@ -146,13 +210,81 @@ func (net *decredNetwork) updateFromBestBlock() error {
 		return err
 	}
 
+	net.mtx.Lock()
 	net.ticketPrice = uint64(bestBlock.Header.SBits)
 	net.blockHeight = uint32(blockHeight)
 	net.blockHash = *bestBlockHash
+	net.mtx.Unlock()
+	net.updateFeeRate()
+	net.updateNextTicketPrice()
 
 	return nil
 }
 
+// estimateFeeRate requests an estimate of the fee rate (in Atoms/KB) needed
+// for transactions to be mined within feeEstimateTargetConfs blocks.
+func (net *decredNetwork) estimateFeeRate() (dcrutil.Amount, error) {
+	params := []json.RawMessage{
+		json.RawMessage(strconv.Itoa(feeEstimateTargetConfs)),
+		json.RawMessage(`"conservative"`),
+	}
+	res, err := net.client.RawRequest("estimatesmartfee", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var feeRate float64
+	err = json.Unmarshal(res, &feeRate)
+	if err != nil {
+		return 0, errors.Wrap(err, "error decoding fee estimate")
+	}
+
+	return dcrutil.NewAmount(feeRate)
+}
+
+// updateFeeRate updates the fee rate used for new sessions from the network
+// estimate, limited to the configured minimum and maximum rates. The minimum
+// rate is used if the estimate is not available.
+func (net *decredNetwork) updateFeeRate() {
+	feeRate := net.minFeeRate
+	estimate, err := net.estimateFeeRate()
+	if err != nil {
+		net.log.Warnf("Error estimating fee rate: %v", err)
+	} else if estimate > feeRate {
+		feeRate = estimate
+	}
+
+	if feeRate > net.maxFeeRate {
+		net.log.Warnf("Estimated fee rate %s/KB higher than maximum allowed",
+			feeRate)
+		feeRate = net.maxFeeRate
+	}
+
+	net.mtx.Lock()
+	if feeRate != net.feeRate {
+		net.log.Infof("Fee rate changed to %s/KB", feeRate)
+	}
+	net.feeRate = feeRate
+	net.mtx.Unlock()
+}
+
+// updateNextTicketPrice updates the expected ticket price of the next stake
+// difficulty window from the estimate of the network.
+func (net *decredNetwork) updateNextTicketPrice() {
+	var nextPrice dcrutil.Amount
+	estimate, err := net.client.EstimateStakeDiff(nil)
+	if err != nil {
+		net.log.Warnf("Error estimating next stake difficulty: %v", err)
+	} else if nextPrice, err = dcrutil.NewAmount(estimate.Expected); err != nil {
+		net.log.Warnf("Invalid next stake difficulty estimate: %v", err)
+		nextPrice = 0
+	}
+
+	net.mtx.Lock()
+	net.nextTicketPrice = uint64(nextPrice)
+	net.mtx.Unlock()
+}
+
 func (net *decredNetwork) notificationHandlers() *rpcclient.NotificationHandlers {
 	return &rpcclient.NotificationHandlers{
 		OnClientConnected:   net.onClientConnected,
@@ -169,40 +301,62 @@ func (net *decredNetwork) onClientConnected() {
 func (net *decredNetwork) onBlockConnected(blockHeader []byte, transactions [][]byte) {
 	header := &wire.BlockHeader{}
 	header.FromBytes(blockHeader)
+	net.mtx.Lock()
 	net.ticketPrice = uint64(header.SBits)
 	net.blockHeight = header.Height
 	net.blockHash = header.BlockHash()
-	stakeDiffChangeDistance := splitticket.StakeDiffChangeDistance(net.blockHeight,
+	net.mtx.Unlock()
+	stakeDiffChangeDistance := splitticket.StakeDiffChangeDistance(header.Height,
 		net.chainParams)
 	net.log.Infof("Block connected. Height=%d StakeDiff=%s WindowChangeDist=%d",
-		header.Height, dcrutil.Amount(net.ticketPrice), stakeDiffChangeDistance)
+		header.Height, dcrutil.Amount(header.SBits), stakeDiffChangeDistance)
+	net.requestRefresh()
 }
 
 func (net *decredNetwork) onBlockDisconnected(blockHeader []byte) {
 	header := &wire.BlockHeader{}
 	header.FromBytes(blockHeader)
 	net.log.Infof("Block disconnected. Height=%d", header.Height)
-	net.updateFromBestBlock()
+	net.requestRefresh()
 }
 
 func (net *decredNetwork) onReorganization(oldHash *chainhash.Hash, oldHeight int32,
 	newHash *chainhash.Hash, newHeight int32) {
 	net.log.Infof("Chain reorg. OldHeight=%d NewHeight=%d", oldHeight, newHeight)
-	net.updateFromBestBlock()
+	net.requestRefresh()
 }
 
 func (net *decredNetwork) CurrentTicketPrice() uint64 {
+	net.mtx.Lock()
+	defer net.mtx.Unlock()
 	return net.ticketPrice
 }
 
 func (net *decredNetwork) CurrentBlockHeight() uint32 {
+	net.mtx.Lock()
+	defer net.mtx.Unlock()
 	return net.blockHeight
 }
 
 func (net *decredNetwork) CurrentBlockHash() chainhash.Hash {
+	net.mtx.Lock()
+	defer net.mtx.Unlock()
 	return net.blockHash
 }
 
+func (net *decredNetwork) CurrentFeeRate() dcrutil.Amount {
+	net.mtx.Lock()
+	defer net.mtx.Unlock()
+	return net.feeRate
+}
+
+// NextTicketPrice fulfills nextTicketPriceEstimator.
+func (net *decredNetwork) NextTicketPrice() uint64 {
+	net.mtx.Lock()
+	defer net.mtx.Unlock()
+	return net.nextTicketPrice
+}
+
 func (net *decredNetwork) ConnectedToDecredNetwork() bool {
 	return !net.client.Disconnected()
 }
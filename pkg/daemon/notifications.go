@@ -0,0 +1,416 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	// eventSessionStarted is sent when a new session is started.
+	eventSessionStarted = "session_started"
+
+	// eventSessionFailed is sent when a session is canceled before completing.
+	eventSessionFailed = "session_failed"
+
+	// eventSessionSucceeded is sent when a session successfully completes.
+	eventSessionSucceeded = "session_succeeded"
+
+	// eventPublishFailed is sent when a session completes but its
+	// transactions could not be published.
+	eventPublishFailed = "publish_failed"
+
+	// webhookSignatureHeader is the http header that carries the hex encoded
+	// HMAC-SHA256 signature of the body of webhook requests.
+	webhookSignatureHeader = "X-Dcrstmd-Signature"
+
+	// webhookEventHeader is the http header that carries the event type of
+	// webhook requests.
+	webhookEventHeader = "X-Dcrstmd-Event"
+
+	// webhookMaxRetryDelay is the maximum time to wait between delivery
+	// attempts of a single webhook notification.
+	webhookMaxRetryDelay = 30 * time.Minute
+
+	// outboxEntryExt is the extension of pending outbox entry files.
+	outboxEntryExt = ".json"
+
+	// outboxFailedExt is the extension outbox entries are renamed to once
+	// they exceed the maximum number of delivery attempts.
+	outboxFailedExt = ".failed"
+)
+
+// sessionEvent is the json payload of notifications about the lifecycle of
+// matcher sessions. All amounts are in atoms.
+type sessionEvent struct {
+	ID              string  `json:"id"`
+	Type            string  `json:"type"`
+	Timestamp       int64   `json:"timestamp"`
+	SessionID       string  `json:"session_id"`
+	Pool            string  `json:"pool,omitempty"`
+	Reason          string  `json:"reason,omitempty"`
+	TicketHash      string  `json:"ticket_hash,omitempty"`
+	SplitHash       string  `json:"split_hash,omitempty"`
+	NumParticipants int     `json:"num_participants"`
+	TicketPrice     int64   `json:"ticket_price"`
+	PoolFee         int64   `json:"pool_fee"`
+	Amounts         []int64 `json:"amounts,omitempty"`
+}
+
+// newSessionEvent creates an event of the given type, filled with the data of
+// the given session.
+func newSessionEvent(eventType string, sess *matcher.Session) *sessionEvent {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	e := &sessionEvent{
+		ID:              hex.EncodeToString(id[:]),
+		Type:            eventType,
+		Timestamp:       time.Now().Unix(),
+		SessionID:       sess.ID.String(),
+		Pool:            sess.Pool,
+		NumParticipants: len(sess.Participants),
+		TicketPrice:     int64(sess.TicketPrice),
+		PoolFee:         int64(sess.PoolFee),
+	}
+	if eventType != eventSessionStarted {
+		e.Amounts = make([]int64, len(sess.Participants))
+		for i, p := range sess.Participants {
+			e.Amounts[i] = int64(p.CommitAmount)
+		}
+	}
+	return e
+}
+
+// setTransactions fills the ticket and split hashes of the event.
+func (e *sessionEvent) setTransactions(ticket, splitTx *wire.MsgTx) {
+	e.TicketHash = ticket.TxHash().String()
+	e.SplitHash = splitTx.TxHash().String()
+}
+
+// outboxEntry is a pending delivery of an event to a specific webhook url.
+type outboxEntry struct {
+	Event       *sessionEvent `json:"event"`
+	URL         string        `json:"url"`
+	Attempts    int           `json:"attempts"`
+	NextAttempt time.Time     `json:"next_attempt"`
+	LastError   string        `json:"last_error,omitempty"`
+}
+
+// fileName returns the name of the file the entry is stored at in the outbox.
+func (e *outboxEntry) fileName() string {
+	urlHash := sha256.Sum256([]byte(e.URL))
+	return e.Event.ID + "-" + hex.EncodeToString(urlHash[:8]) + outboxEntryExt
+}
+
+// notifierConfig stores the parameters for the notification subsystem.
+type notifierConfig struct {
+	WebhookURLs []string
+	Secret      []byte
+	MaxAttempts int
+	RetryDelay  time.Duration
+	OutboxDir   string
+	EventsFile  string
+	Log         slog.Logger
+}
+
+// notifier sends notifications of session events to the configured webhooks
+// and event stream file. Webhook deliveries are first persisted to an outbox
+// directory, such that they are retried (with exponential backoff) across
+// restarts of the daemon until they succeed or the maximum number of attempts
+// is reached.
+type notifier struct {
+	cfg        *notifierConfig
+	log        slog.Logger
+	client     *http.Client
+	newEntries chan *outboxEntry
+
+	eventsMtx sync.Mutex
+}
+
+// newNotifier returns a new notifier instance. Call run() on a goroutine to
+// start delivering webhook notifications.
+func newNotifier(cfg *notifierConfig) (*notifier, error) {
+	if len(cfg.WebhookURLs) > 0 {
+		if err := os.MkdirAll(cfg.OutboxDir, 0700); err != nil {
+			return nil, errors.Wrapf(err, "error creating webhook outbox dir")
+		}
+	}
+
+	for _, u := range cfg.WebhookURLs {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return nil, errors.Errorf("webhook url %s is not an http(s) url", u)
+		}
+	}
+
+	return &notifier{
+		cfg:        cfg,
+		log:        cfg.Log,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		newEntries: make(chan *outboxEntry, 64),
+	}, nil
+}
+
+// notify records the event in the event stream file and schedules its
+// delivery to all configured webhooks.
+func (n *notifier) notify(e *sessionEvent) {
+	if n.cfg.EventsFile != "" {
+		if err := n.appendToEventsFile(e); err != nil {
+			n.log.Errorf("Error writing event %s to events file: %v", e.ID, err)
+		}
+	}
+
+	for _, u := range n.cfg.WebhookURLs {
+		entry := &outboxEntry{
+			Event:       e,
+			URL:         u,
+			NextAttempt: time.Now(),
+		}
+		if err := n.saveEntry(entry); err != nil {
+			n.log.Errorf("Error saving event %s to outbox: %v", e.ID, err)
+		}
+		n.newEntries <- entry
+	}
+}
+
+// appendToEventsFile writes the event as a single json line at the end of the
+// event stream file.
+func (n *notifier) appendToEventsFile(e *sessionEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	n.eventsMtx.Lock()
+	defer n.eventsMtx.Unlock()
+
+	f, err := os.OpenFile(n.cfg.EventsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// saveEntry (re)writes the outbox file of the given entry.
+func (n *notifier) saveEntry(entry *outboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	fname := filepath.Join(n.cfg.OutboxDir, entry.fileName())
+	tmpName := fname + ".tmp"
+	if err = ioutil.WriteFile(tmpName, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, fname)
+}
+
+// loadOutbox returns all pending entries stored in the outbox dir.
+func (n *notifier) loadOutbox() ([]*outboxEntry, error) {
+	files, err := ioutil.ReadDir(n.cfg.OutboxDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*outboxEntry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != outboxEntryExt {
+			continue
+		}
+
+		fname := filepath.Join(n.cfg.OutboxDir, f.Name())
+		data, err := ioutil.ReadFile(fname)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading outbox entry %s",
+				f.Name())
+		}
+
+		entry := new(outboxEntry)
+		if err = json.Unmarshal(data, entry); err != nil || entry.Event == nil {
+			n.log.Warnf("Ignoring malformed outbox entry %s", f.Name())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// deliver performs a single delivery attempt of the given entry.
+func (n *notifier) deliver(ctx context.Context, entry *outboxEntry) error {
+	body, err := json.Marshal(entry.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, entry.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, entry.Event.Type)
+	if len(n.cfg.Secret) > 0 {
+		req.Header.Set(webhookSignatureHeader, "sha256="+
+			hex.EncodeToString(webhookSignature(n.cfg.Secret, body)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// retryDelay returns how long to wait before the next attempt to deliver an
+// entry that has failed the given number of times.
+func (n *notifier) retryDelay(attempts int) time.Duration {
+	delay := n.cfg.RetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+// processEntry attempts to deliver the entry and updates its outbox file
+// according to the result. Returns true if the entry should be retried.
+func (n *notifier) processEntry(ctx context.Context, entry *outboxEntry) bool {
+	fname := filepath.Join(n.cfg.OutboxDir, entry.fileName())
+
+	err := n.deliver(ctx, entry)
+	if err == nil {
+		n.log.Debugf("Delivered event %s (%s) to %s", entry.Event.ID,
+			entry.Event.Type, entry.URL)
+		if err = os.Remove(fname); err != nil && !os.IsNotExist(err) {
+			n.log.Errorf("Error removing delivered outbox entry: %v", err)
+		}
+		return false
+	}
+
+	entry.Attempts++
+	entry.LastError = err.Error()
+	if entry.Attempts >= n.cfg.MaxAttempts {
+		n.log.Errorf("Giving up delivering event %s (%s) to %s after %d "+
+			"attempts: %v", entry.Event.ID, entry.Event.Type, entry.URL,
+			entry.Attempts, err)
+		if err = n.saveEntry(entry); err == nil {
+			err = os.Rename(fname, strings.TrimSuffix(fname, outboxEntryExt)+
+				outboxFailedExt)
+		}
+		if err != nil {
+			n.log.Errorf("Error moving failed outbox entry: %v", err)
+		}
+		return false
+	}
+
+	entry.NextAttempt = time.Now().Add(n.retryDelay(entry.Attempts))
+	n.log.Warnf("Error delivering event %s (%s) to %s (attempt %d): %v",
+		entry.Event.ID, entry.Event.Type, entry.URL, entry.Attempts, err)
+	if err = n.saveEntry(entry); err != nil {
+		n.log.Errorf("Error updating outbox entry: %v", err)
+	}
+	return true
+}
+
+// run delivers pending and new webhook notifications until the context is
+// done.
+func (n *notifier) run(ctx context.Context) {
+	if len(n.cfg.WebhookURLs) == 0 {
+		return
+	}
+
+	pending, err := n.loadOutbox()
+	if err != nil {
+		n.log.Errorf("Error loading webhook outbox: %v", err)
+	} else if len(pending) > 0 {
+		n.log.Infof("Loaded %d pending webhook notifications", len(pending))
+	}
+
+	for {
+		sort.Slice(pending, func(i, j int) bool {
+			return pending[i].NextAttempt.Before(pending[j].NextAttempt)
+		})
+
+		var wait <-chan time.Time
+		if len(pending) > 0 {
+			wait = time.After(time.Until(pending[0].NextAttempt))
+		}
+
+		select {
+		case entry := <-n.newEntries:
+			// Entries saved just before the outbox was loaded are also sent
+			// through the channel, so ignore them if already pending.
+			if !entryPending(pending, entry) {
+				pending = append(pending, entry)
+			}
+			continue
+		case <-wait:
+		case <-ctx.Done():
+			return
+		}
+
+		now := time.Now()
+		remaining := pending[:0]
+		for _, entry := range pending {
+			if entry.NextAttempt.After(now) || n.processEntry(ctx, entry) {
+				remaining = append(remaining, entry)
+			}
+		}
+		pending = remaining
+	}
+}
+
+// entryPending returns true if an entry for the same event and url as the
+// given one is in the pending list.
+func entryPending(pending []*outboxEntry, entry *outboxEntry) bool {
+	fname := entry.fileName()
+	for _, e := range pending {
+		if e.fileName() == fname {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookSignature returns the HMAC-SHA256 of the body of a webhook request,
+// using the given secret as key.
+func webhookSignature(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,213 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+	"golang.org/x/net/context"
+)
+
+func newTestNotifier(t *testing.T, dir string, urls ...string) *notifier {
+	n, err := newNotifier(&notifierConfig{
+		WebhookURLs: urls,
+		Secret:      []byte("test secret"),
+		MaxAttempts: 3,
+		RetryDelay:  10 * time.Millisecond,
+		OutboxDir:   filepath.Join(dir, "outbox"),
+		EventsFile:  filepath.Join(dir, "events.log"),
+		Log:         slog.Disabled,
+	})
+	if err != nil {
+		t.Fatalf("error creating notifier: %v", err)
+	}
+	return n
+}
+
+func outboxFiles(t *testing.T, dir, ext string) int {
+	files, err := filepath.Glob(filepath.Join(dir, "outbox", "*"+ext))
+	if err != nil {
+		t.Fatalf("error listing outbox: %v", err)
+	}
+	return len(files)
+}
+
+func waitFor(t *testing.T, desc string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", desc)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNotifierWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "dcrstmd-notifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mtx sync.Mutex
+	var received []*sessionEvent
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		calls++
+		if calls == 1 {
+			// fail the first attempt so that the entry is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		sig := r.Header.Get(webhookSignatureHeader)
+		expected := "sha256=" + hex.EncodeToString(webhookSignature(
+			[]byte("test secret"), body))
+		if !hmac.Equal([]byte(sig), []byte(expected)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		e := new(sessionEvent)
+		if err := json.Unmarshal(body, e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get(webhookEventHeader) != e.Type {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, e)
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(t, dir, srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.run(ctx)
+
+	event := &sessionEvent{
+		ID:         "00112233",
+		Type:       eventSessionSucceeded,
+		SessionID:  "0001",
+		TicketHash: strings.Repeat("ab", 32),
+		Amounts:    []int64{10, 20},
+	}
+	n.notify(event)
+
+	waitFor(t, "webhook delivery", func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(received) == 1
+	})
+
+	mtx.Lock()
+	if received[0].ID != event.ID || received[0].TicketHash != event.TicketHash {
+		t.Fatalf("received unexpected event %v", received[0])
+	}
+	if calls != 2 {
+		t.Fatalf("unexpected number of delivery attempts %d", calls)
+	}
+	mtx.Unlock()
+
+	waitFor(t, "outbox cleanup", func() bool {
+		return outboxFiles(t, dir, outboxEntryExt) == 0
+	})
+
+	f, err := os.Open(filepath.Join(dir, "events.log"))
+	if err != nil {
+		t.Fatalf("error opening events file: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for scanner.Scan() {
+		e := new(sessionEvent)
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			t.Fatalf("malformed events file line: %v", err)
+		}
+		lines++
+	}
+	if lines != 1 {
+		t.Fatalf("unexpected number of events in events file %d", lines)
+	}
+}
+
+func TestNotifierOutbox(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "dcrstmd-notifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mtx sync.Mutex
+	online := false
+	delivered := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if !online {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		delivered++
+	}))
+	defer srv.Close()
+
+	// Events that fail all attempts are moved out of the pending outbox.
+	n := newTestNotifier(t, dir, srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	go n.run(ctx)
+	n.notify(&sessionEvent{ID: "01", Type: eventSessionStarted})
+	waitFor(t, "failed entry", func() bool {
+		return outboxFiles(t, dir, outboxFailedExt) == 1
+	})
+	if outboxFiles(t, dir, outboxEntryExt) != 0 {
+		t.Fatalf("failed entry still pending in outbox")
+	}
+	cancel()
+
+	// Entries saved while the daemon is not running are delivered once it
+	// starts.
+	n = newTestNotifier(t, dir, srv.URL)
+	n.notify(&sessionEvent{ID: "02", Type: eventSessionFailed, Reason: "test"})
+	if outboxFiles(t, dir, outboxEntryExt) != 1 {
+		t.Fatalf("entry not persisted to outbox")
+	}
+
+	mtx.Lock()
+	online = true
+	mtx.Unlock()
+
+	n = newTestNotifier(t, dir, srv.URL)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go n.run(ctx)
+	waitFor(t, "outbox delivery", func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return delivered == 1
+	})
+	waitFor(t, "outbox cleanup", func() bool {
+		return outboxFiles(t, dir, outboxEntryExt) == 0
+	})
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"path/filepath"
+
+	"github.com/decred/dcrd/chaincfg"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/poolintegrator"
+	"github.com/pkg/errors"
+)
+
+// PoolConfig is the configuration of an additional voting pool served by the
+// matcher, read from one of the PoolConfigFiles.
+type PoolConfig struct {
+	Name                       string   `long:"name" description:"Name buyers use to select the pool. May only contain letters, numbers, '-' and '_'."`
+	PoolFee                    float64  `long:"poolfee" description:"Pool fee as a percentage (eg: 5.0 = 5%)"`
+	PoolSubsidyWalletMasterPub []string `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. May be specified multiple times."`
+	PoolSubsidyGapLimit        uint32   `long:"poolsubsidygaplimit" description:"Number of pool fee addresses accepted past the highest index already seen for each PoolSubsidyWalletMasterPub"`
+	StakepooldIntegratorHost   string   `long:"stakepooldintegratorhost" description:"Host to connect to for stakepoold validation"`
+	StakepooldIntegratorCert   string   `long:"stakepooldintegratorcert" description:"Certificate to use when connecting the stakepool integrator host"`
+}
+
+// validPoolName returns true if the given name can be used as the name of an
+// additional pool.
+func validPoolName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		valid := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9') || c == '-' || c == '_'
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPoolConfig reads the config of an additional pool from the given ini
+// file.
+func loadPoolConfig(fname string) (*PoolConfig, error) {
+	pcfg := &PoolConfig{
+		PoolSubsidyGapLimit: util.DefaultPoolAddrGapLimit,
+	}
+
+	parser := flags.NewParser(pcfg, flags.None)
+	err := flags.NewIniParser(parser).ParseFile(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing pool config file %s",
+			fname)
+	}
+
+	if !validPoolName(pcfg.Name) {
+		return nil, errors.Errorf("invalid pool name '%s' in config file %s",
+			pcfg.Name, fname)
+	}
+	if pcfg.PoolFee < 0.1 {
+		return nil, errors.Errorf("cannot use pool fee less than 0.1%% on "+
+			"pool '%s'", pcfg.Name)
+	}
+	if (pcfg.StakepooldIntegratorHost == "") !=
+		(pcfg.StakepooldIntegratorCert == "") {
+		return nil, errors.Errorf("pool '%s' must specify both the "+
+			"stakepooldintegratorhost and stakepooldintegratorcert", pcfg.Name)
+	}
+
+	return pcfg, nil
+}
+
+// newPool creates the matcher profile of the pool with the given config. The
+// stakepoold integrator of the pool (if configured) is registered on the
+// daemon, so that the tickets of the pool are registered with it.
+func (daemon *Daemon) newPool(pcfg *PoolConfig, chainParams *chaincfg.Params) (
+	*matcher.Pool, error) {
+
+	pool := &matcher.Pool{
+		Name:              pcfg.Name,
+		PoolFee:           pcfg.PoolFee,
+		VoteAddrValidator: matcher.InsecurePoolAddressesValidator{},
+		PoolAddrValidator: matcher.InsecurePoolAddressesValidator{},
+	}
+
+	if pcfg.StakepooldIntegratorHost != "" {
+		integrator, err := poolintegrator.NewClient(
+			pcfg.StakepooldIntegratorHost, pcfg.StakepooldIntegratorCert)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error initializing stakepoold "+
+				"integrator client of pool '%s'", pcfg.Name)
+		}
+		daemon.integrators[pcfg.Name] = integrator
+		pool.VoteAddrValidator = integrator
+		pool.PoolAddrValidator = integrator
+	} else {
+		daemon.log.Warnf("Not validating voter addresses of pool '%s'",
+			pcfg.Name)
+	}
+
+	if len(pcfg.PoolSubsidyWalletMasterPub) > 0 {
+		indexFile := filepath.Join(daemon.cfg.DataDir,
+			"pooladdr-indexes-"+pcfg.Name+".json")
+		validator, err := util.NewMasterPubPoolAddrValidator(
+			pcfg.PoolSubsidyWalletMasterPub, pcfg.PoolSubsidyGapLimit,
+			indexFile, daemon.log, chainParams)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error deriving pool subsidy "+
+				"addresses of pool '%s'", pcfg.Name)
+		}
+		pool.PoolAddrValidator = validator
+	} else if pcfg.StakepooldIntegratorHost == "" {
+		daemon.log.Warnf("Not validating pool subsidy addresses of pool '%s'",
+			pcfg.Name)
+	}
+
+	daemon.log.Infof("Serving pool '%s' with pool fee of %.2f%%", pcfg.Name,
+		pcfg.PoolFee)
+
+	return pool, nil
+}
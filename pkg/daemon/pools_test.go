@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPoolConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-pools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfig := func(name, content string) string {
+		fname := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(fname, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return fname
+	}
+
+	fname := writeConfig("valid.conf", "Name = other-pool_1\n"+
+		"PoolFee = 7.5\n"+
+		"PoolSubsidyWalletMasterPub = xpub1\n"+
+		"PoolSubsidyWalletMasterPub = xpub2:100\n")
+	pcfg, err := loadPoolConfig(fname)
+	if err != nil {
+		t.Fatalf("unexpected error loading valid config: %v", err)
+	}
+	if pcfg.Name != "other-pool_1" || pcfg.PoolFee != 7.5 ||
+		len(pcfg.PoolSubsidyWalletMasterPub) != 2 ||
+		pcfg.PoolSubsidyGapLimit == 0 {
+		t.Fatalf("unexpected loaded config %#v", pcfg)
+	}
+
+	invalid := []string{
+		"PoolFee = 5\n",
+		"Name = other pool\nPoolFee = 5\n",
+		"Name = ../other\nPoolFee = 5\n",
+		"Name = other\nPoolFee = 0\n",
+		"Name = other\nPoolFee = 5\nStakepooldIntegratorHost = localhost\n",
+		"Name = other\nPoolFee = 5\nUnknownOption = 1\n",
+	}
+	for i, content := range invalid {
+		fname := writeConfig("invalid.conf", content)
+		if _, err := loadPoolConfig(fname); err == nil {
+			t.Fatalf("invalid config %d did not return an error", i)
+		}
+	}
+}
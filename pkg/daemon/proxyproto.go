@@ -0,0 +1,274 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// proxyHeaderTimeout is the maximum amount of time to wait for the PROXY
+// protocol header of connections from trusted proxies.
+const proxyHeaderTimeout = 10 * time.Second
+
+// proxyV2Signature is the signature that starts v2 PROXY protocol headers.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// trustedProxies is the list of networks of the reverse proxies (eg: nginx or
+// a load balancer) trusted to report the original address of clients.
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies parses the given list of IP addresses or CIDR networks.
+func parseTrustedProxies(addrs []string) (trustedProxies, error) {
+	res := make(trustedProxies, 0, len(addrs))
+	for _, s := range addrs {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy address %s", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = 8 * net.IPv4len
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy network %s", s)
+		}
+		res = append(res, ipnet)
+	}
+	return res, nil
+}
+
+// trustedIP returns true if the given ip belongs to one of the trusted proxies.
+func (tp trustedProxies) trustedIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range tp {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedAddr returns true if the given host:port address belongs to one of
+// the trusted proxies.
+func (tp trustedProxies) trustedAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return tp.trustedIP(net.ParseIP(host))
+}
+
+// clientAddr returns the address of the client that originated the given http
+// request. The X-Forwarded-For header is only used if the request came from a
+// trusted proxy, in which case the address of the last untrusted hop is
+// returned.
+func (tp trustedProxies) clientAddr(r *http.Request) string {
+	if !tp.trustedAddr(r.RemoteAddr) {
+		return r.RemoteAddr
+	}
+
+	var hops []string
+	for _, h := range r.Header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	if len(hops) == 0 {
+		return r.RemoteAddr
+	}
+
+	// Each proxy appends the address it received the request from, so walk
+	// back the chain until reaching a hop that isn't one of our proxies.
+	for i := len(hops) - 1; i > 0; i-- {
+		if !tp.trustedAddr(hops[i]) {
+			return hops[i]
+		}
+	}
+	return hops[0]
+}
+
+// proxyProtoListener is a listener that reads the PROXY protocol (v1 or v2)
+// header sent by trusted proxies, so that the remote address of their
+// connections is the address of the original client. Connections from other
+// addresses are returned unchanged.
+type proxyProtoListener struct {
+	net.Listener
+	trusted trustedProxies
+}
+
+// Accept fulfills net.Listener. The header of connections from trusted
+// proxies is only read on the first use of the connection, so that a slow
+// proxy does not block accepting other connections.
+func (l *proxyProtoListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.trusted.trustedAddr(conn.RemoteAddr().String()) {
+		return conn, nil
+	}
+
+	return &proxyProtoConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// proxyProtoConn is a connection from a trusted proxy, which starts with a
+// PROXY protocol header.
+type proxyProtoConn struct {
+	net.Conn
+	r      *bufio.Reader
+	once   sync.Once
+	remote net.Addr
+	err    error
+}
+
+func (c *proxyProtoConn) readHeader() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+		c.remote, c.err = readProxyHeader(c.r)
+		c.Conn.SetReadDeadline(time.Time{})
+		if c.err != nil {
+			c.err = errors.Wrapf(c.err, "error reading PROXY header from %s",
+				c.Conn.RemoteAddr())
+		}
+		if c.remote == nil {
+			c.remote = c.Conn.RemoteAddr()
+		}
+	})
+}
+
+// Read fulfills net.Conn.
+func (c *proxyProtoConn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr fulfills net.Conn by returning the address of the original
+// client, as reported by the proxy.
+func (c *proxyProtoConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	return c.remote
+}
+
+// readProxyHeader reads a v1 or v2 PROXY protocol header. It returns a nil
+// address for headers that do not carry the address of a client (eg: health
+// checks performed by the proxy itself).
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	sig, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(sig, proxyV2Signature):
+		return readProxyHeaderV2(r)
+	case bytes.HasPrefix(sig, []byte("PROXY ")):
+		return readProxyHeaderV1(r)
+	default:
+		return nil, errors.New("connection does not start with a PROXY " +
+			"protocol header")
+	}
+}
+
+// readProxyHeaderV1 reads a human readable (v1) PROXY protocol header.
+func readProxyHeaderV1(r *bufio.Reader) (net.Addr, error) {
+	// The header is at most 107 bytes long, including the CRLF.
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("PROXY v1 header too long")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.Errorf("invalid PROXY v1 header %q", line)
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, errors.Errorf("invalid source address in PROXY v1 "+
+			"header %q", line)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyHeaderV2 reads a binary (v2) PROXY protocol header.
+func readProxyHeaderV2(r *bufio.Reader) (net.Addr, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	verCmd, fam := hdr[12], hdr[13]
+	payload := make([]byte, binary.BigEndian.Uint16(hdr[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	if verCmd>>4 != 2 {
+		return nil, errors.Errorf("unsupported PROXY protocol version %d",
+			verCmd>>4)
+	}
+	if verCmd&0x0f == 0 {
+		// LOCAL command: connection established by the proxy itself.
+		return nil, nil
+	}
+
+	var ipLen int
+	switch fam {
+	case 0x11: // TCP over IPv4
+		ipLen = net.IPv4len
+	case 0x21: // TCP over IPv6
+		ipLen = net.IPv6len
+	default:
+		// Unsupported address families are accepted, but the address of the
+		// client is unknown.
+		return nil, nil
+	}
+
+	if len(payload) < 2*ipLen+4 {
+		return nil, errors.New("PROXY v2 header too short for its address " +
+			"family")
+	}
+	ip := net.IP(payload[:ipLen])
+	port := binary.BigEndian.Uint16(payload[2*ipLen:])
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
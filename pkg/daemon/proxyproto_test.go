@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestReadProxyHeader(t *testing.T) {
+	v2 := func(verCmd, fam byte, addr []byte) []byte {
+		var b bytes.Buffer
+		b.Write(proxyV2Signature)
+		b.Write([]byte{verCmd, fam})
+		binary.Write(&b, binary.BigEndian, uint16(len(addr)))
+		b.Write(addr)
+		return b.Bytes()
+	}
+	ipv4Addr := []byte{10, 0, 0, 1, 10, 0, 0, 2, 0x1f, 0x90, 0x21, 0x1b}
+
+	tests := []struct {
+		header []byte
+		addr   string
+	}{
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 8475\r\n"), "192.0.2.1:56324"},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 8475\r\n"), "[2001:db8::1]:56324"},
+		{[]byte("PROXY UNKNOWN\r\n"), ""},
+		{v2(0x21, 0x11, ipv4Addr), "10.0.0.1:8080"},
+		{v2(0x20, 0x00, nil), ""},
+	}
+
+	for i, tc := range tests {
+		r := bufio.NewReader(bytes.NewReader(append(tc.header, "data"...)))
+		addr, err := readProxyHeader(r)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if (addr == nil && tc.addr != "") ||
+			(addr != nil && addr.String() != tc.addr) {
+			t.Fatalf("test %d: unexpected address %v", i, addr)
+		}
+		rest, _ := ioutil.ReadAll(r)
+		if string(rest) != "data" {
+			t.Fatalf("test %d: header not fully consumed (%q)", i, rest)
+		}
+	}
+
+	invalid := [][]byte{
+		[]byte("GET / HTTP/1.1\r\n\r\n"),
+		[]byte("PROXY TCP4 not-an-ip 198.51.100.1 56324 8475\r\n"),
+		[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n"),
+		v2(0x11, 0x11, ipv4Addr),
+		v2(0x21, 0x11, ipv4Addr[:6]),
+	}
+	for i, h := range invalid {
+		r := bufio.NewReader(bytes.NewReader(h))
+		if _, err := readProxyHeader(r); err == nil {
+			t.Fatalf("invalid header %d did not return an error", i)
+		}
+	}
+}
+
+func TestProxyProtoListener(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := &proxyProtoListener{Listener: ln, trusted: trusted}
+	defer lis.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		c.Write([]byte("PROXY TCP4 192.0.2.1 127.0.0.1 56324 8475\r\nhello"))
+		c.Close()
+	}()
+
+	conn, err := lis.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if addr := conn.RemoteAddr().String(); addr != "192.0.2.1:56324" {
+		t.Fatalf("unexpected remote address %s", addr)
+	}
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("unexpected data %q", data)
+	}
+}
+
+func TestTrustedProxiesClientAddr(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote string
+		xff    []string
+		addr   string
+	}{
+		{"203.0.113.5:1234", nil, "203.0.113.5:1234"},
+		{"203.0.113.5:1234", []string{"198.51.100.7"}, "203.0.113.5:1234"},
+		{"10.0.0.5:1234", nil, "10.0.0.5:1234"},
+		{"10.0.0.5:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"10.0.0.5:1234", []string{"1.1.1.1, 198.51.100.7, 192.0.2.1"}, "198.51.100.7"},
+		{"10.0.0.5:1234", []string{"1.1.1.1", "10.0.0.9"}, "1.1.1.1"},
+		{"10.0.0.5:1234", []string{"10.0.0.8, 10.0.0.9"}, "10.0.0.8"},
+	}
+
+	for i, tc := range tests {
+		r := &http.Request{RemoteAddr: tc.remote, Header: http.Header{}}
+		for _, h := range tc.xff {
+			r.Header.Add("X-Forwarded-For", h)
+		}
+		if addr := trusted.clientAddr(r); addr != tc.addr {
+			t.Fatalf("test %d: expected %s, got %s", i, tc.addr, addr)
+		}
+	}
+
+	if _, err := parseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("invalid trusted proxy accepted")
+	}
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+// QueueFileConfig is the configuration of a named queue pre-declared by the
+// operator, read from one of the QueueConfigFiles.
+type QueueFileConfig struct {
+	Name               string        `long:"name" description:"Session name participants use to join the queue"`
+	Pool               string        `long:"pool" description:"Name of the pool of the queue. Empty for the default pool."`
+	MinAmount          float64       `long:"minamount" description:"Minimum amount to participate on the queue (in DCR). Replaces the matcher-wide minamount."`
+	MaxAmount          float64       `long:"maxamount" description:"Maximum amount participants of the queue contribute (in DCR)"`
+	MaxParticipants    int           `long:"maxparticipants" description:"Maximum number of participants waiting in the queue"`
+	MaxSessionDuration time.Duration `long:"maxsessionduration" description:"Maximum duration of sessions of the queue"`
+	AllowedVoteAddress []string      `long:"allowedvoteaddress" description:"Vote address allowed to join the queue. May be specified multiple times. If not specified, any vote address is allowed."`
+	JoinKey            string        `long:"joinkey" description:"Password or pre-shared key participants must know to join the queue"`
+	AllowedClient      []string      `long:"allowedclient" description:"Identity of a client certificate (see clientallowlist and clientcafile) allowed to join the queue. May be specified multiple times. If not specified, any client is allowed."`
+}
+
+// loadQueueConfig reads the config of a pre-declared queue from the given ini
+// file.
+func loadQueueConfig(fname string, chainParams *chaincfg.Params) (
+	*matcher.QueueConfig, error) {
+
+	qfcfg := new(QueueFileConfig)
+	parser := flags.NewParser(qfcfg, flags.None)
+	err := flags.NewIniParser(parser).ParseFile(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing queue config file %s",
+			fname)
+	}
+
+	if qfcfg.Name == "" {
+		return nil, errors.Errorf("queue config file %s does not specify "+
+			"the session name", fname)
+	}
+
+	minAmount, err := dcrutil.NewAmount(qfcfg.MinAmount)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid minimum amount of queue '%s'",
+			qfcfg.Name)
+	}
+	maxAmount, err := dcrutil.NewAmount(qfcfg.MaxAmount)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid maximum amount of queue '%s'",
+			qfcfg.Name)
+	}
+	if minAmount < 0 || maxAmount < 0 {
+		return nil, errors.Errorf("negative amount on queue '%s'", qfcfg.Name)
+	}
+
+	for _, s := range qfcfg.AllowedVoteAddress {
+		var addr dcrutil.Address
+		addr, err = dcrutil.DecodeAddress(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid allowed vote address of "+
+				"queue '%s'", qfcfg.Name)
+		}
+		if !addr.IsForNet(chainParams) {
+			return nil, errors.Errorf("allowed vote address %s of queue '%s' "+
+				"is for a different network", s, qfcfg.Name)
+		}
+	}
+
+	qcfg := &matcher.QueueConfig{
+		Pool:                 qfcfg.Pool,
+		Name:                 qfcfg.Name,
+		MinAmount:            uint64(minAmount),
+		MaxAmount:            uint64(maxAmount),
+		MaxParticipants:      qfcfg.MaxParticipants,
+		MaxSessionDuration:   qfcfg.MaxSessionDuration,
+		AllowedVoteAddresses: qfcfg.AllowedVoteAddress,
+		AllowedClients:       qfcfg.AllowedClient,
+	}
+	if qfcfg.JoinKey != "" {
+		qcfg.JoinKey = []byte(qfcfg.JoinKey)
+	}
+
+	return qcfg, nil
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+)
+
+func TestLoadQueueConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcrstmd-queues")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	net := &chaincfg.TestNet3Params
+	var hash [20]byte
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:], net, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig := func(content string) string {
+		fname := filepath.Join(dir, "queue.conf")
+		if err := ioutil.WriteFile(fname, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return fname
+	}
+
+	fname := writeConfig("Name = friends\n" +
+		"MinAmount = 1.5\n" +
+		"MaxAmount = 10\n" +
+		"MaxParticipants = 4\n" +
+		"MaxSessionDuration = 1m\n" +
+		"AllowedVoteAddress = " + addr.EncodeAddress() + "\n" +
+		"JoinKey = secret\n" +
+		"AllowedClient = alice\n" +
+		"AllowedClient = bob\n")
+	qcfg, err := loadQueueConfig(fname, net)
+	if err != nil {
+		t.Fatalf("unexpected error loading valid config: %v", err)
+	}
+	if qcfg.Name != "friends" || qcfg.MinAmount != 1.5e8 ||
+		qcfg.MaxAmount != 10e8 || qcfg.MaxParticipants != 4 ||
+		qcfg.MaxSessionDuration != time.Minute ||
+		len(qcfg.AllowedVoteAddresses) != 1 ||
+		string(qcfg.JoinKey) != "secret" ||
+		len(qcfg.AllowedClients) != 2 {
+		t.Fatalf("unexpected loaded config %#v", qcfg)
+	}
+
+	invalid := []string{
+		"MinAmount = 1\n",
+		"Name = friends\nMinAmount = -1\n",
+		"Name = friends\nAllowedVoteAddress = xxxx\n",
+		"Name = friends\nAllowedVoteAddress = " + addr.EncodeAddress() +
+			"\nUnknownOption = 1\n",
+	}
+	for i, content := range invalid {
+		if _, err := loadQueueConfig(writeConfig(content), net); err == nil {
+			t.Fatalf("invalid config %d did not return an error", i)
+		}
+	}
+
+	// Addresses of a different network are not allowed.
+	fname = writeConfig("Name = friends\nAllowedVoteAddress = " +
+		addr.EncodeAddress() + "\n")
+	if _, err := loadQueueConfig(fname, &chaincfg.MainNetParams); err == nil {
+		t.Fatalf("allowed vote address of a different network")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"strings"
+	"time"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil"
@@ -32,12 +33,25 @@ func encodeQueueName(name string) string {
 }
 
 func translateMatcherError(err error) error {
-	if err == matcher.ErrSessionExpired {
+	switch err {
+	case matcher.ErrSessionExpired, matcher.ErrParticipantDisconnected,
+		matcher.ErrCallSuperseded:
 		return codes.Aborted.Error(err.Error())
+	case matcher.ErrInvalidJoinKey, matcher.ErrClientNotAllowed,
+		matcher.ErrPrivateQueue:
+		return codes.PermissionDenied.Error(err.Error())
 	}
 	return err
 }
 
+// nextTicketPriceEstimator is implemented by network providers that estimate
+// the ticket price of the next stake difficulty window.
+type nextTicketPriceEstimator interface {
+	// NextTicketPrice returns the expected ticket price (in atoms) of the
+	// next stake difficulty window or zero if not available.
+	NextTicketPrice() uint64
+}
+
 // SplitTicketMatcherService implements the methods required to accept split
 // ticket session commands from a grpc service.
 type SplitTicketMatcherService struct {
@@ -45,6 +59,10 @@ type SplitTicketMatcherService struct {
 	networkProvider    matcher.NetworkProvider
 	allowPublicSession bool
 	log                slog.Logger
+
+	// clientAuth is used to identify the clients by their TLS certificates
+	// when mutual TLS is enabled.
+	clientAuth *clientAuthorizer
 }
 
 // NewSplitTicketMatcherService creates a new instance of a service, given all
@@ -65,7 +83,7 @@ func NewSplitTicketMatcherService(matcher *matcher.Matcher,
 func (svc *SplitTicketMatcherService) WatchWaitingList(req *pb.WatchWaitingListRequest, server pb.SplitTicketMatcherService_WatchWaitingListServer) error {
 
 	watcher := make(chan []matcher.WaitingQueue)
-	ctx := withOriginalSrcFromPeerCtx(server.Context())
+	ctx := withOriginalSrcFromPeerCtx(server.Context(), svc.clientAuth)
 	svc.matcher.WatchWaitingList(ctx, watcher, req.SendCurrent)
 
 	for {
@@ -78,6 +96,7 @@ func (svc *SplitTicketMatcherService) WatchWaitingList(req *pb.WatchWaitingListR
 			}
 			for i, q := range queues {
 				resp.Queues[i] = &pb.WatchWaitingListResponse_Queue{
+					Pool:    q.Pool,
 					Name:    encodeQueueName(q.Name),
 					Amounts: amountsToUint(q.Amounts),
 				}
@@ -94,7 +113,7 @@ func (svc *SplitTicketMatcherService) WatchWaitingList(req *pb.WatchWaitingListR
 func (svc *SplitTicketMatcherService) FindMatches(ctx context.Context, req *pb.FindMatchesRequest) (*pb.FindMatchesResponse, error) {
 	var voteAddr, poolAddr dcrutil.Address
 	var err error
-	ctx = withOriginalSrcFromPeerCtx(ctx)
+	ctx = withOriginalSrcFromPeerCtx(ctx, svc.clientAuth)
 
 	if req.ProtocolVersion != version.ProtocolVersion {
 		return nil, codes.FailedPrecondition.Errorf("server is "+
@@ -117,8 +136,21 @@ func (svc *SplitTicketMatcherService) FindMatches(ctx context.Context, req *pb.F
 			"error decoding pool address")
 	}
 
-	sess, err := svc.matcher.AddParticipant(ctx, req.Amount, req.SessionName,
-		voteAddr, poolAddr)
+	voteChoices := make(splitticket.VoteChoices, len(req.VoteChoices))
+	for i, c := range req.VoteChoices {
+		voteChoices[i] = splitticket.VoteChoice{
+			AgendaID: c.AgendaId,
+			ChoiceID: c.ChoiceId,
+		}
+	}
+
+	if err = voteChoices.Check(); err != nil {
+		return nil, codes.InvalidArgument.Wrap(err, "invalid vote choices")
+	}
+
+	sess, err := svc.matcher.AddParticipant(ctx, req.Amount, req.Pool,
+		req.SessionName, voteAddr, poolAddr, voteChoices,
+		req.VoteChoicesSignature, req.JoinMac)
 	if err != nil {
 		return nil, translateMatcherError(err)
 	}
@@ -133,6 +165,7 @@ func (svc *SplitTicketMatcherService) FindMatches(ctx context.Context, req *pb.F
 		TicketPrice:     uint64(sess.Session.TicketPrice),
 		NbParticipants:  uint32(len(sess.Session.Participants)),
 		SessionToken:    sess.SessionToken,
+		FeeRate:         uint64(sess.Session.FeeRate),
 	}
 	return res, nil
 }
@@ -144,7 +177,7 @@ func (svc *SplitTicketMatcherService) GenerateTicket(ctx context.Context, req *p
 	var commitAddr, splitAddr dcrutil.Address
 	var err error
 
-	ctx = withOriginalSrcFromPeerCtx(ctx)
+	ctx = withOriginalSrcFromPeerCtx(ctx, svc.clientAuth)
 
 	if req.SplitTxChange != nil {
 		splitChange = wire.NewTxOut(int64(req.SplitTxChange.Value), req.SplitTxChange.Script)
@@ -215,7 +248,7 @@ func (svc *SplitTicketMatcherService) GenerateTicket(ctx context.Context, req *p
 // FundTicket fulfills SplitTicketMatcherServiceServer
 func (svc *SplitTicketMatcherService) FundTicket(ctx context.Context, req *pb.FundTicketRequest) (*pb.FundTicketResponse, error) {
 
-	ctx = withOriginalSrcFromPeerCtx(ctx)
+	ctx = withOriginalSrcFromPeerCtx(ctx, svc.clientAuth)
 
 	ticketsInput := make([][]byte, len(req.Tickets))
 	for i, t := range req.Tickets {
@@ -246,7 +279,7 @@ func (svc *SplitTicketMatcherService) FundTicket(ctx context.Context, req *pb.Fu
 // FundSplitTx fulfills SplitTicketMatcherServiceServer
 func (svc *SplitTicketMatcherService) FundSplitTx(ctx context.Context, req *pb.FundSplitTxRequest) (*pb.FundSplitTxResponse, error) {
 
-	ctx = withOriginalSrcFromPeerCtx(ctx)
+	ctx = withOriginalSrcFromPeerCtx(ctx, svc.clientAuth)
 
 	split, secrets, err := svc.matcher.FundSplit(ctx,
 		matcher.ParticipantID(req.SessionId),
@@ -284,11 +317,47 @@ func (svc *SplitTicketMatcherService) BuyerError(ctx context.Context, req *pb.Bu
 }
 
 // Status fulfills SplitTicketMatcherServiceServer
-func (svc *SplitTicketMatcherService) Status(context.Context, *pb.StatusRequest) (*pb.StatusResponse, error) {
+func (svc *SplitTicketMatcherService) Status(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
 	currentHash := svc.networkProvider.CurrentBlockHash()
+	maxDuration := svc.matcher.MaxSessionDuration(req.Pool, req.SessionName)
+
+	resp := &pb.StatusResponse{
+		TicketPrice:               svc.networkProvider.CurrentTicketPrice(),
+		MainchainHash:             currentHash[:],
+		MainchainHeight:           svc.networkProvider.CurrentBlockHeight(),
+		StakeDiffChangeStopWindow: svc.matcher.StakeDiffChangeStopWindow(),
+		FeeRate:                   uint64(svc.networkProvider.CurrentFeeRate()),
+		MaxSessionDuration:        uint32(maxDuration.Seconds()),
+	}
+	if est, is := svc.networkProvider.(nextTicketPriceEstimator); is {
+		resp.NextTicketPrice = est.NextTicketPrice()
+	}
+	return resp, nil
+}
+
+// EstimateWait fulfills SplitTicketMatcherServiceServer
+func (svc *SplitTicketMatcherService) EstimateWait(ctx context.Context, req *pb.EstimateWaitRequest) (*pb.EstimateWaitResponse, error) {
+	if req.SessionName == "" && !svc.allowPublicSession {
+		return nil, codes.FailedPrecondition.Errorf("server does not " +
+			"allow participation in the public session")
+	}
+
+	est, err := svc.matcher.EstimateWait(ctx, req.Pool, req.SessionName,
+		req.Amount)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	if err == matcher.ErrPrivateQueue {
+		return nil, translateMatcherError(err)
+	}
+	if err != nil {
+		return nil, codes.InvalidArgument.Wrap(err, "error estimating wait")
+	}
 
-	return &pb.StatusResponse{
-		TicketPrice:   svc.networkProvider.CurrentTicketPrice(),
-		MainchainHash: currentHash[:],
+	return &pb.EstimateWaitResponse{
+		MissingAmount:     uint64(est.MissingAmount),
+		AverageAmount:     uint64(est.AverageAmount),
+		ArrivalIntervalMs: int64(est.ArrivalInterval / time.Millisecond),
+		ExpectedArrivals:  uint32(est.ExpectedArrivals),
 	}, nil
 }
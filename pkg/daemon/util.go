@@ -2,124 +2,75 @@ package daemon
 
 import (
 	"context"
-	"github.com/decred/dcrd/chaincfg"
-	"github.com/decred/dcrd/chaincfg/chainec"
-	"github.com/decred/dcrd/dcrec"
-	"github.com/decred/dcrd/dcrec/secp256k1"
-	"github.com/decred/dcrd/dcrutil"
-	"github.com/decred/dcrd/txscript"
-	"github.com/decred/dcrd/wire"
+	"fmt"
+
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
-	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
 )
 
-// privateKeySplitPoolSigner fulfills the SignPoolSplitOutputProvider by storing
-// a single secp256k1 private key and signing the pool fee always using it.
-type privateKeySplitPoolSigner struct {
-	privateKey chainec.PrivateKey
-	address    dcrutil.Address
-	net        *chaincfg.Params
-}
-
-// newPrivateKeySplitPoolSigner creates a new privateKeySplitPoolSigner given
-// a secp256k1 private key wif.
-func newPrivateKeySplitPoolSigner(privateKeyWif string, net *chaincfg.Params) (*privateKeySplitPoolSigner, error) {
-	if privateKeyWif == "" {
-		return nil, errors.Errorf("private key wif is empty")
-	}
-
-	wif, err := dcrutil.DecodeWIF(privateKeyWif)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error decoding private key wif")
-	}
-
-	if wif.DSA() != dcrec.STEcdsaSecp256k1 {
-		return nil, errors.Errorf("only a secp256k1 private key is acceptable")
+// withOriginalSrcFromPeerCtx returns a new context usable within the matcher
+// package that indicates the original source for a given matcher operation. It
+// tries to extract the original source by using the peer grpc package to
+// extract the source information.
+//
+// When auth is specified, the identity of the client certificate is also
+// stored in the context and included in the original source.
+func withOriginalSrcFromPeerCtx(parent context.Context,
+	auth *clientAuthorizer) context.Context {
+
+	if connCtx, ok := parent.Value(connTrackerCtxKey{}).(context.Context); ok {
+		parent = matcher.WithConnectionContext(parent, connCtx)
 	}
 
-	privKey := wif.PrivKey
-	x, y := privKey.Public()
-
-	pubKey := secp256k1.NewPublicKey(x, y)
-	pubKeyAddr, err := dcrutil.NewAddressSecpPubKey(pubKey.Serialize(), net)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error creating secpPubKeyAddr from pubKey")
+	if pr, ok := peer.FromContext(parent); ok {
+		src := pr.Addr.String()
+		tlsInfo, isTLS := pr.AuthInfo.(credentials.TLSInfo)
+		if auth != nil && isTLS && len(tlsInfo.State.PeerCertificates) > 0 {
+			id := auth.identity(tlsInfo.State.PeerCertificates[0])
+			parent = matcher.WithClientIdentity(parent, id)
+			src = fmt.Sprintf("%s (%s)", src, id)
+		}
+		return matcher.WithOriginalSrc(parent, src)
 	}
 
-	pkhAddr := pubKeyAddr.AddressPubKeyHash()
-
-	return &privateKeySplitPoolSigner{
-		address:    pkhAddr,
-		privateKey: privKey,
-		net:        net,
-	}, nil
-}
-
-// PoolFeeAddress fulfills SignPoolSplitOutputProvider.PoolFeeAddress
-func (signer *privateKeySplitPoolSigner) PoolFeeAddress() dcrutil.Address {
-	return signer.address
+	return matcher.WithOriginalSrc(parent, "[peer unkonwn]")
 }
 
-// SignPoolSplitOutput fulfills SignPoolSplitOutputProvider.SignPoolSplitOutput.
-// It uses the stored private key to sign the pool split output.
-// Assumes the pool output is the second TxOut of the split tx.
-func (signer *privateKeySplitPoolSigner) SignPoolSplitOutput(split, ticket *wire.MsgTx) ([]byte, error) {
-	if len(split.TxOut) < 2 {
-		return nil, errors.Errorf("split has less than 2 outputs")
-	}
-
-	splitOut := split.TxOut[1]
-
-	// Extract and print details from the script.
-	scriptClass, addresses, reqSigs, err := txscript.ExtractPkScriptAddrs(
-		splitOut.Version, splitOut.PkScript, signer.net)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error decoding split.TxOut[1].PkScript")
-	}
-
-	if scriptClass != txscript.PubKeyHashTy {
-		return nil, errors.Errorf("script class is not PubKeyHashTy")
-	}
-
-	if reqSigs != 1 {
-		return nil, errors.Errorf("reqSigs different than 1")
-	}
-
-	if len(addresses) != 1 {
-		return nil, errors.Errorf("decoded a different number of addresses "+
-			"(%d) than expected (1)", len(addresses))
-	}
+type (
+	connTrackerCtxKey       struct{}
+	connTrackerCancelCtxKey struct{}
+)
 
-	if addresses[0].EncodeAddress() != signer.address.EncodeAddress() {
-		return nil, errors.Errorf("decoded a different address (%s) than "+
-			"expected (%s)", addresses[0].EncodeAddress(),
-			signer.address.EncodeAddress())
-	}
+// connTracker is a grpc stats handler that tracks the lifetime of the
+// connections of clients. The context of each connection is stored in the
+// context of the calls performed through it and is canceled once the
+// connection is closed (either by the client or after failing keepalive
+// checks), so that the matcher can detect disconnected participants.
+type connTracker struct{}
+
+// TagConn fulfills stats.Handler
+func (connTracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	connCtx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, connTrackerCtxKey{}, connCtx)
+	return context.WithValue(ctx, connTrackerCancelCtxKey{}, cancel)
+}
 
-	lookupKey := func(a dcrutil.Address) (chainec.PrivateKey, bool, error) {
-		return signer.privateKey, true, nil
+// HandleConn fulfills stats.Handler
+func (connTracker) HandleConn(ctx context.Context, s stats.ConnStats) {
+	if _, isEnd := s.(*stats.ConnEnd); !isEnd {
+		return
 	}
-
-	sigScript, err := txscript.SignTxOutput(signer.net,
-		ticket, 0, splitOut.PkScript, txscript.SigHashAll,
-		txscript.KeyClosure(lookupKey), nil, nil,
-		dcrec.STEcdsaSecp256k1)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error signing ticket.TxOut[0]")
+	if cancel, ok := ctx.Value(connTrackerCancelCtxKey{}).(context.CancelFunc); ok {
+		cancel()
 	}
-
-	return sigScript, nil
 }
 
-// withOriginalSrcFromPeerCtx returns a new context usable within the matcher
-// package that indicates the original source for a given matcher operation. It
-// tries to extract the original source by using the peer grpc package to
-// extract the source information.
-func withOriginalSrcFromPeerCtx(parent context.Context) context.Context {
-	if pr, ok := peer.FromContext(parent); ok {
-		return matcher.WithOriginalSrc(parent, pr.Addr.String())
-	}
-
-	return matcher.WithOriginalSrc(parent, "[peer unkonwn]")
+// TagRPC fulfills stats.Handler
+func (connTracker) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
 }
+
+// HandleRPC fulfills stats.Handler
+func (connTracker) HandleRPC(ctx context.Context, s stats.RPCStats) {}
@@ -81,13 +81,14 @@ func (wallet *WalletClient) Run(serverCtx context.Context) {
 
 // PoolFeeAddress returns the address to use when moving funds into the utxo of
 // the split tx that will be used to fund the pool fee input of the ticket.
-func (wallet *WalletClient) PoolFeeAddress() dcrutil.Address {
-	return wallet.poolFeeAddress
+func (wallet *WalletClient) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	return wallet.poolFeeAddress, 0, nil
 }
 
 // SignPoolSplitOutput signs the pool fee input of the ticket with the key
 // associated with PoolFeeAddress().
-func (wallet *WalletClient) SignPoolSplitOutput(split, ticket *wire.MsgTx) ([]byte, error) {
+func (wallet *WalletClient) SignPoolSplitOutput(split, ticket *wire.MsgTx,
+	keyIndex uint32) ([]byte, error) {
 
 	inputs := make([]dcrjson.RawTxInput, len(split.TxOut))
 	txid := split.TxHash().String()
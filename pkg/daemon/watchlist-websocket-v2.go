@@ -0,0 +1,562 @@
+package daemon
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/gorilla/websocket"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"golang.org/x/net/context"
+)
+
+// wsV2Version is the version of the waiting list websocket protocol served on
+// the /v2/watchWaitingList endpoint.
+//
+// In this protocol, every message is a JSON object with the "version" and
+// "type" fields. Clients may send the following requests (with an optional
+// "id" field, echoed in the reply):
+//
+//   - {"type": "subscribe", "queues": [hashes]}: watch only the queues with
+//     the given (hex encoded sha256) name hashes, in addition to the ones
+//     previously subscribed. An empty list watches every queue, which is the
+//     initial state of new connections.
+//   - {"type": "unsubscribe", "queues": [hashes]}: stop watching the given
+//     queues. When watching every queue, the given ones are excluded from it.
+//     An empty list stops watching every queue.
+//   - {"type": "ping"}: replied with a "pong" message.
+//
+// Subscription changes are replied with a "subscriptions" message. The server
+// pushes "queues" messages with the current state of the watched queues and
+// "status" messages with the state of the matcher (ticket price, blocks until
+// the stake difficulty changes, active sessions and the estimated number of
+// seconds until the next session starts) whenever they change.
+const wsV2Version = 2
+
+const (
+	// wsPingPeriod is the interval between the pings sent to websocket
+	// watchers.
+	wsPingPeriod = 50 * time.Second
+
+	// wsPongWait is how long to wait for any message (including pongs) from
+	// a watcher before considering it dead.
+	wsPongWait = 60 * time.Second
+
+	// wsWriteWait is how long to wait for a message to be written to a
+	// watcher.
+	wsWriteWait = 10 * time.Second
+
+	// wsStatusInterval is the interval between updates of the matcher status
+	// sent to watchers.
+	wsStatusInterval = 5 * time.Second
+
+	// wsV2MaxMessageSize is the maximum size of requests sent by watchers.
+	wsV2MaxMessageSize = 32 * 1024
+
+	// wsV2MaxSubscriptions is the maximum number of queues a single watcher
+	// can subscribe to.
+	wsV2MaxSubscriptions = 256
+
+	// wsV2MaxPendingReplies is the maximum number of replies to requests of a
+	// watcher waiting to be written. Watchers that send requests faster than
+	// they read the replies are disconnected once this is reached.
+	wsV2MaxPendingReplies = 16
+)
+
+// Types of the requests sent by v2 watchers.
+const (
+	wsV2ReqSubscribe   = "subscribe"
+	wsV2ReqUnsubscribe = "unsubscribe"
+	wsV2ReqPing        = "ping"
+)
+
+// Types of the messages sent to v2 watchers.
+const (
+	wsV2MsgQueues        = "queues"
+	wsV2MsgStatus        = "status"
+	wsV2MsgSubscriptions = "subscriptions"
+	wsV2MsgPong          = "pong"
+	wsV2MsgError         = "error"
+)
+
+type wsV2Request struct {
+	Type   string          `json:"type"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Queues []string        `json:"queues,omitempty"`
+}
+
+type wsV2Header struct {
+	Version int             `json:"version"`
+	Type    string          `json:"type"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+func newWSV2Header(msgType string, id json.RawMessage) wsV2Header {
+	return wsV2Header{Version: wsV2Version, Type: msgType, ID: id}
+}
+
+type wsV2Queue struct {
+	Pool    string           `json:"pool,omitempty"`
+	Name    string           `json:"name"`
+	Amounts []dcrutil.Amount `json:"amounts"`
+	Total   dcrutil.Amount   `json:"total"`
+}
+
+type wsV2QueuesMsg struct {
+	wsV2Header
+	Queues []wsV2Queue `json:"queues"`
+}
+
+type wsV2StatusMsg struct {
+	wsV2Header
+	TicketPrice             dcrutil.Amount `json:"ticketPrice"`
+	BlockHeight             uint32         `json:"blockHeight"`
+	BlocksToStakeDiffChange int32          `json:"blocksToStakeDiffChange"`
+	ActiveSessions          int            `json:"activeSessions"`
+
+	// NextMatchEstimate is the estimated number of seconds until the next
+	// session starts. It is omitted when no estimate is available.
+	NextMatchEstimate *int64 `json:"nextMatchEstimate,omitempty"`
+}
+
+type wsV2SubscriptionsMsg struct {
+	wsV2Header
+	All      bool     `json:"all"`
+	Queues   []string `json:"queues"`
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+type wsV2ErrorMsg struct {
+	wsV2Header
+	Error string `json:"error"`
+}
+
+// wsV2Client is the state of the connection of a v2 waiting list watcher.
+//
+// Updates of the queues and of the matcher status are coalesced, so that a
+// watcher that is slow to read its messages receives the most recent state
+// once it catches up instead of missing updates.
+type wsV2Client struct {
+	mtx sync.Mutex
+
+	// all is true when subscribed to every queue except the ones in
+	// excluded. Otherwise, subs are the name hashes of the watched queues.
+	all      bool
+	subs     map[string]struct{}
+	excluded map[string]struct{}
+
+	queues      []matcher.WaitingQueue
+	hasQueues   bool
+	queuesDirty bool
+	sentQueues  []wsV2Queue
+
+	status      *matcher.Status
+	statusDirty bool
+
+	replies  []interface{}
+	overflow bool
+
+	// signal is written to (without blocking) whenever there are messages to
+	// send to the watcher.
+	signal chan struct{}
+}
+
+func newWSV2Client() *wsV2Client {
+	return &wsV2Client{
+		all:      true,
+		subs:     make(map[string]struct{}),
+		excluded: make(map[string]struct{}),
+		signal:   make(chan struct{}, 1),
+	}
+}
+
+func (c *wsV2Client) notify() {
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (c *wsV2Client) setQueues(queues []matcher.WaitingQueue) {
+	c.mtx.Lock()
+	c.queues = queues
+	c.hasQueues = true
+	c.queuesDirty = true
+	c.mtx.Unlock()
+	c.notify()
+}
+
+func (c *wsV2Client) setStatus(st *matcher.Status) {
+	c.mtx.Lock()
+	if c.status != nil && *c.status == *st {
+		c.mtx.Unlock()
+		return
+	}
+	c.status = st
+	c.statusDirty = true
+	c.mtx.Unlock()
+	c.notify()
+}
+
+// reply enqueues a reply to a request. Must be called with the mutex held.
+func (c *wsV2Client) reply(msg interface{}) {
+	if len(c.replies) >= wsV2MaxPendingReplies {
+		c.overflow = true
+		return
+	}
+	c.replies = append(c.replies, msg)
+}
+
+func (c *wsV2Client) replyError(id json.RawMessage, err string) {
+	c.reply(&wsV2ErrorMsg{newWSV2Header(wsV2MsgError, id), err})
+}
+
+// sortedHashes returns the queue name hashes of the given set, sorted.
+func sortedHashes(set map[string]struct{}) []string {
+	res := make([]string, 0, len(set))
+	for h := range set {
+		res = append(res, h)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (c *wsV2Client) replySubscriptions(id json.RawMessage) {
+	c.reply(&wsV2SubscriptionsMsg{newWSV2Header(wsV2MsgSubscriptions, id),
+		c.all, sortedHashes(c.subs), sortedHashes(c.excluded)})
+}
+
+// decodeQueueHashes returns the normalized queue name hashes of a request or
+// an error message if any of them is not a valid hash.
+func decodeQueueHashes(hashes []string) ([]string, string) {
+	res := make([]string, len(hashes))
+	for i, h := range hashes {
+		h = strings.ToLower(h)
+		if b, err := hex.DecodeString(h); err != nil || len(b) != 32 {
+			return nil, "invalid queue hash " + h
+		}
+		res[i] = h
+	}
+	return res, ""
+}
+
+// handleRequest processes a request sent by the watcher.
+func (c *wsV2Client) handleRequest(b []byte) {
+	c.mtx.Lock()
+	defer func() {
+		c.mtx.Unlock()
+		c.notify()
+	}()
+
+	var req wsV2Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		c.replyError(nil, "malformed request")
+		return
+	}
+
+	switch req.Type {
+	case wsV2ReqSubscribe:
+		hashes, errMsg := decodeQueueHashes(req.Queues)
+		if errMsg != "" {
+			c.replyError(req.ID, errMsg)
+			return
+		}
+		if len(hashes) == 0 {
+			c.all = true
+			c.subs = make(map[string]struct{})
+			c.excluded = make(map[string]struct{})
+		} else {
+			subs := make(map[string]struct{}, len(c.subs)+len(hashes))
+			if !c.all {
+				for h := range c.subs {
+					subs[h] = struct{}{}
+				}
+			}
+			for _, h := range hashes {
+				subs[h] = struct{}{}
+			}
+			if len(subs) > wsV2MaxSubscriptions {
+				c.replyError(req.ID, "too many subscriptions")
+				return
+			}
+			c.all = false
+			c.subs = subs
+			c.excluded = make(map[string]struct{})
+		}
+		c.queuesDirty = c.hasQueues
+		c.replySubscriptions(req.ID)
+
+	case wsV2ReqUnsubscribe:
+		hashes, errMsg := decodeQueueHashes(req.Queues)
+		if errMsg != "" {
+			c.replyError(req.ID, errMsg)
+			return
+		}
+		switch {
+		case len(hashes) == 0:
+			c.all = false
+			c.subs = make(map[string]struct{})
+			c.excluded = make(map[string]struct{})
+		case c.all:
+			excluded := make(map[string]struct{}, len(c.excluded)+len(hashes))
+			for h := range c.excluded {
+				excluded[h] = struct{}{}
+			}
+			for _, h := range hashes {
+				excluded[h] = struct{}{}
+			}
+			if len(excluded) > wsV2MaxSubscriptions {
+				c.replyError(req.ID, "too many excluded queues")
+				return
+			}
+			c.excluded = excluded
+		default:
+			for _, h := range hashes {
+				delete(c.subs, h)
+			}
+		}
+		c.queuesDirty = c.hasQueues
+		c.replySubscriptions(req.ID)
+
+	case wsV2ReqPing:
+		c.reply(&wsV2Header{Version: wsV2Version, Type: wsV2MsgPong, ID: req.ID})
+
+	default:
+		c.replyError(req.ID, "unknown request type "+req.Type)
+	}
+}
+
+// watchedQueues returns the queues the watcher is subscribed to. Must be called
+// with the mutex held.
+func (c *wsV2Client) watchedQueues() []wsV2Queue {
+	res := make([]wsV2Queue, 0, len(c.queues))
+	for _, q := range c.queues {
+		name := encodeQueueName(q.Name)
+		if c.all {
+			if _, excluded := c.excluded[name]; excluded {
+				continue
+			}
+		} else if _, has := c.subs[name]; !has {
+			continue
+		}
+
+		var total dcrutil.Amount
+		for _, a := range q.Amounts {
+			total += a
+		}
+		res = append(res, wsV2Queue{q.Pool, name, q.Amounts, total})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Pool != res[j].Pool {
+			return res[i].Pool < res[j].Pool
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// pending returns the messages that need to be sent to the watcher and
+// whether it has overflowed its pending replies.
+func (c *wsV2Client) pending(now time.Time) ([]interface{}, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	msgs := c.replies
+	c.replies = nil
+
+	if c.statusDirty {
+		st := c.status
+		msg := &wsV2StatusMsg{
+			wsV2Header:              newWSV2Header(wsV2MsgStatus, nil),
+			TicketPrice:             st.TicketPrice,
+			BlockHeight:             st.BlockHeight,
+			BlocksToStakeDiffChange: st.BlocksToStakeDiffChange,
+			ActiveSessions:          st.ActiveSessions,
+		}
+		if eta, ok := st.NextSessionEstimate(now); ok {
+			secs := int64(eta / time.Second)
+			msg.NextMatchEstimate = &secs
+		}
+		msgs = append(msgs, msg)
+		c.statusDirty = false
+	}
+
+	if c.queuesDirty {
+		queues := c.watchedQueues()
+		if c.sentQueues == nil || !reflect.DeepEqual(queues, c.sentQueues) {
+			msgs = append(msgs, &wsV2QueuesMsg{
+				newWSV2Header(wsV2MsgQueues, nil), queues})
+			c.sentQueues = queues
+		}
+		c.queuesDirty = false
+	}
+
+	return msgs, c.overflow
+}
+
+func (svc *waitlistWebsocketService) watchWaitingListV2(w http.ResponseWriter, r *http.Request) {
+	c, err := svc.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		svc.log.Errorf("Error upgrading websocket connection: %v", err)
+		return
+	}
+	defer c.Close()
+
+	srcAddr := svc.trusted.clientAddr(r)
+	svc.log.Debugf("New websocket v2 watcher %s", srcAddr)
+
+	ctx, cancel := context.WithCancel(matcher.WithOriginalSrc(r.Context(),
+		"[wss2]"+srcAddr))
+	defer cancel()
+
+	client := newWSV2Client()
+	if st := svc.currentStatus(); st != nil {
+		client.setStatus(st)
+	}
+	svc.v2Clients.Store(client, struct{}{})
+	defer svc.v2Clients.Delete(client)
+
+	shutdownChan := make(chan struct{})
+	svc.openWatchers.Store(shutdownChan, struct{}{})
+	defer svc.openWatchers.Delete(shutdownChan)
+
+	watcher := make(chan []matcher.WaitingQueue)
+	svc.matcher.WatchWaitingList(ctx, watcher, true)
+	go func() {
+		for {
+			select {
+			case queues := <-watcher:
+				client.setQueues(queues)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Any message from the watcher (including pongs and pings) shows it is
+	// still alive.
+	alive := func() {
+		c.SetReadDeadline(time.Now().Add(svc.pongWait))
+	}
+	c.SetReadLimit(wsV2MaxMessageSize)
+	alive()
+	c.SetPongHandler(func(string) error {
+		alive()
+		return nil
+	})
+	c.SetPingHandler(func(data string) error {
+		alive()
+		err := c.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(svc.writeWait))
+		if e, is := err.(net.Error); err == websocket.ErrCloseSent ||
+			(is && e.Temporary()) {
+			return nil
+		}
+		return err
+	})
+
+	readChan := make(chan error, 1)
+	go func() {
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				readChan <- err
+				return
+			}
+			alive()
+			client.handleRequest(msg)
+		}
+	}()
+
+	closeWith := func(code int, reason string) {
+		c.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(code, reason),
+			time.Now().Add(svc.writeWait))
+	}
+
+	pingTicker := time.NewTicker(svc.pingPeriod)
+	defer pingTicker.Stop()
+	for {
+		select {
+		case <-client.signal:
+			msgs, overflow := client.pending(time.Now())
+			for _, msg := range msgs {
+				c.SetWriteDeadline(time.Now().Add(svc.writeWait))
+				if err := c.WriteJSON(msg); err != nil {
+					svc.log.Infof("Dropping websocket watcher %s: error "+
+						"writing message: %v", srcAddr, err)
+					return
+				}
+			}
+			if overflow {
+				svc.log.Infof("Dropping websocket watcher %s: too many "+
+					"pending replies", srcAddr)
+				closeWith(websocket.CloseTryAgainLater, "too many pending replies")
+				return
+			}
+		case <-pingTicker.C:
+			err := c.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(svc.writeWait))
+			if err != nil {
+				svc.log.Infof("Dropping websocket watcher %s: error "+
+					"writing ping: %v", srcAddr, err)
+				return
+			}
+		case err := <-readChan:
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure,
+				websocket.CloseGoingAway) {
+				svc.log.Debugf("Done websocket watcher %s", srcAddr)
+			} else {
+				svc.log.Infof("Dropping websocket watcher %s: %v", srcAddr, err)
+				closeWith(websocket.CloseGoingAway, "")
+			}
+			return
+		case <-ctx.Done():
+			svc.log.Debugf("Done websocket watcher %s", srcAddr)
+			return
+		case <-shutdownChan:
+			closeWith(websocket.CloseGoingAway, "server shutting down")
+			return
+		}
+	}
+}
+
+// currentStatus returns the most recent status of the matcher fetched by
+// pollStatus (or nil if it was not fetched yet).
+func (svc *waitlistWebsocketService) currentStatus() *matcher.Status {
+	svc.statusMtx.Lock()
+	defer svc.statusMtx.Unlock()
+	return svc.status
+}
+
+// pollStatus regularly fetches the status of the matcher and sends it to the
+// connected v2 watchers. It returns once the context is done.
+func (svc *waitlistWebsocketService) pollStatus(ctx context.Context) {
+	for {
+		st, err := svc.matcher.Status(ctx)
+		if err == nil {
+			svc.statusMtx.Lock()
+			svc.status = st
+			svc.statusMtx.Unlock()
+
+			svc.v2Clients.Range(func(key, value interface{}) bool {
+				key.(*wsV2Client).setStatus(st)
+				return true
+			})
+		}
+
+		select {
+		case <-time.After(svc.statusInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
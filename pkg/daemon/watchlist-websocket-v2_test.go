@@ -0,0 +1,340 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	"github.com/gorilla/websocket"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+)
+
+// testNetwork fulfills the matcher.NetworkProvider interface for tests.
+type testNetwork struct{}
+
+func (n testNetwork) CurrentTicketPrice() uint64              { return 100e8 }
+func (n testNetwork) CurrentBlockHeight() uint32              { return 1000 }
+func (n testNetwork) CurrentBlockHash() chainhash.Hash        { return chainhash.Hash{} }
+func (n testNetwork) CurrentFeeRate() dcrutil.Amount          { return splitticket.TxFeeRate }
+func (n testNetwork) ConnectedToDecredNetwork() bool          { return true }
+func (n testNetwork) PublishTransactions([]*wire.MsgTx) error { return nil }
+
+func (n testNetwork) GetUtxos(outpoints []*wire.OutPoint) (splitticket.UtxoMap, error) {
+	return nil, nil
+}
+
+func testAddress(b byte) dcrutil.Address {
+	var hash [20]byte
+	hash[0] = b
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:],
+		&chaincfg.TestNet3Params, 0)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// newTestWaitlistService returns a websocket service (served by an httptest
+// server) for a running matcher with participants waiting on the "alpha" and
+// "beta" queues.
+func newTestWaitlistService(t *testing.T, ctx context.Context) (
+	*waitlistWebsocketService, *httptest.Server) {
+
+	m, err := matcher.NewMatcher(&matcher.Config{
+		MinAmount:          1e8,
+		NetworkProvider:    testNetwork{},
+		VoteAddrValidator:  matcher.InsecurePoolAddressesValidator{},
+		PoolAddrValidator:  matcher.InsecurePoolAddressesValidator{},
+		Log:                slog.Disabled,
+		SessionLog:         slog.Disabled,
+		ChainParams:        &chaincfg.TestNet3Params,
+		MaxSessionDuration: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go m.Run(ctx)
+
+	for i, name := range []string{"alpha", "beta"} {
+		go m.AddParticipant(ctx, 10e8, matcher.DefaultPoolName, name,
+			testAddress(byte(i+1)), testAddress(byte(i+0x10)), nil, nil, nil)
+	}
+
+	svc, err := newWaitlistWebsocketService("127.0.0.1:0", m, nil,
+		slog.Disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.listener.Close()
+	svc.statusInterval = 20 * time.Millisecond
+	go svc.pollStatus(ctx)
+
+	srv := httptest.NewServer(svc.server.Handler)
+	return svc, srv
+}
+
+func dialTestWaitlistService(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v2/watchWaitingList"
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("unexpected error dialing websocket: %v", err)
+	}
+	return c
+}
+
+// testWSV2Msg has the fields of all messages sent to v2 watchers.
+type testWSV2Msg struct {
+	wsV2Header
+	wsV2StatusMsg
+	All    bool            `json:"all"`
+	Queues json.RawMessage `json:"queues"`
+	Error  string          `json:"error"`
+}
+
+func TestWaitlistWebsocketV2(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, srv := newTestWaitlistService(t, ctx)
+	defer srv.Close()
+
+	c := dialTestWaitlistService(t, srv)
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	alpha := encodeQueueName("alpha")
+	reqs := []string{
+		`{"type": "subscribe", "id": 1, "queues": ["` + alpha + `"]}`,
+		`{"type": "subscribe", "id": 2, "queues": ["xx"]}`,
+		`{"type": "ping", "id": "p"}`,
+		`{"type": "unknown", "id": 3}`,
+	}
+	for _, req := range reqs {
+		if err := c.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotSubs, gotPong, gotStatus bool
+	var errIDs []string
+	for !gotSubs || !gotPong || !gotStatus || len(errIDs) < 2 {
+		var msg testWSV2Msg
+		if err := c.ReadJSON(&msg); err != nil {
+			t.Fatalf("unexpected error reading message: %v", err)
+		}
+		if msg.Version != wsV2Version {
+			t.Fatalf("unexpected version %d", msg.Version)
+		}
+
+		switch msg.wsV2Header.Type {
+		case wsV2MsgSubscriptions:
+			var queues []string
+			json.Unmarshal(msg.Queues, &queues)
+			if string(msg.wsV2Header.ID) != "1" || msg.All ||
+				len(queues) != 1 || queues[0] != alpha {
+				t.Fatalf("unexpected subscriptions reply %v", msg)
+			}
+			gotSubs = true
+		case wsV2MsgPong:
+			if string(msg.wsV2Header.ID) != `"p"` {
+				t.Fatalf("unexpected pong id %s", msg.wsV2Header.ID)
+			}
+			gotPong = true
+		case wsV2MsgError:
+			errIDs = append(errIDs, string(msg.wsV2Header.ID))
+		case wsV2MsgStatus:
+			if msg.TicketPrice != 100e8 || msg.ActiveSessions != 0 ||
+				msg.BlocksToStakeDiffChange <= 0 {
+				t.Fatalf("unexpected status %v", msg.wsV2StatusMsg)
+			}
+			gotStatus = true
+		case wsV2MsgQueues:
+			// Wait for the initial list of queues below.
+		default:
+			t.Fatalf("unexpected message type %s", msg.wsV2Header.Type)
+		}
+	}
+	if errIDs[0] != "2" || errIDs[1] != "3" {
+		t.Fatalf("unexpected errors %v", errIDs)
+	}
+
+	// The initial list of queues only includes the subscribed one.
+	for {
+		var msg testWSV2Msg
+		if err := c.ReadJSON(&msg); err != nil {
+			t.Fatalf("unexpected error reading message: %v", err)
+		}
+		if msg.wsV2Header.Type != wsV2MsgQueues {
+			continue
+		}
+
+		var queues []wsV2Queue
+		if err := json.Unmarshal(msg.Queues, &queues); err != nil {
+			t.Fatal(err)
+		}
+		if len(queues) != 1 || queues[0].Name != alpha ||
+			queues[0].Total != 10e8 {
+			t.Fatalf("unexpected queues %v", queues)
+		}
+		break
+	}
+
+	// Unsubscribing from all queues sends an empty list.
+	err := c.WriteMessage(websocket.TextMessage,
+		[]byte(`{"type": "unsubscribe"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var msg testWSV2Msg
+		if err := c.ReadJSON(&msg); err != nil {
+			t.Fatalf("unexpected error reading message: %v", err)
+		}
+		if msg.wsV2Header.Type == wsV2MsgQueues {
+			if string(msg.Queues) != "[]" {
+				t.Fatalf("unexpected queues after unsubscribing %s",
+					msg.Queues)
+			}
+			break
+		}
+	}
+}
+
+func TestWaitlistWebsocketV2PingTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc, srv := newTestWaitlistService(t, ctx)
+	defer srv.Close()
+	svc.pingPeriod = 20 * time.Millisecond
+	svc.pongWait = 200 * time.Millisecond
+
+	// A watcher that keeps reading answers the pings and stays connected.
+	c := dialTestWaitlistService(t, srv)
+	defer c.Close()
+	done := time.Now().Add(500 * time.Millisecond)
+	c.SetReadDeadline(done)
+	for {
+		_, _, err := c.ReadMessage()
+		if websocket.IsUnexpectedCloseError(err) {
+			t.Fatalf("reading watcher disconnected: %v", err)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if time.Now().Before(done) {
+		t.Fatalf("reading watcher disconnected early")
+	}
+
+	// A watcher that stops reading (and answering pings) is dropped.
+	c2 := dialTestWaitlistService(t, srv)
+	defer c2.Close()
+	time.Sleep(500 * time.Millisecond)
+	c2.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := c2.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if e, is := err.(net.Error); is && e.Timeout() {
+			t.Fatalf("watcher that stopped reading was not dropped")
+		}
+		break
+	}
+}
+
+func TestWSV2ClientCoalescing(t *testing.T) {
+	c := newWSV2Client()
+	now := time.Now()
+
+	// Only the most recent status and queues are sent.
+	c.setStatus(&matcher.Status{TicketPrice: 1})
+	c.setStatus(&matcher.Status{TicketPrice: 2})
+	c.setQueues([]matcher.WaitingQueue{{Name: "a", Amounts: []dcrutil.Amount{1}}})
+	c.setQueues([]matcher.WaitingQueue{{Name: "b", Amounts: []dcrutil.Amount{2}}})
+	msgs, overflow := c.pending(now)
+	if overflow || len(msgs) != 2 {
+		t.Fatalf("unexpected pending messages %v", msgs)
+	}
+	if st := msgs[0].(*wsV2StatusMsg); st.TicketPrice != 2 {
+		t.Fatalf("unexpected status %v", st)
+	}
+	if q := msgs[1].(*wsV2QueuesMsg); len(q.Queues) != 1 ||
+		q.Queues[0].Name != encodeQueueName("b") {
+		t.Fatalf("unexpected queues %v", q.Queues)
+	}
+
+	// Unchanged state is not sent again.
+	c.setStatus(&matcher.Status{TicketPrice: 2})
+	c.setQueues([]matcher.WaitingQueue{{Name: "b", Amounts: []dcrutil.Amount{2}}})
+	if msgs, _ = c.pending(now); len(msgs) != 0 {
+		t.Fatalf("unexpected pending messages %v", msgs)
+	}
+
+	// Watchers that don't read their replies overflow.
+	for i := 0; i <= wsV2MaxPendingReplies; i++ {
+		c.handleRequest([]byte(`{"type": "ping"}`))
+	}
+	if msgs, overflow = c.pending(now); !overflow ||
+		len(msgs) != wsV2MaxPendingReplies {
+		t.Fatalf("pending replies did not overflow")
+	}
+}
+
+func TestWSV2ClientUnsubscribe(t *testing.T) {
+	c := newWSV2Client()
+	now := time.Now()
+	c.setQueues([]matcher.WaitingQueue{
+		{Name: "a", Amounts: []dcrutil.Amount{1}},
+		{Name: "b", Amounts: []dcrutil.Amount{2}},
+	})
+	a, b := encodeQueueName("a"), encodeQueueName("b")
+
+	// Unsubscribing from a queue while watching every queue excludes it.
+	c.handleRequest([]byte(`{"type": "unsubscribe", "queues": ["` + a + `"]}`))
+	msgs, _ := c.pending(now)
+	if len(msgs) != 2 {
+		t.Fatalf("unexpected pending messages %v", msgs)
+	}
+	if subs := msgs[0].(*wsV2SubscriptionsMsg); !subs.All ||
+		len(subs.Queues) != 0 || len(subs.Excluded) != 1 ||
+		subs.Excluded[0] != a {
+		t.Fatalf("unexpected subscriptions %v", subs)
+	}
+	if q := msgs[1].(*wsV2QueuesMsg); len(q.Queues) != 1 ||
+		q.Queues[0].Name != b {
+		t.Fatalf("unexpected queues %v", q.Queues)
+	}
+
+	// New queues are still watched.
+	c.setQueues([]matcher.WaitingQueue{
+		{Name: "a", Amounts: []dcrutil.Amount{1}},
+		{Name: "b", Amounts: []dcrutil.Amount{2}},
+		{Name: "c", Amounts: []dcrutil.Amount{3}},
+	})
+	msgs, _ = c.pending(now)
+	if q := msgs[0].(*wsV2QueuesMsg); len(q.Queues) != 2 ||
+		q.Queues[0].Name == a || q.Queues[1].Name == a {
+		t.Fatalf("unexpected queues %v", q.Queues)
+	}
+
+	// Subscribing to every queue again clears the exclusions.
+	c.handleRequest([]byte(`{"type": "subscribe"}`))
+	msgs, _ = c.pending(now)
+	if subs := msgs[0].(*wsV2SubscriptionsMsg); !subs.All ||
+		len(subs.Excluded) != 0 {
+		t.Fatalf("unexpected subscriptions %v", subs)
+	}
+	if q := msgs[1].(*wsV2QueuesMsg); len(q.Queues) != 3 {
+		t.Fatalf("unexpected queues %v", q.Queues)
+	}
+}
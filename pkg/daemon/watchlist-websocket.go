@@ -1,9 +1,11 @@
 package daemon
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/slog"
@@ -20,10 +22,24 @@ type waitlistWebsocketService struct {
 	server       *http.Server
 	openWatchers *sync.Map
 	listener     net.Listener
+	trusted      trustedProxies
+
+	// v2Clients are the currently connected watchers of the v2 protocol.
+	v2Clients *sync.Map
+
+	// statusMtx protects status, the most recent status of the matcher
+	// fetched by pollStatus.
+	statusMtx sync.Mutex
+	status    *matcher.Status
+
+	pingPeriod     time.Duration
+	pongWait       time.Duration
+	writeWait      time.Duration
+	statusInterval time.Duration
 }
 
 func newWaitlistWebsocketService(bindAddr string, matcher *matcher.Matcher,
-	log slog.Logger) (*waitlistWebsocketService, error) {
+	trusted trustedProxies, log slog.Logger) (*waitlistWebsocketService, error) {
 
 	mux := http.NewServeMux()
 
@@ -39,15 +55,23 @@ func newWaitlistWebsocketService(bindAddr string, matcher *matcher.Matcher,
 		log:          log,
 		server:       &http.Server{Addr: bindAddr, Handler: mux},
 		listener:     ln,
+		trusted:      trusted,
+		v2Clients:    &sync.Map{},
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
+
+		pingPeriod:     wsPingPeriod,
+		pongWait:       wsPongWait,
+		writeWait:      wsWriteWait,
+		statusInterval: wsStatusInterval,
 	}
 
 	mux.HandleFunc("/", svc.index)
 	mux.HandleFunc("/watchWaitingList", svc.watchWaitingList)
+	mux.HandleFunc("/v2/watchWaitingList", svc.watchWaitingListV2)
 	svc.server.RegisterOnShutdown(svc.closeWebsockets)
 
 	return svc, nil
@@ -66,7 +90,7 @@ func (svc *waitlistWebsocketService) watchWaitingList(w http.ResponseWriter, r *
 		return
 	}
 
-	srcAddr := r.RemoteAddr // TODO: support reverse proxying
+	srcAddr := svc.trusted.clientAddr(r)
 	svc.log.Debugf("New websocket watcher %s", srcAddr)
 
 	ctx := matcher.WithOriginalSrc(r.Context(), "[wss]"+srcAddr)
@@ -75,6 +99,7 @@ func (svc *waitlistWebsocketService) watchWaitingList(w http.ResponseWriter, r *
 	svc.matcher.WatchWaitingList(ctx, watcher, true)
 
 	type replyqueue struct {
+		Pool    string           `json:"pool,omitempty"`
 		Name    string           `json:"name"`
 		Amounts []dcrutil.Amount `json:"amounts"`
 	}
@@ -107,7 +132,7 @@ func (svc *waitlistWebsocketService) watchWaitingList(w http.ResponseWriter, r *
 		case queues := <-watcher:
 			reply := make([]replyqueue, len(queues))
 			for i, q := range queues {
-				reply[i] = replyqueue{encodeQueueName(q.Name), q.Amounts}
+				reply[i] = replyqueue{q.Pool, encodeQueueName(q.Name), q.Amounts}
 			}
 			c.WriteJSON(reply)
 		case <-shutdownChan:
@@ -128,13 +153,15 @@ func (svc *waitlistWebsocketService) closeWebsockets() {
 }
 
 func (svc *waitlistWebsocketService) run(serverCtx context.Context,
-	certFile, keyFile string) error {
+	tlsCfg *tls.Config) error {
 
 	go func() {
 		<-serverCtx.Done()
 		svc.server.Shutdown(context.Background())
 		svc.listener.Close()
 	}()
+	go svc.pollStatus(serverCtx)
 
-	return svc.server.ServeTLS(svc.listener, certFile, keyFile)
+	svc.server.TLSConfig = tlsCfg
+	return svc.server.ServeTLS(svc.listener, "", "")
 }
@@ -0,0 +1,704 @@
+// Package acme implements the subset of the ACME protocol (RFC 8555) needed to
+// obtain TLS certificates from CAs such as Let's Encrypt, using either the
+// http-01 or the tls-alpn-01 (RFC 8737) challenges.
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LetsEncryptURL is the directory URL of the production Let's Encrypt CA.
+	LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+	// ChallengeHTTP01 is the type of the http-01 challenge.
+	ChallengeHTTP01 = "http-01"
+
+	// ChallengeTLSALPN01 is the type of the tls-alpn-01 challenge.
+	ChallengeTLSALPN01 = "tls-alpn-01"
+
+	// ALPNProto is the ALPN protocol negotiated by CAs when validating
+	// tls-alpn-01 challenges.
+	ALPNProto = "acme-tls/1"
+
+	// HTTP01ChallengePrefix is the path prefix of the responses to http-01
+	// challenges.
+	HTTP01ChallengePrefix = "/.well-known/acme-challenge/"
+
+	// Statuses of ACME objects.
+	StatusPending     = "pending"
+	StatusReady       = "ready"
+	StatusProcessing  = "processing"
+	StatusValid       = "valid"
+	StatusInvalid     = "invalid"
+	StatusDeactivated = "deactivated"
+	StatusExpired     = "expired"
+	StatusRevoked     = "revoked"
+
+	errBadNonce = "urn:ietf:params:acme:error:badNonce"
+
+	// maxRespSize is the maximum size of responses read from the CA.
+	maxRespSize = 1 << 20
+)
+
+// idPeAcmeIdentifier is the oid of the acmeIdentifier extension of
+// tls-alpn-01 challenge certificates.
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// Problem is an error returned by the ACME server (RFC 7807).
+type Problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+	Status int    `json:"status"`
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf("acme error %s (status %d): %s", p.Type, p.Status,
+		p.Detail)
+}
+
+// Directory lists the URLs of the operations of an ACME server.
+type Directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+// Identifier is the identifier (a DNS name) of an order or authorization.
+type Identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Order is a request for a certificate.
+type Order struct {
+	URL            string       `json:"-"`
+	Status         string       `json:"status"`
+	Identifiers    []Identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate,omitempty"`
+	Error          *Problem     `json:"error,omitempty"`
+}
+
+// Authorization is the proof that the account controls an identifier.
+type Authorization struct {
+	Status     string      `json:"status"`
+	Identifier Identifier  `json:"identifier"`
+	Challenges []Challenge `json:"challenges"`
+}
+
+// Challenge is one of the ways the account can prove it controls the
+// identifier of an authorization.
+type Challenge struct {
+	Type   string   `json:"type"`
+	URL    string   `json:"url"`
+	Token  string   `json:"token"`
+	Status string   `json:"status"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+// Solver makes the responses to challenges available to the CA.
+type Solver interface {
+	// ChallengeType is the type of the challenges solved.
+	ChallengeType() string
+
+	// Present makes the key authorization of the challenge token available
+	// to the CA.
+	Present(domain, token, keyAuth string) error
+
+	// CleanUp removes the response to the challenge after it is validated.
+	CleanUp(domain, token string)
+}
+
+// Client is an ACME client bound to an account key.
+type Client struct {
+	// Key is the account key.
+	Key *ecdsa.PrivateKey
+
+	// DirectoryURL is the URL of the directory of the ACME server.
+	DirectoryURL string
+
+	// Contact is the list of contact URLs (eg: mailto:admin@example.com)
+	// registered with the account.
+	Contact []string
+
+	// HTTPClient is used to perform the requests. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// PollInterval is the interval between checks of the status of pending
+	// authorizations and orders when the server does not specify one.
+	// Defaults to one second.
+	PollInterval time.Duration
+
+	mtx    sync.Mutex
+	dir    *Directory
+	kid    string
+	nonces []string
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// KeyAuthorization returns the key authorization of the token for the account
+// of this client.
+func (c *Client) KeyAuthorization(token string) string {
+	return KeyAuthorization(&c.Key.PublicKey, token)
+}
+
+// directory returns the (cached) directory of the server.
+func (c *Client) directory(ctx context.Context) (*Directory, error) {
+	c.mtx.Lock()
+	dir := c.dir
+	c.mtx.Unlock()
+	if dir != nil {
+		return dir, nil
+	}
+
+	req, err := http.NewRequest("GET", c.DirectoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching acme directory")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error fetching acme directory: status %d",
+			resp.StatusCode)
+	}
+
+	dir = new(Directory)
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxRespSize)).Decode(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding acme directory")
+	}
+
+	c.mtx.Lock()
+	c.dir = dir
+	c.mtx.Unlock()
+	return dir, nil
+}
+
+// addNonce stores a nonce returned by the server for use in a later request.
+func (c *Client) addNonce(resp *http.Response) {
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return
+	}
+	c.mtx.Lock()
+	c.nonces = append(c.nonces, nonce)
+	c.mtx.Unlock()
+}
+
+// nonce returns a nonce for a new request, fetching a new one from the server
+// if no unused nonce is available.
+func (c *Client) nonce(ctx context.Context) (string, error) {
+	c.mtx.Lock()
+	if l := len(c.nonces); l > 0 {
+		nonce := c.nonces[l-1]
+		c.nonces = c.nonces[:l-1]
+		c.mtx.Unlock()
+		return nonce, nil
+	}
+	c.mtx.Unlock()
+
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("HEAD", dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "error fetching nonce")
+	}
+	resp.Body.Close()
+
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errors.New("acme server did not return a nonce")
+	}
+	return nonce, nil
+}
+
+// responseProblem decodes the problem document of a failed response.
+func responseProblem(resp *http.Response) *Problem {
+	prob := new(Problem)
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxRespSize))
+	if err := json.Unmarshal(b, prob); err != nil || prob.Type == "" {
+		prob.Type = "about:blank"
+		prob.Detail = string(b)
+	}
+	if prob.Status == 0 {
+		prob.Status = resp.StatusCode
+	}
+	return prob
+}
+
+// post performs a signed POST request to the given url. A nil payload
+// performs a POST-as-GET request. Requests refused due to bad nonces are
+// retried once. The caller must close the body of the returned response.
+func (c *Client) post(ctx context.Context, url string,
+	payload interface{}) (*http.Response, error) {
+
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		hdr := &ProtectedHeader{Nonce: nonce, URL: url}
+		c.mtx.Lock()
+		hdr.KID = c.kid
+		c.mtx.Unlock()
+		if hdr.KID == "" {
+			hdr.JWK = NewJWK(&c.Key.PublicKey)
+		}
+
+		jws, err := signJWS(c.Key, hdr, body)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jws))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		resp, err := c.httpClient().Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error performing acme request "+
+				"to %s", url)
+		}
+		c.addNonce(resp)
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		prob := responseProblem(resp)
+		resp.Body.Close()
+		if prob.Type == errBadNonce && attempt == 0 {
+			continue
+		}
+		return nil, prob
+	}
+}
+
+// postJSON performs a signed request and decodes its json response into res.
+func (c *Client) postJSON(ctx context.Context, url string, payload,
+	res interface{}) (*http.Response, error) {
+
+	resp, err := c.post(ctx, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxRespSize)).Decode(res)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding response from %s", url)
+	}
+	return resp, nil
+}
+
+// Register creates the account of the key on the server or, if it already
+// exists, fetches its url. The server terms of service are agreed to.
+func (c *Client) Register(ctx context.Context) error {
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.kid = ""
+	c.mtx.Unlock()
+
+	req := struct {
+		Contact              []string `json:"contact,omitempty"`
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+	}{
+		Contact:              c.Contact,
+		TermsOfServiceAgreed: true,
+	}
+	resp, err := c.post(ctx, dir.NewAccount, req)
+	if err != nil {
+		return errors.Wrap(err, "error registering acme account")
+	}
+	resp.Body.Close()
+
+	kid := resp.Header.Get("Location")
+	if kid == "" {
+		return errors.New("acme server did not return the account url")
+	}
+	c.mtx.Lock()
+	c.kid = kid
+	c.mtx.Unlock()
+	return nil
+}
+
+// registered returns whether the url of the account is known.
+func (c *Client) registered() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.kid != ""
+}
+
+// NewOrder requests a new certificate for the given domains.
+func (c *Client) NewOrder(ctx context.Context, domains []string) (*Order,
+	error) {
+
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Identifiers []Identifier `json:"identifiers"`
+	}{}
+	for _, d := range domains {
+		req.Identifiers = append(req.Identifiers, Identifier{Type: "dns",
+			Value: d})
+	}
+
+	order := new(Order)
+	resp, err := c.postJSON(ctx, dir.NewOrder, req, order)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating acme order")
+	}
+	order.URL = resp.Header.Get("Location")
+	return order, nil
+}
+
+// Authorization fetches the authorization at the given url.
+func (c *Client) Authorization(ctx context.Context, url string) (
+	*Authorization, error) {
+
+	authz := new(Authorization)
+	if _, err := c.postJSON(ctx, url, nil, authz); err != nil {
+		return nil, errors.Wrap(err, "error fetching acme authorization")
+	}
+	return authz, nil
+}
+
+// Accept informs the server that the response to the challenge is ready to
+// be validated.
+func (c *Client) Accept(ctx context.Context, chal *Challenge) error {
+	resp, err := c.post(ctx, chal.URL, struct{}{})
+	if err != nil {
+		return errors.Wrapf(err, "error accepting %s challenge", chal.Type)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// retryDelay returns the delay before polling a pending object again.
+func (c *Client) retryDelay(resp *http.Response) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil &&
+		secs > 0 && secs < 60 {
+		return time.Duration(secs) * time.Second
+	}
+	if c.PollInterval > 0 {
+		return c.PollInterval
+	}
+	return time.Second
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// WaitAuthorization polls the authorization at the given url until it is
+// either valid or has failed.
+func (c *Client) WaitAuthorization(ctx context.Context, url string) error {
+	for {
+		authz := new(Authorization)
+		resp, err := c.postJSON(ctx, url, nil, authz)
+		if err != nil {
+			return errors.Wrap(err, "error fetching acme authorization")
+		}
+
+		switch authz.Status {
+		case StatusValid:
+			return nil
+		case StatusPending:
+		default:
+			for _, chal := range authz.Challenges {
+				if chal.Error != nil {
+					return errors.Wrapf(chal.Error, "%s challenge for %s "+
+						"failed", chal.Type, authz.Identifier.Value)
+				}
+			}
+			return errors.Errorf("authorization for %s is %s",
+				authz.Identifier.Value, authz.Status)
+		}
+
+		if err := sleep(ctx, c.retryDelay(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitOrder polls the order until it leaves the pending and processing
+// states. The returned order is either ready or valid.
+func (c *Client) WaitOrder(ctx context.Context, url string) (*Order, error) {
+	for {
+		order := new(Order)
+		resp, err := c.postJSON(ctx, url, nil, order)
+		if err != nil {
+			return nil, errors.Wrap(err, "error fetching acme order")
+		}
+		order.URL = url
+
+		switch order.Status {
+		case StatusReady, StatusValid:
+			return order, nil
+		case StatusPending, StatusProcessing:
+		default:
+			if order.Error != nil {
+				return nil, errors.Wrap(order.Error, "acme order failed")
+			}
+			return nil, errors.Errorf("acme order is %s", order.Status)
+		}
+
+		if err := sleep(ctx, c.retryDelay(resp)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Finalize sends the certificate signing request of a ready order.
+func (c *Client) Finalize(ctx context.Context, order *Order,
+	csr []byte) error {
+
+	req := struct {
+		CSR string `json:"csr"`
+	}{
+		CSR: b64.EncodeToString(csr),
+	}
+	resp, err := c.post(ctx, order.Finalize, req)
+	if err != nil {
+		return errors.Wrap(err, "error finalizing acme order")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// FetchCertificate downloads the certificate chain at the given url. The
+// returned slice has the DER encoded certificates, starting with the leaf.
+func (c *Client) FetchCertificate(ctx context.Context, url string) ([][]byte,
+	error) {
+
+	resp, err := c.post(ctx, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching certificate")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRespSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading certificate")
+	}
+
+	var chain [][]byte
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("no certificates returned by the acme server")
+	}
+	return chain, nil
+}
+
+// ObtainCertificate performs the full flow of requesting a certificate for
+// the given domains: registering the account (if needed), creating the
+// order, solving its challenges with the solver and finalizing it with the
+// given CSR. It returns the DER encoded certificate chain.
+func (c *Client) ObtainCertificate(ctx context.Context, domains []string,
+	csr []byte, solver Solver) ([][]byte, error) {
+
+	if !c.registered() {
+		if err := c.Register(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := c.NewOrder(ctx, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, url := range order.Authorizations {
+		if err = c.authorize(ctx, url, solver); err != nil {
+			return nil, err
+		}
+	}
+
+	if order, err = c.WaitOrder(ctx, order.URL); err != nil {
+		return nil, err
+	}
+	if order.Status == StatusReady {
+		if err = c.Finalize(ctx, order, csr); err != nil {
+			return nil, err
+		}
+		if order, err = c.WaitOrder(ctx, order.URL); err != nil {
+			return nil, err
+		}
+	}
+	if order.Status != StatusValid || order.Certificate == "" {
+		return nil, errors.New("acme order finalized without a certificate")
+	}
+
+	return c.FetchCertificate(ctx, order.Certificate)
+}
+
+// authorize solves the challenge of the authorization at the given url with
+// the solver, unless the authorization is already valid.
+func (c *Client) authorize(ctx context.Context, url string,
+	solver Solver) error {
+
+	authz, err := c.Authorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if authz.Status == StatusValid {
+		return nil
+	}
+
+	var chal *Challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == solver.ChallengeType() {
+			chal = &authz.Challenges[i]
+			break
+		}
+	}
+	if chal == nil {
+		return errors.Errorf("acme server does not offer %s challenges for "+
+			"%s", solver.ChallengeType(), authz.Identifier.Value)
+	}
+
+	domain := authz.Identifier.Value
+	err = solver.Present(domain, chal.Token, c.KeyAuthorization(chal.Token))
+	if err != nil {
+		return errors.Wrapf(err, "error presenting challenge for %s", domain)
+	}
+	defer solver.CleanUp(domain, chal.Token)
+
+	if err = c.Accept(ctx, chal); err != nil {
+		return err
+	}
+	return c.WaitAuthorization(ctx, url)
+}
+
+// TLSALPN01Certificate returns the self signed certificate presented when
+// validating a tls-alpn-01 challenge for the domain.
+func TLSALPN01Certificate(domain, keyAuth string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.Sum256([]byte(keyAuth))
+	ext, err := asn1.Marshal(h[:])
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ACME challenge"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{domain},
+		ExtraExtensions: []pkix.Extension{{
+			Id:       idPeAcmeIdentifier,
+			Critical: true,
+			Value:    ext,
+		}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey,
+		key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// VerifyTLSALPN01Certificate checks whether the given certificate is a valid
+// response to a tls-alpn-01 challenge for the domain.
+func VerifyTLSALPN01Certificate(cert *x509.Certificate, domain,
+	keyAuth string) error {
+
+	if err := cert.VerifyHostname(domain); err != nil {
+		return err
+	}
+
+	h := sha256.Sum256([]byte(keyAuth))
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPeAcmeIdentifier) {
+			continue
+		}
+		var got []byte
+		if _, err := asn1.Unmarshal(ext.Value, &got); err != nil {
+			return errors.Wrap(err, "invalid acmeIdentifier extension")
+		}
+		if !ext.Critical || !bytes.Equal(got, h[:]) {
+			return errors.New("wrong acmeIdentifier extension")
+		}
+		return nil
+	}
+	return errors.New("certificate does not have the acmeIdentifier extension")
+}
@@ -0,0 +1,217 @@
+package acme_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme/acmetest"
+)
+
+// testSolver solves http-01 challenges by storing the key authorizations
+// served by its handler and tls-alpn-01 challenges by storing the challenge
+// certificates returned by its tls config.
+type testSolver struct {
+	typ string
+
+	mtx      sync.Mutex
+	keyAuths map[string]string
+	certs    map[string]*tls.Certificate
+}
+
+func newTestSolver(typ string) *testSolver {
+	return &testSolver{
+		typ:      typ,
+		keyAuths: make(map[string]string),
+		certs:    make(map[string]*tls.Certificate),
+	}
+}
+
+func (s *testSolver) ChallengeType() string { return s.typ }
+
+func (s *testSolver) Present(domain, token, keyAuth string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.typ == acme.ChallengeHTTP01 {
+		s.keyAuths[token] = keyAuth
+		return nil
+	}
+	cert, err := acme.TLSALPN01Certificate(domain, keyAuth)
+	s.certs[domain] = cert
+	return err
+}
+
+func (s *testSolver) CleanUp(domain, token string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.keyAuths, token)
+	delete(s.certs, domain)
+}
+
+func (s *testSolver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	keyAuth, ok := s.keyAuths[strings.TrimPrefix(r.URL.Path,
+		acme.HTTP01ChallengePrefix)]
+	s.mtx.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(keyAuth))
+}
+
+func (s *testSolver) tlsConfig() *tls.Config {
+	return &tls.Config{
+		NextProtos: []string{acme.ALPNProto},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+			return s.certs[hello.ServerName], nil
+		},
+	}
+}
+
+func (s *testSolver) validate(typ, domain, token, keyAuth string) error {
+	if typ == acme.ChallengeHTTP01 {
+		return acmetest.ValidateHTTP01(s, domain, token, keyAuth)
+	}
+	return acmetest.ValidateTLSALPN01(s.tlsConfig(), domain, keyAuth)
+}
+
+func newCSR(t *testing.T, domains ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{DNSNames: domains}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func TestObtainCertificate(t *testing.T) {
+	domains := []string{"matcher.example.com", "split.example.com"}
+
+	for _, typ := range []string{acme.ChallengeHTTP01, acme.ChallengeTLSALPN01} {
+		solver := newTestSolver(typ)
+		srv, err := acmetest.NewServer(func(chalType, domain, token,
+			keyAuth string) error {
+
+			if chalType != typ {
+				t.Errorf("unexpected validation of %s challenge", chalType)
+			}
+			return solver.validate(chalType, domain, token, keyAuth)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer srv.Close()
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &acme.Client{
+			Key:          key,
+			DirectoryURL: srv.DirectoryURL(),
+			Contact:      []string{"mailto:admin@example.com"},
+			HTTPClient:   srv.Client(),
+			PollInterval: 10 * time.Millisecond,
+		}
+
+		ctx := context.Background()
+		chain, err := client.ObtainCertificate(ctx, domains,
+			newCSR(t, domains...), solver)
+		if err != nil {
+			t.Fatalf("unexpected error obtaining cert with %s: %v", typ, err)
+		}
+
+		leaf, err := x509.ParseCertificate(chain[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range domains {
+			_, err = leaf.Verify(x509.VerifyOptions{DNSName: d,
+				Roots: srv.Roots()})
+			if err != nil {
+				t.Fatalf("issued cert not valid for %s: %v", d, err)
+			}
+		}
+
+		// Challenges are cleaned up after the validation.
+		if len(solver.keyAuths) != 0 || len(solver.certs) != 0 {
+			t.Fatalf("challenges of %s not cleaned up", typ)
+		}
+	}
+}
+
+func TestObtainCertificateFailedChallenge(t *testing.T) {
+	// A solver that presents the wrong key authorization.
+	solver := newTestSolver(acme.ChallengeHTTP01)
+	srv, err := acmetest.NewServer(func(chalType, domain, token,
+		keyAuth string) error {
+
+		return solver.validate(chalType, domain, token, keyAuth+"x")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &acme.Client{
+		Key:          key,
+		DirectoryURL: srv.DirectoryURL(),
+		HTTPClient:   srv.Client(),
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	_, err = client.ObtainCertificate(context.Background(),
+		[]string{"matcher.example.com"}, newCSR(t, "matcher.example.com"),
+		solver)
+	if err == nil {
+		t.Fatalf("certificate obtained with failed challenge")
+	}
+	if srv.Issued() != 0 {
+		t.Fatalf("certificate issued with failed challenge")
+	}
+}
+
+func TestVerifyTLSALPN01Certificate(t *testing.T) {
+	cert, err := acme.TLSALPN01Certificate("matcher.example.com", "token.auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = acme.VerifyTLSALPN01Certificate(leaf, "matcher.example.com",
+		"token.auth")
+	if err != nil {
+		t.Fatalf("unexpected error verifying challenge cert: %v", err)
+	}
+	if acme.VerifyTLSALPN01Certificate(leaf, "other.example.com",
+		"token.auth") == nil {
+		t.Fatalf("challenge cert accepted for the wrong domain")
+	}
+	if acme.VerifyTLSALPN01Certificate(leaf, "matcher.example.com",
+		"token.other") == nil {
+		t.Fatalf("challenge cert accepted for the wrong key authorization")
+	}
+}
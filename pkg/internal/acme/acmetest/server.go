@@ -0,0 +1,599 @@
+// Package acmetest provides an in-process ACME server for tests, similar in
+// spirit to pebble. It issues certificates signed by an ephemeral CA after
+// validating challenges with a caller provided function.
+package acmetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/acme"
+	"github.com/pkg/errors"
+)
+
+// ValidateFunc validates the response to a challenge of the given type for
+// the domain. The keyAuth is the expected key authorization.
+type ValidateFunc func(chalType, domain, token, keyAuth string) error
+
+type order struct {
+	acme.Order
+	kid     string
+	domains []string
+	cert    []byte
+}
+
+type authz struct {
+	acme.Authorization
+	kid string
+}
+
+// Server is an in-process ACME server.
+type Server struct {
+	*httptest.Server
+
+	// CertLifetime is the validity of the issued certificates. Defaults to
+	// 90 days.
+	CertLifetime time.Duration
+
+	validate ValidateFunc
+	caCert   *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+
+	mtx      sync.Mutex
+	nextID   int
+	nonces   map[string]bool
+	accounts map[string]*ecdsa.PublicKey
+	orders   map[string]*order
+	authzs   map[string]*authz
+	chals    map[string]string // challenge url => authz url
+	issued   int
+}
+
+// NewServer starts a new ACME server that validates challenges by calling
+// the given function.
+func NewServer(validate ValidateFunc) (*Server, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "acmetest CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl,
+		&caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		CertLifetime: 90 * 24 * time.Hour,
+		validate:     validate,
+		caCert:       caCert,
+		caKey:        caKey,
+		nonces:       make(map[string]bool),
+		accounts:     make(map[string]*ecdsa.PublicKey),
+		orders:       make(map[string]*order),
+		authzs:       make(map[string]*authz),
+		chals:        make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/directory", s.handleDirectory)
+	mux.HandleFunc("/nonce", s.handleNonce)
+	mux.HandleFunc("/account", s.handleNewAccount)
+	mux.HandleFunc("/order", s.handleNewOrder)
+	mux.HandleFunc("/order/", s.handleOrder)
+	mux.HandleFunc("/authz/", s.handleAuthz)
+	mux.HandleFunc("/chal/", s.handleChallenge)
+	mux.HandleFunc("/finalize/", s.handleFinalize)
+	mux.HandleFunc("/cert/", s.handleCert)
+	s.Server = httptest.NewTLSServer(mux)
+
+	return s, nil
+}
+
+// DirectoryURL returns the url of the directory of the server.
+func (s *Server) DirectoryURL() string {
+	return s.URL + "/directory"
+}
+
+// Roots returns a pool with the CA that signs the issued certificates.
+func (s *Server) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.caCert)
+	return pool
+}
+
+// Issued returns the number of certificates issued by the server.
+func (s *Server) Issued() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.issued
+}
+
+// newID returns a new unique id. Must be called with the mutex held.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%d", s.nextID)
+}
+
+func (s *Server) newNonce() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	nonce := "nonce" + s.newID()
+	s.nonces[nonce] = true
+	return nonce
+}
+
+func (s *Server) writeProblem(w http.ResponseWriter, status int, typ,
+	detail string) {
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&acme.Problem{
+		Type:   "urn:ietf:params:acme:error:" + typ,
+		Detail: detail,
+		Status: status,
+	})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(&acme.Directory{
+		NewNonce:   s.URL + "/nonce",
+		NewAccount: s.URL + "/account",
+		NewOrder:   s.URL + "/order",
+	})
+}
+
+func (s *Server) handleNonce(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the JWS of the request. It returns the payload and the url of
+// the account that signed it (empty for new account requests).
+func (s *Server) verify(w http.ResponseWriter, r *http.Request,
+	newAccount bool) ([]byte, string, *ecdsa.PublicKey, bool) {
+
+	if r.Method != "POST" {
+		s.writeProblem(w, http.StatusMethodNotAllowed, "malformed",
+			"only POST is allowed")
+		return nil, "", nil, false
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil, "", nil, false
+	}
+
+	var pub *ecdsa.PublicKey
+	hdr, payload, err := acme.VerifyJWS(body,
+		func(hdr *acme.ProtectedHeader) (*ecdsa.PublicKey, error) {
+			var err error
+			switch {
+			case newAccount && hdr.JWK != nil:
+				pub, err = hdr.JWK.PublicKey()
+			case !newAccount && hdr.KID != "":
+				s.mtx.Lock()
+				pub = s.accounts[hdr.KID]
+				s.mtx.Unlock()
+				if pub == nil {
+					err = errors.New("unknown account")
+				}
+			default:
+				err = errors.New("wrong key identification")
+			}
+			return pub, err
+		})
+	if err != nil {
+		s.writeProblem(w, http.StatusUnauthorized, "unauthorized",
+			err.Error())
+		return nil, "", nil, false
+	}
+
+	s.mtx.Lock()
+	validNonce := s.nonces[hdr.Nonce]
+	delete(s.nonces, hdr.Nonce)
+	s.mtx.Unlock()
+	if !validNonce {
+		s.writeProblem(w, http.StatusBadRequest, "badNonce", "invalid nonce")
+		return nil, "", nil, false
+	}
+
+	if hdr.URL != s.URL+r.URL.Path {
+		s.writeProblem(w, http.StatusBadRequest, "malformed", "wrong url")
+		return nil, "", nil, false
+	}
+
+	return payload, hdr.KID, pub, true
+}
+
+func (s *Server) handleNewAccount(w http.ResponseWriter, r *http.Request) {
+	payload, _, pub, ok := s.verify(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		TermsOfServiceAgreed bool `json:"termsOfServiceAgreed"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil ||
+		!req.TermsOfServiceAgreed {
+		s.writeProblem(w, http.StatusBadRequest, "malformed",
+			"terms of service not agreed")
+		return
+	}
+
+	kid := s.URL + "/account/" + acme.Thumbprint(pub)
+	s.mtx.Lock()
+	_, exists := s.accounts[kid]
+	s.accounts[kid] = pub
+	s.mtx.Unlock()
+
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	w.Header().Set("Location", kid)
+	s.writeJSON(w, status, map[string]string{"status": acme.StatusValid})
+}
+
+func (s *Server) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	payload, kid, _, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Identifiers []acme.Identifier `json:"identifiers"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil ||
+		len(req.Identifiers) == 0 {
+		s.writeProblem(w, http.StatusBadRequest, "malformed",
+			"invalid identifiers")
+		return
+	}
+
+	s.mtx.Lock()
+	id := s.newID()
+	o := &order{kid: kid}
+	o.URL = s.URL + "/order/" + id
+	o.Status = acme.StatusPending
+	o.Identifiers = req.Identifiers
+	o.Finalize = s.URL + "/finalize/" + id
+	for _, ident := range req.Identifiers {
+		azID := s.newID()
+		az := &authz{kid: kid}
+		az.Status = acme.StatusPending
+		az.Identifier = ident
+		azURL := s.URL + "/authz/" + azID
+		for _, typ := range []string{acme.ChallengeHTTP01,
+			acme.ChallengeTLSALPN01} {
+
+			chalURL := s.URL + "/chal/" + s.newID()
+			az.Challenges = append(az.Challenges, acme.Challenge{
+				Type:   typ,
+				URL:    chalURL,
+				Token:  base64.RawURLEncoding.EncodeToString([]byte(chalURL)),
+				Status: acme.StatusPending,
+			})
+			s.chals[chalURL] = azURL
+		}
+		s.authzs[azURL] = az
+		o.Authorizations = append(o.Authorizations, azURL)
+		o.domains = append(o.domains, ident.Value)
+	}
+	s.orders[o.URL] = o
+	resp := o.Order
+	s.mtx.Unlock()
+
+	w.Header().Set("Location", o.URL)
+	s.writeJSON(w, http.StatusCreated, &resp)
+}
+
+// updateOrder updates the status of the order based on its authorizations.
+// Must be called with the mutex held.
+func (s *Server) updateOrder(o *order) {
+	if o.Status != acme.StatusPending {
+		return
+	}
+	for _, azURL := range o.Authorizations {
+		switch s.authzs[azURL].Status {
+		case acme.StatusInvalid:
+			o.Status = acme.StatusInvalid
+			return
+		case acme.StatusPending:
+			return
+		}
+	}
+	o.Status = acme.StatusReady
+}
+
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	_, kid, _, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	o := s.orders[s.URL+r.URL.Path]
+	if o == nil || o.kid != kid {
+		s.mtx.Unlock()
+		s.writeProblem(w, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	s.updateOrder(o)
+	resp := o.Order
+	s.mtx.Unlock()
+
+	s.writeJSON(w, http.StatusOK, &resp)
+}
+
+func (s *Server) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	_, kid, _, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	az := s.authzs[s.URL+r.URL.Path]
+	if az == nil || az.kid != kid {
+		s.mtx.Unlock()
+		s.writeProblem(w, http.StatusNotFound, "malformed", "no such authz")
+		return
+	}
+	resp := az.Authorization
+	resp.Challenges = append([]acme.Challenge(nil), az.Challenges...)
+	s.mtx.Unlock()
+
+	s.writeJSON(w, http.StatusOK, &resp)
+}
+
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	_, kid, pub, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	chalURL := s.URL + r.URL.Path
+	s.mtx.Lock()
+	az := s.authzs[s.chals[chalURL]]
+	if az == nil || az.kid != kid {
+		s.mtx.Unlock()
+		s.writeProblem(w, http.StatusNotFound, "malformed", "no such chal")
+		return
+	}
+	var chal *acme.Challenge
+	for i := range az.Challenges {
+		if az.Challenges[i].URL == chalURL {
+			chal = &az.Challenges[i]
+		}
+	}
+	typ, token, domain := chal.Type, chal.Token, az.Identifier.Value
+	s.mtx.Unlock()
+
+	// Validation is performed synchronously, unlike on real CAs.
+	keyAuth := acme.KeyAuthorization(pub, token)
+	err := s.validate(typ, domain, token, keyAuth)
+
+	s.mtx.Lock()
+	if err != nil {
+		chal.Status = acme.StatusInvalid
+		chal.Error = &acme.Problem{
+			Type:   "urn:ietf:params:acme:error:unauthorized",
+			Detail: err.Error(),
+			Status: http.StatusForbidden,
+		}
+		az.Status = acme.StatusInvalid
+	} else {
+		chal.Status = acme.StatusValid
+		az.Status = acme.StatusValid
+	}
+	resp := *chal
+	s.mtx.Unlock()
+
+	s.writeJSON(w, http.StatusOK, &resp)
+}
+
+func (s *Server) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	payload, kid, _, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	o := s.orders[s.URL+"/order/"+strings.TrimPrefix(r.URL.Path,
+		"/finalize/")]
+	if o != nil {
+		s.updateOrder(o)
+	}
+	s.mtx.Unlock()
+	if o == nil || o.kid != kid {
+		s.writeProblem(w, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	if o.Status != acme.StatusReady {
+		s.writeProblem(w, http.StatusForbidden, "orderNotReady",
+			"order is "+o.Status)
+		return
+	}
+
+	var req struct {
+		CSR string `json:"csr"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	if err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+	cert, err := s.issue(der, o.domains)
+	if err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+
+	s.mtx.Lock()
+	o.cert = cert
+	o.Status = acme.StatusValid
+	o.Certificate = s.URL + "/cert/" + s.newID()
+	s.orders[o.Certificate] = o
+	s.issued++
+	resp := o.Order
+	s.mtx.Unlock()
+
+	s.writeJSON(w, http.StatusOK, &resp)
+}
+
+// issue signs a certificate for the CSR, which must request exactly the
+// domains of the order. It returns the PEM encoded chain.
+func (s *Server) issue(der []byte, domains []string) ([]byte, error) {
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err = csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	want := make(map[string]bool)
+	for _, d := range domains {
+		want[d] = true
+	}
+	if len(csr.DNSNames) != len(want) {
+		return nil, errors.New("CSR names do not match the order")
+	}
+	for _, d := range csr.DNSNames {
+		if !want[d] {
+			return nil, errors.Errorf("CSR name %s not in order", d)
+		}
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(s.CertLifetime),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, s.caCert,
+		csr.PublicKey, s.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf})
+	chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+		Bytes: s.caCert.Raw})...)
+	return chain, nil
+}
+
+func (s *Server) handleCert(w http.ResponseWriter, r *http.Request) {
+	_, kid, _, ok := s.verify(w, r, false)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	o := s.orders[s.URL+r.URL.Path]
+	s.mtx.Unlock()
+	if o == nil || o.kid != kid {
+		s.writeProblem(w, http.StatusNotFound, "malformed", "no such cert")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	w.Write(o.cert)
+}
+
+// ValidateHTTP01 validates an http-01 challenge by requesting the challenge
+// path from the given handler.
+func ValidateHTTP01(h http.Handler, domain, token, keyAuth string) error {
+	req := httptest.NewRequest("GET", "http://"+domain+
+		acme.HTTP01ChallengePrefix+token, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return errors.Errorf("challenge response returned status %d",
+			rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != keyAuth {
+		return errors.Errorf("wrong key authorization %q", got)
+	}
+	return nil
+}
+
+// ValidateTLSALPN01 validates a tls-alpn-01 challenge by performing a TLS
+// handshake with a server using the given config.
+func ValidateTLSALPN01(cfg *tls.Config, domain, keyAuth string) error {
+	cliConn, srvConn := net.Pipe()
+	defer cliConn.Close()
+	defer srvConn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	srvConn.SetDeadline(deadline)
+	cliConn.SetDeadline(deadline)
+	go tls.Server(srvConn, cfg).Handshake()
+
+	cli := tls.Client(cliConn, &tls.Config{
+		ServerName:         domain,
+		NextProtos:         []string{acme.ALPNProto},
+		InsecureSkipVerify: true,
+	})
+	if err := cli.Handshake(); err != nil {
+		return errors.Wrap(err, "error performing tls-alpn-01 handshake")
+	}
+
+	state := cli.ConnectionState()
+	if state.NegotiatedProtocol != acme.ALPNProto {
+		return errors.Errorf("negotiated protocol %q",
+			state.NegotiatedProtocol)
+	}
+	return acme.VerifyTLSALPN01Certificate(state.PeerCertificates[0], domain,
+		keyAuth)
+}
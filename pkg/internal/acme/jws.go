@@ -0,0 +1,175 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// coordSize is the size (in bytes) of the coordinates and signature
+// components of P-256 keys.
+const coordSize = 32
+
+// JWK is the JSON web key of a P-256 ECDSA public key. The fields are in the
+// lexicographic order required to calculate key thumbprints.
+type JWK struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWS is a JSON web signature in the flattened JSON serialization.
+type JWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// ProtectedHeader is the protected header of the JWS of ACME requests.
+// Exactly one of JWK (for new account requests) or KID (the account url) is
+// filled.
+type ProtectedHeader struct {
+	Alg   string `json:"alg"`
+	Nonce string `json:"nonce"`
+	URL   string `json:"url"`
+	JWK   *JWK   `json:"jwk,omitempty"`
+	KID   string `json:"kid,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// padCoord encodes the given number as a big endian number of coordSize bytes.
+func padCoord(n *big.Int) []byte {
+	b := n.Bytes()
+	res := make([]byte, coordSize)
+	copy(res[coordSize-len(b):], b)
+	return res
+}
+
+// NewJWK returns the JWK of the given P-256 public key.
+func NewJWK(pub *ecdsa.PublicKey) *JWK {
+	return &JWK{
+		Crv: "P-256",
+		Kty: "EC",
+		X:   b64.EncodeToString(padCoord(pub.X)),
+		Y:   b64.EncodeToString(padCoord(pub.Y)),
+	}
+}
+
+// PublicKey returns the public key encoded in the JWK.
+func (k *JWK) PublicKey() (*ecdsa.PublicKey, error) {
+	if k.Kty != "EC" || k.Crv != "P-256" {
+		return nil, errors.Errorf("unsupported key type %s/%s", k.Kty, k.Crv)
+	}
+	x, err := b64.DecodeString(k.X)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid x coordinate")
+	}
+	y, err := b64.DecodeString(k.Y)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid y coordinate")
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return pub, nil
+}
+
+// Thumbprint returns the base64url encoded SHA-256 thumbprint (RFC 7638) of
+// the given public key.
+func Thumbprint(pub *ecdsa.PublicKey) string {
+	b, _ := json.Marshal(NewJWK(pub))
+	h := sha256.Sum256(b)
+	return b64.EncodeToString(h[:])
+}
+
+// signJWS signs the given payload with the key. A nil payload generates an
+// empty payload (used on POST-as-GET requests).
+func signJWS(key *ecdsa.PrivateKey, hdr *ProtectedHeader,
+	payload []byte) ([]byte, error) {
+
+	hdr.Alg = "ES256"
+	protected, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	jws := &JWS{
+		Protected: b64.EncodeToString(protected),
+		Payload:   b64.EncodeToString(payload),
+	}
+	h := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing request")
+	}
+	jws.Signature = b64.EncodeToString(append(padCoord(r), padCoord(s)...))
+
+	return json.Marshal(jws)
+}
+
+// VerifyJWS verifies the signature of the given JWS and returns its decoded
+// protected header and payload. The key is obtained by calling getKey with
+// the header, so that the caller can look up the key of the KID.
+func VerifyJWS(b []byte, getKey func(*ProtectedHeader) (*ecdsa.PublicKey,
+	error)) (*ProtectedHeader, []byte, error) {
+
+	jws := new(JWS)
+	if err := json.Unmarshal(b, jws); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid JWS")
+	}
+
+	protected, err := b64.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid protected header")
+	}
+	hdr := new(ProtectedHeader)
+	if err = json.Unmarshal(protected, hdr); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid protected header")
+	}
+	if hdr.Alg != "ES256" {
+		return nil, nil, errors.Errorf("unsupported algorithm %s", hdr.Alg)
+	}
+
+	payload, err := b64.DecodeString(jws.Payload)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid payload")
+	}
+	sig, err := b64.DecodeString(jws.Signature)
+	if err != nil || len(sig) != 2*coordSize {
+		return nil, nil, errors.New("invalid signature encoding")
+	}
+
+	pub, err := getKey(hdr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	r := new(big.Int).SetBytes(sig[:coordSize])
+	s := new(big.Int).SetBytes(sig[coordSize:])
+	if !ecdsa.Verify(pub, h[:], r, s) {
+		return nil, nil, errors.New("invalid signature")
+	}
+
+	return hdr, payload, nil
+}
+
+// KeyAuthorization returns the key authorization of the challenge token for
+// the account with the given key.
+func KeyAuthorization(pub *ecdsa.PublicKey, token string) string {
+	return token + "." + Thumbprint(pub)
+}
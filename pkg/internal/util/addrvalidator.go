@@ -1,57 +1,239 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/dcrd/hdkeychain"
 	"github.com/decred/dcrwallet/wallet/udb"
+	"github.com/decred/slog"
 	"github.com/pkg/errors"
 )
 
-// MasterPubPoolAddrValidator validates addresses given a masterpubkey
+const (
+	// DefaultPoolAddrGapLimit is the default number of addresses derived past
+	// the highest index of a validated pool address of each master pubkey.
+	DefaultPoolAddrGapLimit = 10000
+
+	// PoolAddrIndexFilename is the name of the file (in the data dir) where
+	// the highest index of the validated pool addresses of each master
+	// pubkey is stored.
+	PoolAddrIndexFilename = "pooladdr-indexes.json"
+)
+
+// masterPubAccount is the external branch of one of the master pubkeys of a
+// MasterPubPoolAddrValidator.
+type masterPubAccount struct {
+	masterPubKey string
+	branchKey    *hdkeychain.ExtendedKey
+
+	// derived is the number of addresses already derived (addresses [0,
+	// derived) are in the address map).
+	derived uint32
+
+	// minEnd is the index up to which addresses are accepted regardless of
+	// the gap limit.
+	minEnd uint32
+
+	// lastUsed is the highest index of a validated address or -1 if no
+	// address of the account has been validated yet.
+	lastUsed int64
+
+	// extending is true while addresses of the account are being derived in
+	// the background.
+	extending bool
+}
+
+// end returns the index up to which addresses of the account are accepted.
+func (acct *masterPubAccount) end(gapLimit uint32) uint32 {
+	end := uint64(acct.lastUsed+1) + uint64(gapLimit)
+	if end < uint64(acct.minEnd) {
+		end = uint64(acct.minEnd)
+	}
+	if end > hdkeychain.HardenedKeyStart {
+		end = hdkeychain.HardenedKeyStart
+	}
+	return uint32(end)
+}
+
+// poolAddrIndex is the location of a derived address.
+type poolAddrIndex struct {
+	account *masterPubAccount
+	index   uint32
+}
+
+// MasterPubPoolAddrValidator validates addresses given a list of masterpubkeys.
+//
+// Addresses are derived up to a gap limit past the highest index of an
+// address already validated for each masterpubkey. The initial range is
+// derived when the validator is created and extended in the background once a
+// higher index is validated, so addresses of the extended range are only
+// accepted after their derivation finishes. The highest validated index is
+// stored in an index file, so that the accepted range is kept across restarts.
 type MasterPubPoolAddrValidator struct {
-	addresses map[string]struct{}
+	mtx       sync.Mutex
+	net       *chaincfg.Params
+	gapLimit  uint32
+	indexFile string
+	log       slog.Logger
+	accounts  []*masterPubAccount
+	addresses map[string]poolAddrIndex
+
+	// extensions tracks the running background derivations.
+	extensions sync.WaitGroup
 }
 
-// NewMasterPubPoolAddrValidator creates a new validator given an extended
-// master pubkey for an account.
-func NewMasterPubPoolAddrValidator(masterPubKey string, net *chaincfg.Params) (
+// NewMasterPubPoolAddrValidator creates a new validator given the extended
+// master pubkeys for a list of accounts. Each master pubkey may have an
+// :[index] suffix, in which case addresses up to that index are accepted
+// regardless of the gap limit.
+//
+// If indexFile is not empty, the highest index of the validated addresses of
+// each account is read from and saved to it. Errors saving the index file and
+// deriving addresses in the background are reported to log.
+func NewMasterPubPoolAddrValidator(masterPubKeys []string, gapLimit uint32,
+	indexFile string, log slog.Logger, net *chaincfg.Params) (
 	*MasterPubPoolAddrValidator, error) {
 
-	end := uint32(10000)
+	if len(masterPubKeys) == 0 {
+		return nil, errors.New("no masterPubKey provided")
+	}
+	if gapLimit == 0 {
+		return nil, errors.New("gap limit must be greater than zero")
+	}
+
+	lastUsed, err := readPoolAddrIndexes(indexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &MasterPubPoolAddrValidator{
+		net:       net,
+		gapLimit:  gapLimit,
+		indexFile: indexFile,
+		log:       log,
+		addresses: make(map[string]poolAddrIndex),
+	}
+
+	for _, masterPubKey := range masterPubKeys {
+		var minEnd uint32
+		if strings.Contains(masterPubKey, ":") {
+			idxStart := strings.Index(masterPubKey, ":") + 1
+			newEnd, err := strconv.ParseUint(masterPubKey[idxStart:], 10, 31)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error decoding end index of "+
+					"masterPubKey")
+			}
+			minEnd = uint32(newEnd)
+			masterPubKey = masterPubKey[:idxStart-1]
+		}
+
+		key, err := hdkeychain.NewKeyFromString(masterPubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !key.IsForNet(net) {
+			return nil, fmt.Errorf("extended public key is for wrong network")
+		}
+
+		// Derive from external branch
+		branchKey, err := key.Child(udb.ExternalBranch)
+		if err != nil {
+			return nil, err
+		}
+
+		acct := &masterPubAccount{
+			masterPubKey: masterPubKey,
+			branchKey:    branchKey,
+			minEnd:       minEnd,
+			lastUsed:     -1,
+		}
+		if idx, has := lastUsed[masterPubKey]; has {
+			acct.lastUsed = int64(idx)
+		}
 
-	if strings.Contains(masterPubKey, ":") {
-		idxStart := strings.Index(masterPubKey, ":") + 1
-		newEnd, err := strconv.Atoi(masterPubKey[idxStart:])
+		end := acct.end(gapLimit)
+		addrs, err := deriveAddresses(branchKey, 0, end, net)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error decoding end index of "+
-				"masterPubKey")
+			return nil, err
 		}
-		end = uint32(newEnd)
-		masterPubKey = masterPubKey[:idxStart-1]
+		for addr, i := range addrs {
+			v.addresses[addr] = poolAddrIndex{account: acct, index: i}
+		}
+		acct.derived = end
+		v.accounts = append(v.accounts, acct)
 	}
 
-	key, err := hdkeychain.NewKeyFromString(masterPubKey)
-	if err != nil {
-		return nil, err
+	return v, nil
+}
+
+// readPoolAddrIndexes reads the highest validated index of each master pubkey
+// from the given index file. A missing file is not an error.
+func readPoolAddrIndexes(indexFile string) (map[string]uint32, error) {
+	res := make(map[string]uint32)
+	if indexFile == "" {
+		return res, nil
+	}
+
+	data, err := ioutil.ReadFile(indexFile)
+	if os.IsNotExist(err) {
+		return res, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error reading pool address index file")
+	}
+
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, errors.Wrap(err, "error decoding pool address index file")
 	}
-	if !key.IsForNet(net) {
-		return nil, fmt.Errorf("extended public key is for wrong network")
+	return res, nil
+}
+
+// storeIndexes saves the highest validated index of each account to the index
+// file. Must be called with the mutex held.
+func (v *MasterPubPoolAddrValidator) storeIndexes() error {
+	if v.indexFile == "" {
+		return nil
+	}
+
+	indexes := make(map[string]uint32, len(v.accounts))
+	for _, acct := range v.accounts {
+		if acct.lastUsed >= 0 {
+			indexes[acct.masterPubKey] = uint32(acct.lastUsed)
+		}
 	}
 
-	// Derive from external branch
-	branchKey, err := key.Child(udb.ExternalBranch)
+	data, err := json.MarshalIndent(indexes, "", "  ")
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "error encoding pool address indexes")
+	}
+
+	if err = os.MkdirAll(filepath.Dir(v.indexFile), 0700); err != nil {
+		return errors.Wrap(err, "error creating pool address index dir")
+	}
+
+	tmpFile := v.indexFile + ".tmp"
+	if err = ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return errors.Wrap(err, "error writing pool address index file")
 	}
+	return errors.Wrap(os.Rename(tmpFile, v.indexFile),
+		"error replacing pool address index file")
+}
+
+// deriveAddresses derives the addresses of the given branch in the range
+// [start, end), returning their index by encoded address.
+func deriveAddresses(branchKey *hdkeychain.ExtendedKey, start, end uint32,
+	net *chaincfg.Params) (map[string]uint32, error) {
 
-	// Derive the addresses from [0, end) for this extended public key.
-	addrMap := make(map[string]struct{}, end)
-	for i := uint32(0); i < end; i++ {
+	res := make(map[string]uint32, end-start)
+	for i := start; i < end; i++ {
 		child, err := branchKey.Child(i)
 		if err == hdkeychain.ErrInvalidChild {
 			continue
@@ -59,18 +241,47 @@ func NewMasterPubPoolAddrValidator(masterPubKey string, net *chaincfg.Params) (
 		if err != nil {
 			return nil, errors.Wrapf(err, "error deriving %d'nth child key", i)
 		}
-		addr, err := child.Address(net)
+		childAddr, err := child.Address(net)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error creating address for %d'nth "+
 				"key", i)
 		}
-
-		addrMap[addr.EncodeAddress()] = struct{}{}
+		res[childAddr.EncodeAddress()] = i
 	}
+	return res, nil
+}
+
+// extend derives the addresses of the account up to the end of its accepted
+// range. Runs in the background, so that validations are not blocked by the
+// derivation.
+func (v *MasterPubPoolAddrValidator) extend(acct *masterPubAccount) {
+	defer v.extensions.Done()
+
+	for {
+		v.mtx.Lock()
+		start, end := acct.derived, acct.end(v.gapLimit)
+		if start >= end {
+			acct.extending = false
+			v.mtx.Unlock()
+			return
+		}
+		v.mtx.Unlock()
+
+		addrs, err := deriveAddresses(acct.branchKey, start, end, v.net)
 
-	return &MasterPubPoolAddrValidator{
-		addresses: addrMap,
-	}, nil
+		v.mtx.Lock()
+		if err != nil {
+			acct.extending = false
+			v.mtx.Unlock()
+			v.log.Errorf("Error extending pool subsidy addresses: %v", err)
+			return
+		}
+		for addr, i := range addrs {
+			v.addresses[addr] = poolAddrIndex{account: acct, index: i}
+		}
+		acct.derived = end
+		v.mtx.Unlock()
+	}
 }
 
 // ValidatePoolSubsidyAddress fulfills matcher.PoolAddressValidationProvider
@@ -81,9 +292,28 @@ func (v *MasterPubPoolAddrValidator) ValidatePoolSubsidyAddress(poolAddr dcrutil
 // ValidateByEncodedAddr validate directly by a string value. Assumes addr is
 // the result of a call to EncodeAddress()
 func (v *MasterPubPoolAddrValidator) ValidateByEncodedAddr(addr string) error {
-	if _, has := v.addresses[addr]; !has {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	loc, has := v.addresses[addr]
+	if !has {
 		return errors.Errorf("pool address not found in addresses map")
 	}
 
+	// Extend the accepted range of the account when a higher index is seen.
+	// Saving the index is best effort, given the address is valid anyway.
+	acct := loc.account
+	if int64(loc.index) > acct.lastUsed {
+		acct.lastUsed = int64(loc.index)
+		if err := v.storeIndexes(); err != nil {
+			v.log.Errorf("Error saving pool address indexes: %v", err)
+		}
+		if !acct.extending && acct.derived < acct.end(v.gapLimit) {
+			acct.extending = true
+			v.extensions.Add(1)
+			go v.extend(acct)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,152 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/hdkeychain"
+	"github.com/decred/dcrwallet/wallet/udb"
+	"github.com/decred/slog"
+)
+
+// testMasterPub returns a test extended master pubkey and a function that
+// returns the encoded address of the given index of its external branch.
+func testMasterPub(t *testing.T, b byte) (string, func(uint32) string) {
+	net := &chaincfg.TestNet3Params
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	seed[0] = b
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch, err := pub.Child(udb.ExternalBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := func(i uint32) string {
+		child, err := branch.Child(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := child.Address(net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addr.EncodeAddress()
+	}
+	return pub.String(), addr
+}
+
+func TestMasterPubPoolAddrValidatorGapLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrvalidator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexFile := filepath.Join(dir, PoolAddrIndexFilename)
+	net := &chaincfg.TestNet3Params
+
+	pub, addr := testMasterPub(t, 0x01)
+	v, err := NewMasterPubPoolAddrValidator([]string{pub}, 5, indexFile, slog.Disabled, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Addresses past the gap limit are not accepted until a higher index is
+	// seen and the range is extended.
+	if err := v.ValidateByEncodedAddr(addr(7)); err == nil {
+		t.Fatalf("accepted address past the gap limit")
+	}
+	for _, i := range []uint32{4, 7, 11} {
+		if err := v.ValidateByEncodedAddr(addr(i)); err != nil {
+			t.Fatalf("unexpected error validating address %d: %v", i, err)
+		}
+		v.extensions.Wait()
+	}
+	if err := v.ValidateByEncodedAddr(addr(17)); err == nil {
+		t.Fatalf("accepted address past the extended gap limit")
+	}
+
+	// The highest seen index is kept across restarts.
+	v, err = NewMasterPubPoolAddrValidator([]string{pub}, 5, indexFile, slog.Disabled, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ValidateByEncodedAddr(addr(16)); err != nil {
+		t.Fatalf("unexpected error validating address after restart: %v", err)
+	}
+}
+
+func TestMasterPubPoolAddrValidatorIndexFileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrvalidator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	subDir := filepath.Join(dir, "sub")
+	indexFile := filepath.Join(subDir, PoolAddrIndexFilename)
+	pub, addr := testMasterPub(t, 0x01)
+	v, err := NewMasterPubPoolAddrValidator([]string{pub}, 5, indexFile,
+		slog.Disabled, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The index file can't be written inside a regular file.
+	if err := ioutil.WriteFile(subDir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ValidateByEncodedAddr(addr(4)); err != nil {
+		t.Fatalf("unexpected error validating address: %v", err)
+	}
+	v.extensions.Wait()
+	if err := v.ValidateByEncodedAddr(addr(7)); err != nil {
+		t.Fatalf("range not extended after failing to save indexes: %v", err)
+	}
+}
+
+func TestMasterPubPoolAddrValidatorMultiple(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	pub1, addr1 := testMasterPub(t, 0x01)
+	pub2, addr2 := testMasterPub(t, 0x02)
+	_, addr3 := testMasterPub(t, 0x03)
+
+	// The end index suffix accepts addresses regardless of the gap limit.
+	v, err := NewMasterPubPoolAddrValidator([]string{pub1, pub2 + ":20"}, 3,
+		"", slog.Disabled, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := []string{addr1(0), addr1(2), addr2(19), addr2(1)}
+	for i, addr := range valid {
+		if err := v.ValidateByEncodedAddr(addr); err != nil {
+			t.Fatalf("unexpected error validating address %d: %v", i, err)
+		}
+	}
+
+	invalid := []string{addr1(9), addr2(40), addr3(0)}
+	for i, addr := range invalid {
+		if err := v.ValidateByEncodedAddr(addr); err == nil {
+			t.Fatalf("invalid address %d accepted", i)
+		}
+	}
+}
+
+func TestMasterPubPoolAddrValidatorWrongNet(t *testing.T) {
+	pub, _ := testMasterPub(t, 0x01)
+	_, err := NewMasterPubPoolAddrValidator([]string{pub}, 5, "",
+		slog.Disabled, &chaincfg.MainNetParams)
+	if err == nil {
+		t.Fatalf("accepted master pubkey of a different network")
+	}
+}
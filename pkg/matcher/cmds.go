@@ -33,16 +33,24 @@ type (
 		secrets []splitticket.SecretNumber
 		err     error
 	}
+
+	waitEstimateResponse struct {
+		estimate WaitEstimate
+		err      error
+	}
 )
 
 type (
 	addParticipantRequest struct {
-		ctx         context.Context
-		maxAmount   uint64
-		sessionName string
-		voteAddress dcrutil.Address
-		poolAddress dcrutil.Address
-		resp        chan addParticipantResponse
+		ctx            context.Context
+		maxAmount      uint64
+		pool           string
+		sessionName    string
+		voteAddress    dcrutil.Address
+		poolAddress    dcrutil.Address
+		voteChoices    splitticket.VoteChoices
+		voteChoicesSig []byte
+		resp           chan addParticipantResponse
 	}
 
 	setParticipantOutputsRequest struct {
@@ -75,12 +83,49 @@ type (
 		resp            chan fundSplitTxResponse
 	}
 
+	// poolFeeSignResult is the result of signing the pool fee inputs of the
+	// tickets of a session, which happens outside of the Run goroutine.
+	poolFeeSignResult struct {
+		sess    *Session
+		ticket  *wire.MsgTx
+		splitTx *wire.MsgTx
+		sigs    [][]byte
+		err     error
+	}
+
+	// poolFeeAddress is a pool fee address fetched in advance (outside of
+	// the Run goroutine) for a new session.
+	poolFeeAddress struct {
+		addr     dcrutil.Address
+		keyIndex uint32
+	}
+
+	participantWatchEvent struct {
+		participant *SessionParticipant
+		watchID     uint64
+	}
+
 	watchWaitingListRequest struct {
 		ctx     context.Context
 		watcher chan []WaitingQueue
 	}
+
+	statusRequest struct {
+		resp chan Status
+	}
+
+	waitEstimateRequest struct {
+		pool        string
+		sessionName string
+		amount      uint64
+		resp        chan waitEstimateResponse
+	}
 )
 
+func (req *addParticipantRequest) queueKey() queueKey {
+	return queueKey{pool: req.pool, name: req.sessionName}
+}
+
 type addParticipantRequestsByAmount []*addParticipantRequest
 
 func (a addParticipantRequestsByAmount) Len() int           { return len(a) }
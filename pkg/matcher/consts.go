@@ -1,10 +1,40 @@
 package matcher
 
-import "github.com/pkg/errors"
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 const (
 	// MaximumExpiry accepted for split and ticket transactions
 	MaximumExpiry = 16
+
+	// sessionStartsHistory is the number of recent session start times used
+	// to estimate the interval between sessions.
+	sessionStartsHistory = 10
+
+	// queueStatsHistory is the number of recent arrivals of participants
+	// tracked per queue to estimate waiting times.
+	queueStatsHistory = 50
+
+	// queueStatsMaxAge is the maximum age of arrivals used to estimate
+	// waiting times.
+	queueStatsMaxAge = 24 * time.Hour
+
+	// maxQueueStats is the maximum number of queues with tracked arrivals.
+	// Queues are created by participants at will, so this bounds the memory
+	// used by the statistics.
+	maxQueueStats = 1000
+
+	// poolFeeAddressPrefetch is the maximum number of pool fee addresses
+	// fetched in advance for new sessions. Addresses not yet used when the
+	// matcher stops are discarded, so this is kept small.
+	poolFeeAddressPrefetch = 2
+
+	// poolFeeAddressRetryInterval is how long to wait before requesting a
+	// pool fee address again after an error.
+	poolFeeAddressRetryInterval = 10 * time.Second
 )
 
 type contextKey string
@@ -19,10 +49,40 @@ var (
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x88, 0xac}
 
 	originalSrcCtxKey = contextKey("OriginalSrcCtxKey")
+	connectionCtxKey  = contextKey("ConnectionCtxKey")
+	clientIDCtxKey    = contextKey("ClientIDCtxKey")
 
 	// ErrSessionExpired is the error triggered when the session has expired the
 	// maximum allowed elapsed time.
 	ErrSessionExpired = errors.New("session expired")
+
+	// ErrParticipantDisconnected is the error triggered when a participant of
+	// a session disconnects and does not reconnect within the grace period.
+	ErrParticipantDisconnected = errors.New("participant disconnected")
+
+	// ErrCallSuperseded is the error returned to an outstanding call of a
+	// participant when the same participant reconnects and repeats the call.
+	ErrCallSuperseded = errors.New("call superseded by reconnected participant")
+
+	// ErrInvalidJoinKey is the error returned when a participant tries to
+	// join a queue that requires a join key without proving knowledge of it.
+	ErrInvalidJoinKey = errors.New("invalid join key for queue")
+
+	// ErrClientNotAllowed is the error returned when an authenticated client
+	// tries to join a queue restricted to other clients.
+	ErrClientNotAllowed = errors.New("client not allowed in queue")
+
+	// ErrEvictedFromQueue is the error returned to a participant waiting on a
+	// full queue that couldn't fund a ticket, when it is replaced by a
+	// participant willing to contribute a larger amount.
+	ErrEvictedFromQueue = errors.New("evicted from full queue by a larger " +
+		"participant")
+
+	// ErrPrivateQueue is the error returned when estimating the wait of a
+	// queue restricted by a join key or to a list of clients, given the
+	// estimate would disclose its activity to anyone.
+	ErrPrivateQueue = errors.New("wait estimates not available for private " +
+		"queues")
 )
 
 // SessionStage is the stage of a given session
@@ -0,0 +1,298 @@
+package matcher
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// queueArrival is the arrival of a participant on a queue.
+type queueArrival struct {
+	time   time.Time
+	amount dcrutil.Amount
+}
+
+// queueStats are the statistics of the participants that recently arrived on
+// a queue. They are kept after the queue is emptied by a new session, so that
+// the waiting time of future participants can be estimated.
+type queueStats struct {
+	// arrivals are the most recent arrivals (up to queueStatsHistory of
+	// them), oldest first.
+	arrivals []queueArrival
+
+	// waiting are the vote addresses of the participants that arrived at the
+	// queue and have not yet been matched into a session, along with when
+	// they were last added to it. Participants that leave the queue and
+	// register again (eg: after reconnecting) are not new arrivals.
+	waiting map[string]time.Time
+}
+
+// participantAdded records that the participant with the given vote address
+// was added to the queue. Returns true if this is a new arrival (that is, if
+// the participant was not recently waiting on the queue).
+func (s *queueStats) participantAdded(voteAddr string, now time.Time) bool {
+	if s.waiting == nil {
+		s.waiting = make(map[string]time.Time)
+	}
+	for addr, t := range s.waiting {
+		if now.Sub(t) > queueStatsMaxAge {
+			delete(s.waiting, addr)
+		}
+	}
+
+	_, waiting := s.waiting[voteAddr]
+	s.waiting[voteAddr] = now
+	return !waiting
+}
+
+func (s *queueStats) addArrival(t time.Time, amount dcrutil.Amount) {
+	s.arrivals = append(s.arrivals, queueArrival{time: t, amount: amount})
+	if len(s.arrivals) > queueStatsHistory {
+		s.arrivals = s.arrivals[1:]
+	}
+}
+
+func (s *queueStats) lastArrival() time.Time {
+	return s.arrivals[len(s.arrivals)-1].time
+}
+
+// recent returns the arrivals that happened at most queueStatsMaxAge before
+// now.
+func (s *queueStats) recent(now time.Time) []queueArrival {
+	for i, a := range s.arrivals {
+		if now.Sub(a.time) <= queueStatsMaxAge {
+			return s.arrivals[i:]
+		}
+	}
+	return nil
+}
+
+// WaitEstimate is the estimate of how long a participant will wait on a queue
+// for a new session to start, based on the rate participants have recently
+// been arriving at the queue.
+type WaitEstimate struct {
+	// MissingAmount is the amount that other participants still need to
+	// contribute for a new session to start. It is zero if the session
+	// would start immediately.
+	MissingAmount dcrutil.Amount
+
+	// AverageAmount is the average amount of the participants that recently
+	// arrived at the queue.
+	AverageAmount dcrutil.Amount
+
+	// ArrivalInterval is the average time between the arrival of
+	// participants at the queue. It is zero when not enough participants have
+	// recently arrived to calculate it.
+	ArrivalInterval time.Duration
+
+	// ExpectedArrivals is the number of participants (with the average
+	// amount) that need to arrive for a new session to start.
+	ExpectedArrivals int
+}
+
+// Known returns true if there is enough history about the queue to estimate
+// the waiting time.
+func (e *WaitEstimate) Known() bool {
+	return e.MissingAmount == 0 || e.ArrivalInterval > 0
+}
+
+// ExpectedWait returns the expected time until a new session of the queue
+// starts. It is only meaningful when the estimate is known.
+func (e *WaitEstimate) ExpectedWait() time.Duration {
+	return time.Duration(e.ExpectedArrivals) * e.ArrivalInterval
+}
+
+// MatchProbability returns the probability that a new session of the queue
+// starts within the given duration, assuming participants arrive as a poisson
+// process with the recent arrival rate. It is zero when the estimate is not
+// known.
+func (e *WaitEstimate) MatchProbability(within time.Duration) float64 {
+	if e.MissingAmount == 0 {
+		return 1
+	}
+	if e.ArrivalInterval <= 0 || e.ExpectedArrivals <= 0 || within <= 0 {
+		return 0
+	}
+
+	// Probability of at least ExpectedArrivals arrivals, which is one minus
+	// the probability of each lower number of arrivals.
+	lambda := float64(within) / float64(e.ArrivalInterval)
+	term := math.Exp(-lambda)
+	cumulative := term
+	for i := 1; i < e.ExpectedArrivals; i++ {
+		term *= lambda / float64(i)
+		cumulative += term
+	}
+	return math.Max(0, 1-cumulative)
+}
+
+// estimateWait returns the estimate of the waiting time for a new session of
+// a queue with participants waiting with the given amounts, given the recent
+// arrivals at the queue.
+func estimateWait(amounts []dcrutil.Amount, arrivals []queueArrival,
+	now time.Time, ticketPrice, feeRate dcrutil.Amount) WaitEstimate {
+
+	var est WaitEstimate
+
+	var available dcrutil.Amount
+	for _, a := range amounts {
+		available += a
+	}
+	needed := ticketPrice + splitticket.SessionFeeEstimate(len(amounts),
+		feeRate)
+
+	// Sessions start when the available amount is strictly greater than the
+	// needed amount.
+	if available > needed {
+		return est
+	}
+	est.MissingAmount = needed - available + 1
+
+	if len(arrivals) == 0 {
+		return est
+	}
+	var total dcrutil.Amount
+	for _, a := range arrivals {
+		total += a.amount
+	}
+	est.AverageAmount = total / dcrutil.Amount(len(arrivals))
+	if est.AverageAmount <= 0 {
+		return est
+	}
+	est.ExpectedArrivals = int((est.MissingAmount + est.AverageAmount - 1) /
+		est.AverageAmount)
+
+	// The first arrival starts the observation period, so only the following
+	// ones count for the rate.
+	if len(arrivals) > 1 {
+		est.ArrivalInterval = now.Sub(arrivals[0].time) /
+			time.Duration(len(arrivals)-1)
+	}
+
+	return est
+}
+
+// recordArrival adds the arrival of a participant with the given vote address
+// to the statistics of the given queue. Participants that register again
+// while still waiting for a session are not counted more than once.
+func (matcher *Matcher) recordArrival(key queueKey, voteAddr string,
+	amount uint64, now time.Time) {
+
+	stats, has := matcher.queueStats[key]
+	if !has {
+		if len(matcher.queueStats) >= maxQueueStats {
+			matcher.pruneQueueStats(now)
+		}
+		stats = new(queueStats)
+		matcher.queueStats[key] = stats
+	}
+	if stats.participantAdded(voteAddr, now) {
+		stats.addArrival(now, dcrutil.Amount(amount))
+	}
+}
+
+// recordMatched removes the given participants from the ones waiting on the
+// queue, so that they count as new arrivals if they join it again.
+func (matcher *Matcher) recordMatched(key queueKey,
+	parts []*addParticipantRequest) {
+
+	stats, has := matcher.queueStats[key]
+	if !has {
+		return
+	}
+	for _, r := range parts {
+		delete(stats.waiting, r.voteAddress.EncodeAddress())
+	}
+}
+
+// pruneQueueStats removes the statistics of queues without recent arrivals.
+// If all queues had recent arrivals, the statistics of the queue with the
+// oldest last arrival are removed.
+func (matcher *Matcher) pruneQueueStats(now time.Time) {
+	var stalestKey queueKey
+	var stalest time.Time
+	for key, stats := range matcher.queueStats {
+		last := stats.lastArrival()
+		if now.Sub(last) > queueStatsMaxAge {
+			delete(matcher.queueStats, key)
+			continue
+		}
+		if stalest.IsZero() || last.Before(stalest) {
+			stalestKey, stalest = key, last
+		}
+	}
+
+	if len(matcher.queueStats) >= maxQueueStats {
+		delete(matcher.queueStats, stalestKey)
+	}
+}
+
+// estimateWait returns the wait estimate for the given request.
+func (matcher *Matcher) estimateWait(req *waitEstimateRequest) (WaitEstimate,
+	error) {
+
+	if _, has := matcher.pools[req.pool]; !has {
+		return WaitEstimate{}, errors.Errorf("unknown pool '%s'", req.pool)
+	}
+
+	key := queueKey{pool: req.pool, name: req.sessionName}
+	qcfg := matcher.queueConfigs[key]
+	if qcfg != nil && (len(qcfg.JoinKey) > 0 || len(qcfg.AllowedClients) > 0) {
+		return WaitEstimate{}, ErrPrivateQueue
+	}
+
+	var amounts []dcrutil.Amount
+	if q, has := matcher.queues[key]; has {
+		amounts = q.waitingAmounts()
+	}
+	if req.amount > 0 {
+		amount := req.amount
+		if qcfg != nil && qcfg.MaxAmount > 0 && amount > qcfg.MaxAmount {
+			amount = qcfg.MaxAmount
+		}
+		amounts = append(amounts, dcrutil.Amount(amount))
+	}
+
+	now := time.Now()
+	var arrivals []queueArrival
+	if stats, has := matcher.queueStats[key]; has {
+		arrivals = stats.recent(now)
+	}
+
+	network := matcher.cfg.NetworkProvider
+	return estimateWait(amounts, arrivals, now,
+		dcrutil.Amount(network.CurrentTicketPrice()),
+		network.CurrentFeeRate()), nil
+}
+
+// EstimateWait returns the estimate of how long a participant will wait for a
+// new session on the queue of the given pool and session name.
+//
+// amount is the amount of a participant that is about to join the queue. It
+// should be zero for participants already waiting on it, given that their
+// amount is already accounted for.
+func (matcher *Matcher) EstimateWait(ctx context.Context, pool,
+	sessionName string, amount uint64) (*WaitEstimate, error) {
+
+	req := waitEstimateRequest{
+		pool:        pool,
+		sessionName: sessionName,
+		amount:      amount,
+		resp:        make(chan waitEstimateResponse, 1),
+	}
+	select {
+	case matcher.waitEstimateRequests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	resp := <-req.resp
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	return &resp.estimate, nil
+}
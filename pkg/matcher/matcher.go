@@ -1,6 +1,7 @@
 package matcher
 
 import (
+	"bytes"
 	"context"
 	"crypto/subtle"
 	"encoding/hex"
@@ -27,6 +28,7 @@ type NetworkProvider interface {
 	CurrentTicketPrice() uint64
 	CurrentBlockHeight() uint32
 	CurrentBlockHash() chainhash.Hash
+	CurrentFeeRate() dcrutil.Amount
 	ConnectedToDecredNetwork() bool
 	PublishTransactions([]*wire.MsgTx) error
 	GetUtxos(outpoints []*wire.OutPoint) (splitticket.UtxoMap, error)
@@ -35,8 +37,13 @@ type NetworkProvider interface {
 // SignPoolSplitOutputProvider is the interface for the poerations the matcher
 // needs for generating and signing the pool fee address input of tickets.
 type SignPoolSplitOutputProvider interface {
-	PoolFeeAddress() dcrutil.Address
-	SignPoolSplitOutput(split, ticket *wire.MsgTx) ([]byte, error)
+	// PoolFeeAddress returns the address for the pool fee output of the split
+	// tx of a new session and the index of the key that controls it.
+	PoolFeeAddress() (dcrutil.Address, uint32, error)
+
+	// SignPoolSplitOutput signs the pool fee input of the ticket with the key
+	// of the given index.
+	SignPoolSplitOutput(split, ticket *wire.MsgTx, keyIndex uint32) ([]byte, error)
 }
 
 // VoteAddressValidationProvider is the interface for operations the matcher needs to
@@ -70,9 +77,39 @@ type Config struct {
 	PublishTransactions       bool
 	SessionDataDir            string
 
+	// Pools are the profiles of additional voting pools served by the matcher,
+	// besides the default pool built from the PoolFee, VoteAddrValidator and
+	// PoolAddrValidator fields.
+	Pools []*Pool
+
+	// Queues are the named queues pre-declared by the operator, with their
+	// own participation rules. Queues not declared here use the matcher-wide
+	// settings.
+	Queues []*QueueConfig
+
+	// DisconnectGracePeriod is how long to wait for a participant that
+	// disconnected in the middle of a session to reconnect (and resume its
+	// participation by repeating its last call) before canceling the session.
+	DisconnectGracePeriod time.Duration
+
+	// SessionStartedNtfn is a function run after a new session is started
+	// and its participants have been selected. This is run as a goroutine.
+	SessionStartedNtfn func(sess *Session)
+
+	// SessionFailedNtfn is a function run after a session is canceled before
+	// completing. It receives the session and the reason for its failure. This
+	// is run as a goroutine.
+	SessionFailedNtfn func(sess *Session, err error)
+
 	// SuccessfulSesssionNtfn is a function run after a successful session is
-	// completed. This is run as a goroutine.
-	SuccessfulSesssionNtfn func(ticketHash chainhash.Hash)
+	// completed. It receives the (finished) session and the final ticket and
+	// split transactions. This is run as a goroutine.
+	SuccessfulSesssionNtfn func(sess *Session, ticket, splitTx *wire.MsgTx)
+
+	// PublishFailedNtfn is a function run when all participants of a session
+	// have funded the split transaction but publishing the transactions failed.
+	// This is run as a goroutine.
+	PublishFailedNtfn func(sess *Session, ticket, splitTx *wire.MsgTx, err error)
 }
 
 type cancelSessionChanReq struct {
@@ -92,19 +129,72 @@ type ParticipantTicketOutput struct {
 // WaitingQueue returns information about participants waiting on a specific
 // queue
 type WaitingQueue struct {
+	Pool    string
 	Name    string
 	Amounts []dcrutil.Amount
 }
 
+// Status is a snapshot of the state of the matcher and of the decred network,
+// useful for clients deciding whether and when to join a queue.
+type Status struct {
+	TicketPrice dcrutil.Amount
+	BlockHeight uint32
+
+	// BlocksToStakeDiffChange is the number of blocks until the ticket price
+	// changes.
+	BlocksToStakeDiffChange int32
+
+	// StakeDiffChangeStopWindow is the number of blocks around a change of
+	// stake difficulty during which new participants are not accepted.
+	StakeDiffChangeStopWindow int32
+
+	// ActiveSessions is the number of sessions currently in progress.
+	ActiveSessions int
+
+	// LastSessionStart is the start time of the most recent session. It is
+	// zero when no session has started since the matcher started running.
+	LastSessionStart time.Time
+
+	// SessionInterval is the average time between the start of the most
+	// recent sessions. It is zero when not enough sessions have started to
+	// calculate it.
+	SessionInterval time.Duration
+}
+
+// NextSessionEstimate returns the estimated duration (from now) until the
+// next session of the matcher starts, based on the average interval between
+// the recent sessions. The second value is false when no estimate is
+// available.
+func (s *Status) NextSessionEstimate(now time.Time) (time.Duration, bool) {
+	if s.SessionInterval <= 0 || s.LastSessionStart.IsZero() {
+		return 0, false
+	}
+
+	// If the next session is overdue, assume it will start as soon as the
+	// current interval ends.
+	elapsed := now.Sub(s.LastSessionStart)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return s.SessionInterval - elapsed%s.SessionInterval, true
+}
+
 // Matcher is the main engine for matching operations
 type Matcher struct {
-	queues              map[string]*splitTicketQueue
+	queues              map[queueKey]*splitTicketQueue
+	pools               map[string]*Pool
+	queueConfigs        map[queueKey]*QueueConfig
 	sessions            map[SessionID]*Session
 	participants        map[ParticipantID]*SessionParticipant
 	waitingListWatchers map[context.Context]chan []WaitingQueue
 	cfg                 *Config
 	log                 slog.Logger
 
+	// serverCtx is the context of the running Run() call. Goroutines that
+	// send back to the Run() loop also select on it, so that they don't
+	// block forever once the matcher stops.
+	serverCtx context.Context
+
 	// waitingListWatcherTimer is filled when there's an active timer for
 	// sending waiting list notifications. It is nil (and therefore always
 	// blocking) when there are no outstanding notifications for waiting list
@@ -112,6 +202,14 @@ type Matcher struct {
 	waitingListWatcherTimer      <-chan time.Time
 	waitingListChangedDuringWait bool
 
+	// sessionStarts are the start times of the most recent sessions (up to
+	// sessionStartsHistory of them), oldest first.
+	sessionStarts []time.Time
+
+	// queueStats are the statistics of recent arrivals of participants on
+	// each queue, used to estimate waiting times.
+	queueStats map[queueKey]*queueStats
+
 	cancelWaitingParticipant      chan *addParticipantRequest
 	addParticipantRequests        chan addParticipantRequest
 	setParticipantOutputsRequests chan setParticipantOutputsRequest
@@ -120,18 +218,41 @@ type Matcher struct {
 	cancelSessionChan             chan cancelSessionChanReq
 	watchWaitingListRequests      chan watchWaitingListRequest
 	cancelWaitingListWatcher      chan context.Context
+	participantDisconnected       chan participantWatchEvent
+	disconnectGraceExpired        chan participantWatchEvent
+	statusRequests                chan statusRequest
+	waitEstimateRequests          chan waitEstimateRequest
+	poolFeeSignResults            chan poolFeeSignResult
+
+	// poolFeeAddrs are the pool fee addresses fetched in advance (and sent
+	// over poolFeeAddresses) by fetchPoolFeeAddresses, so that starting a
+	// session does not block the Run goroutine on the pool signer.
+	poolFeeAddrs     []poolFeeAddress
+	poolFeeAddresses chan poolFeeAddress
 }
 
 // NewMatcher creates an instance of a new split ticket matcher. Call
 // matcher.run() on a goroutine to start processing.
-func NewMatcher(cfg *Config) *Matcher {
+func NewMatcher(cfg *Config) (*Matcher, error) {
+	pools, err := buildPools(cfg)
+	if err != nil {
+		return nil, err
+	}
+	queueConfigs, err := buildQueueConfigs(cfg, pools)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &Matcher{
 		cfg:                 cfg,
-		queues:              make(map[string]*splitTicketQueue),
+		queues:              make(map[queueKey]*splitTicketQueue),
+		pools:               pools,
+		queueConfigs:        queueConfigs,
 		log:                 cfg.Log,
 		sessions:            make(map[SessionID]*Session),
 		participants:        make(map[ParticipantID]*SessionParticipant),
 		waitingListWatchers: make(map[context.Context]chan []WaitingQueue),
+		queueStats:          make(map[queueKey]*queueStats),
 
 		addParticipantRequests:        make(chan addParticipantRequest),
 		cancelWaitingParticipant:      make(chan *addParticipantRequest),
@@ -141,14 +262,28 @@ func NewMatcher(cfg *Config) *Matcher {
 		cancelSessionChan:             make(chan cancelSessionChanReq),
 		watchWaitingListRequests:      make(chan watchWaitingListRequest),
 		cancelWaitingListWatcher:      make(chan context.Context),
+		participantDisconnected:       make(chan participantWatchEvent),
+		disconnectGraceExpired:        make(chan participantWatchEvent),
+		statusRequests:                make(chan statusRequest),
+		waitEstimateRequests:          make(chan waitEstimateRequest),
+		poolFeeSignResults:            make(chan poolFeeSignResult),
+		poolFeeAddresses:              make(chan poolFeeAddress),
 	}
 
-	return m
+	return m, nil
 }
 
 // Run listens for all matcher messages and runs the matching engine.
 func (matcher *Matcher) Run(serverCtx context.Context) error {
+	matcher.serverCtx = serverCtx
+	go matcher.fetchPoolFeeAddresses(serverCtx)
 	for {
+		// Only accept new pool fee addresses while there's room for them.
+		poolFeeAddresses := matcher.poolFeeAddresses
+		if len(matcher.poolFeeAddrs) >= poolFeeAddressPrefetch {
+			poolFeeAddresses = nil
+		}
+
 		select {
 		case req := <-matcher.addParticipantRequests:
 			err := matcher.addParticipant(&req)
@@ -158,13 +293,15 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 				}
 			}
 		case cancelReq := <-matcher.cancelWaitingParticipant:
-			q, has := matcher.queues[cancelReq.sessionName]
+			key := cancelReq.queueKey()
+			q, has := matcher.queues[key]
 			if has {
-				matcher.log.Infof("Dropping waiting participant of queue '%s' for %s",
-					cancelReq.sessionName, dcrutil.Amount(cancelReq.maxAmount))
+				matcher.log.Infof("Dropping waiting participant of queue '%s' "+
+					"(pool '%s') for %s", cancelReq.sessionName,
+					cancelReq.pool, dcrutil.Amount(cancelReq.maxAmount))
 				q.removeWaitingParticipant(cancelReq)
 				if q.empty() {
-					delete(matcher.queues, cancelReq.sessionName)
+					delete(matcher.queues, key)
 				}
 			}
 
@@ -175,6 +312,8 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 				err = matcher.setParticipantsOutputs(&req, part)
 				if err != nil {
 					part.log.Error(err)
+				} else {
+					matcher.watchParticipant(part, req.ctx)
 				}
 			} else {
 				err = errors.Errorf("session %s not found", req.sessionID.String())
@@ -191,6 +330,8 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 				err = matcher.fundTicket(&req, part)
 				if err != nil {
 					part.log.Error(err)
+				} else {
+					matcher.watchParticipant(part, req.ctx)
 				}
 			} else {
 				err = errors.Errorf("session %s not found", req.sessionID.String())
@@ -207,6 +348,8 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 				err = matcher.fundSplitTx(&req, part)
 				if err != nil {
 					part.log.Error(err)
+				} else {
+					matcher.watchParticipant(part, req.ctx)
 				}
 			} else {
 				err = errors.Errorf("session %s not found", req.sessionID.String())
@@ -217,22 +360,48 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 					err: err,
 				}
 			}
+		case res := <-matcher.poolFeeSignResults:
+			matcher.poolFeeOutputsSigned(&res)
+		case addr := <-poolFeeAddresses:
+			matcher.poolFeeAddrs = append(matcher.poolFeeAddrs, addr)
+			matcher.startWaitingSessions()
 		case cancelReq := <-matcher.cancelSessionChan:
-			matcher.log.Infof("Cancelling session %s", cancelReq.session.ID)
-			cancelReq.session.Canceled = true
-			matcher.removeSession(cancelReq.session, cancelReq.err)
+			matcher.cancelSession(cancelReq.session, cancelReq.err)
+		case e := <-matcher.participantDisconnected:
+			matcher.participantConnectionDone(e)
+		case e := <-matcher.disconnectGraceExpired:
+			part := e.participant
+			if e.watchID == part.watchID && part.disconnected &&
+				!part.Session.Done && !part.Session.Canceled {
+
+				part.log.Warnf("Participant did not reconnect within the " +
+					"grace period")
+				matcher.cancelSession(part.Session, ErrParticipantDisconnected)
+			}
 		case req := <-matcher.watchWaitingListRequests:
 			origSrc := OriginalSrcFromCtx(req.ctx)
 			matcher.log.Infof("Adding new waiting list watcher from %s", origSrc)
 			matcher.waitingListWatchers[req.ctx] = req.watcher
 			go func(c context.Context) {
-				<-c.Done()
-				matcher.cancelWaitingListWatcher <- c
+				select {
+				case <-c.Done():
+				case <-serverCtx.Done():
+					return
+				}
+				select {
+				case matcher.cancelWaitingListWatcher <- c:
+				case <-serverCtx.Done():
+				}
 			}(req.ctx)
 		case cancelReq := <-matcher.cancelWaitingListWatcher:
 			origSrc := OriginalSrcFromCtx(cancelReq)
 			matcher.log.Infof("Removing waiting list watcher from %s", origSrc)
 			delete(matcher.waitingListWatchers, cancelReq)
+		case req := <-matcher.statusRequests:
+			req.resp <- matcher.status()
+		case req := <-matcher.waitEstimateRequests:
+			est, err := matcher.estimateWait(&req)
+			req.resp <- waitEstimateResponse{estimate: est, err: err}
 		case <-matcher.waitingListWatcherTimer:
 			if matcher.waitingListChangedDuringWait {
 				matcher.notifyWaitingListWatchers()
@@ -250,15 +419,7 @@ func (matcher *Matcher) Run(serverCtx context.Context) error {
 // outstanding waiting list watcher channels. It will not block if any of
 // the watchers are blocked.
 func (matcher *Matcher) notifyWaitingListWatchers() {
-	queues := make([]WaitingQueue, len(matcher.queues))
-	i := 0
-	for name, q := range matcher.queues {
-		queues[i] = WaitingQueue{
-			Name:    name,
-			Amounts: q.waitingAmounts(),
-		}
-		i++
-	}
+	queues := matcher.waitingQueues()
 
 	matcher.log.Trace("Sending waiting list change notification")
 
@@ -275,6 +436,45 @@ func (matcher *Matcher) notifyWaitingListWatchers() {
 	}
 }
 
+// waitingQueues returns information about the current waiting queues.
+func (matcher *Matcher) waitingQueues() []WaitingQueue {
+	queues := make([]WaitingQueue, len(matcher.queues))
+	i := 0
+	for key, q := range matcher.queues {
+		queues[i] = WaitingQueue{
+			Pool:    key.pool,
+			Name:    key.name,
+			Amounts: q.waitingAmounts(),
+		}
+		i++
+	}
+	return queues
+}
+
+// status returns the current status of the matcher.
+func (matcher *Matcher) status() Status {
+	network := matcher.cfg.NetworkProvider
+	height := network.CurrentBlockHeight()
+	st := Status{
+		TicketPrice: dcrutil.Amount(network.CurrentTicketPrice()),
+		BlockHeight: height,
+		BlocksToStakeDiffChange: splitticket.BlocksToStakeDiffChange(height,
+			matcher.cfg.ChainParams),
+		StakeDiffChangeStopWindow: matcher.cfg.StakeDiffChangeStopWindow,
+		ActiveSessions:            len(matcher.sessions),
+	}
+
+	if n := len(matcher.sessionStarts); n > 0 {
+		st.LastSessionStart = matcher.sessionStarts[n-1]
+		if n > 1 {
+			st.SessionInterval = st.LastSessionStart.Sub(
+				matcher.sessionStarts[0]) / time.Duration(n-1)
+		}
+	}
+
+	return st
+}
+
 // enqueueWaitingListNotification will send a notification to waiting list
 // watchers (if one is not yet outstanding) or enqueue a request so that
 // notifications are sent at a rate of at most once every 5 seconds.
@@ -297,35 +497,66 @@ func (matcher *Matcher) addParticipant(req *addParticipantRequest) error {
 
 	origSrc := OriginalSrcFromCtx(req.ctx)
 
-	err := matcher.cfg.VoteAddrValidator.ValidateVoteAddress(req.voteAddress)
+	pool, has := matcher.pools[req.pool]
+	if !has {
+		return errors.Errorf("unknown pool '%s'", req.pool)
+	}
+
+	err := pool.VoteAddrValidator.ValidateVoteAddress(req.voteAddress)
 	if err != nil {
 		matcher.log.Errorf("Participant sent invalid vote address %s from "+
 			"%s: %s", req.voteAddress.EncodeAddress(), origSrc, err)
 		return errors.Wrapf(err, "invalid vote address")
 	}
 
-	err = matcher.cfg.PoolAddrValidator.ValidatePoolSubsidyAddress(req.poolAddress)
+	err = pool.PoolAddrValidator.ValidatePoolSubsidyAddress(req.poolAddress)
 	if err != nil {
 		matcher.log.Errorf("Participant sent invalid pool address %s from "+
 			"%s: %s", req.poolAddress.EncodeAddress(), origSrc, err)
 		return errors.Wrapf(err, "invalid pool address")
 	}
 
-	matcher.log.Infof("Adding participant for amount %s on queue '%s' using "+
-		"vote address %s from %s", dcrutil.Amount(req.maxAmount),
-		req.sessionName, req.voteAddress.EncodeAddress(), origSrc)
-	q, has := matcher.queues[req.sessionName]
+	matcher.log.Infof("Adding participant for amount %s on queue '%s' (pool "+
+		"'%s') using vote address %s from %s", dcrutil.Amount(req.maxAmount),
+		req.sessionName, req.pool, req.voteAddress.EncodeAddress(), origSrc)
+	key := req.queueKey()
+	q, has := matcher.queues[key]
 	if !has {
-		q = newSplitTicketQueue(matcher.cfg.NetworkProvider)
-		matcher.queues[req.sessionName] = q
+		q = newSplitTicketQueue(matcher.cfg.NetworkProvider, pool,
+			matcher.queueConfigs[key])
+		matcher.queues[key] = q
+	}
+
+	if evicted := q.evictForAmount(req.maxAmount); evicted != nil {
+		matcher.log.Infof("Evicting waiting participant of amount %s from "+
+			"full queue '%s' (pool '%s')", dcrutil.Amount(evicted.maxAmount),
+			req.sessionName, req.pool)
+		evicted.resp <- addParticipantResponse{
+			err: ErrEvictedFromQueue,
+		}
+	}
+
+	if q.full() {
+		if q.empty() {
+			delete(matcher.queues, key)
+		}
+		return errors.Errorf("queue '%s' is full", req.sessionName)
 	}
 
 	q.addWaitingParticipant(req)
+	matcher.recordArrival(key, req.voteAddress.EncodeAddress(), req.maxAmount,
+		time.Now())
 
-	if q.enoughForNewSession() {
-		delete(matcher.queues, req.sessionName)
+	if q.enoughForNewSession() && len(matcher.poolFeeAddrs) > 0 {
+		delete(matcher.queues, key)
+		matcher.recordMatched(key, q.waitingParticipants)
 		matcher.startNewSession(q)
 	} else {
+		if q.enoughForNewSession() {
+			matcher.log.Warnf("Waiting for a pool fee address to start "+
+				"session on queue '%s' (pool '%s')", req.sessionName,
+				req.pool)
+		}
 		go func(r *addParticipantRequest) {
 			<-r.ctx.Done()
 			if r.ctx.Err() != nil {
@@ -339,15 +570,41 @@ func (matcher *Matcher) addParticipant(req *addParticipantRequest) error {
 	return nil
 }
 
+// startWaitingSessions starts the sessions of the queues that had enough
+// participants while no pool fee address was available.
+func (matcher *Matcher) startWaitingSessions() {
+	started := false
+	for key, q := range matcher.queues {
+		if len(matcher.poolFeeAddrs) == 0 {
+			break
+		}
+		if !q.enoughForNewSession() {
+			continue
+		}
+
+		delete(matcher.queues, key)
+		matcher.recordMatched(key, q.waitingParticipants)
+		matcher.startNewSession(q)
+		started = true
+	}
+
+	if started {
+		matcher.enqueueWaitingListNotification()
+	}
+}
+
+// startNewSession starts a new session with the participants waiting on the
+// given queue. A pool fee address must be available in matcher.poolFeeAddrs.
 func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 	numParts := len(q.waitingParticipants)
-	partFee := splitticket.SessionParticipantFee(numParts)
+	feeRate := matcher.cfg.NetworkProvider.CurrentFeeRate()
+	partFee := splitticket.SessionParticipantFee(numParts, feeRate)
 	ticketTxFee := partFee * dcrutil.Amount(numParts)
 	ticketPrice := dcrutil.Amount(matcher.cfg.NetworkProvider.CurrentTicketPrice())
 	blockHeight := matcher.cfg.NetworkProvider.CurrentBlockHeight()
-	poolFeePerc := matcher.cfg.PoolFee
+	poolFeePerc := q.pool.PoolFee
 	poolFee := splitticket.SessionPoolFee(numParts, ticketPrice,
-		int(blockHeight), poolFeePerc, matcher.cfg.ChainParams)
+		int(blockHeight), poolFeePerc, feeRate, matcher.cfg.ChainParams)
 	sessID := matcher.newSessionID()
 	parts := q.waitingParticipants
 	curHeight := matcher.cfg.NetworkProvider.CurrentBlockHeight()
@@ -355,7 +612,9 @@ func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 		matcher.cfg.ChainParams)
 	q.waitingParticipants = nil
 
-	splitPoolOutAddr := matcher.cfg.SignPoolSplitOutProvider.PoolFeeAddress()
+	splitPoolOutAddr := matcher.poolFeeAddrs[0].addr
+	poolFeeKeyIndex := matcher.poolFeeAddrs[0].keyIndex
+	matcher.poolFeeAddrs = matcher.poolFeeAddrs[1:]
 	splitPoolOutScript, err := txscript.PayToAddrScript(splitPoolOutAddr)
 	if err != nil {
 		matcher.log.Errorf("Error generating splitPoolOutScript: %v", err)
@@ -369,9 +628,12 @@ func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 		MainchainHeight: curHeight,
 		PoolFee:         poolFee,
 		TicketFee:       ticketTxFee,
+		FeeRate:         feeRate,
 		ChainParams:     matcher.cfg.ChainParams,
 		TicketPoolIn:    wire.NewTxIn(&wire.OutPoint{Index: 1}, int64(poolFee), nil), // FIXME: this should probably be removed from here and moved into the session
 		SplitTxPoolOut:  wire.NewTxOut(int64(poolFee), splitPoolOutScript),           // ditto above
+		PoolFeeKeyIndex: poolFeeKeyIndex,
+		Pool:            q.pool.Name,
 		ID:              sessID,
 		StartTime:       time.Now(),
 		TicketExpiry:    expiry,
@@ -381,9 +643,16 @@ func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 	}
 	matcher.sessions[sessID] = sess
 
+	matcher.sessionStarts = append(matcher.sessionStarts, sess.StartTime)
+	if len(matcher.sessionStarts) > sessionStartsHistory {
+		matcher.sessionStarts = matcher.sessionStarts[1:]
+	}
+
 	sess.log.Infof("Starting new session with Ticket Price=%s Fees=%s "+
-		"Participants=%d PoolFee=%s", ticketPrice, ticketTxFee,
-		numParts, poolFee)
+		"FeeRate=%s/KB Participants=%d PoolFee=%s Pool='%s'", ticketPrice,
+		ticketTxFee, feeRate, numParts, poolFee, sess.Pool)
+	sess.log.Debugf("Using pool fee address %s (key index %d)",
+		splitPoolOutAddr.EncodeAddress(), poolFeeKeyIndex)
 
 	sort.Sort(addParticipantRequestsByAmount(parts))
 	maxAmounts := make([]dcrutil.Amount, len(parts))
@@ -415,9 +684,12 @@ func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 			ID:           id,
 			VoteAddress:  r.voteAddress,
 			PoolAddress:  r.poolAddress,
+			VoteChoices:  r.voteChoices,
 			log:          util.NewPrefixLogger(id.String(), matcher.cfg.SessionLog),
 			SessionToken: mustGenSessionToken(),
 			CurrentStage: StageWaitingOutputs,
+
+			VoteChoicesSignature: r.voteChoicesSig,
 		}
 		sess.Participants[i] = sessPart
 		matcher.participants[id] = sessPart
@@ -429,10 +701,17 @@ func (matcher *Matcher) startNewSession(q *splitTicketQueue) {
 		sessPart.log.Infof("Participant contribution %s ticket address %s "+
 			"source %s", commitments[i], sessPart.VoteAddress.EncodeAddress(),
 			OriginalSrcFromCtx(r.ctx))
+
+		matcher.watchParticipant(sessPart, r.ctx)
 	}
 
+	if matcher.cfg.SessionStartedNtfn != nil {
+		go matcher.cfg.SessionStartedNtfn(sess)
+	}
+
+	maxDuration := q.maxSessionDuration(matcher.cfg.MaxSessionDuration)
 	go func(s *Session) {
-		sessTimer := time.NewTimer(matcher.cfg.MaxSessionDuration)
+		sessTimer := time.NewTimer(maxDuration)
 		<-sessTimer.C
 		if !s.Done && !s.Canceled {
 			sess.log.Warnf("Session lasted more than MaxSessionDuration")
@@ -470,6 +749,10 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 		return errors.Errorf("Wrong session token submitted on setParticipantsOutputs")
 	}
 
+	if part.CurrentStage > StageWaitingOutputs {
+		return matcher.resumeSetParticipantsOutputs(req, part)
+	}
+
 	if part.Session.CurrentStage != StageWaitingOutputs {
 		return errors.Errorf("participant tried to send outputs while session "+
 			"was in stage [%s]", part.Session.CurrentStage)
@@ -482,6 +765,14 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 
 	var err error
 
+	if len(part.VoteChoices) > 0 {
+		err = splitticket.CheckVoteChoicesSignature(part.VoteChoices,
+			part.VoteAddress, req.commitAddress, part.VoteChoicesSignature)
+		if err != nil {
+			return errors.Wrap(err, "invalid vote choices signature")
+		}
+	}
+
 	if len(req.splitTxOutPoints) > splitticket.MaximumSplitInputs {
 		return errors.Errorf("participant tried to use too many inputs "+
 			"into the split tx (%d)", len(req.splitTxOutPoints))
@@ -508,10 +799,15 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 		inputAmount += utxo.Value
 	}
 
-	// FIXME: take into account estimate for split tx fees
+	// the participant pays the fee for its own inputs and outputs into the
+	// split tx, plus its share of the fee for the common outputs.
+	splitFee := splitticket.SplitTxParticipantFee(len(part.Session.Participants),
+		len(req.splitTxOutPoints), req.splitTxChange != nil,
+		part.Session.FeeRate)
 
 	// this checks whether the **total** input amount is consistent
-	expectedInputAmount := part.CommitAmount + part.PoolFee + part.Fee + changeAmount
+	expectedInputAmount := part.CommitAmount + part.PoolFee + part.Fee +
+		splitFee + changeAmount
 	if inputAmount < expectedInputAmount {
 		return errors.Errorf("total input amount (%s) less than the expected "+
 			"(%s)", inputAmount, expectedInputAmount)
@@ -519,7 +815,7 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 
 	// this checks whether the **participation** input amount (whatever is input
 	// and not sent to change) is consistent
-	expectedInputAmount = part.CommitAmount + part.PoolFee + part.Fee
+	expectedInputAmount = part.CommitAmount + part.PoolFee + part.Fee + splitFee
 	if inputAmount-changeAmount < expectedInputAmount {
 		return errors.Errorf("participation input amount (%s) less than the "+
 			"expected (%s)", (inputAmount - changeAmount).String(),
@@ -552,7 +848,6 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 		sess.CurrentStage = StageWaitingTicketFunds
 
 		var ticket, splitTx *wire.MsgTx
-		var poolTicketInSig []byte
 		var splitUtxos splitticket.UtxoMap
 		var err error
 
@@ -577,38 +872,127 @@ func (matcher *Matcher) setParticipantsOutputs(req *setParticipantOutputsRequest
 			sess.log.Errorf("error obtaining utxo map for session: %v", err)
 		}
 
-		if err == nil {
-			toSign := ticket.Copy()
-			for _, p := range sess.Participants {
-				p.replaceTicketIOs(toSign)
-				poolTicketInSig, err = matcher.cfg.SignPoolSplitOutProvider.SignPoolSplitOutput(splitTx, toSign)
-				if err != nil {
-					p.log.Errorf("Error signing pool fee output: %v", err)
-					return err
-				}
-				p.poolFeeInputScriptSig = poolTicketInSig
-			}
-
-		} else {
+		if err != nil {
 			sess.log.Errorf("Error generating session transactions: %v", err)
+			matcher.notifyTransactionsCreated(sess, ticket, splitTx, err)
+			return nil
 		}
 
-		parts := sess.ParticipantTicketOutputs()
+		// The pool fee input of each participant's version of the ticket is
+		// signed by a (possibly remote) signer, so this is done on a separate
+		// goroutine and the participants are notified once it completes.
+		tickets := make([]*wire.MsgTx, len(sess.Participants))
+		for i, p := range sess.Participants {
+			tickets[i] = ticket.Copy()
+			p.replaceTicketIOs(tickets[i])
+		}
+		go matcher.signPoolFeeOutputs(sess, ticket, splitTx, tickets)
+	}
 
-		for _, p := range sess.Participants {
-			p.sendSetOutputsResponse(setParticipantOutputsResponse{
-				ticket:       ticket,
-				splitTx:      splitTx,
-				participants: parts,
-				index:        uint32(p.Index),
-				err:          err,
-			})
+	return nil
+}
+
+// fetchPoolFeeAddresses requests pool fee addresses for new sessions from the
+// configured provider and sends them to the Run goroutine, which accepts up to
+// poolFeeAddressPrefetch of them in advance. A new address is only requested
+// once the previous one is accepted, which limits the number of requests to
+// the (possibly remote) provider. This blocks, therefore it MUST be run from
+// a goroutine.
+func (matcher *Matcher) fetchPoolFeeAddresses(ctx context.Context) {
+	provider := matcher.cfg.SignPoolSplitOutProvider
+	if provider == nil {
+		return
+	}
+
+	for {
+		addr, keyIndex, err := provider.PoolFeeAddress()
+		if err != nil {
+			matcher.log.Errorf("Error obtaining pool fee address: %v", err)
+			select {
+			case <-time.After(poolFeeAddressRetryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
 		}
 
-		sess.log.Infof("Notified participants of created txs")
+		select {
+		case matcher.poolFeeAddresses <- poolFeeAddress{addr, keyIndex}:
+		case <-ctx.Done():
+			return
+		}
 	}
+}
 
-	return nil
+// signPoolFeeOutputs signs the pool fee input of the given tickets (one for
+// each participant of the session) and sends the result to the Run goroutine.
+// This blocks, therefore it MUST be run from a goroutine.
+func (matcher *Matcher) signPoolFeeOutputs(sess *Session, ticket,
+	splitTx *wire.MsgTx, tickets []*wire.MsgTx) {
+
+	res := poolFeeSignResult{
+		sess:    sess,
+		ticket:  ticket,
+		splitTx: splitTx,
+		sigs:    make([][]byte, len(tickets)),
+	}
+	signer := matcher.cfg.SignPoolSplitOutProvider
+	split := splitTx.Copy()
+	for i, toSign := range tickets {
+		res.sigs[i], res.err = signer.SignPoolSplitOutput(split, toSign,
+			sess.PoolFeeKeyIndex)
+		if res.err != nil {
+			res.err = errors.Wrapf(res.err, "error signing pool fee output "+
+				"of participant %d", i)
+			break
+		}
+	}
+
+	select {
+	case matcher.poolFeeSignResults <- res:
+	case <-matcher.serverCtx.Done():
+	}
+}
+
+// poolFeeOutputsSigned stores the pool fee input signatures of a session and
+// notifies its participants of the created transactions (or of the signing
+// error).
+func (matcher *Matcher) poolFeeOutputsSigned(res *poolFeeSignResult) {
+	sess := res.sess
+	if sess.Done || sess.Canceled {
+		return
+	}
+
+	if res.err != nil {
+		sess.log.Errorf("Error signing pool fee outputs: %v", res.err)
+		matcher.notifyTransactionsCreated(sess, res.ticket, res.splitTx, res.err)
+		return
+	}
+
+	for i, p := range sess.Participants {
+		p.poolFeeInputScriptSig = res.sigs[i]
+	}
+	matcher.notifyTransactionsCreated(sess, res.ticket, res.splitTx, nil)
+}
+
+// notifyTransactionsCreated sends the transactions of the session (or the
+// error generating them) to all of its participants.
+func (matcher *Matcher) notifyTransactionsCreated(sess *Session, ticket,
+	splitTx *wire.MsgTx, err error) {
+
+	parts := sess.ParticipantTicketOutputs()
+
+	for _, p := range sess.Participants {
+		p.sendSetOutputsResponse(setParticipantOutputsResponse{
+			ticket:       ticket,
+			splitTx:      splitTx,
+			participants: parts,
+			index:        uint32(p.Index),
+			err:          err,
+		})
+	}
+
+	sess.log.Infof("Notified participants of created txs")
 }
 
 func (matcher *Matcher) fundTicket(req *fundTicketRequest, part *SessionParticipant) error {
@@ -619,6 +1003,10 @@ func (matcher *Matcher) fundTicket(req *fundTicketRequest, part *SessionParticip
 		return errors.Errorf("Wrong session token submitted on fundTicket")
 	}
 
+	if part.CurrentStage > StageWaitingTicketFunds {
+		return matcher.resumeFundTicket(req, part)
+	}
+
 	if part.Session.CurrentStage != StageWaitingTicketFunds {
 		return errors.Errorf("participant tried to fund ticket while session "+
 			"was in stage [%s]", part.Session.CurrentStage)
@@ -670,7 +1058,7 @@ func (matcher *Matcher) fundTicket(req *fundTicketRequest, part *SessionParticip
 			p.replaceTicketIOs(ticket)
 			ticketHash = ticket.TxHash()
 			revocation, err = splitticket.CreateUnsignedRevocation(&ticketHash,
-				ticket, splitticket.RevocationFeeRate(sess.ChainParams))
+				ticket, splitticket.RevocationFeeRate(sess.FeeRate, sess.ChainParams))
 			if err != nil {
 				p.log.Errorf("Error creating participant's revocation: %v", err)
 				return err
@@ -716,6 +1104,10 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 		return errors.Errorf("Wrong session token submitted on fundSplitTx")
 	}
 
+	if part.CurrentStage > StageWaitingSplitFunds {
+		return matcher.resumeFundSplitTx(req, part)
+	}
+
 	if part.Session.CurrentStage != StageWaitingSplitFunds {
 		return errors.Errorf("participant tried to fund split while session "+
 			"was in stage [%s]", part.Session.CurrentStage)
@@ -763,6 +1155,7 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 		sess.VoterIndex = selIndex
 		sess.SelectedCoin = selCoin
 		voter := sess.Participants[sess.VoterIndex]
+		sess.VoteChoices = voter.VoteChoices
 
 		sess.log.Infof("All inputs for split tx received. Creating split tx.")
 		sess.log.Infof("Voter index selected: %d (%s coin %s)", sess.VoterIndex,
@@ -784,7 +1177,7 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 			sess.log.Errorf("error on final checkSplit: %v", err)
 		}
 
-		err = splitticket.CheckSignedSplit(splitTx, splitUtxoMap,
+		err = splitticket.CheckSignedSplit(splitTx, splitUtxoMap, sess.FeeRate,
 			matcher.cfg.ChainParams)
 		if err != nil {
 			sess.log.Errorf("error on final checkSignedSplit: %v", err)
@@ -797,29 +1190,31 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 			sess.log.Errorf("error on final checkTicket: %v", err)
 		}
 
-		err = splitticket.CheckSignedTicket(splitTx, ticket,
+		err = splitticket.CheckSignedTicket(splitTx, ticket, sess.FeeRate,
 			matcher.cfg.ChainParams)
 		if err != nil {
 			sess.log.Errorf("error on final checkSignedTicket: %v", err)
 		}
 
-		err = splitticket.CheckRevocation(ticket, revocation,
+		err = splitticket.CheckRevocation(ticket, revocation, sess.FeeRate,
 			matcher.cfg.ChainParams)
 		if err != nil {
 			sess.log.Errorf("error on final checkRevocation: %v", err)
 		}
 
+		var publishErr error
 		if matcher.cfg.PublishTransactions {
 			txs := []*wire.MsgTx{splitTx, ticket}
 			sess.log.Infof("Publishing transactions")
-			err = matcher.cfg.NetworkProvider.PublishTransactions(txs)
-			if err != nil {
-				sess.log.Errorf("Error publishing transactions: %s", err)
+			publishErr = matcher.cfg.NetworkProvider.PublishTransactions(txs)
+			if publishErr != nil {
+				sess.log.Errorf("Error publishing transactions: %s", publishErr)
 			}
 		} else {
 			sess.log.Infof("Skipping publishing transactions")
 		}
 
+		err = publishErr
 		if err == nil {
 			splitBytes, err = splitTx.Bytes()
 		}
@@ -839,11 +1234,20 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 			}
 		}
 
+		if publishErr != nil {
+			if matcher.cfg.PublishFailedNtfn != nil {
+				go matcher.cfg.PublishFailedNtfn(sess, ticket, splitTx,
+					publishErr)
+			}
+			matcher.removeSession(sess, nil)
+			return nil
+		}
+
 		sess.log.Infof("Session successfully finished as ticket %s",
 			ticket.TxHash())
 
 		if matcher.cfg.SuccessfulSesssionNtfn != nil {
-			go matcher.cfg.SuccessfulSesssionNtfn(ticket.TxHash())
+			go matcher.cfg.SuccessfulSesssionNtfn(sess, ticket, splitTx)
 		}
 		matcher.removeSession(sess, nil)
 	}
@@ -851,16 +1255,202 @@ func (matcher *Matcher) fundSplitTx(req *fundSplitTxRequest, part *SessionPartic
 	return nil
 }
 
-// func (matcher *Matcher) cancelSessionOnContextDone(ctx context.Context, sessPart *SessionParticipant) {
-// 	go func() {
-// 		<-ctx.Done()
-// 		sess := sessPart.Session
-// 		if (ctx.Err() != nil) && (!sess.Canceled) && (!sess.Done) {
-// 			matcher.log.Warningf("Cancelling session %s due to context error on participant %s: %v", sess.ID, sessPart.ID, ctx.Err())
-// 			matcher.cancelSessionChan <- cancelSessionChanReq{session: sessPart.Session, err: errors.Errorf("participant disconnected")}
-// 		}
-// 	}()
-// }
+// resumeSetParticipantsOutputs is called when a participant that already sent
+// its outputs repeats the call (usually after reconnecting). The new call
+// either replaces the outstanding one or immediately receives the response
+// the participant missed.
+func (matcher *Matcher) resumeSetParticipantsOutputs(req *setParticipantOutputsRequest,
+	part *SessionParticipant) error {
+
+	if !req.secretHash.Equals(part.SecretHash) ||
+		req.commitAddress.EncodeAddress() != part.CommitmentAddress.EncodeAddress() {
+		return errors.Errorf("participant tried to send different outputs "+
+			"while participant was in stage [%s]", part.CurrentStage)
+	}
+
+	part.log.Infof("Participant resumed sending outputs from %s",
+		OriginalSrcFromCtx(req.ctx))
+
+	if part.chanSetOutputsResponse != nil {
+		old := part.chanSetOutputsResponse
+		part.chanSetOutputsResponse = req.resp
+		old <- setParticipantOutputsResponse{err: ErrCallSuperseded}
+		return nil
+	}
+
+	if part.setOutputsResponse == nil {
+		return errors.Errorf("no outputs response to resume")
+	}
+	req.resp <- *part.setOutputsResponse
+	return nil
+}
+
+// resumeFundTicket is the equivalent of resumeSetParticipantsOutputs for the
+// fundTicket call.
+func (matcher *Matcher) resumeFundTicket(req *fundTicketRequest,
+	part *SessionParticipant) error {
+
+	if !bytes.Equal(req.revocationScriptSig, part.revocationScriptSig) {
+		return errors.Errorf("participant tried to send different ticket "+
+			"funds while participant was in stage [%s]", part.CurrentStage)
+	}
+
+	part.log.Infof("Participant resumed funding ticket from %s",
+		OriginalSrcFromCtx(req.ctx))
+
+	if part.chanFundTicketResponse != nil {
+		old := part.chanFundTicketResponse
+		part.chanFundTicketResponse = req.resp
+		old <- fundTicketResponse{err: ErrCallSuperseded}
+		return nil
+	}
+
+	if part.fundTicketResponse == nil {
+		return errors.Errorf("no fund ticket response to resume")
+	}
+	req.resp <- *part.fundTicketResponse
+	return nil
+}
+
+// resumeFundSplitTx is the equivalent of resumeSetParticipantsOutputs for the
+// fundSplitTx call.
+func (matcher *Matcher) resumeFundSplitTx(req *fundSplitTxRequest,
+	part *SessionParticipant) error {
+
+	if !bytes.Equal(req.secretNb, part.SecretNb) {
+		return errors.Errorf("participant tried to send different split "+
+			"funds while participant was in stage [%s]", part.CurrentStage)
+	}
+
+	part.log.Infof("Participant resumed funding split from %s",
+		OriginalSrcFromCtx(req.ctx))
+
+	if part.chanFundSplitTxResponse != nil {
+		old := part.chanFundSplitTxResponse
+		part.chanFundSplitTxResponse = req.resp
+		old <- fundSplitTxResponse{err: ErrCallSuperseded}
+		return nil
+	}
+
+	if part.fundSplitTxResponse == nil {
+		return errors.Errorf("no fund split response to resume")
+	}
+	req.resp <- *part.fundSplitTxResponse
+	return nil
+}
+
+// watchParticipant starts watching the context of the given (successful) call
+// of a participant and of its underlying connection, so that the session is
+// canceled if the participant disconnects and does not reconnect within the
+// grace period. Watching a new call supersedes watching the previous ones.
+func (matcher *Matcher) watchParticipant(part *SessionParticipant,
+	ctx context.Context) {
+
+	if _, has := matcher.participants[part.ID]; !has {
+		// session already finished
+		return
+	}
+
+	part.watchID++
+	part.connCtx = connectionCtxFromCtx(ctx)
+	if part.disconnected {
+		part.log.Infof("Participant reconnected from %s",
+			OriginalSrcFromCtx(ctx))
+		part.disconnected = false
+	}
+
+	matcher.watchContexts(part, ctx)
+}
+
+// watchContexts notifies the matcher once either the given call context or the
+// connection context of the participant is done.
+func (matcher *Matcher) watchContexts(part *SessionParticipant,
+	callCtx context.Context) {
+
+	var callDone, connDone <-chan struct{}
+	if callCtx != nil {
+		callDone = callCtx.Done()
+	}
+	if part.connCtx != nil {
+		connDone = part.connCtx.Done()
+	}
+	if callDone == nil && connDone == nil {
+		return
+	}
+
+	e := participantWatchEvent{participant: part, watchID: part.watchID}
+	serverDone := matcher.serverCtx.Done()
+	go func() {
+		select {
+		case <-callDone:
+		case <-connDone:
+		case <-serverDone:
+			return
+		}
+		select {
+		case matcher.participantDisconnected <- e:
+		case <-serverDone:
+		}
+	}()
+}
+
+// participantConnectionDone is called when the context of the last call or of
+// the connection of a participant is done. If the participant was still
+// waiting for the call to complete or if its connection was closed, the
+// participant is considered disconnected and its session is canceled unless it
+// reconnects within the grace period.
+func (matcher *Matcher) participantConnectionDone(e participantWatchEvent) {
+	part := e.participant
+	sess := part.Session
+	if e.watchID != part.watchID || part.disconnected || sess.Done ||
+		sess.Canceled {
+		return
+	}
+	if _, has := matcher.participants[part.ID]; !has {
+		return
+	}
+
+	connClosed := part.connCtx != nil && part.connCtx.Err() != nil
+	if !connClosed && !part.waitingResponse() {
+		// The call completed normally, so keep watching only the connection
+		// until the participant performs its next call.
+		matcher.watchContexts(part, nil)
+		return
+	}
+
+	part.disconnected = true
+	grace := matcher.cfg.DisconnectGracePeriod
+	part.log.Warnf("Participant disconnected during stage [%s]. Waiting %s "+
+		"for reconnection", sess.CurrentStage, grace)
+
+	serverDone := matcher.serverCtx.Done()
+	go func() {
+		select {
+		case <-time.After(grace):
+		case <-serverDone:
+			return
+		}
+		select {
+		case matcher.disconnectGraceExpired <- e:
+		case <-serverDone:
+		}
+	}()
+}
+
+// cancelSession cancels the given session with the given error, notifying its
+// participants.
+func (matcher *Matcher) cancelSession(sess *Session, err error) {
+	if sess.Done || sess.Canceled {
+		return
+	}
+
+	matcher.log.Infof("Cancelling session %s: %v", sess.ID, err)
+	sess.Canceled = true
+	matcher.removeSession(sess, err)
+	if matcher.cfg.SessionFailedNtfn != nil {
+		go matcher.cfg.SessionFailedNtfn(sess, err)
+	}
+}
 
 func (matcher *Matcher) removeSession(sess *Session, err error) {
 	delete(matcher.sessions, sess.ID)
@@ -875,13 +1465,25 @@ func (matcher *Matcher) removeSession(sess *Session, err error) {
 }
 
 // AddParticipant is the public API for a matcher to add a new participant to a
-// split ticket queue.
+// split ticket queue of the given pool.
+//
+// joinMAC is the proof of knowledge of the join key of the queue (generated
+// with JoinQueueMAC), needed when joining pre-declared queues that require
+// one.
 func (matcher *Matcher) AddParticipant(ctx context.Context, maxAmount uint64,
-	sessionName string, voteAddress, poolAddress dcrutil.Address) (*SessionParticipant, error) {
-	if maxAmount < matcher.cfg.MinAmount {
+	pool, sessionName string, voteAddress, poolAddress dcrutil.Address,
+	voteChoices splitticket.VoteChoices, voteChoicesSig []byte,
+	joinMAC []byte) (*SessionParticipant, error) {
+
+	qcfg := matcher.queueConfigs[queueKey{pool: pool, name: sessionName}]
+	minAmount := matcher.cfg.MinAmount
+	if qcfg != nil && qcfg.MinAmount > 0 {
+		minAmount = qcfg.MinAmount
+	}
+	if maxAmount < minAmount {
 		return nil, errors.Errorf("participation amount (%s) less than "+
 			"minimum required (%s)", dcrutil.Amount(maxAmount),
-			dcrutil.Amount(matcher.cfg.MinAmount))
+			dcrutil.Amount(minAmount))
 	}
 
 	curStakeDiffChangeDist := splitticket.StakeDiffChangeDistance(
@@ -904,13 +1506,37 @@ func (matcher *Matcher) AddParticipant(ctx context.Context, maxAmount uint64,
 		return nil, errors.New("empty pool address")
 	}
 
+	if err := voteChoices.Check(); err != nil {
+		return nil, errors.Wrap(err, "invalid vote choices")
+	}
+
+	if len(voteChoices) > 0 && len(voteChoicesSig) == 0 {
+		return nil, errors.New("empty vote choices signature")
+	}
+
+	if _, has := matcher.pools[pool]; !has {
+		return nil, errors.Errorf("unknown pool '%s'", pool)
+	}
+
+	if qcfg != nil {
+		var err error
+		maxAmount, err = qcfg.checkParticipant(maxAmount, voteAddress,
+			poolAddress, joinMAC, ClientIdentityFromCtx(ctx))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	req := addParticipantRequest{
-		ctx:         ctx,
-		maxAmount:   maxAmount,
-		sessionName: sessionName,
-		voteAddress: voteAddress,
-		poolAddress: poolAddress,
-		resp:        make(chan addParticipantResponse),
+		ctx:            ctx,
+		maxAmount:      maxAmount,
+		pool:           pool,
+		sessionName:    sessionName,
+		voteAddress:    voteAddress,
+		poolAddress:    poolAddress,
+		voteChoices:    voteChoices,
+		voteChoicesSig: voteChoicesSig,
+		resp:           make(chan addParticipantResponse),
 	}
 	matcher.addParticipantRequests <- req
 
@@ -935,22 +1561,45 @@ func (matcher *Matcher) WatchWaitingList(ctx context.Context,
 				return
 			}
 
-			queues := make([]WaitingQueue, len(matcher.queues))
-			i := 0
-			for name, q := range matcher.queues {
-				queues[i] = WaitingQueue{
-					Name:    name,
-					Amounts: q.waitingAmounts(),
-				}
-				i++
-			}
-			watcher <- queues
+			watcher <- matcher.waitingQueues()
 		}()
 	}
 
 	matcher.watchWaitingListRequests <- req
 }
 
+// Status returns the current status of the matcher.
+func (matcher *Matcher) Status(ctx context.Context) (*Status, error) {
+	req := statusRequest{resp: make(chan Status, 1)}
+	select {
+	case matcher.statusRequests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	st := <-req.resp
+	return &st, nil
+}
+
+// StakeDiffChangeStopWindow returns the number of blocks around a change of
+// stake difficulty during which the matcher does not start new sessions. This
+// does not need to go through the Run goroutine, given the config is not
+// modified after the matcher is created.
+func (matcher *Matcher) StakeDiffChangeStopWindow() int32 {
+	return matcher.cfg.StakeDiffChangeStopWindow
+}
+
+// MaxSessionDuration returns the maximum duration of the sessions started from
+// the given queue. As with StakeDiffChangeStopWindow, this does not need to go
+// through the Run goroutine.
+func (matcher *Matcher) MaxSessionDuration(pool, sessionName string) time.Duration {
+	qcfg := matcher.queueConfigs[queueKey{pool: pool, name: sessionName}]
+	if qcfg != nil && qcfg.MaxSessionDuration > 0 {
+		return qcfg.MaxSessionDuration
+	}
+	return matcher.cfg.MaxSessionDuration
+}
+
 // SetParticipantsOutputs validates and sets the outputs of the given participant
 // for the provided outputs, waits for all participants to provide their own
 // outputs, then generates the ticket tx and returns the index of the input
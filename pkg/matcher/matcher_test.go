@@ -0,0 +1,884 @@
+package matcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+var _testNetwork = &chaincfg.TestNet3Params
+
+// mockNetwork fulfills the NetworkProvider interface for tests, returning
+// valid utxos for any outpoint.
+type mockNetwork struct{}
+
+func (n mockNetwork) CurrentTicketPrice() uint64              { return 100e8 }
+func (n mockNetwork) CurrentBlockHeight() uint32              { return 1000 }
+func (n mockNetwork) CurrentBlockHash() chainhash.Hash        { return chainhash.Hash{} }
+func (n mockNetwork) CurrentFeeRate() dcrutil.Amount          { return splitticket.TxFeeRate }
+func (n mockNetwork) ConnectedToDecredNetwork() bool          { return true }
+func (n mockNetwork) PublishTransactions([]*wire.MsgTx) error { return nil }
+
+func (n mockNetwork) GetUtxos(outpoints []*wire.OutPoint) (splitticket.UtxoMap, error) {
+	addr := testAddress(0xff)
+	script, _ := txscript.PayToAddrScript(addr)
+	res := make(splitticket.UtxoMap, len(outpoints))
+	for _, outp := range outpoints {
+		res[*outp] = splitticket.UtxoEntry{
+			PkScript:      script,
+			Value:         60e8,
+			Confirmations: 10,
+		}
+	}
+	return res, nil
+}
+
+type mockPoolSigner struct{}
+
+func (s mockPoolSigner) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	return testAddress(0xfe), 0, nil
+}
+
+func (s mockPoolSigner) SignPoolSplitOutput(split, ticket *wire.MsgTx, keyIndex uint32) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+// failingPoolSigner is a pool signer that refuses to sign pool fee outputs.
+type failingPoolSigner struct {
+	mockPoolSigner
+}
+
+var errRefusedSigning = errors.New("refused to sign")
+
+func (s failingPoolSigner) SignPoolSplitOutput(split, ticket *wire.MsgTx, keyIndex uint32) ([]byte, error) {
+	return nil, errRefusedSigning
+}
+
+// slowPoolSigner is a pool signer that only provides pool fee addresses once
+// its release channel is closed.
+type slowPoolSigner struct {
+	mockPoolSigner
+	release chan struct{}
+	calls   *int32
+}
+
+func (s slowPoolSigner) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	atomic.AddInt32(s.calls, 1)
+	<-s.release
+	return s.mockPoolSigner.PoolFeeAddress()
+}
+
+func testAddress(b byte) dcrutil.Address {
+	var hash [20]byte
+	hash[0] = b
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:], _testNetwork, 0)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// testParticipant simulates a participant connected to the matcher.
+type testParticipant struct {
+	part       *SessionParticipant
+	index      byte
+	connCtx    context.Context
+	disconnect func()
+}
+
+func (tp *testParticipant) callCtx() (context.Context, func()) {
+	return context.WithCancel(WithConnectionContext(context.Background(),
+		tp.connCtx))
+}
+
+type setOutputsResult struct {
+	err error
+}
+
+// setOutputs performs a SetParticipantsOutputs call on a goroutine.
+func (tp *testParticipant) setOutputs(ctx context.Context, m *Matcher) chan setOutputsResult {
+	c := make(chan setOutputsResult, 1)
+	outp := &wire.OutPoint{Index: uint32(tp.index)}
+	var secretHash splitticket.SecretNumberHash
+	secretHash[0] = tp.index
+	go func() {
+		_, _, _, _, err := m.SetParticipantsOutputs(ctx, tp.part.ID,
+			testAddress(tp.index), testAddress(tp.index+0x10), nil,
+			[]*wire.OutPoint{outp}, secretHash, tp.part.SessionToken)
+		c <- setOutputsResult{err: err}
+	}()
+	return c
+}
+
+func startTestSession(t *testing.T, m *Matcher, nbParts int) []*testParticipant {
+	res := make([]*testParticipant, nbParts)
+	errs := make(chan error, nbParts)
+	for i := range res {
+		connCtx, disconnect := context.WithCancel(context.Background())
+		tp := &testParticipant{
+			index:      byte(i + 1),
+			connCtx:    connCtx,
+			disconnect: disconnect,
+		}
+		res[i] = tp
+		go func() {
+			ctx, cancel := tp.callCtx()
+			defer cancel()
+			var err error
+			tp.part, err = m.AddParticipant(ctx, 60e8, DefaultPoolName, "test",
+				testAddress(tp.index+0x20), testAddress(tp.index+0x30), nil,
+				nil, nil)
+			errs <- err
+		}()
+	}
+
+	for range res {
+		if err := <-errs; err != nil {
+			t.Fatalf("error adding participant: %v", err)
+		}
+	}
+	return res
+}
+
+// allowedVoteAddresses are the vote addresses of the participants of the
+// "private" queue of the test matcher.
+var allowedVoteAddresses = []string{
+	testAddress(0x21).EncodeAddress(),
+	testAddress(0x22).EncodeAddress(),
+	testAddress(0x23).EncodeAddress(),
+	testAddress(0x24).EncodeAddress(),
+}
+
+func newTestMatcher(gracePeriod time.Duration) *Matcher {
+	m, err := NewMatcher(&Config{
+		MinAmount:                1e8,
+		NetworkProvider:          mockNetwork{},
+		SignPoolSplitOutProvider: mockPoolSigner{},
+		VoteAddrValidator:        InsecurePoolAddressesValidator{},
+		PoolAddrValidator:        InsecurePoolAddressesValidator{},
+		Log:                      slog.Disabled,
+		SessionLog:               slog.Disabled,
+		ChainParams:              _testNetwork,
+		PoolFee:                  5,
+		MaxSessionDuration:       time.Minute,
+		DisconnectGracePeriod:    gracePeriod,
+		Pools: []*Pool{{
+			Name:              "other",
+			PoolFee:           7.5,
+			VoteAddrValidator: InsecurePoolAddressesValidator{},
+			PoolAddrValidator: InsecurePoolAddressesValidator{},
+		}},
+		Queues: []*QueueConfig{{
+			Name:                 "private",
+			MinAmount:            0.5e8,
+			MaxAmount:            40e8,
+			MaxParticipants:      3,
+			MaxSessionDuration:   time.Second,
+			AllowedVoteAddresses: allowedVoteAddresses,
+			JoinKey:              []byte("test key"),
+		}, {
+			Name:      "capped",
+			MaxAmount: 40e8,
+		}, {
+			Name:            "small",
+			MaxParticipants: 3,
+		}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func waitResult(t *testing.T, c chan setOutputsResult) error {
+	select {
+	case res := <-c:
+		return res.err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for call result")
+	}
+	return nil
+}
+
+func TestCancelSessionOnDisconnect(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(10 * time.Millisecond)
+	go m.Run(ctx)
+
+	parts := startTestSession(t, m, 2)
+
+	// The first participant sends its outputs then disconnects while waiting
+	// for the second one. The session should be canceled without waiting for
+	// the session to expire.
+	callCtx, cancelCall := parts[0].callCtx()
+	defer cancelCall()
+	res := parts[0].setOutputs(callCtx, m)
+	time.Sleep(50 * time.Millisecond)
+	parts[0].disconnect()
+
+	if err := waitResult(t, res); err != ErrParticipantDisconnected {
+		t.Fatalf("unexpected error on disconnected participant: %v", err)
+	}
+
+	callCtx, cancelCall = parts[1].callCtx()
+	defer cancelCall()
+	if err := waitResult(t, parts[1].setOutputs(callCtx, m)); err == nil {
+		t.Fatalf("participant of canceled session sent outputs")
+	}
+}
+
+func TestCancelSessionOnDisconnectBetweenCalls(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(10 * time.Millisecond)
+	go m.Run(ctx)
+
+	parts := startTestSession(t, m, 2)
+
+	// The second participant waits for the first one, which disconnects
+	// before sending its outputs.
+	callCtx, cancelCall := parts[1].callCtx()
+	defer cancelCall()
+	res := parts[1].setOutputs(callCtx, m)
+	parts[0].disconnect()
+
+	if err := waitResult(t, res); err != ErrParticipantDisconnected {
+		t.Fatalf("unexpected error on waiting participant: %v", err)
+	}
+}
+
+func TestResumeAfterDisconnect(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	parts := startTestSession(t, m, 2)
+
+	// The first participant's call is dropped while waiting for the second
+	// participant.
+	callCtx, cancelCall := parts[0].callCtx()
+	oldRes := parts[0].setOutputs(callCtx, m)
+	time.Sleep(50 * time.Millisecond)
+	cancelCall()
+	time.Sleep(50 * time.Millisecond)
+
+	// It then reconnects and repeats the call, superseding the dropped one.
+	connCtx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	parts[0].connCtx = connCtx
+	callCtx, cancelCall = parts[0].callCtx()
+	defer cancelCall()
+	newRes := parts[0].setOutputs(callCtx, m)
+	if err := waitResult(t, oldRes); err != ErrCallSuperseded {
+		t.Fatalf("unexpected error on superseded call: %v", err)
+	}
+
+	// Sending different outputs after the participant has already sent them
+	// is not a valid resumption.
+	wrongCtx, cancelWrong := parts[0].callCtx()
+	defer cancelWrong()
+	wrong := &testParticipant{part: parts[0].part, index: 0x40}
+	if err := waitResult(t, wrong.setOutputs(wrongCtx, m)); err == nil {
+		t.Fatalf("participant resumed with different outputs")
+	}
+
+	callCtx2, cancelCall2 := parts[1].callCtx()
+	defer cancelCall2()
+	err1 := waitResult(t, parts[1].setOutputs(callCtx2, m))
+	err0 := waitResult(t, newRes)
+	if err0 != err1 {
+		t.Fatalf("participants received different results (%v / %v)",
+			err0, err1)
+	}
+	if err0 == ErrParticipantDisconnected || err0 == ErrSessionExpired {
+		t.Fatalf("session canceled: %v", err0)
+	}
+}
+
+func TestPoolFeeSigningError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	m.cfg.SignPoolSplitOutProvider = failingPoolSigner{}
+	go m.Run(ctx)
+
+	parts := startTestSession(t, m, 2)
+
+	// Both participants are notified when the pool fee output can't be
+	// signed, not only the last one to send its outputs.
+	results := make([]chan setOutputsResult, len(parts))
+	for i, tp := range parts {
+		callCtx, cancelCall := tp.callCtx()
+		defer cancelCall()
+		results[i] = tp.setOutputs(callCtx, m)
+	}
+	for i, res := range results {
+		err := waitResult(t, res)
+		if errors.Cause(err) != errRefusedSigning {
+			t.Fatalf("unexpected error on participant %d: %v", i, err)
+		}
+	}
+}
+
+func TestSessionWaitsPoolFeeAddress(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	signer := slowPoolSigner{release: make(chan struct{}), calls: new(int32)}
+	m.cfg.SignPoolSplitOutProvider = signer
+	go m.Run(ctx)
+
+	c := make(chan error, 2)
+	for i := byte(1); i <= 2; i++ {
+		go func(i byte) {
+			_, err := m.AddParticipant(ctx, 60e8, DefaultPoolName, "addr",
+				testAddress(i+0x20), testAddress(i+0x30), nil, nil, nil)
+			c <- err
+		}(i)
+	}
+
+	// The session only starts once a pool fee address is available, without
+	// blocking the matcher meanwhile.
+	select {
+	case err := <-c:
+		t.Fatalf("session started without a pool fee address (err: %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := m.Status(ctx); err != nil {
+		t.Fatalf("unexpected error fetching status: %v", err)
+	}
+
+	close(signer.release)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-c:
+			if err != nil {
+				t.Fatalf("unexpected error adding participant: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for session to start")
+		}
+	}
+
+	// Only a few addresses are requested in advance.
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(signer.calls); n > poolFeeAddressPrefetch+2 {
+		t.Fatalf("unexpected number of pool fee address requests %d", n)
+	}
+}
+
+func TestPoolQueues(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	addParticipant := func(pool string, index byte) chan *SessionParticipant {
+		c := make(chan *SessionParticipant, 1)
+		go func() {
+			part, err := m.AddParticipant(ctx, 60e8, pool, "test",
+				testAddress(index+0x20), testAddress(index+0x30), nil, nil, nil)
+			if err != nil {
+				t.Errorf("error adding participant: %v", err)
+			}
+			c <- part
+		}()
+		return c
+	}
+
+	_, err := m.AddParticipant(ctx, 60e8, "unknown", "test", testAddress(0x20),
+		testAddress(0x30), nil, nil, nil)
+	if err == nil {
+		t.Fatalf("participant added to unknown pool")
+	}
+
+	// Participants of different pools are not matched, even if they use the
+	// same session name.
+	defaultPart := addParticipant(DefaultPoolName, 1)
+	time.Sleep(50 * time.Millisecond)
+	otherParts := []chan *SessionParticipant{addParticipant("other", 2)}
+	time.Sleep(50 * time.Millisecond)
+	otherParts = append(otherParts, addParticipant("other", 3))
+
+	for _, c := range otherParts {
+		var part *SessionParticipant
+		select {
+		case part = <-c:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for session")
+		}
+		if part == nil {
+			t.FailNow()
+		}
+
+		sess := part.Session
+		if sess.Pool != "other" || len(sess.Participants) != 2 {
+			t.Fatalf("unexpected session of pool '%s' with %d participants",
+				sess.Pool, len(sess.Participants))
+		}
+		expectedPoolFee := splitticket.SessionPoolFee(2, sess.TicketPrice,
+			int(sess.MainchainHeight), 7.5, sess.FeeRate, _testNetwork)
+		if sess.PoolFee != expectedPoolFee {
+			t.Fatalf("unexpected pool fee %s (expected %s)", sess.PoolFee,
+				expectedPoolFee)
+		}
+	}
+
+	select {
+	case <-defaultPart:
+		t.Fatalf("participant of the default pool joined a session")
+	default:
+	}
+}
+
+func TestQueueConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	join := func(amount uint64, index byte, key string) (*SessionParticipant, error) {
+		voteAddr, poolAddr := testAddress(index+0x20), testAddress(index+0x30)
+		mac := JoinQueueMAC([]byte(key), DefaultPoolName, "private", amount,
+			voteAddr.EncodeAddress(), poolAddr.EncodeAddress())
+		callCtx, cancelCall := context.WithTimeout(ctx, time.Second)
+		defer cancelCall()
+		return m.AddParticipant(callCtx, amount, DefaultPoolName, "private",
+			voteAddr, poolAddr, nil, nil, mac)
+	}
+
+	invalid := []struct {
+		name   string
+		amount uint64
+		index  byte
+		key    string
+	}{
+		{"wrong key", 60e8, 1, "wrong key"},
+		{"empty key", 60e8, 1, ""},
+		{"vote address not allowed", 60e8, 5, "test key"},
+		{"amount below queue minimum", 0.1e8, 1, "test key"},
+	}
+	for _, tc := range invalid {
+		if _, err := join(tc.amount, tc.index, tc.key); err == nil {
+			t.Fatalf("%s: participant joined queue", tc.name)
+		}
+	}
+
+	// Participants are limited to the maximum amount of the queue, so the
+	// session only starts with the third participant. The fourth one is
+	// rejected while the queue is full.
+	type result struct {
+		part *SessionParticipant
+		err  error
+	}
+	results := make(chan result, 3)
+	for i := byte(1); i <= 3; i++ {
+		go func(i byte) {
+			part, err := join(60e8, i, "test key")
+			results <- result{part, err}
+		}(i)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		res := <-results
+		if res.err != nil {
+			t.Fatalf("unexpected error joining queue: %v", res.err)
+		}
+		sess := res.part.Session
+		if len(sess.Participants) != 3 {
+			t.Fatalf("unexpected number of participants %d",
+				len(sess.Participants))
+		}
+		if res.part.CommitAmount > 40e8 {
+			t.Fatalf("participant contributing more than the queue maximum")
+		}
+	}
+}
+
+func TestQueueConfigFull(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	// Small participants never fill a ticket, so the queue gets full.
+	errs := make(chan error, 4)
+	for i := byte(1); i <= 4; i++ {
+		go func(i byte) {
+			voteAddr, poolAddr := testAddress(i+0x20), testAddress(i+0x30)
+			mac := JoinQueueMAC([]byte("test key"), DefaultPoolName,
+				"private", 1e8, voteAddr.EncodeAddress(),
+				poolAddr.EncodeAddress())
+			_, err := m.AddParticipant(ctx, 1e8, DefaultPoolName, "private",
+				voteAddr, poolAddr, nil, nil, mac)
+			errs <- err
+		}(i)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatalf("participant joined full queue")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for full queue error")
+	}
+}
+
+func TestQueueConfigFullEviction(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	type result struct {
+		amount uint64
+		part   *SessionParticipant
+		err    error
+	}
+	results := make(chan result, 5)
+	join := func(amount uint64, index byte) {
+		go func() {
+			part, err := m.AddParticipant(ctx, amount, DefaultPoolName,
+				"small", testAddress(index+0x20), testAddress(index+0x30),
+				nil, nil, nil)
+			results <- result{amount, part, err}
+		}()
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Fill the queue with participants that can't fund a ticket. Larger
+	// participants replace the smallest ones until a session starts.
+	join(1e8, 1)
+	join(2e8, 2)
+	join(3e8, 3)
+	join(60e8, 4)
+	join(60e8, 5)
+
+	var evicted []uint64
+	var sessParts int
+	for i := 0; i < 5; i++ {
+		var res result
+		select {
+		case res = <-results:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for queue to start a session")
+		}
+		switch {
+		case res.err == ErrEvictedFromQueue:
+			evicted = append(evicted, res.amount)
+		case res.err != nil:
+			t.Fatalf("unexpected error joining queue: %v", res.err)
+		default:
+			sessParts = len(res.part.Session.Participants)
+		}
+	}
+
+	if len(evicted) != 2 || evicted[0] != 1e8 || evicted[1] != 2e8 {
+		t.Fatalf("unexpected evicted participants %v", evicted)
+	}
+	if sessParts != 3 {
+		t.Fatalf("unexpected number of session participants %d", sessParts)
+	}
+}
+
+func TestBuildQueueConfigsMaxParticipants(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{Queues: []*QueueConfig{
+		{Name: "unlimited"},
+		{Name: "large", MaxParticipants: 100},
+		{Name: "small", MaxParticipants: 3},
+	}}
+	pools := map[string]*Pool{DefaultPoolName: {}}
+	queues, err := buildQueueConfigs(cfg, pools)
+	if err != nil {
+		t.Fatalf("unexpected error building queues: %v", err)
+	}
+
+	expected := map[string]int{
+		"unlimited": MaxQueueParticipants,
+		"large":     MaxQueueParticipants,
+		"small":     3,
+	}
+	for name, max := range expected {
+		q := queues[queueKey{pool: DefaultPoolName, name: name}]
+		if q.MaxParticipants != max {
+			t.Fatalf("unexpected maximum participants %d of queue '%s'",
+				q.MaxParticipants, name)
+		}
+	}
+	if cfg.Queues[1].MaxParticipants != 100 {
+		t.Fatalf("config of queue modified")
+	}
+}
+
+func TestQueueConfigAllowedClients(t *testing.T) {
+	t.Parallel()
+
+	q := &QueueConfig{Name: "members", AllowedClients: []string{"alice"}}
+	voteAddr, poolAddr := testAddress(0x21), testAddress(0x31)
+
+	for _, id := range []string{"", "bob"} {
+		_, err := q.checkParticipant(1e8, voteAddr, poolAddr, nil, id)
+		if err != ErrClientNotAllowed {
+			t.Fatalf("client '%s' allowed in queue (err = %v)", id, err)
+		}
+	}
+
+	if _, err := q.checkParticipant(1e8, voteAddr, poolAddr, nil, "alice"); err != nil {
+		t.Fatalf("unexpected error for allowed client: %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	st, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching status: %v", err)
+	}
+	if st.TicketPrice != 100e8 || st.BlockHeight != 1000 {
+		t.Fatalf("unexpected network status %s/%d", st.TicketPrice,
+			st.BlockHeight)
+	}
+	winSize := int32(_testNetwork.StakeDiffWindowSize)
+	if st.BlocksToStakeDiffChange != winSize-1000%winSize {
+		t.Fatalf("unexpected blocks to stake diff change %d",
+			st.BlocksToStakeDiffChange)
+	}
+	if st.ActiveSessions != 0 || !st.LastSessionStart.IsZero() {
+		t.Fatalf("unexpected sessions in new matcher")
+	}
+	if _, ok := st.NextSessionEstimate(time.Now()); ok {
+		t.Fatalf("estimate available without sessions")
+	}
+
+	startTestSession(t, m, 2)
+	time.Sleep(10 * time.Millisecond)
+	startTestSession(t, m, 2)
+	st, err = m.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching status: %v", err)
+	}
+	if st.ActiveSessions != 2 {
+		t.Fatalf("unexpected number of active sessions %d", st.ActiveSessions)
+	}
+	if st.LastSessionStart.IsZero() || st.SessionInterval <= 0 {
+		t.Fatalf("session history not tracked")
+	}
+	if _, ok := st.NextSessionEstimate(time.Now()); !ok {
+		t.Fatalf("estimate not available after sessions")
+	}
+}
+
+func TestStatusNextSessionEstimate(t *testing.T) {
+	t.Parallel()
+
+	last := time.Now()
+	st := &Status{LastSessionStart: last, SessionInterval: time.Minute}
+	tests := []struct {
+		now  time.Time
+		want time.Duration
+	}{
+		{last, time.Minute},
+		{last.Add(20 * time.Second), 40 * time.Second},
+
+		// Overdue sessions are expected at the end of the current interval.
+		{last.Add(80 * time.Second), 40 * time.Second},
+	}
+
+	for _, tc := range tests {
+		got, ok := st.NextSessionEstimate(tc.now)
+		if !ok || got != tc.want {
+			t.Errorf("unexpected estimate %s (want %s)", got, tc.want)
+		}
+	}
+}
+
+func TestWaitEstimate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	arrivals := []queueArrival{
+		{time: now.Add(-30 * time.Minute), amount: 10e8},
+		{time: now.Add(-20 * time.Minute), amount: 30e8},
+		{time: now.Add(-10 * time.Minute), amount: 20e8},
+	}
+	feeRate := splitticket.TxFeeRate
+
+	// Participants arrive every 10 minutes with 20 DCR on average, so 4 of
+	// them are needed for a 70 DCR ticket when 10 DCR are already waiting.
+	est := estimateWait([]dcrutil.Amount{10e8}, arrivals, now, 70e8, feeRate)
+	if !est.Known() || est.AverageAmount != 20e8 ||
+		est.ArrivalInterval != 15*time.Minute || est.ExpectedArrivals != 4 {
+		t.Fatalf("unexpected estimate %v", est)
+	}
+	if est.ExpectedWait() != time.Hour {
+		t.Fatalf("unexpected expected wait %s", est.ExpectedWait())
+	}
+	p1, p2 := est.MatchProbability(30*time.Minute), est.MatchProbability(3*time.Hour)
+	if p1 <= 0 || p1 >= p2 || p2 >= 1 {
+		t.Fatalf("unexpected match probabilities %f %f", p1, p2)
+	}
+
+	// A single arrival of the missing amount in the interval happens with
+	// probability 1-e^-1.
+	est = WaitEstimate{MissingAmount: 1, ArrivalInterval: time.Minute,
+		ExpectedArrivals: 1}
+	if p := est.MatchProbability(time.Minute); math.Abs(p-(1-1/math.E)) > 1e-9 {
+		t.Fatalf("unexpected match probability %f", p)
+	}
+
+	// Sessions that would start right away need no arrivals.
+	est = estimateWait([]dcrutil.Amount{60e8, 60e8}, nil, now, 100e8, feeRate)
+	if !est.Known() || est.MissingAmount != 0 || est.MatchProbability(0) != 1 {
+		t.Fatalf("unexpected estimate for full queue %v", est)
+	}
+
+	// Without history the wait is unknown.
+	est = estimateWait([]dcrutil.Amount{10e8}, arrivals[:1], now, 100e8, feeRate)
+	if est.Known() || est.MatchProbability(time.Hour) != 0 {
+		t.Fatalf("unexpected estimate without history %v", est)
+	}
+}
+
+func TestEstimateWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := newTestMatcher(time.Minute)
+	go m.Run(ctx)
+
+	if _, err := m.EstimateWait(ctx, "unknown", "est", 0); err == nil {
+		t.Fatalf("estimate returned for unknown pool")
+	}
+	est, err := m.EstimateWait(ctx, DefaultPoolName, "est", 10e8)
+	if err != nil {
+		t.Fatalf("unexpected error estimating wait: %v", err)
+	}
+	if est.Known() || est.MissingAmount <= 90e8 {
+		t.Fatalf("unexpected estimate for empty queue %v", est)
+	}
+
+	for i := byte(1); i <= 2; i++ {
+		go m.AddParticipant(ctx, 10e8, DefaultPoolName, "est",
+			testAddress(i+0x20), testAddress(i+0x30), nil, nil, nil)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	est, err = m.EstimateWait(ctx, DefaultPoolName, "est", 0)
+	if err != nil {
+		t.Fatalf("unexpected error estimating wait: %v", err)
+	}
+	if !est.Known() || est.AverageAmount != 10e8 ||
+		est.ExpectedArrivals != 9 {
+		t.Fatalf("unexpected estimate %v", est)
+	}
+
+	// The amount of new participants of pre-declared queues is limited to
+	// the maximum of the queue.
+	est, err = m.EstimateWait(ctx, DefaultPoolName, "capped", 100e8)
+	if err != nil {
+		t.Fatalf("unexpected error estimating wait: %v", err)
+	}
+	if est.MissingAmount < 60e8 {
+		t.Fatalf("maximum amount of queue not applied %v", est)
+	}
+
+	// Private queues are not estimated.
+	_, err = m.EstimateWait(ctx, DefaultPoolName, "private", 10e8)
+	if err != ErrPrivateQueue {
+		t.Fatalf("unexpected error estimating private queue: %v", err)
+	}
+}
+
+func TestRecordArrival(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMatcher(time.Minute)
+	key := queueKey{name: "arrivals"}
+	now := time.Now()
+	voteAddr := testAddress(0x21)
+	m.recordArrival(key, voteAddr.EncodeAddress(), 1e8, now)
+
+	// Participants registering again while waiting are not new arrivals.
+	m.recordArrival(key, voteAddr.EncodeAddress(), 1e8, now.Add(time.Second))
+	if n := len(m.queueStats[key].arrivals); n != 1 {
+		t.Fatalf("re-registration recorded as arrival (%d arrivals)", n)
+	}
+
+	// Once matched, joining the queue again is a new arrival.
+	m.recordMatched(key, []*addParticipantRequest{{voteAddress: voteAddr}})
+	m.recordArrival(key, voteAddr.EncodeAddress(), 1e8, now.Add(time.Minute))
+	if n := len(m.queueStats[key].arrivals); n != 2 {
+		t.Fatalf("unexpected number of arrivals %d", n)
+	}
+}
+
+func TestPruneQueueStats(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMatcher(time.Minute)
+	now := time.Now()
+	m.recordArrival(queueKey{name: "old"}, "", 1e8, now.Add(-2*queueStatsMaxAge))
+	for i := 1; i < maxQueueStats; i++ {
+		m.recordArrival(queueKey{name: fmt.Sprint(i)}, "", 1e8,
+			now.Add(time.Duration(i)*time.Second))
+	}
+
+	// Queues without recent arrivals are removed first.
+	m.recordArrival(queueKey{name: "new"}, "", 1e8, now.Add(time.Hour))
+	if _, has := m.queueStats[queueKey{name: "old"}]; has {
+		t.Fatalf("stale queue not pruned")
+	}
+
+	// Then the queue with the oldest arrival.
+	m.recordArrival(queueKey{name: "newer"}, "", 1e8, now.Add(time.Hour))
+	if _, has := m.queueStats[queueKey{name: "1"}]; has {
+		t.Fatalf("queue with oldest arrival not pruned")
+	}
+	if len(m.queueStats) != maxQueueStats {
+		t.Fatalf("unexpected number of queue stats %d", len(m.queueStats))
+	}
+}
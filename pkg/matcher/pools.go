@@ -0,0 +1,65 @@
+package matcher
+
+import (
+	"github.com/pkg/errors"
+)
+
+// DefaultPoolName is the name of the pool profile built from the pool fee and
+// validators of the matcher config. It is used by participants that do not
+// select a pool.
+const DefaultPoolName = ""
+
+// Pool is the profile of a voting pool served by the matcher. Participants
+// select the pool when joining a queue and sessions only match participants of
+// the same pool.
+type Pool struct {
+	// Name is the name participants use to select the pool.
+	Name string
+
+	// PoolFee is the pool fee of tickets of the pool, as a percentage (eg:
+	// 5.0 = 5%).
+	PoolFee float64
+
+	// VoteAddrValidator validates the vote addresses of participants of the
+	// pool.
+	VoteAddrValidator VoteAddressValidationProvider
+
+	// PoolAddrValidator validates the pool fee addresses of participants of
+	// the pool.
+	PoolAddrValidator PoolAddressValidationProvider
+}
+
+// queueKey identifies a waiting queue of the matcher.
+type queueKey struct {
+	pool string
+	name string
+}
+
+// buildPools returns the registry of pool profiles of the given config: the
+// default pool (built from the PoolFee and validator fields) plus the
+// additional pools.
+func buildPools(cfg *Config) (map[string]*Pool, error) {
+	pools := make(map[string]*Pool, len(cfg.Pools)+1)
+	pools[DefaultPoolName] = &Pool{
+		Name:              DefaultPoolName,
+		PoolFee:           cfg.PoolFee,
+		VoteAddrValidator: cfg.VoteAddrValidator,
+		PoolAddrValidator: cfg.PoolAddrValidator,
+	}
+
+	for _, p := range cfg.Pools {
+		if p.Name == DefaultPoolName {
+			return nil, errors.New("additional pools must have a name")
+		}
+		if _, has := pools[p.Name]; has {
+			return nil, errors.Errorf("duplicated pool '%s'", p.Name)
+		}
+		if p.VoteAddrValidator == nil || p.PoolAddrValidator == nil {
+			return nil, errors.Errorf("pool '%s' does not have its address "+
+				"validators", p.Name)
+		}
+		pools[p.Name] = p
+	}
+
+	return pools, nil
+}
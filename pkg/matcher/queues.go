@@ -1,23 +1,191 @@
 package matcher
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/stake"
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
 )
 
-// splitTicketQueue is the queue of participants waiting for a split ticket
-// session. May be named or not.
+// QueueConfig is the configuration of a named queue pre-declared by the
+// operator of the matcher. Zero values mean the matcher-wide setting applies.
+type QueueConfig struct {
+	// Pool is the name of the pool of the queue.
+	Pool string
+
+	// Name is the session name participants use to join the queue.
+	Name string
+
+	// MinAmount is the minimum participation amount (in atoms). Replaces the
+	// matcher-wide minimum amount.
+	MinAmount uint64
+
+	// MaxAmount is the maximum participation amount (in atoms). Participants
+	// willing to contribute more than this are limited to this amount.
+	MaxAmount uint64
+
+	// MaxParticipants is the maximum number of participants waiting in the
+	// queue (and therefore in a session of the queue). Limited to the number
+	// of participants a ticket can hold (MaxQueueParticipants).
+	MaxParticipants int
+
+	// MaxSessionDuration is the maximum duration of sessions of the queue.
+	MaxSessionDuration time.Duration
+
+	// AllowedVoteAddresses is the list of (encoded) vote addresses allowed to
+	// join the queue. When empty, any vote address is allowed.
+	AllowedVoteAddresses []string
+
+	// AllowedClients is the list of identities of authenticated clients (see
+	// WithClientIdentity) allowed to join the queue. When empty, any client
+	// is allowed.
+	AllowedClients []string
+
+	// JoinKey is the password or pre-shared key participants must know in
+	// order to join the queue. Participants prove knowledge of the key by
+	// sending the MAC generated by JoinQueueMAC.
+	JoinKey []byte
+}
+
+// MaxQueueParticipants is the maximum number of participants of a session,
+// given the ticket must also hold the pool fee input.
+const MaxQueueParticipants = stake.MaxInputsPerSStx - 1
+
+// JoinQueueMAC returns the MAC that proves knowledge of the join key of a
+// queue, when participating with the given amount and addresses.
+func JoinQueueMAC(key []byte, pool, sessionName string, amount uint64,
+	voteAddr, poolAddr string) []byte {
+
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\x00%s\x00%d\x00%s\x00%s", pool, sessionName, amount,
+		voteAddr, poolAddr)
+	return mac.Sum(nil)
+}
+
+// buildQueueConfigs returns the registry of pre-declared queues of the given
+// config.
+func buildQueueConfigs(cfg *Config, pools map[string]*Pool) (
+	map[queueKey]*QueueConfig, error) {
+
+	queues := make(map[queueKey]*QueueConfig, len(cfg.Queues))
+	for _, q := range cfg.Queues {
+		if _, has := pools[q.Pool]; !has {
+			return nil, errors.Errorf("queue '%s' is of unknown pool '%s'",
+				q.Name, q.Pool)
+		}
+		key := queueKey{pool: q.Pool, name: q.Name}
+		if _, has := queues[key]; has {
+			return nil, errors.Errorf("duplicated queue '%s' of pool '%s'",
+				q.Name, q.Pool)
+		}
+		if q.MaxAmount > 0 && q.MaxAmount < q.MinAmount {
+			return nil, errors.Errorf("maximum amount of queue '%s' is "+
+				"lower than its minimum amount", q.Name)
+		}
+		if q.MaxParticipants == 1 || q.MaxParticipants < 0 {
+			return nil, errors.Errorf("invalid maximum number of "+
+				"participants of queue '%s'", q.Name)
+		}
+		if q.MaxParticipants == 0 || q.MaxParticipants > MaxQueueParticipants {
+			capped := *q
+			capped.MaxParticipants = MaxQueueParticipants
+			q = &capped
+		}
+		queues[key] = q
+	}
+	return queues, nil
+}
+
+// checkParticipant checks whether the given participant is allowed to join
+// the queue. Returns the amount the participant may contribute, which may be
+// lower than the requested amount.
+func (q *QueueConfig) checkParticipant(maxAmount uint64, voteAddress,
+	poolAddress dcrutil.Address, joinMAC []byte, clientID string) (uint64,
+	error) {
+
+	if len(q.AllowedClients) > 0 {
+		allowed := false
+		for _, id := range q.AllowedClients {
+			if clientID != "" && id == clientID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return 0, ErrClientNotAllowed
+		}
+	}
+
+	if len(q.JoinKey) > 0 {
+		expected := JoinQueueMAC(q.JoinKey, q.Pool, q.Name, maxAmount,
+			voteAddress.EncodeAddress(), poolAddress.EncodeAddress())
+		if !hmac.Equal(expected, joinMAC) {
+			return 0, ErrInvalidJoinKey
+		}
+	}
+
+	if len(q.AllowedVoteAddresses) > 0 {
+		allowed := false
+		encoded := voteAddress.EncodeAddress()
+		for _, addr := range q.AllowedVoteAddresses {
+			if addr == encoded {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return 0, errors.Errorf("vote address %s not allowed in queue",
+				encoded)
+		}
+	}
+
+	if q.MaxAmount > 0 && maxAmount > q.MaxAmount {
+		maxAmount = q.MaxAmount
+	}
+	return maxAmount, nil
+}
+
+// splitTicketQueue is the queue of participants of a pool waiting for a split
+// ticket session. May be named or not.
 type splitTicketQueue struct {
 	networkProvider     NetworkProvider
+	pool                *Pool
+	cfg                 *QueueConfig
 	waitingParticipants []*addParticipantRequest
 }
 
-func newSplitTicketQueue(networkProvider NetworkProvider) *splitTicketQueue {
+// newSplitTicketQueue creates a new queue of the given pool. cfg is nil for
+// queues that were not pre-declared.
+func newSplitTicketQueue(networkProvider NetworkProvider, pool *Pool,
+	cfg *QueueConfig) *splitTicketQueue {
+
 	return &splitTicketQueue{
 		networkProvider: networkProvider,
+		pool:            pool,
+		cfg:             cfg,
 	}
 }
 
+// full returns true if no more participants may join the queue.
+func (q *splitTicketQueue) full() bool {
+	return q.cfg != nil && q.cfg.MaxParticipants > 0 &&
+		len(q.waitingParticipants) >= q.cfg.MaxParticipants
+}
+
+// maxSessionDuration returns the maximum duration of a session started from
+// this queue, given the default duration.
+func (q *splitTicketQueue) maxSessionDuration(def time.Duration) time.Duration {
+	if q.cfg != nil && q.cfg.MaxSessionDuration > 0 {
+		return q.cfg.MaxSessionDuration
+	}
+	return def
+}
+
 func (q *splitTicketQueue) enoughForNewSession() bool {
 
 	ticketPrice := dcrutil.Amount(q.networkProvider.CurrentTicketPrice())
@@ -27,11 +195,35 @@ func (q *splitTicketQueue) enoughForNewSession() bool {
 		availableSum += r.maxAmount
 	}
 
-	ticketFee := splitticket.SessionFeeEstimate(len(q.waitingParticipants))
+	ticketFee := splitticket.SessionFeeEstimate(len(q.waitingParticipants),
+		q.networkProvider.CurrentFeeRate())
 	neededAmount := uint64(ticketPrice + ticketFee)
 	return availableSum > neededAmount
 }
 
+// evictForAmount removes and returns the smallest waiting participant of a
+// full queue that can't fund a ticket, when a participant willing to
+// contribute the given amount would replace it. Otherwise the full queue
+// would never start a session. Returns nil if no participant was evicted.
+func (q *splitTicketQueue) evictForAmount(amount uint64) *addParticipantRequest {
+	if !q.full() || q.enoughForNewSession() {
+		return nil
+	}
+
+	var smallest *addParticipantRequest
+	for _, p := range q.waitingParticipants {
+		if smallest == nil || p.maxAmount < smallest.maxAmount {
+			smallest = p
+		}
+	}
+	if smallest == nil || smallest.maxAmount >= amount {
+		return nil
+	}
+
+	q.removeWaitingParticipant(smallest)
+	return smallest
+}
+
 func (q *splitTicketQueue) addWaitingParticipant(p *addParticipantRequest) {
 	q.waitingParticipants = append(q.waitingParticipants, p)
 }
@@ -2,6 +2,7 @@ package matcher
 
 import (
 	"bufio"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -42,6 +43,12 @@ type SessionParticipant struct {
 	SessionToken      []byte
 	CurrentStage      SessionStage
 
+	// VoteChoices are the (optional) vote preferences of the participant,
+	// to be used if this participant is selected as the voter. They are
+	// signed by the participant's commitment address.
+	VoteChoices          splitticket.VoteChoices
+	VoteChoicesSignature []byte
+
 	Session *Session
 	Index   int
 	log     slog.Logger
@@ -60,26 +67,49 @@ type SessionParticipant struct {
 	chanSetOutputsResponse  chan setParticipantOutputsResponse
 	chanFundTicketResponse  chan fundTicketResponse
 	chanFundSplitTxResponse chan fundSplitTxResponse
+
+	// The last responses sent to the participant are kept so that they can be
+	// sent again if the participant reconnects after having missed them.
+	setOutputsResponse  *setParticipantOutputsResponse
+	fundTicketResponse  *fundTicketResponse
+	fundSplitTxResponse *fundSplitTxResponse
+
+	// watchID identifies the latest call whose connection is being watched for
+	// disconnections. Disconnection events of previous calls are ignored.
+	watchID      uint64
+	connCtx      context.Context
+	disconnected bool
 }
 
 func (part *SessionParticipant) sendSetOutputsResponse(resp setParticipantOutputsResponse) {
 	c := part.chanSetOutputsResponse
 	part.chanSetOutputsResponse = nil
+	part.setOutputsResponse = &resp
 	c <- resp
 }
 
 func (part *SessionParticipant) sendFundTicketResponse(resp fundTicketResponse) {
 	c := part.chanFundTicketResponse
 	part.chanFundTicketResponse = nil
+	part.fundTicketResponse = &resp
 	c <- resp
 }
 
 func (part *SessionParticipant) sendFundSplitTxResponse(resp fundSplitTxResponse) {
 	c := part.chanFundSplitTxResponse
 	part.chanFundSplitTxResponse = nil
+	part.fundSplitTxResponse = &resp
 	c <- resp
 }
 
+// waitingResponse returns true if the participant has an outstanding call
+// waiting for the other participants of the session.
+func (part *SessionParticipant) waitingResponse() bool {
+	return part.chanSetOutputsResponse != nil ||
+		part.chanFundTicketResponse != nil ||
+		part.chanFundSplitTxResponse != nil
+}
+
 func (part *SessionParticipant) sessionCanceled(err error) {
 	if part.chanSetOutputsResponse != nil {
 		part.sendSetOutputsResponse(setParticipantOutputsResponse{err: err})
@@ -167,15 +197,22 @@ type Session struct {
 	SelectedCoin    dcrutil.Amount
 	PoolFee         dcrutil.Amount
 	TicketFee       dcrutil.Amount
+	FeeRate         dcrutil.Amount
 	ChainParams     *chaincfg.Params
 	SplitTxPoolOut  *wire.TxOut
 	TicketPoolIn    *wire.TxIn
+	PoolFeeKeyIndex uint32
+	Pool            string
 	StartTime       time.Time
 	Done            bool
 	Canceled        bool
 	TicketExpiry    uint32
 	CurrentStage    SessionStage
 	log             slog.Logger
+
+	// VoteChoices are the vote preferences of the selected voter. Only filled
+	// once the voter is known.
+	VoteChoices splitticket.VoteChoices
 }
 
 // AllOutputsFilled returns true if all commitment and change outputs for all
@@ -362,7 +399,7 @@ func (sess *Session) CreateVoterTransactions() (*wire.MsgTx, *wire.MsgTx, *wire.
 	ticketHash := ticket.TxHash()
 
 	revocation, err := splitticket.CreateUnsignedRevocation(&ticketHash, ticket,
-		splitticket.RevocationFeeRate(sess.ChainParams))
+		splitticket.RevocationFeeRate(sess.FeeRate, sess.ChainParams))
 	if err != nil {
 		return nil, nil, nil, errors.Wrap(err, "error creating unsigned revocation")
 	}
@@ -553,11 +590,14 @@ func (sess *Session) SaveSession(sessionDir string) error {
 	out("Mainchain Height = %d\n", sess.MainchainHeight)
 	out("Ticket Price = %s\n", sess.TicketPrice)
 	out("Number of Participants = %d\n", len(sess.Participants))
+	out("Negotiated Fee Rate = %s/KB\n", sess.FeeRate)
 	out("Split tx Fee = %s (%.4f DCR/KB)\n", actualSplitFee, actualSplitFeeRate)
 	out("Estimated Ticket Fee = %s\n", sess.TicketFee)
 	out("Actual Ticket Fee = %s (%.4f DCR/KB)\n", actualTicketFee, actualTicketFeeRate)
 	out("Revocation Fee = %s (%.4f DCR/KB)\n", actualRevocationFee, actualRevocationFeeRate)
 	out("Pool Fee = %s\n", sess.PoolFee)
+	out("Pool Fee Key Index = %d\n", sess.PoolFeeKeyIndex)
+	out("Pool = %s\n", sess.Pool)
 	out("Split Transaction hash = %s\n", splitHash.String())
 	out("Final Ticket Hash = %s\n", ticketHashHex)
 	out("Final Revocation Hash = %s\n", revocationHash.String())
@@ -575,6 +615,7 @@ func (sess *Session) SaveSession(sessionDir string) error {
 	out("Secret Numbers = %v\n", sess.SecretNumbers())
 	out("Selected Coin = %s\n", sess.SelectedCoin)
 	out("Selected Voter Index = %d\n", sess.VoterIndex)
+	out("Selected Voter Vote Choices = %s\n", sess.VoteChoices)
 
 	out("\n")
 	out("====== Final Transactions ======\n")
@@ -604,7 +645,7 @@ func (sess *Session) SaveSession(sessionDir string) error {
 		p.replaceTicketIOs(ticketTempl)
 		ticketHash := ticketTempl.TxHash()
 		revocationTempl, err := splitticket.CreateUnsignedRevocation(&ticketHash,
-			ticketTempl, splitticket.RevocationFeeRate(sess.ChainParams))
+			ticketTempl, splitticket.RevocationFeeRate(sess.FeeRate, sess.ChainParams))
 		if err != nil {
 			return errors.Wrapf(err, "error creating unsigned revocation")
 		}
@@ -636,6 +677,8 @@ func (sess *Session) SaveSession(sessionDir string) error {
 		out("Secret Number = %s\n", p.SecretNb)
 		out("Vote Address = %s\n", p.VoteAddress.EncodeAddress())
 		out("Pool Address = %s\n", p.PoolAddress.EncodeAddress())
+		out("Vote Choices = %s\n", p.VoteChoices)
+		out("Vote Choices Signature = %s\n", hex.EncodeToString(p.VoteChoicesSignature))
 		out("Vote PkScript = %s\n", voteScript)
 		out("Pool PkScript = %s\n", poolScript)
 		out("Ticket = %s\n", hex.EncodeToString(partTicket))
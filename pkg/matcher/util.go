@@ -151,6 +151,37 @@ func OriginalSrcFromCtx(ctx context.Context) string {
 	return "[original src not provided]"
 }
 
+// WithClientIdentity returns a new context usable within the matcher package
+// that carries the identity of the authenticated client (eg: from its TLS
+// certificate) performing a matcher operation.
+func WithClientIdentity(parent context.Context, identity string) context.Context {
+	return context.WithValue(parent, clientIDCtxKey, identity)
+}
+
+// ClientIdentityFromCtx extracts the identity of the authenticated client
+// from a context variable. Returns an empty string for unauthenticated
+// clients.
+func ClientIdentityFromCtx(ctx context.Context) string {
+	val, _ := ctx.Value(clientIDCtxKey).(string)
+	return val
+}
+
+// WithConnectionContext returns a new context usable within the matcher
+// package that carries the context of the underlying network connection of
+// a participant. The connection context must be canceled once the connection
+// is closed, so that the matcher can promptly detect disconnected participants
+// even when they do not have an outstanding call.
+func WithConnectionContext(parent, conn context.Context) context.Context {
+	return context.WithValue(parent, connectionCtxKey, conn)
+}
+
+// connectionCtxFromCtx extracts the connection context from a context
+// variable. Returns nil if the connection context was not provided.
+func connectionCtxFromCtx(ctx context.Context) context.Context {
+	val, _ := ctx.Value(connectionCtxKey).(context.Context)
+	return val
+}
+
 // mustGenSessionToken generates a random session token so that individual
 // participants are correctly identified
 func mustGenSessionToken() []byte {
@@ -4,8 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
 	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/integratorrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -83,3 +86,119 @@ func (c *Client) ValidatePoolSubsidyAddress(poolAddr dcrutil.Address) error {
 
 	return nil
 }
+
+// SetVoteChoices requests the voting pool to use the given (signed) choices
+// when voting the ticket. Returns the vote bits the pool will use.
+func (c *Client) SetVoteChoices(ticketHash *chainhash.Hash, voteAddr,
+	commitAddr dcrutil.Address, choices splitticket.VoteChoices,
+	signature []byte) (uint16, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := &pb.SetVoteChoicesRequest{
+		TicketHash:        ticketHash[:],
+		VoteAddress:       voteAddr.EncodeAddress(),
+		CommitmentAddress: commitAddr.EncodeAddress(),
+		Choices:           make([]*pb.VoteChoice, len(choices)),
+		Signature:         signature,
+	}
+	for i, choice := range choices {
+		req.Choices[i] = &pb.VoteChoice{
+			AgendaId: choice.AgendaID,
+			ChoiceId: choice.ChoiceID,
+		}
+	}
+
+	resp, err := c.client.SetVoteChoices(ctx, req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error contacting stakepoold integrator to "+
+			"set vote choices of ticket %s", ticketHash)
+	}
+
+	if resp.Error != "" {
+		return 0, errors.Errorf("stakepoold integrator replied with error: %s",
+			resp.Error)
+	}
+
+	return uint16(resp.VoteBits), nil
+}
+
+// RegisterTicket registers the given ticket with the voting pool, so that its
+// wallet votes it once selected.
+func (c *Client) RegisterTicket(ticket *wire.MsgTx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ticketBytes, err := ticket.Bytes()
+	if err != nil {
+		return errors.Wrap(err, "error encoding ticket")
+	}
+
+	req := &pb.RegisterTicketRequest{
+		Ticket: ticketBytes,
+	}
+
+	resp, err := c.client.RegisterTicket(ctx, req)
+	if err != nil {
+		return errors.Wrapf(err, "error contacting stakepoold integrator to "+
+			"register ticket %s", ticket.TxHash())
+	}
+
+	if resp.Error != "" {
+		return errors.Errorf("stakepoold integrator replied with error: %s",
+			resp.Error)
+	}
+
+	return nil
+}
+
+// TicketVotingStatus is the voting status of a ticket, as reported by the
+// voting pool.
+type TicketVotingStatus struct {
+	Status        string
+	VoteBits      uint16
+	VoteVersion   uint32
+	SpentBy       *chainhash.Hash
+	SpentByHeight uint32
+}
+
+// TicketVotingStatus queries the voting pool for the current voting status of
+// the given ticket.
+func (c *Client) TicketVotingStatus(ticketHash *chainhash.Hash,
+	voteAddr dcrutil.Address) (*TicketVotingStatus, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := &pb.TicketVotingStatusRequest{
+		TicketHash:  ticketHash[:],
+		VoteAddress: voteAddr.EncodeAddress(),
+	}
+
+	resp, err := c.client.TicketVotingStatus(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error contacting stakepoold integrator "+
+			"to fetch voting status of ticket %s", ticketHash)
+	}
+
+	if resp.Error != "" {
+		return nil, errors.Errorf("stakepoold integrator replied with error: %s",
+			resp.Error)
+	}
+
+	status := &TicketVotingStatus{
+		Status:        resp.Status,
+		VoteBits:      uint16(resp.VoteBits),
+		VoteVersion:   resp.VoteVersion,
+		SpentByHeight: resp.SpentByHeight,
+	}
+	if len(resp.SpentBy) > 0 {
+		status.SpentBy, err = chainhash.NewHash(resp.SpentBy)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding spent by hash")
+		}
+	}
+
+	return status, nil
+}
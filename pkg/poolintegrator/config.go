@@ -7,6 +7,7 @@ import (
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/slog"
 	flags "github.com/jessevdk/go-flags"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
 	"github.com/pkg/errors"
 )
 
@@ -29,7 +30,9 @@ type Config struct {
 	DcrwPass string `long:"dcrwpass" description:"Password of the rpc connection to dcrwallet"`
 	DcrwCert string `long:"dcrwcert" descript}ion:"Location of the rpc.cert file of dcrwallet"`
 
-	PoolSubsidyWalletMasterPub string `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. If empty, pool fee addresses are not validated. Append a :[index] to generate addresses up to the provided index (default: 10000)."`
+	PoolSubsidyWalletMasterPub []string `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. May be specified multiple times (eg: to keep accepting the addresses of a previous key). The coldwalletextpub of stakepoold is added to these when reading its config. Append a :[index] to accept addresses up to the provided index regardless of the gap limit."`
+	PoolSubsidyGapLimit        uint32   `long:"poolsubsidygaplimit" description:"Number of pool fee addresses accepted past the highest index already seen for each PoolSubsidyWalletMasterPub"`
+	PoolAddrIndexFile          string   `long:"pooladdrindexfile" description:"File where the highest index of the seen pool fee addresses is saved"`
 }
 
 // stakepooldConfig is the information read from stakepoold.conf file
@@ -56,6 +59,9 @@ func LoadConfig() (*Config, error) {
 		CertFile: filepath.Join(defaultDataDir, "rpc.cert"),
 
 		StakepooldConfigFile: filepath.Join(dcrutil.AppDataDir("stakepoold", false), "stakepoold.conf"),
+
+		PoolSubsidyGapLimit: util.DefaultPoolAddrGapLimit,
+		PoolAddrIndexFile:   filepath.Join(defaultDataDir, util.PoolAddrIndexFilename),
 	}
 
 	parser := flags.NewParser(cfg, flags.Default)
@@ -115,7 +121,10 @@ func loadConfigFromStakepoold(cfg *Config) error {
 	cfg.DcrwCert = spCfg.WalletCert
 	cfg.DcrwUser = spCfg.WalletUser
 	cfg.DcrwPass = spCfg.WalletPassword
-	cfg.PoolSubsidyWalletMasterPub = spCfg.ColdWalletExtPub
+	if spCfg.ColdWalletExtPub != "" {
+		cfg.PoolSubsidyWalletMasterPub = append([]string{spCfg.ColdWalletExtPub},
+			cfg.PoolSubsidyWalletMasterPub...)
+	}
 	cfg.TestNet = spCfg.TestNet
 
 	return nil
@@ -134,7 +143,7 @@ func hasFullConfig(cfg *Config) error {
 	if cfg.DcrwCert == "" {
 		return errors.New("missing dcrwcert config")
 	}
-	if cfg.PoolSubsidyWalletMasterPub == "" {
+	if len(cfg.PoolSubsidyWalletMasterPub) == 0 {
 		return errors.New("missing poolsubsidywalletmasterpub config")
 	}
 
@@ -10,6 +10,14 @@ import (
 const (
 	// DefaultPort that the pool integrator runs on
 	DefaultPort = 9872
+
+	// TicketStatusUnknown is the voting status returned for tickets not (yet)
+	// known to the voting wallet.
+	TicketStatusUnknown = "unknown"
+
+	// TicketStatusInvalid is the voting status returned for tickets the voting
+	// wallet considers invalid for the given vote address.
+	TicketStatusInvalid = "invalid"
 )
 
 var (
@@ -7,7 +7,6 @@ import (
 	"net"
 
 	"github.com/decred/dcrd/chaincfg"
-	"github.com/decred/dcrd/rpcclient"
 	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/integratorrpc"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/version"
@@ -23,7 +22,7 @@ type Daemon struct {
 	rpcKeys           *tls.Certificate
 	chainParams       *chaincfg.Params
 	poolAddrValidator *util.MasterPubPoolAddrValidator
-	wallet            *rpcclient.Client
+	wallet            poolWallet
 }
 
 // NewDaemon initializes a new pool integrator daemon
@@ -47,7 +46,9 @@ func NewDaemon(cfg *Config) (*Daemon, error) {
 		return nil, errors.Wrap(err, "error loading rpc key pair")
 	}
 
-	poolAddrValidator, err := util.NewMasterPubPoolAddrValidator(cfg.PoolSubsidyWalletMasterPub, chainParams)
+	poolAddrValidator, err := util.NewMasterPubPoolAddrValidator(
+		cfg.PoolSubsidyWalletMasterPub, cfg.PoolSubsidyGapLimit,
+		cfg.PoolAddrIndexFile, log, chainParams)
 	if err != nil {
 		return nil, errors.Wrap(err, "error initializing pool fee address table")
 	}
@@ -3,9 +3,14 @@ package poolintegrator
 import (
 	"context"
 
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
 
 	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/integratorrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 )
 
@@ -60,3 +65,237 @@ func (d *Daemon) ValidatePoolSubsidyAddress(ctx context.Context,
 
 	return resp, nil
 }
+
+// SetVoteChoices fullfill grpc service requirements
+func (d *Daemon) SetVoteChoices(ctx context.Context,
+	req *pb.SetVoteChoicesRequest) (*pb.SetVoteChoicesResponse, error) {
+
+	resp := new(pb.SetVoteChoicesResponse)
+
+	ticketHash, err := chainhash.NewHash(req.TicketHash)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding ticket hash").Error()
+		d.log.Warnf("Received set vote choices request with undecodable "+
+			"ticket hash: %s", err)
+		return resp, nil
+	}
+
+	d.log.Infof("Received set vote choices request for ticket %s", ticketHash)
+
+	voteAddr, err := dcrutil.DecodeAddress(req.VoteAddress)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding vote address").Error()
+		d.log.Warnf("Received set vote choices request with undecodable "+
+			"vote address (%s): %s", req.VoteAddress, err)
+		return resp, nil
+	}
+
+	commitAddr, err := dcrutil.DecodeAddress(req.CommitmentAddress)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding commitment address").Error()
+		d.log.Warnf("Received set vote choices request with undecodable "+
+			"commitment address (%s): %s", req.CommitmentAddress, err)
+		return resp, nil
+	}
+
+	choices := make(splitticket.VoteChoices, len(req.Choices))
+	for i, c := range req.Choices {
+		choices[i] = splitticket.VoteChoice{
+			AgendaID: c.AgendaId,
+			ChoiceID: c.ChoiceId,
+		}
+	}
+
+	// The matcher already verified the signature, but we double check it
+	// here so that only choices attested by the ticket owner are used.
+	err = splitticket.CheckVoteChoicesSignature(choices, voteAddr, commitAddr,
+		req.Signature)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "invalid vote choices signature").Error()
+		d.log.Warnf("Received set vote choices request for ticket %s with "+
+			"invalid signature: %s", ticketHash, err)
+		return resp, nil
+	}
+
+	wresp, err := d.wallet.ValidateAddress(voteAddr)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error validating vote address").Error()
+		d.log.Warnf("Received error trying to validate address %s with wallet: %s",
+			req.VoteAddress, err)
+		return resp, nil
+	} else if !wresp.IsMine {
+		resp.Error = "address is not from this voting pool"
+		d.log.Infof("Received set vote choices request with address not "+
+			"owned (%s)", req.VoteAddress)
+		return resp, nil
+	}
+
+	voteBits, err := choices.VoteBits(splitticket.LatestVoteVersion(d.chainParams),
+		d.chainParams)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "invalid vote choices").Error()
+		d.log.Warnf("Received set vote choices request for ticket %s with "+
+			"invalid choices: %s", ticketHash, err)
+		return resp, nil
+	}
+
+	for _, c := range choices {
+		err = setTicketVoteChoice(d.wallet, ticketHash, c.AgendaID, c.ChoiceID)
+		if err != nil {
+			resp.Error = err.Error()
+			d.log.Errorf("Error setting vote choices of ticket %s: %s",
+				ticketHash, err)
+			return resp, nil
+		}
+	}
+
+	d.log.Infof("Set vote choices of ticket %s to %s (vote bits %.4x)",
+		ticketHash, choices, voteBits)
+	resp.VoteBits = uint32(voteBits)
+
+	return resp, nil
+}
+
+// RegisterTicket fullfill grpc service requirements
+func (d *Daemon) RegisterTicket(ctx context.Context,
+	req *pb.RegisterTicketRequest) (*pb.RegisterTicketResponse, error) {
+
+	resp := new(pb.RegisterTicketResponse)
+
+	ticket := wire.NewMsgTx()
+	err := ticket.FromBytes(req.Ticket)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding ticket").Error()
+		d.log.Warnf("Received register ticket request with undecodable "+
+			"ticket: %s", err)
+		return resp, nil
+	}
+	ticketHash := ticket.TxHash()
+
+	d.log.Infof("Received register ticket request for ticket %s", ticketHash)
+
+	if !stake.IsSStx(ticket) {
+		resp.Error = "transaction is not a ticket"
+		d.log.Warnf("Received register ticket request with a non-ticket "+
+			"transaction %s", ticketHash)
+		return resp, nil
+	}
+
+	// only accept tickets that are actually voted by this pool
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(ticket.TxOut[0].Version,
+		ticket.TxOut[0].PkScript, d.chainParams)
+	if err != nil || len(addrs) != 1 {
+		resp.Error = "could not extract vote address of ticket"
+		d.log.Warnf("Could not extract vote address of ticket %s (err: %v)",
+			ticketHash, err)
+		return resp, nil
+	}
+
+	wresp, err := d.wallet.ValidateAddress(addrs[0])
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error validating vote address").Error()
+		d.log.Warnf("Received error trying to validate address %s with wallet: %s",
+			addrs[0].EncodeAddress(), err)
+		return resp, nil
+	} else if !wresp.IsMine {
+		resp.Error = "ticket vote address is not from this voting pool"
+		d.log.Infof("Received register ticket request with vote address not "+
+			"owned (%s)", addrs[0].EncodeAddress())
+		return resp, nil
+	}
+
+	err = d.wallet.AddTicket(dcrutil.NewTx(ticket))
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error adding ticket to wallet").Error()
+		d.log.Errorf("Error adding ticket %s to wallet: %s", ticketHash, err)
+		return resp, nil
+	}
+
+	d.log.Infof("Registered ticket %s on the voting wallet", ticketHash)
+
+	return resp, nil
+}
+
+// TicketVotingStatus fullfill grpc service requirements
+func (d *Daemon) TicketVotingStatus(ctx context.Context,
+	req *pb.TicketVotingStatusRequest) (*pb.TicketVotingStatusResponse, error) {
+
+	resp := new(pb.TicketVotingStatusResponse)
+
+	ticketHash, err := chainhash.NewHash(req.TicketHash)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding ticket hash").Error()
+		d.log.Warnf("Received ticket voting status request with undecodable "+
+			"ticket hash: %s", err)
+		return resp, nil
+	}
+
+	d.log.Debugf("Received ticket voting status request for ticket %s",
+		ticketHash)
+
+	voteAddr, err := dcrutil.DecodeAddress(req.VoteAddress)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding vote address").Error()
+		d.log.Warnf("Received ticket voting status request with undecodable "+
+			"vote address (%s): %s", req.VoteAddress, err)
+		return resp, nil
+	}
+
+	info, err := d.wallet.StakePoolUserInfo(voteAddr)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error fetching stakepool user "+
+			"info").Error()
+		d.log.Errorf("Error fetching stakepool user info of %s: %s",
+			req.VoteAddress, err)
+		return resp, nil
+	}
+
+	resp.Status = TicketStatusUnknown
+	ticketHashStr := ticketHash.String()
+	for _, t := range info.Tickets {
+		if t.Ticket != ticketHashStr {
+			continue
+		}
+
+		resp.Status = t.Status
+		if t.SpentBy != "" {
+			spentBy, err := chainhash.NewHashFromStr(t.SpentBy)
+			if err == nil {
+				resp.SpentBy = spentBy[:]
+				resp.SpentByHeight = t.SpentByHeight
+			}
+		}
+		break
+	}
+	for _, t := range info.InvalidTickets {
+		if t == ticketHashStr {
+			resp.Status = TicketStatusInvalid
+			break
+		}
+	}
+
+	if resp.Status == TicketStatusUnknown {
+		return resp, nil
+	}
+
+	voteVersion, choices, err := getTicketVoteChoices(d.wallet, ticketHash)
+	if err != nil {
+		resp.Error = err.Error()
+		d.log.Errorf("Error fetching vote choices of ticket %s: %s",
+			ticketHash, err)
+		return resp, nil
+	}
+
+	voteBits, err := choices.VoteBits(voteVersion, d.chainParams)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error calculating vote bits").Error()
+		d.log.Errorf("Error calculating vote bits of ticket %s: %s",
+			ticketHash, err)
+		return resp, nil
+	}
+
+	resp.VoteBits = uint32(voteBits)
+	resp.VoteVersion = voteVersion
+
+	return resp, nil
+}
@@ -0,0 +1,242 @@
+package poolintegrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/integratorrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+var _testNetwork = &chaincfg.MainNetParams
+
+// mockPoolWallet replaces the voting pool's dcrwallet rpc client during tests.
+type mockPoolWallet struct {
+	mine        map[string]bool
+	tickets     map[chainhash.Hash]*wire.MsgTx
+	choices     map[chainhash.Hash]map[string]string
+	voteVersion uint32
+}
+
+func newMockPoolWallet() *mockPoolWallet {
+	return &mockPoolWallet{
+		mine:        make(map[string]bool),
+		tickets:     make(map[chainhash.Hash]*wire.MsgTx),
+		choices:     make(map[chainhash.Hash]map[string]string),
+		voteVersion: splitticket.LatestVoteVersion(_testNetwork),
+	}
+}
+
+func (w *mockPoolWallet) ValidateAddress(addr dcrutil.Address) (*dcrjson.ValidateAddressWalletResult, error) {
+	return &dcrjson.ValidateAddressWalletResult{
+		IsValid: true,
+		IsMine:  w.mine[addr.EncodeAddress()],
+	}, nil
+}
+
+func (w *mockPoolWallet) AddTicket(ticket *dcrutil.Tx) error {
+	w.tickets[*ticket.Hash()] = ticket.MsgTx()
+	return nil
+}
+
+func (w *mockPoolWallet) StakePoolUserInfo(addr dcrutil.Address) (*dcrjson.StakePoolUserInfoResult, error) {
+	res := new(dcrjson.StakePoolUserInfoResult)
+	for hash := range w.tickets {
+		res.Tickets = append(res.Tickets, dcrjson.PoolUserTicket{
+			Status: "live",
+			Ticket: hash.String(),
+		})
+	}
+	return res, nil
+}
+
+func (w *mockPoolWallet) RawRequest(method string, params []json.RawMessage) (json.RawMessage, error) {
+	args := make([]string, len(params))
+	for i, p := range params {
+		if err := json.Unmarshal(p, &args[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case method == "setvotechoice" && len(args) == 3:
+		hash, err := chainhash.NewHashFromStr(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if _, has := w.tickets[*hash]; !has {
+			return nil, errors.Errorf("unknown ticket %s", hash)
+		}
+		if _, has := w.choices[*hash]; !has {
+			w.choices[*hash] = make(map[string]string)
+		}
+		w.choices[*hash][args[0]] = args[1]
+		return nil, nil
+
+	case method == "getvotechoices" && len(args) == 1:
+		hash, err := chainhash.NewHashFromStr(args[0])
+		if err != nil {
+			return nil, err
+		}
+		res := dcrjson.GetVoteChoicesResult{Version: w.voteVersion}
+		for agenda, choice := range w.choices[*hash] {
+			res.Choices = append(res.Choices, dcrjson.VoteChoice{
+				AgendaID: agenda,
+				ChoiceID: choice,
+			})
+		}
+		return json.Marshal(res)
+	}
+
+	return nil, errors.Errorf("unsupported method %s", method)
+}
+
+func newTestKeyAddr(t *testing.T) (*secp256k1.PrivateKey, dcrutil.Address) {
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	pk := (*secp256k1.PublicKey)(&key.PublicKey)
+	addr, err := dcrutil.NewAddressPubKeyHash(dcrutil.Hash160(pk.SerializeCompressed()),
+		_testNetwork, 0)
+	if err != nil {
+		t.Fatalf("error creating address: %v", err)
+	}
+	return key, addr
+}
+
+func createTestTicket(t *testing.T, voteAddr, commitAddr dcrutil.Address) *wire.MsgTx {
+	voteScript, err := txscript.PayToSStx(voteAddr)
+	if err != nil {
+		t.Fatalf("error creating vote script: %v", err)
+	}
+	commitScript, err := txscript.GenerateSStxAddrPush(commitAddr, 1e8,
+		splitticket.CommitmentLimits)
+	if err != nil {
+		t.Fatalf("error creating commitment script: %v", err)
+	}
+	changeScript, err := txscript.PayToSStxChange(commitAddr)
+	if err != nil {
+		t.Fatalf("error creating change script: %v", err)
+	}
+
+	ticket := wire.NewMsgTx()
+	ticket.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, 1e8, nil))
+	ticket.AddTxOut(wire.NewTxOut(1e8, voteScript))
+	ticket.AddTxOut(wire.NewTxOut(0, commitScript))
+	ticket.AddTxOut(wire.NewTxOut(0, changeScript))
+	return ticket
+}
+
+func signVoteChoices(t *testing.T, key *secp256k1.PrivateKey,
+	choices splitticket.VoteChoices, voteAddr dcrutil.Address) []byte {
+
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, "Decred Signed Message:\n")
+	wire.WriteVarString(&buf, 0, splitticket.VoteChoicesSignMessage(choices,
+		voteAddr))
+	sig, err := secp256k1.SignCompact(key, chainhash.HashB(buf.Bytes()), true)
+	if err != nil {
+		t.Fatalf("error signing vote choices: %v", err)
+	}
+	return sig
+}
+
+func TestRegisterTicketAndSetVoteChoices(t *testing.T) {
+	t.Parallel()
+
+	wallet := newMockPoolWallet()
+	d := &Daemon{
+		cfg:         &Config{},
+		log:         slog.Disabled,
+		chainParams: _testNetwork,
+		wallet:      wallet,
+	}
+	ctx := context.Background()
+
+	_, voteAddr := newTestKeyAddr(t)
+	commitKey, commitAddr := newTestKeyAddr(t)
+	ticket := createTestTicket(t, voteAddr, commitAddr)
+	ticketHash := ticket.TxHash()
+	ticketBytes, _ := ticket.Bytes()
+
+	// tickets for vote addresses not from the pool are rejected
+	regResp, _ := d.RegisterTicket(ctx, &pb.RegisterTicketRequest{Ticket: ticketBytes})
+	if regResp.Error == "" {
+		t.Fatalf("ticket with vote address not owned by the pool registered")
+	}
+
+	wallet.mine[voteAddr.EncodeAddress()] = true
+	regResp, _ = d.RegisterTicket(ctx, &pb.RegisterTicketRequest{Ticket: ticketBytes})
+	if regResp.Error != "" {
+		t.Fatalf("unexpected error registering ticket: %s", regResp.Error)
+	}
+	if _, has := wallet.tickets[ticketHash]; !has {
+		t.Fatalf("ticket not added to wallet")
+	}
+
+	statusReq := &pb.TicketVotingStatusRequest{
+		TicketHash:  ticketHash[:],
+		VoteAddress: voteAddr.EncodeAddress(),
+	}
+	statusResp, _ := d.TicketVotingStatus(ctx, statusReq)
+	if statusResp.Error != "" {
+		t.Fatalf("unexpected error fetching voting status: %s", statusResp.Error)
+	}
+	if statusResp.Status != "live" || statusResp.VoteBits != 1 {
+		t.Fatalf("unexpected voting status %s / vote bits %.4x",
+			statusResp.Status, statusResp.VoteBits)
+	}
+
+	choices := splitticket.VoteChoices{{AgendaID: "lnfeatures", ChoiceID: "yes"}}
+	setReq := &pb.SetVoteChoicesRequest{
+		TicketHash:        ticketHash[:],
+		VoteAddress:       voteAddr.EncodeAddress(),
+		CommitmentAddress: commitAddr.EncodeAddress(),
+		Choices:           []*pb.VoteChoice{{AgendaId: "lnfeatures", ChoiceId: "yes"}},
+	}
+
+	// choices not signed by the commitment address are rejected
+	wrongKey, _ := newTestKeyAddr(t)
+	setReq.Signature = signVoteChoices(t, wrongKey, choices, voteAddr)
+	setResp, _ := d.SetVoteChoices(ctx, setReq)
+	if setResp.Error == "" {
+		t.Fatalf("vote choices with wrong signature accepted")
+	}
+
+	setReq.Signature = signVoteChoices(t, commitKey, choices, voteAddr)
+	setResp, _ = d.SetVoteChoices(ctx, setReq)
+	if setResp.Error != "" {
+		t.Fatalf("unexpected error setting vote choices: %s", setResp.Error)
+	}
+	if setResp.VoteBits != 0x05 {
+		t.Fatalf("unexpected vote bits %.4x", setResp.VoteBits)
+	}
+
+	statusResp, _ = d.TicketVotingStatus(ctx, statusReq)
+	if statusResp.Error != "" {
+		t.Fatalf("unexpected error fetching voting status: %s", statusResp.Error)
+	}
+	if statusResp.VoteBits != setResp.VoteBits {
+		t.Fatalf("voting status reported vote bits %.4x (expected %.4x)",
+			statusResp.VoteBits, setResp.VoteBits)
+	}
+
+	// unknown tickets are reported as such
+	statusReq.TicketHash = make([]byte, chainhash.HashSize)
+	statusResp, _ = d.TicketVotingStatus(ctx, statusReq)
+	if statusResp.Status != TicketStatusUnknown {
+		t.Fatalf("unexpected status for unknown ticket: %s", statusResp.Status)
+	}
+}
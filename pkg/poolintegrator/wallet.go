@@ -1,13 +1,27 @@
 package poolintegrator
 
 import (
+	"encoding/json"
 	"io/ioutil"
 
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/dcrd/rpcclient"
 	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
 	"github.com/pkg/errors"
 )
 
+// poolWallet is the interface for the operations the integrator needs from the
+// voting pool's wallet. It is fulfilled by *rpcclient.Client.
+type poolWallet interface {
+	ValidateAddress(address dcrutil.Address) (*dcrjson.ValidateAddressWalletResult, error)
+	AddTicket(ticket *dcrutil.Tx) error
+	StakePoolUserInfo(addr dcrutil.Address) (*dcrjson.StakePoolUserInfoResult, error)
+	RawRequest(method string, params []json.RawMessage) (json.RawMessage, error)
+}
+
 // connectToDcrWallet tries to connect to the given wallet.
 func connectToDcrWallet(cfg *Config) (*rpcclient.Client, error) {
 
@@ -37,3 +51,61 @@ func connectToDcrWallet(cfg *Config) (*rpcclient.Client, error) {
 
 	return client, nil
 }
+
+// setTicketVoteChoice sets the choice for the given agenda of a single ticket
+// on the wallet. This requires a wallet version that supports the optional
+// ticket hash argument of the setvotechoice command.
+func setTicketVoteChoice(wallet poolWallet, ticketHash *chainhash.Hash,
+	agendaID, choiceID string) error {
+
+	args := []string{agendaID, choiceID, ticketHash.String()}
+	params := make([]json.RawMessage, len(args))
+	for i, arg := range args {
+		param, err := json.Marshal(arg)
+		if err != nil {
+			return errors.Wrapf(err, "error encoding setvotechoice arg %d", i)
+		}
+		params[i] = param
+	}
+
+	_, err := wallet.RawRequest("setvotechoice", params)
+	if err != nil {
+		return errors.Wrapf(err, "error setting choice %s for agenda %s "+
+			"of ticket %s", choiceID, agendaID, ticketHash)
+	}
+
+	return nil
+}
+
+// getTicketVoteChoices returns the vote version and choices currently set on the
+// wallet for the given ticket.
+func getTicketVoteChoices(wallet poolWallet, ticketHash *chainhash.Hash) (
+	uint32, splitticket.VoteChoices, error) {
+
+	param, err := json.Marshal(ticketHash.String())
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "error encoding getvotechoices arg")
+	}
+
+	resp, err := wallet.RawRequest("getvotechoices", []json.RawMessage{param})
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "error getting vote choices of "+
+			"ticket %s", ticketHash)
+	}
+
+	var res dcrjson.GetVoteChoicesResult
+	err = json.Unmarshal(resp, &res)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "error decoding getvotechoices result")
+	}
+
+	choices := make(splitticket.VoteChoices, len(res.Choices))
+	for i, c := range res.Choices {
+		choices[i] = splitticket.VoteChoice{
+			AgendaID: c.AgendaID,
+			ChoiceID: c.ChoiceID,
+		}
+	}
+
+	return res.Version, choices, nil
+}
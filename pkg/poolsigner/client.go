@@ -0,0 +1,126 @@
+package poolsigner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/poolsignerrpc"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Client represents a client to a pool signer daemon (ie, the part that runs
+// on the matcher service). It fulfills matcher.SignPoolSplitOutputProvider so
+// that the matcher does not need to hold the private key for the pool fee.
+type Client struct {
+	client pb.PoolSignerServiceClient
+	net    *chaincfg.Params
+}
+
+// NewClient creates a new client connection to the pool signer at the given
+// host. The connection is authenticated with the provided client certificate,
+// which must be accepted by the signer (ie. included in its ClientCAFile).
+func NewClient(host, cert string, clientCert *tls.Certificate,
+	net *chaincfg.Params) (*Client, error) {
+
+	pem, err := ioutil.ReadFile(cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading pool signer certificate")
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in %s", cert)
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{*clientCert},
+		RootCAs:      rootCAs,
+		ServerName:   "localhost",
+	})
+
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		client: pb.NewPoolSignerServiceClient(conn),
+		net:    net,
+	}
+
+	return client, nil
+}
+
+// PoolFeeAddress fulfills matcher.SignPoolSplitOutputProvider.PoolFeeAddress
+// by requesting the address for a new session from the pool signer.
+func (c *Client) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.PoolFeeAddress(ctx, &pb.PoolFeeAddressRequest{})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error contacting pool signer to "+
+			"fetch pool fee address")
+	}
+
+	if resp.Error != "" {
+		return nil, 0, errors.Errorf("pool signer replied with error: %s",
+			resp.Error)
+	}
+
+	addr, err := dcrutil.DecodeAddress(resp.Address)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error decoding pool fee address")
+	}
+	if !addr.IsForNet(c.net) {
+		return nil, 0, errors.Errorf("pool fee address %s is not for the "+
+			"current network", resp.Address)
+	}
+
+	return addr, resp.KeyIndex, nil
+}
+
+// SignPoolSplitOutput fulfills matcher.SignPoolSplitOutputProvider.SignPoolSplitOutput
+// by requesting the pool signer to sign the pool fee input of the ticket.
+func (c *Client) SignPoolSplitOutput(split, ticket *wire.MsgTx,
+	keyIndex uint32) ([]byte, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	splitBytes, err := split.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding split tx")
+	}
+
+	ticketBytes, err := ticket.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding ticket")
+	}
+
+	req := &pb.SignPoolSplitOutputRequest{
+		SplitTx:  splitBytes,
+		Ticket:   ticketBytes,
+		KeyIndex: keyIndex,
+	}
+
+	resp, err := c.client.SignPoolSplitOutput(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error contacting pool signer to sign "+
+			"ticket %s", ticket.TxHash())
+	}
+
+	if resp.Error != "" {
+		return nil, errors.Errorf("pool signer replied with error: %s",
+			resp.Error)
+	}
+
+	return resp.SignatureScript, nil
+}
@@ -0,0 +1,124 @@
+package poolsigner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/decred/slog"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// Config holds information on how to configure the pool signer daemon.
+type Config struct {
+	ConfigFile   string `short:"C" long:"configfile" description:"Path to config file"`
+	Port         int    `long:"port" description:"Port to run the service on"`
+	LogLevel     slog.Level
+	LogLevelName string `long:"loglevel" description:"Log Level (CRITICAL, ERROR, WARNING, NOTICE, INFO, DEBUG)"`
+	LogDir       string `long:"logdir" description:"Log directory. Specify to save log messages to a file"`
+	DataDir      string `long:"datadir" description:"Dir where the signer state is saved"`
+	KeyFile      string `long:"keyfile" description:"Location of the rpc.key file (private key for the TLS certificate)."`
+	CertFile     string `long:"certfile" description:"Location of the rpc.cert file (TLS certificate)."`
+	ClientCAFile string `long:"clientcafile" description:"Location of the file with the certificates of the matchers allowed to request signatures. Clients without a certificate signed by one of these are rejected."`
+	ShowVersion  bool   `long:"version" description:"Show version and quit"`
+
+	TestNet bool `long:"testnet" description:"Whether to run on testnet"`
+	SimNet  bool `long:"simnet" description:"Whether to run on simnet"`
+
+	SplitPoolSignKey           string   `long:"splitpoolsignkey" description:"WIF private key for signing the split -> ticket intermediate pool fee txo"`
+	SplitPoolSignXPriv         string   `long:"splitpoolsignxpriv" description:"Extended private key from which a different key is derived for the split -> ticket intermediate pool fee txo of each session. Takes precedence over splitpoolsignkey."`
+	PoolSubsidyWalletMasterPub []string `long:"poolsubsidywalletmasterpub" description:"MasterPubKey for deriving addresses where the pool fee is payed to. Only tickets paying the pool fee to one of these addresses are signed. May be specified multiple times. Append a :[index] to accept addresses up to the provided index regardless of the gap limit."`
+	PoolSubsidyGapLimit        uint32   `long:"poolsubsidygaplimit" description:"Number of pool fee addresses accepted past the highest index already seen for each PoolSubsidyWalletMasterPub"`
+	PoolFee                    float64  `long:"poolfee" description:"Pool fee as a percentage (eg: 5.0 = 5%). Only tickets paying this pool fee are signed."`
+	MaxSignaturesPerHour       int      `long:"maxsignaturesperhour" description:"Maximum number of pool fee inputs signed per hour. Requests above this limit are refused."`
+	MaxAddressesPerHour        int      `long:"maxaddressesperhour" description:"Maximum number of pool fee addresses provided per hour. Requests above this limit are refused, so that a misbehaving matcher can't exhaust the keys derived from splitpoolsignxpriv."`
+}
+
+// LoadConfig loads configuration for a pool signer daemon from the config file
+// and passed command line args
+func LoadConfig() (*Config, error) {
+	var err error
+
+	preCfg := &Config{
+		ConfigFile: filepath.Join(defaultDataDir, "stmpoolsigner.conf"),
+	}
+	preParser := flags.NewParser(preCfg, flags.Default)
+	_, err = preParser.Parse()
+	if err != nil {
+		e, ok := err.(*flags.Error)
+		if ok && e.Type == flags.ErrHelp {
+			return nil, ErrHelpRequested
+		}
+		preParser.WriteHelp(os.Stderr)
+		return nil, errors.Wrapf(err, "error parsing arguments")
+	}
+
+	if preCfg.ShowVersion {
+		return nil, ErrVersionRequested
+	}
+
+	cfg := &Config{
+		Port:         DefaultPort,
+		LogLevelName: "INFO",
+		DataDir:      defaultDataDir,
+
+		KeyFile:      filepath.Join(defaultDataDir, "rpc.key"),
+		CertFile:     filepath.Join(defaultDataDir, "rpc.cert"),
+		ClientCAFile: filepath.Join(defaultDataDir, "clients.cert"),
+
+		PoolSubsidyGapLimit:  util.DefaultPoolAddrGapLimit,
+		PoolFee:              splitticket.MaxPoolFeeRateMainnet,
+		MaxSignaturesPerHour: 500,
+		MaxAddressesPerHour:  500,
+	}
+
+	parser := flags.NewParser(cfg, flags.Default)
+	if _, err = os.Stat(preCfg.ConfigFile); err == nil {
+		err = flags.NewIniParser(parser).ParseFile(preCfg.ConfigFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing config file")
+		}
+	}
+
+	_, err = parser.Parse()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing arguments")
+	}
+
+	if err = hasFullConfig(cfg); err != nil {
+		return nil, errors.Wrap(err, "missing config arguments")
+	}
+
+	logLvl, ok := slog.LevelFromString(cfg.LogLevelName)
+	if !ok {
+		return nil, errors.Errorf("Invalid log level name %s", cfg.LogLevelName)
+	}
+	cfg.LogLevel = logLvl
+
+	return cfg, nil
+}
+
+func hasFullConfig(cfg *Config) error {
+	if cfg.SplitPoolSignKey == "" && cfg.SplitPoolSignXPriv == "" {
+		return errors.New("missing splitpoolsignkey or splitpoolsignxpriv config")
+	}
+	if len(cfg.PoolSubsidyWalletMasterPub) == 0 {
+		return errors.New("missing poolsubsidywalletmasterpub config")
+	}
+	if cfg.ClientCAFile == "" {
+		return errors.New("missing clientcafile config")
+	}
+	if cfg.MaxSignaturesPerHour < 1 {
+		return errors.New("maxsignaturesperhour must be at least 1")
+	}
+	if cfg.MaxAddressesPerHour < 1 {
+		return errors.New("maxaddressesperhour must be at least 1")
+	}
+	if cfg.TestNet && cfg.SimNet {
+		return errors.New("testnet and simnet cannot be both specified")
+	}
+
+	return nil
+}
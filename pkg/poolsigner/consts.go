@@ -0,0 +1,34 @@
+package poolsigner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort that the pool signer runs on
+	DefaultPort = 9873
+
+	// KeyIndexFilename is the name of the file (in the data dir) that stores
+	// the index of the next key derived from an extended private key.
+	KeyIndexFilename = "poolfee-keyindex"
+
+	// rateLimitWindow is the period over which the maximum number of
+	// signatures is enforced.
+	rateLimitWindow = time.Hour
+)
+
+var (
+	// ErrHelpRequested is an error returned when the command line options
+	// requested the help information
+	ErrHelpRequested = errors.New("help requested")
+
+	// ErrVersionRequested is the error returned when the version command line
+	// option was requested
+	ErrVersionRequested = fmt.Errorf("version requested")
+
+	defaultDataDir = dcrutil.AppDataDir("stmpoolsigner", false)
+)
@@ -0,0 +1,162 @@
+package poolsigner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/hdkeychain"
+	"github.com/decred/dcrd/wire"
+	"github.com/pkg/errors"
+)
+
+// HDKeySigner fulfills matcher.SignPoolSplitOutputProvider by deriving a new
+// child key of an extended private key for each session, such that the pool
+// fee outputs of different sessions do not share the same address.
+//
+// The index of the next child key is stored in a file, so that addresses are
+// not reused after restarts.
+type HDKeySigner struct {
+	mtx       sync.Mutex
+	key       *hdkeychain.ExtendedKey
+	indexFile string
+	nextIndex uint32
+	net       *chaincfg.Params
+}
+
+// NewHDKeySigner creates a new HDKeySigner given an encoded extended private
+// key. The index of the next child key to use is read from (and stored into)
+// indexFile.
+func NewHDKeySigner(xpriv, indexFile string, net *chaincfg.Params) (*HDKeySigner, error) {
+	if xpriv == "" {
+		return nil, errors.Errorf("extended private key is empty")
+	}
+
+	key, err := hdkeychain.NewKeyFromString(xpriv)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding extended private key")
+	}
+	if !key.IsPrivate() {
+		return nil, errors.New("extended key is not a private key")
+	}
+	if !key.IsForNet(net) {
+		return nil, errors.New("extended private key is for wrong network")
+	}
+
+	signer := &HDKeySigner{
+		key:       key,
+		indexFile: indexFile,
+		net:       net,
+	}
+
+	data, err := ioutil.ReadFile(indexFile)
+	if err == nil {
+		var index uint64
+		index, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding key index file")
+		}
+		signer.nextIndex = uint32(index)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "error reading key index file")
+	}
+
+	return signer, nil
+}
+
+// NextIndex returns the index of the next child key that will be used.
+func (signer *HDKeySigner) NextIndex() uint32 {
+	signer.mtx.Lock()
+	defer signer.mtx.Unlock()
+	return signer.nextIndex
+}
+
+// KeyForIndex returns a signer for the child key of the given index.
+func (signer *HDKeySigner) KeyForIndex(index uint32) (*KeySigner, error) {
+	if index >= hdkeychain.HardenedKeyStart {
+		return nil, errors.Errorf("key index %d is not a valid child index",
+			index)
+	}
+
+	child, err := signer.key.Child(index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error deriving child key %d", index)
+	}
+
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error obtaining private key %d", index)
+	}
+
+	return newKeySigner(privKey, signer.net)
+}
+
+// PoolFeeAddress fulfills matcher.SignPoolSplitOutputProvider.PoolFeeAddress.
+// Each call derives the address of a new child key.
+func (signer *HDKeySigner) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	signer.mtx.Lock()
+	defer signer.mtx.Unlock()
+
+	for index := signer.nextIndex; index < hdkeychain.HardenedKeyStart; index++ {
+		child, err := signer.key.Child(index)
+		if err == hdkeychain.ErrInvalidChild {
+			continue
+		} else if err != nil {
+			return nil, 0, errors.Wrapf(err, "error deriving child key %d",
+				index)
+		}
+
+		addr, err := child.Address(signer.net)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "error obtaining address %d",
+				index)
+		}
+
+		if err = signer.storeNextIndex(index + 1); err != nil {
+			return nil, 0, err
+		}
+
+		return addr, index, nil
+	}
+
+	return nil, 0, errors.New("exhausted child keys of extended private key")
+}
+
+// storeNextIndex records the index of the next child key to use. Must be
+// called with the mutex held.
+func (signer *HDKeySigner) storeNextIndex(index uint32) error {
+	if signer.indexFile != "" {
+		err := os.MkdirAll(filepath.Dir(signer.indexFile), 0700)
+		if err != nil {
+			return errors.Wrap(err, "error creating key index dir")
+		}
+
+		data := []byte(strconv.FormatUint(uint64(index), 10))
+		err = ioutil.WriteFile(signer.indexFile, data, 0600)
+		if err != nil {
+			return errors.Wrap(err, "error writing key index file")
+		}
+	}
+
+	signer.nextIndex = index
+	return nil
+}
+
+// SignPoolSplitOutput fulfills matcher.SignPoolSplitOutputProvider.SignPoolSplitOutput.
+// It signs the pool fee input of the ticket with the child key of the given
+// index. Assumes the pool output is the second TxOut of the split tx.
+func (signer *HDKeySigner) SignPoolSplitOutput(split, ticket *wire.MsgTx,
+	keyIndex uint32) ([]byte, error) {
+
+	keySigner, err := signer.KeyForIndex(keyIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return keySigner.SignPoolSplitOutput(split, ticket, keyIndex)
+}
@@ -0,0 +1,103 @@
+package poolsigner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/hdkeychain"
+)
+
+func testHDKeySigner(t *testing.T, indexFile string) (*HDKeySigner, string) {
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	seed[0] = 0x01
+	master, err := hdkeychain.NewMaster(seed, _testNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewHDKeySigner(master.String(), indexFile, _testNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, master.String()
+}
+
+func TestHDKeySignerAddresses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poolsigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexFile := filepath.Join(dir, KeyIndexFilename)
+
+	signer, xpriv := testHDKeySigner(t, indexFile)
+
+	seen := make(map[string]struct{})
+	for i := uint32(0); i < 3; i++ {
+		addr, index, err := signer.PoolFeeAddress()
+		if err != nil {
+			t.Fatalf("unexpected error deriving address: %v", err)
+		}
+		if index != i {
+			t.Fatalf("unexpected key index %d (expected %d)", index, i)
+		}
+		if _, has := seen[addr.EncodeAddress()]; has {
+			t.Fatalf("address %s reused", addr.EncodeAddress())
+		}
+		seen[addr.EncodeAddress()] = struct{}{}
+
+		keySigner, err := signer.KeyForIndex(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keySigner.Address().EncodeAddress() != addr.EncodeAddress() {
+			t.Fatalf("key of index %d does not correspond to its address",
+				index)
+		}
+	}
+
+	// The next index is restored after restarting.
+	restored, err := NewHDKeySigner(xpriv, indexFile, _testNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.NextIndex() != 3 {
+		t.Fatalf("unexpected next index after restart %d",
+			restored.NextIndex())
+	}
+
+	// Extended public keys cannot be used to sign.
+	pub, err := signer.key.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewHDKeySigner(pub.String(), "", _testNetwork); err == nil {
+		t.Fatalf("created signer with an extended public key")
+	}
+}
+
+func TestHDKeySignerSignPoolSplitOutput(t *testing.T) {
+	d := testDaemon(t, 10)
+	hdSigner, _ := testHDKeySigner(t, "")
+	d.signer = hdSigner
+
+	// Skip the first address, so that the key index is relevant.
+	if _, _, err := hdSigner.PoolFeeAddress(); err != nil {
+		t.Fatal(err)
+	}
+	addr, index, err := hdSigner.PoolFeeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	split, ticket := testSessionTxs(t, addr, testAddress(t, 0x01))
+	if resp := signRequest(t, d, split, ticket, index); resp.Error != "" {
+		t.Fatalf("unexpected error signing ticket: %s", resp.Error)
+	}
+
+	if resp := signRequest(t, d, split, ticket, index-1); resp.Error == "" {
+		t.Fatalf("signed ticket with the key of a different index")
+	}
+}
@@ -0,0 +1,133 @@
+package poolsigner
+
+import (
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainec"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/pkg/errors"
+)
+
+// KeySigner fulfills matcher.SignPoolSplitOutputProvider by storing a single
+// secp256k1 private key and signing the pool fee always using it.
+type KeySigner struct {
+	privateKey chainec.PrivateKey
+	address    dcrutil.Address
+	net        *chaincfg.Params
+}
+
+// NewKeySigner creates a new KeySigner given a secp256k1 private key wif.
+func NewKeySigner(privateKeyWif string, net *chaincfg.Params) (*KeySigner, error) {
+	if privateKeyWif == "" {
+		return nil, errors.Errorf("private key wif is empty")
+	}
+
+	wif, err := dcrutil.DecodeWIF(privateKeyWif)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding private key wif")
+	}
+
+	if wif.DSA() != dcrec.STEcdsaSecp256k1 {
+		return nil, errors.Errorf("only a secp256k1 private key is acceptable")
+	}
+
+	return newKeySigner(wif.PrivKey, net)
+}
+
+// newKeySigner creates a new KeySigner for the given secp256k1 private key.
+func newKeySigner(privKey chainec.PrivateKey, net *chaincfg.Params) (*KeySigner, error) {
+	x, y := privKey.Public()
+
+	pubKey := secp256k1.NewPublicKey(x, y)
+	pubKeyAddr, err := dcrutil.NewAddressSecpPubKey(pubKey.Serialize(), net)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating secpPubKeyAddr from pubKey")
+	}
+
+	pkhAddr := pubKeyAddr.AddressPubKeyHash()
+
+	return &KeySigner{
+		address:    pkhAddr,
+		privateKey: privKey,
+		net:        net,
+	}, nil
+}
+
+// Address returns the p2pkh address of the key of the signer.
+func (signer *KeySigner) Address() dcrutil.Address {
+	return signer.address
+}
+
+// PoolFeeAddress fulfills matcher.SignPoolSplitOutputProvider.PoolFeeAddress.
+// The same address (with key index 0) is used for every session.
+func (signer *KeySigner) PoolFeeAddress() (dcrutil.Address, uint32, error) {
+	return signer.address, 0, nil
+}
+
+// SignPoolSplitOutput fulfills matcher.SignPoolSplitOutputProvider.SignPoolSplitOutput.
+// It uses the stored private key to sign the pool split output, regardless of
+// the key index. Assumes the pool output is the second TxOut of the split tx.
+func (signer *KeySigner) SignPoolSplitOutput(split, ticket *wire.MsgTx,
+	keyIndex uint32) ([]byte, error) {
+
+	if len(split.TxOut) < 2 {
+		return nil, errors.Errorf("split has less than 2 outputs")
+	}
+
+	return signer.signInput(ticket, 0, split.TxOut[1])
+}
+
+// signInput signs the input at the given index of tx, which spends the
+// provided output. The output must be a p2pkh output to the signer's address.
+func (signer *KeySigner) signInput(tx *wire.MsgTx, idx int,
+	prevOut *wire.TxOut) ([]byte, error) {
+
+	// Extract and print details from the script.
+	scriptClass, addresses, reqSigs, err := txscript.ExtractPkScriptAddrs(
+		prevOut.Version, prevOut.PkScript, signer.net)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding pool fee output PkScript")
+	}
+
+	if scriptClass != txscript.PubKeyHashTy {
+		return nil, errors.Errorf("script class is not PubKeyHashTy")
+	}
+
+	if reqSigs != 1 {
+		return nil, errors.Errorf("reqSigs different than 1")
+	}
+
+	if len(addresses) != 1 {
+		return nil, errors.Errorf("decoded a different number of addresses "+
+			"(%d) than expected (1)", len(addresses))
+	}
+
+	if addresses[0].EncodeAddress() != signer.address.EncodeAddress() {
+		return nil, errors.Errorf("decoded a different address (%s) than "+
+			"expected (%s)", addresses[0].EncodeAddress(),
+			signer.address.EncodeAddress())
+	}
+
+	lookupKey := func(a dcrutil.Address) (chainec.PrivateKey, bool, error) {
+		return signer.privateKey, true, nil
+	}
+
+	sigScript, err := txscript.SignTxOutput(signer.net,
+		tx, idx, prevOut.PkScript, txscript.SigHashAll,
+		txscript.KeyClosure(lookupKey), nil, nil,
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing input %d", idx)
+	}
+
+	return sigScript, nil
+}
+
+// KeyForIndex returns the signer itself, given the same key is used for every
+// key index.
+func (signer *KeySigner) KeyForIndex(index uint32) (*KeySigner, error) {
+	return signer, nil
+}
@@ -0,0 +1,170 @@
+package poolsigner
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/stake"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/poolsignerrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// PoolFeeAddress fullfill grpc service requirements
+func (d *Daemon) PoolFeeAddress(ctx context.Context,
+	req *pb.PoolFeeAddressRequest) (*pb.PoolFeeAddressResponse, error) {
+
+	resp := new(pb.PoolFeeAddressResponse)
+
+	if !d.addrLimiter.allow(time.Now()) {
+		resp.Error = "maximum number of pool fee addresses per hour reached"
+		d.log.Warnf("Refused to provide pool fee address: rate limit reached")
+		return resp, nil
+	}
+
+	addr, index, err := d.signer.PoolFeeAddress()
+	if err != nil {
+		resp.Error = err.Error()
+		d.log.Errorf("Error obtaining pool fee address: %s", err)
+		return resp, nil
+	}
+
+	d.log.Infof("Providing pool fee address %s (key index %d)",
+		addr.EncodeAddress(), index)
+	resp.Address = addr.EncodeAddress()
+	resp.KeyIndex = index
+
+	return resp, nil
+}
+
+// SignPoolSplitOutput fullfill grpc service requirements
+func (d *Daemon) SignPoolSplitOutput(ctx context.Context,
+	req *pb.SignPoolSplitOutputRequest) (*pb.SignPoolSplitOutputResponse, error) {
+
+	resp := new(pb.SignPoolSplitOutputResponse)
+
+	split := wire.NewMsgTx()
+	err := split.FromBytes(req.SplitTx)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding split tx").Error()
+		d.log.Warnf("Received sign request with undecodable split tx: %s", err)
+		return resp, nil
+	}
+
+	ticket := wire.NewMsgTx()
+	err = ticket.FromBytes(req.Ticket)
+	if err != nil {
+		resp.Error = errors.Wrap(err, "error decoding ticket").Error()
+		d.log.Warnf("Received sign request with undecodable ticket: %s", err)
+		return resp, nil
+	}
+	ticketHash := ticket.TxHash()
+
+	d.log.Infof("Received sign request for ticket %s", ticketHash)
+
+	err = d.checkSignRequest(split, ticket)
+	if err != nil {
+		resp.Error = err.Error()
+		d.log.Warnf("Refused to sign pool fee input of ticket %s: %s",
+			ticketHash, err)
+		return resp, nil
+	}
+
+	if !d.limiter.allow(time.Now()) {
+		resp.Error = "maximum number of signatures per hour reached"
+		d.log.Warnf("Refused to sign pool fee input of ticket %s: rate "+
+			"limit reached", ticketHash)
+		return resp, nil
+	}
+
+	sigScript, err := d.signer.SignPoolSplitOutput(split, ticket, req.KeyIndex)
+	if err != nil {
+		resp.Error = err.Error()
+		d.log.Warnf("Error signing pool fee input of ticket %s: %s",
+			ticketHash, err)
+		return resp, nil
+	}
+
+	d.log.Infof("Signed pool fee input of ticket %s (%s)", ticketHash,
+		dcrutil.Amount(split.TxOut[1].Value))
+	resp.SignatureScript = sigScript
+
+	return resp, nil
+}
+
+// checkSignRequest enforces the signing policy of the daemon: the transaction
+// to be signed must be a ticket that spends the outputs of the split tx and
+// commits the full pool fee output of the split tx to an address of the pool,
+// with an amount corresponding to the configured pool fee rate.
+func (d *Daemon) checkSignRequest(split, ticket *wire.MsgTx) error {
+	if !stake.IsSStx(ticket) {
+		return errors.New("transaction is not a ticket")
+	}
+
+	if len(split.TxOut) < 2 {
+		return errors.New("split tx does not have a pool fee output")
+	}
+
+	splitHash := split.TxHash()
+	for i, in := range ticket.TxIn {
+		outp := in.PreviousOutPoint
+		if outp.Hash != splitHash || outp.Tree != wire.TxTreeRegular ||
+			int(outp.Index) >= len(split.TxOut) {
+			return errors.Errorf("ticket input %d does not spend an output "+
+				"of the split tx", i)
+		}
+	}
+	if ticket.TxIn[0].PreviousOutPoint.Index != 1 {
+		return errors.New("ticket input 0 does not spend the pool fee output " +
+			"of the split tx")
+	}
+
+	poolScript := ticket.TxOut[1].PkScript
+	poolAddr, err := stake.AddrFromSStxPkScrCommitment(poolScript,
+		d.chainParams)
+	if err != nil {
+		return errors.Wrap(err, "error decoding pool fee commitment address")
+	}
+
+	err = d.poolAddrValidator.ValidatePoolSubsidyAddress(poolAddr)
+	if err != nil {
+		return errors.Wrapf(err, "ticket does not pay the pool fee to a "+
+			"pool address (%s)", poolAddr.EncodeAddress())
+	}
+
+	poolFee, err := stake.AmountFromSStxPkScrCommitment(poolScript)
+	if err != nil {
+		return errors.Wrap(err, "error decoding pool fee commitment amount")
+	}
+
+	if int64(poolFee) != split.TxOut[1].Value {
+		return errors.Errorf("pool fee commitment (%s) different than the "+
+			"pool fee output of the split tx (%s)", poolFee,
+			dcrutil.Amount(split.TxOut[1].Value))
+	}
+
+	return d.checkPoolFeeRate(split, ticket)
+}
+
+// checkPoolFeeRate checks whether the pool fee of the ticket corresponds to
+// the configured pool fee rate. The signer does not track the chain, so the
+// height of the session is bounded by the ticket expiry (see
+// splitticket.TargetTicketExpirationBlock) and the pool fee is accepted if it
+// is valid at either end of that range.
+func (d *Daemon) checkPoolFeeRate(split, ticket *wire.MsgTx) error {
+	if ticket.Expiry <= matcher.MaximumExpiry {
+		return errors.Errorf("ticket expiry (%d) is not valid for a session",
+			ticket.Expiry)
+	}
+
+	err := splitticket.CheckTicketPoolFeeRate(split, ticket, d.cfg.PoolFee,
+		ticket.Expiry-matcher.MaximumExpiry, d.chainParams)
+	if err != nil {
+		err = splitticket.CheckTicketPoolFeeRate(split, ticket, d.cfg.PoolFee,
+			ticket.Expiry, d.chainParams)
+	}
+	return err
+}
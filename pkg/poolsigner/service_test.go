@@ -0,0 +1,277 @@
+package poolsigner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/poolsignerrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+var _testNetwork = &chaincfg.TestNet3Params
+
+const (
+	_testPoolFeeRate = 7.5
+	_testHeight      = 300000
+)
+
+// mockPoolAddrValidator accepts only the addresses it has been created with.
+type mockPoolAddrValidator map[string]struct{}
+
+func (v mockPoolAddrValidator) ValidatePoolSubsidyAddress(addr dcrutil.Address) error {
+	if _, has := v[addr.EncodeAddress()]; !has {
+		return errors.New("unknown pool address")
+	}
+	return nil
+}
+
+func testAddress(t *testing.T, b byte) dcrutil.Address {
+	var hash [20]byte
+	hash[0] = b
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:], _testNetwork, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func testDaemon(t *testing.T, maxSignatures int) *Daemon {
+	privKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wif, err := dcrutil.NewWIF(privKey, _testNetwork, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewKeySigner(wif.String(), _testNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Daemon{
+		cfg:         &Config{PoolFee: _testPoolFeeRate},
+		log:         slog.Disabled,
+		chainParams: _testNetwork,
+		poolAddrValidator: mockPoolAddrValidator{
+			testAddress(t, 0x01).EncodeAddress(): {},
+		},
+		signer:      signer,
+		limiter:     newRateLimiter(maxSignatures, time.Hour),
+		addrLimiter: newRateLimiter(maxSignatures, time.Hour),
+	}
+}
+
+// testSessionTxs creates a split tx and ticket for a single participant
+// session, with the pool fee output of the split paying to the given address
+// and the pool fee commitment of the ticket paying to poolAddr.
+func testSessionTxs(t *testing.T, splitPoolAddr, poolAddr dcrutil.Address) (
+	*wire.MsgTx, *wire.MsgTx) {
+
+	ticketPrice := dcrutil.Amount(100e8)
+	feeRate := splitticket.TxFeeRate
+	fee := splitticket.SessionFeeEstimate(1, feeRate)
+	poolFee := splitticket.SessionPoolFee(1, ticketPrice, _testHeight,
+		_testPoolFeeRate, feeRate, _testNetwork)
+	partAmount := ticketPrice - poolFee + fee
+
+	mustScript := func(script []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return script
+	}
+
+	split := wire.NewMsgTx()
+	split.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		wire.NullValueIn, nil))
+	split.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	split.AddTxOut(wire.NewTxOut(int64(poolFee),
+		mustScript(txscript.PayToAddrScript(splitPoolAddr))))
+	split.AddTxOut(wire.NewTxOut(int64(partAmount),
+		mustScript(txscript.PayToAddrScript(testAddress(t, 0x02)))))
+	splitHash := split.TxHash()
+
+	ticket := wire.NewMsgTx()
+	ticket.Expiry = _testHeight + 16
+	ticket.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&splitHash, 1,
+		wire.TxTreeRegular), int64(poolFee), nil))
+	ticket.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&splitHash, 2,
+		wire.TxTreeRegular), int64(partAmount), nil))
+	ticket.AddTxOut(wire.NewTxOut(int64(ticketPrice),
+		mustScript(txscript.PayToSStx(testAddress(t, 0x03)))))
+	ticket.AddTxOut(wire.NewTxOut(0, mustScript(txscript.GenerateSStxAddrPush(
+		poolAddr, poolFee, splitticket.CommitmentLimits))))
+	ticket.AddTxOut(wire.NewTxOut(0,
+		mustScript(txscript.PayToSStxChange(testAddress(t, 0x00)))))
+	ticket.AddTxOut(wire.NewTxOut(0, mustScript(txscript.GenerateSStxAddrPush(
+		testAddress(t, 0x04), partAmount, splitticket.CommitmentLimits))))
+	ticket.AddTxOut(wire.NewTxOut(0,
+		mustScript(txscript.PayToSStxChange(testAddress(t, 0x00)))))
+
+	return split, ticket
+}
+
+func signRequest(t *testing.T, d *Daemon, split, ticket *wire.MsgTx,
+	keyIndex uint32) *pb.SignPoolSplitOutputResponse {
+
+	splitBytes, err := split.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticketBytes, err := ticket.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := d.SignPoolSplitOutput(context.Background(),
+		&pb.SignPoolSplitOutputRequest{SplitTx: splitBytes, Ticket: ticketBytes,
+			KeyIndex: keyIndex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSignPoolSplitOutput(t *testing.T) {
+	d := testDaemon(t, 10)
+	poolAddr := testAddress(t, 0x01)
+	split, ticket := testSessionTxs(t, d.signer.(*KeySigner).Address(), poolAddr)
+
+	resp := signRequest(t, d, split, ticket, 0)
+	if resp.Error != "" {
+		t.Fatalf("unexpected error signing valid ticket: %s", resp.Error)
+	}
+
+	// The signature must be valid for the pool fee input.
+	ticket.TxIn[0].SignatureScript = resp.SignatureScript
+	engine, err := txscript.NewEngine(split.TxOut[1].PkScript, ticket, 0,
+		txscript.ScriptVerifyCleanStack, split.TxOut[1].Version, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = engine.Execute(); err != nil {
+		t.Fatalf("invalid pool fee input signature: %v", err)
+	}
+}
+
+func TestSignPoolSplitOutputPolicy(t *testing.T) {
+	d := testDaemon(t, 10)
+	poolAddr := testAddress(t, 0x01)
+
+	tests := []struct {
+		name   string
+		modify func(split, ticket *wire.MsgTx)
+	}{
+		{"not a ticket", func(split, ticket *wire.MsgTx) {
+			ticket.TxOut = ticket.TxOut[:1]
+		}},
+		{"pool input from another tx", func(split, ticket *wire.MsgTx) {
+			ticket.TxIn[0].PreviousOutPoint.Hash = chainhash.Hash{0xff}
+		}},
+		{"input not from pool output", func(split, ticket *wire.MsgTx) {
+			ticket.TxIn[0].PreviousOutPoint.Index = 2
+			ticket.TxIn[1].PreviousOutPoint.Index = 1
+		}},
+		{"unknown pool address", func(split, ticket *wire.MsgTx) {
+			script, _ := txscript.GenerateSStxAddrPush(testAddress(t, 0x05),
+				dcrutil.Amount(split.TxOut[1].Value), splitticket.CommitmentLimits)
+			ticket.TxOut[1].PkScript = script
+		}},
+		{"pool commitment amount", func(split, ticket *wire.MsgTx) {
+			script, _ := txscript.GenerateSStxAddrPush(poolAddr,
+				dcrutil.Amount(split.TxOut[1].Value-1), splitticket.CommitmentLimits)
+			ticket.TxOut[1].PkScript = script
+		}},
+		{"pool fee rate", func(split, ticket *wire.MsgTx) {
+			d.cfg.PoolFee = 2
+		}},
+		{"invalid expiry", func(split, ticket *wire.MsgTx) {
+			ticket.Expiry = 0
+		}},
+	}
+
+	for _, tc := range tests {
+		d.cfg.PoolFee = _testPoolFeeRate
+		split, ticket := testSessionTxs(t, d.signer.(*KeySigner).Address(), poolAddr)
+		tc.modify(split, ticket)
+		resp := signRequest(t, d, split, ticket, 0)
+		if resp.Error == "" || len(resp.SignatureScript) > 0 {
+			t.Fatalf("%s: signed ticket that does not follow the policy",
+				tc.name)
+		}
+	}
+
+	// The pool fee output of the split must pay to the signer's address.
+	d.cfg.PoolFee = _testPoolFeeRate
+	split, ticket := testSessionTxs(t, testAddress(t, 0x06), poolAddr)
+	resp := signRequest(t, d, split, ticket, 0)
+	if resp.Error == "" {
+		t.Fatalf("signed pool fee output of a different address")
+	}
+}
+
+func TestSignPoolSplitOutputRateLimit(t *testing.T) {
+	d := testDaemon(t, 2)
+	split, ticket := testSessionTxs(t, d.signer.(*KeySigner).Address(),
+		testAddress(t, 0x01))
+
+	for i := 0; i < 2; i++ {
+		if resp := signRequest(t, d, split, ticket, 0); resp.Error != "" {
+			t.Fatalf("unexpected error on request %d: %s", i, resp.Error)
+		}
+	}
+
+	if resp := signRequest(t, d, split, ticket, 0); resp.Error == "" {
+		t.Fatalf("signed request above the rate limit")
+	}
+}
+
+func TestPoolFeeAddressRateLimit(t *testing.T) {
+	d := testDaemon(t, 2)
+
+	for i := 0; i < 2; i++ {
+		resp, err := d.PoolFeeAddress(context.Background(),
+			&pb.PoolFeeAddressRequest{})
+		if err != nil || resp.Error != "" || resp.Address == "" {
+			t.Fatalf("unexpected error on request %d: %v %s", i, err,
+				resp.Error)
+		}
+	}
+
+	resp, err := d.PoolFeeAddress(context.Background(),
+		&pb.PoolFeeAddressRequest{})
+	if err != nil || resp.Error == "" || resp.Address != "" {
+		t.Fatalf("provided address above the rate limit")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Hour)
+	now := time.Now()
+
+	if !l.allow(now) || !l.allow(now.Add(time.Minute)) {
+		t.Fatalf("rate limiter refused events below the limit")
+	}
+	if l.allow(now.Add(2 * time.Minute)) {
+		t.Fatalf("rate limiter allowed event above the limit")
+	}
+
+	// Events older than the window no longer count towards the limit.
+	if !l.allow(now.Add(time.Hour + time.Second)) {
+		t.Fatalf("rate limiter refused event after the window expired")
+	}
+	if l.allow(now.Add(time.Hour + 2*time.Second)) {
+		t.Fatalf("rate limiter allowed event above the limit")
+	}
+}
@@ -0,0 +1,199 @@
+package poolsigner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/slog"
+	pb "github.com/matheusd/dcr-split-ticket-matcher/pkg/api/poolsignerrpc"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/internal/util"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/matcher"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/version"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Daemon is the structure that defines a pool signer daemon. It holds the
+// private key for the pool fee output of split transactions and only signs
+// tickets that pass its policy checks, such that a compromised matcher host
+// cannot sign arbitrary spends of the pool fee funds.
+type Daemon struct {
+	cfg               *Config
+	log               slog.Logger
+	rpcKeys           *tls.Certificate
+	clientCAs         *x509.CertPool
+	chainParams       *chaincfg.Params
+	poolAddrValidator matcher.PoolAddressValidationProvider
+	signer            Signer
+	limiter           *rateLimiter
+	addrLimiter       *rateLimiter
+}
+
+// NewDaemon initializes a new pool signer daemon
+func NewDaemon(cfg *Config) (*Daemon, error) {
+	logBackend := util.StandardLogBackend(true, cfg.LogDir, "stmpoolsigner-{date}-{time}.log")
+	log := logBackend.Logger("SIGN")
+	log.SetLevel(cfg.LogLevel)
+
+	log.Criticalf("Split Ticket Matcher / Pool signer v%s", version.String())
+
+	chainParams := &chaincfg.MainNetParams
+	if cfg.TestNet {
+		chainParams = &chaincfg.TestNet3Params
+	} else if cfg.SimNet {
+		chainParams = &chaincfg.SimNetParams
+	}
+
+	cert, err := util.LoadRPCKeyPair(cfg.KeyFile, cfg.CertFile)
+	if err == util.ErrKeyPairCreated {
+		log.Infof("Created RPC keypair with cert '%s' and key '%s'",
+			cfg.CertFile, cfg.KeyFile)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error loading rpc key pair")
+	}
+
+	clientCAs, err := loadClientCAs(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	poolAddrValidator, err := util.NewMasterPubPoolAddrValidator(
+		cfg.PoolSubsidyWalletMasterPub, cfg.PoolSubsidyGapLimit,
+		filepath.Join(cfg.DataDir, util.PoolAddrIndexFilename), log,
+		chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing pool fee address table")
+	}
+
+	signer, err := NewSigner(cfg.SplitPoolSignKey, cfg.SplitPoolSignXPriv,
+		filepath.Join(cfg.DataDir, KeyIndexFilename), chainParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing pool fee signer")
+	}
+	log.Infof("Requiring pool fee of %.2f%%", cfg.PoolFee)
+	log.Infof("Signing at most %d pool fee inputs per hour",
+		cfg.MaxSignaturesPerHour)
+	log.Infof("Providing at most %d pool fee addresses per hour",
+		cfg.MaxAddressesPerHour)
+
+	d := &Daemon{
+		cfg:               cfg,
+		log:               log,
+		rpcKeys:           cert,
+		clientCAs:         clientCAs,
+		chainParams:       chainParams,
+		poolAddrValidator: poolAddrValidator,
+		signer:            signer,
+		limiter:           newRateLimiter(cfg.MaxSignaturesPerHour, rateLimitWindow),
+		addrLimiter:       newRateLimiter(cfg.MaxAddressesPerHour, rateLimitWindow),
+	}
+
+	return d, nil
+}
+
+// Signer is the interface for the signers of the pool fee input of tickets that
+// hold the private keys of the pool fee outputs.
+type Signer interface {
+	matcher.SignPoolSplitOutputProvider
+
+	// KeyForIndex returns a signer for the key of the given index.
+	KeyForIndex(index uint32) (*KeySigner, error)
+}
+
+// NewSigner returns the signer for the pool fee inputs given either an
+// extended private key (from which a different key is derived for each
+// session) or a single private key wif. The index of the next key derived
+// from xpriv is stored in indexFile.
+func NewSigner(wif, xpriv, indexFile string, net *chaincfg.Params) (Signer, error) {
+	if xpriv != "" {
+		return NewHDKeySigner(xpriv, indexFile, net)
+	}
+	return NewKeySigner(wif, net)
+}
+
+// loadClientCAs loads the certificates used to authenticate the clients of the
+// daemon.
+func loadClientCAs(fname string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading client CA file")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in client CA file %s",
+			fname)
+	}
+
+	return pool, nil
+}
+
+// ListenAndServe blocks execution by opening the appropriate listening sockets
+// and responding to signing requests. Only clients presenting a certificate
+// signed by one of the configured client CAs are accepted.
+func (d *Daemon) ListenAndServe() error {
+	intf := fmt.Sprintf(":%d", d.cfg.Port)
+
+	lis, err := net.Listen("tcp", intf)
+	if err != nil {
+		d.log.Errorf("Error listening: %v", err)
+		return err
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{*d.rpcKeys},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    d.clientCAs,
+	})
+	server := grpc.NewServer(grpc.Creds(creds))
+
+	pb.RegisterPoolSignerServiceServer(server, d)
+
+	d.log.Criticalf("Listening on %s", intf)
+	return server.Serve(lis)
+}
+
+// rateLimiter limits the number of events accepted within a sliding time
+// window.
+type rateLimiter struct {
+	mtx    sync.Mutex
+	max    int
+	window time.Duration
+	events []time.Time
+}
+
+func newRateLimiter(max int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		max:    max,
+		window: window,
+	}
+}
+
+// allow returns true and records a new event at the given time if doing so
+// does not exceed the limit of events within the window.
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	cutoff := now.Add(-l.window)
+	expired := 0
+	for expired < len(l.events) && !l.events[expired].After(cutoff) {
+		expired++
+	}
+	l.events = l.events[expired:]
+
+	if len(l.events) >= l.max {
+		return false
+	}
+
+	l.events = append(l.events, now)
+	return true
+}
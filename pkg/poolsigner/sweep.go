@@ -0,0 +1,206 @@
+package poolsigner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrwallet/wallet/txrules"
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+	"github.com/pkg/errors"
+)
+
+// PoolFeeOutput is the pool fee output of the split tx of a session, as
+// recorded in the session files saved by the matcher.
+type PoolFeeOutput struct {
+	Session  string
+	OutPoint wire.OutPoint
+	Output   *wire.TxOut
+	KeyIndex uint32
+
+	// FeeRate is the fee rate (in Atoms/KB) negotiated for the session or
+	// zero if it was not recorded.
+	FeeRate dcrutil.Amount
+}
+
+// ReadSessionPoolFeeOutput reads the pool fee output (and the index of the key
+// that controls it) of the split tx recorded in the given session file.
+// Sessions recorded before key indices were stored use the key index 0.
+func ReadSessionPoolFeeOutput(fname string) (*PoolFeeOutput, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening session file")
+	}
+	defer f.Close()
+
+	res := &PoolFeeOutput{Session: filepath.Base(fname)}
+	var split *wire.MsgTx
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Pool Fee Key Index = "):
+			var index uint64
+			index, err = strconv.ParseUint(strings.TrimPrefix(line,
+				"Pool Fee Key Index = "), 10, 32)
+			if err != nil {
+				return nil, errors.Wrap(err, "error decoding pool fee key index")
+			}
+			res.KeyIndex = uint32(index)
+
+		case strings.HasPrefix(line, "Negotiated Fee Rate = "):
+			var feeRate float64
+			feeRate, err = strconv.ParseFloat(strings.TrimSuffix(
+				strings.TrimPrefix(line, "Negotiated Fee Rate = "),
+				" DCR/KB"), 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "error decoding fee rate")
+			}
+			res.FeeRate, err = dcrutil.NewAmount(feeRate)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid fee rate")
+			}
+
+		case line == "== Split Transaction ==" && split == nil && scanner.Scan():
+			var splitBytes []byte
+			splitBytes, err = hex.DecodeString(scanner.Text())
+			if err != nil {
+				return nil, errors.Wrap(err, "error decoding split tx hex")
+			}
+			split = wire.NewMsgTx()
+			if err = split.FromBytes(splitBytes); err != nil {
+				return nil, errors.Wrap(err, "error decoding split tx")
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading session file")
+	}
+
+	if split == nil {
+		return nil, errors.New("session file does not contain a split tx")
+	}
+	if len(split.TxOut) < 2 {
+		return nil, errors.New("split tx does not have a pool fee output")
+	}
+
+	splitHash := split.TxHash()
+	res.OutPoint = *wire.NewOutPoint(&splitHash, 1, wire.TxTreeRegular)
+	res.Output = split.TxOut[1]
+	return res, nil
+}
+
+// FindStuckPoolFeeOutputs reads the session files in sessionsDir and returns
+// the pool fee outputs that have not been spent by their ticket (eg. because
+// the ticket was never mined), according to isUnspent. Session files that
+// can't be read are logged and skipped.
+func FindStuckPoolFeeOutputs(sessionsDir string, log slog.Logger,
+	isUnspent func(outp *wire.OutPoint) (bool, error)) ([]*PoolFeeOutput, error) {
+
+	files, err := ioutil.ReadDir(sessionsDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing sessions dir")
+	}
+
+	var res []*PoolFeeOutput
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		var out *PoolFeeOutput
+		out, err = ReadSessionPoolFeeOutput(filepath.Join(sessionsDir, f.Name()))
+		if err != nil {
+			log.Warnf("Skipping session %s: %v", f.Name(), err)
+			continue
+		}
+
+		var unspent bool
+		unspent, err = isUnspent(&out.OutPoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error checking pool fee output of "+
+				"session %s", f.Name())
+		}
+		if unspent {
+			res = append(res, out)
+		}
+	}
+
+	return res, nil
+}
+
+// SweepFeeRate returns the fee rate (in Atoms/KB) to use when sweeping the
+// given outputs: the highest fee rate negotiated in their sessions. Sessions
+// recorded before fee rates were negotiated used splitticket.TxFeeRate.
+func SweepFeeRate(outputs []*PoolFeeOutput) dcrutil.Amount {
+	var res dcrutil.Amount
+	for _, out := range outputs {
+		feeRate := out.FeeRate
+		if feeRate == 0 {
+			feeRate = splitticket.TxFeeRate
+		}
+		if feeRate > res {
+			res = feeRate
+		}
+	}
+	return res
+}
+
+// CreateSweepTx creates a transaction that spends the given pool fee outputs
+// into destAddr, signed with the keys of the signer.
+func CreateSweepTx(outputs []*PoolFeeOutput, signer Signer,
+	destAddr dcrutil.Address, feeRate dcrutil.Amount) (*wire.MsgTx, error) {
+
+	if len(outputs) == 0 {
+		return nil, errors.New("no outputs to sweep")
+	}
+
+	pkScript, err := txscript.PayToAddrScript(destAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating sweep pkscript")
+	}
+
+	tx := wire.NewMsgTx()
+	var total dcrutil.Amount
+	for _, out := range outputs {
+		outp := out.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&outp, out.Output.Value, nil))
+		total += dcrutil.Amount(out.Output.Value)
+	}
+
+	size := 12 + 3 + 3 + 3 + // tx header + varints
+		len(outputs)*splitticket.SplitTxInputSize + splitticket.SplitTxOutputSize
+	fee := txrules.FeeForSerializeSize(feeRate, size)
+	if txrules.IsDustAmount(total-fee, len(pkScript), feeRate) {
+		return nil, errors.Errorf("swept amount (%s) is dust", total-fee)
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(total-fee), pkScript))
+
+	for i, out := range outputs {
+		var keySigner *KeySigner
+		keySigner, err = signer.KeyForIndex(out.KeyIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		var sigScript []byte
+		sigScript, err = keySigner.signInput(tx, i, out.Output)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error signing pool fee output of "+
+				"session %s", out.Session)
+		}
+		tx.TxIn[i].SignatureScript = sigScript
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,131 @@
+package poolsigner
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/txscript"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/slog"
+	"github.com/matheusd/dcr-split-ticket-matcher/pkg/splitticket"
+)
+
+// writeTestSession writes a session file in the format of the matcher's
+// session records with the given split tx, pool fee key index and negotiated
+// fee rate (not recorded if zero).
+func writeTestSession(t *testing.T, dir string, split *wire.MsgTx,
+	keyIndex uint32, feeRate dcrutil.Amount) {
+
+	splitBytes, err := split.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feeRateLine string
+	if feeRate > 0 {
+		feeRateLine = fmt.Sprintf("Negotiated Fee Rate = %s/KB\n", feeRate)
+	}
+
+	content := fmt.Sprintf("====== General Info ======\n"+
+		"Session ID = 0001\n"+
+		"Pool Fee = %d\n"+
+		"Pool Fee Key Index = %d\n"+
+		"%s\n"+
+		"====== Final Transactions ======\n"+
+		"== Split Transaction ==\n"+
+		"%s\n\n"+
+		"== Ticket ==\n"+
+		"00\n\n", split.TxOut[1].Value, keyIndex, feeRateLine,
+		hex.EncodeToString(splitBytes))
+
+	fname := filepath.Join(dir, split.TxHash().String())
+	if err = ioutil.WriteFile(fname, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSweepPoolFeeOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poolsigner-sweep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	signer, _ := testHDKeySigner(t, "")
+	poolAddr := testAddress(t, 0x01)
+
+	// Three sessions, the second of which had its pool fee output spent by
+	// the ticket. Only the first one recorded its fee rate.
+	feeRates := []dcrutil.Amount{2e5, 3e5, 0}
+	var splits []*wire.MsgTx
+	for i := 0; i < 3; i++ {
+		addr, index, err := signer.PoolFeeAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		split, _ := testSessionTxs(t, addr, poolAddr)
+		split.TxIn[0].PreviousOutPoint.Index = uint32(i)
+		writeTestSession(t, dir, split, index, feeRates[i])
+		splits = append(splits, split)
+	}
+
+	// Unparsable session files are skipped.
+	err = ioutil.WriteFile(filepath.Join(dir, "broken"), []byte("== Split "+
+		"Transaction ==\nxx\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spent := splits[1].TxHash()
+	stuck, err := FindStuckPoolFeeOutputs(dir, slog.Disabled, func(outp *wire.OutPoint) (bool, error) {
+		return outp.Hash != spent, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error finding stuck outputs: %v", err)
+	}
+	if len(stuck) != 2 {
+		t.Fatalf("unexpected number of stuck outputs %d", len(stuck))
+	}
+
+	if stuck[0].FeeRate+stuck[1].FeeRate != feeRates[0] {
+		t.Fatalf("unexpected fee rates of stuck outputs")
+	}
+	feeRate := SweepFeeRate(stuck)
+	if feeRate != feeRates[0] {
+		t.Fatalf("unexpected sweep fee rate %s", feeRate)
+	}
+
+	destAddr := testAddress(t, 0x07)
+	tx, err := CreateSweepTx(stuck, signer, destAddr, feeRate)
+	if err != nil {
+		t.Fatalf("unexpected error creating sweep tx: %v", err)
+	}
+
+	var total int64
+	for i, out := range stuck {
+		total += out.Output.Value
+		engine, err := txscript.NewEngine(out.Output.PkScript, tx, i,
+			txscript.ScriptVerifyCleanStack, out.Output.Version, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = engine.Execute(); err != nil {
+			t.Fatalf("invalid signature on input %d: %v", i, err)
+		}
+	}
+
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value >= total {
+		t.Fatalf("unexpected sweep tx outputs")
+	}
+
+	// Signing with the wrong key index fails.
+	stuck[0].KeyIndex++
+	if _, err = CreateSweepTx(stuck, signer, destAddr, splitticket.TxFeeRate); err == nil {
+		t.Fatalf("created sweep tx with wrong key index")
+	}
+}
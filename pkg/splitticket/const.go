@@ -27,9 +27,16 @@ const (
 	// this should be reasonable.
 	MaximumSplitInputs = 20
 
-	// TxFeeRate is the expected transaction fee rate for the split and ticket
-	// transactions of participants of split tickets.
+	// TxFeeRate is the default transaction fee rate for the split and ticket
+	// transactions of participants of split tickets. The fee rate actually
+	// used in a session is negotiated between the matcher and buyers, and
+	// this is used when the matcher does not specify one.
 	//
 	// Measured as Atoms/KB. 1e5 = 0.001 DCR
 	TxFeeRate dcrutil.Amount = 1e5
+
+	// MinTxFeeRate is the minimum fee rate (in Atoms/KB) that may be
+	// negotiated for a session. Transactions paying less than this are not
+	// relayed by the network.
+	MinTxFeeRate dcrutil.Amount = 1e4
 )
@@ -26,6 +26,67 @@ const (
 		8 + 2 + 1 + 26 // Stake Change TxOut = amount + version + script
 )
 
+const (
+	// SplitTxCommonSize is the size estimate for the parts of the split
+	// transaction that are shared by all participants: the tx header, the
+	// voter lottery commitment output and the pool fee output. Input and
+	// output counts are assumed to use 3 byte varints.
+	SplitTxCommonSize = 12 + // tx header prefix (sertype, version, locktime, expiry)
+		3 + 3 + 3 + // input count varint + output count varint + witness varint
+		8 + 2 + 1 + VoterLotteryPkScriptSize + // lottery commitment TxOut = amount + version + script
+		8 + 2 + 1 + 25 // pool fee TxOut = amount + version + script
+
+	// SplitTxInputSize is the size estimate for each P2PKH input that a
+	// participant adds to the split transaction.
+	SplitTxInputSize = 32 + 4 + 1 + 4 + // TxIn NonWitness = Outpoint hash + Index + tree + Sequence
+		8 + 4 + 4 + // TxIn Witness = Amount + Block Height + Block Index
+		1 + 108 // TxIn len(ScriptSig) + ScriptSig
+
+	// SplitTxOutputSize is the size estimate for each P2PKH output that a
+	// participant adds to the split transaction (its ticket funding output and
+	// optional change).
+	SplitTxOutputSize = 8 + 2 + 1 + 25 // amount + version + script
+)
+
+// feeForSize returns the fee for a transaction (or part of one) with the given
+// size, at the given fee rate (in Atoms/KB).
+func feeForSize(size int, txFeeRate dcrutil.Amount) dcrutil.Amount {
+	return dcrutil.Amount(math.Ceil(float64(size) * float64(txFeeRate) / 1000))
+}
+
+// SplitTxParticipantSize returns the size estimate for the inputs and outputs
+// that a single participant adds to the split transaction.
+func SplitTxParticipantSize(numInputs int, hasChange bool) int {
+	size := numInputs*SplitTxInputSize + SplitTxOutputSize
+	if hasChange {
+		size += SplitTxOutputSize
+	}
+	return size
+}
+
+// SplitTxCommonFeeShare returns the share of the fee for the common parts of
+// the split transaction (see SplitTxCommonSize) that each participant of a
+// session with the given number of participants should pay.
+func SplitTxCommonFeeShare(numParticipants int, txFeeRate dcrutil.Amount) dcrutil.Amount {
+	shareSize := (SplitTxCommonSize + numParticipants - 1) / numParticipants
+	return feeForSize(shareSize, txFeeRate)
+}
+
+// SplitTxParticipantFee returns the fee that a participant should contribute
+// to the split transaction of a session with the given number of
+// participants: the fee for its own inputs and outputs, plus its share of the
+// fee for the common outputs.
+//
+// The sum of the fees of all participants is guaranteed to be at least the
+// fee required for the full split transaction at the given fee rate.
+func SplitTxParticipantFee(numParticipants, numInputs int, hasChange bool,
+	txFeeRate dcrutil.Amount) dcrutil.Amount {
+
+	shareSize := (SplitTxCommonSize + numParticipants - 1) / numParticipants
+	size := SplitTxParticipantSize(numInputs, hasChange) + shareSize
+	return feeForSize(size, txFeeRate)
+}
+
 // TicketSizeEstimate returns the size estimate for the ticket transaction for
 // the given number of participants
 func TicketSizeEstimate(numParticipants int) int {
@@ -35,9 +96,10 @@ func TicketSizeEstimate(numParticipants int) int {
 }
 
 // SessionParticipantFee returns the fee that a single participant of a ticket
-// split tx with the given number of participants should pay
-func SessionParticipantFee(numParticipants int) dcrutil.Amount {
-	feeRate := float64(TxFeeRate) / 1e11 // 1e11 = 1e8 * 1e3
+// split tx with the given number of participants should pay, given the fee
+// rate (in Atoms/KB) of the session.
+func SessionParticipantFee(numParticipants int, txFeeRate dcrutil.Amount) dcrutil.Amount {
+	feeRate := float64(txFeeRate) / 1e11 // 1e11 = 1e8 * 1e3
 	txSize := TicketSizeEstimate(numParticipants)
 	ticketFee, _ := dcrutil.NewAmount(float64(txSize) * feeRate)
 	partFee := dcrutil.Amount(math.Ceil(float64(ticketFee) / float64(numParticipants)))
@@ -49,16 +111,17 @@ func SessionParticipantFee(numParticipants int) dcrutil.Amount {
 //
 // Note that the calculation is done from SessionParticipantFee in order to be
 // certain that all participants will pay an integer and equal amount of fees.
-func SessionFeeEstimate(numParticipants int) dcrutil.Amount {
-	return SessionParticipantFee(numParticipants) * dcrutil.Amount(numParticipants)
+func SessionFeeEstimate(numParticipants int, txFeeRate dcrutil.Amount) dcrutil.Amount {
+	return SessionParticipantFee(numParticipants, txFeeRate) * dcrutil.Amount(numParticipants)
 }
 
 // SessionPoolFee returns the estimate for pool fee contribution for a split
 // ticket session, given the parameters.
 func SessionPoolFee(numParticipants int, ticketPrice dcrutil.Amount,
-	blockHeight int, poolFeePerc float64, net *chaincfg.Params) dcrutil.Amount {
+	blockHeight int, poolFeePerc float64, txFeeRate dcrutil.Amount,
+	net *chaincfg.Params) dcrutil.Amount {
 
-	ticketTxFee := SessionFeeEstimate(numParticipants)
+	ticketTxFee := SessionFeeEstimate(numParticipants, txFeeRate)
 	minPoolFee := txrules.StakePoolTicketFee(ticketPrice, ticketTxFee, int32(blockHeight),
 		poolFeePerc, net)
 	return minPoolFee
@@ -69,10 +132,10 @@ func SessionPoolFee(numParticipants int, ticketPrice dcrutil.Amount,
 // contribution amount.
 func CheckParticipantSessionPoolFee(numParticipants int, ticketPrice dcrutil.Amount,
 	contribAmount, partPoolFee, partFee dcrutil.Amount, blockHeight int,
-	poolFeePerc float64, net *chaincfg.Params) error {
+	poolFeePerc float64, txFeeRate dcrutil.Amount, net *chaincfg.Params) error {
 
 	poolFee := SessionPoolFee(numParticipants, ticketPrice, blockHeight,
-		poolFeePerc, net)
+		poolFeePerc, txFeeRate, net)
 	contribPerc := float64(contribAmount+partFee) / float64(ticketPrice-poolFee)
 	partPoolFeePerc := float64(partPoolFee) / float64(poolFee)
 	if partPoolFeePerc-contribPerc > 0.0001 {
@@ -13,7 +13,7 @@ func TestEqualTxFees(t *testing.T) {
 	maxParts := stake.MaxInputsPerSStx - 1
 
 	for p := 1; p <= maxParts; p++ {
-		fee := SessionFeeEstimate(p)
+		fee := SessionFeeEstimate(p, TxFeeRate)
 		if (fee % dcrutil.Amount(p)) != 0 {
 			t.Errorf("Session fee for %d participants is not equally divided", p)
 		}
@@ -45,7 +45,7 @@ func TestFeeEstimation(t *testing.T) {
 		tx.AddTxOut(commitOutTempl)
 		tx.AddTxOut(changeOutTempl)
 
-		feeEstimate := SessionFeeEstimate(i)
+		feeEstimate := SessionFeeEstimate(i, TxFeeRate)
 
 		txSize := dcrutil.Amount(tx.SerializeSize())
 		minFee := (txSize * relayFeeRate) / dcrutil.Amount(1000)
@@ -62,6 +62,63 @@ func TestFeeEstimation(t *testing.T) {
 
 }
 
+func TestSplitTxFeeEstimation(t *testing.T) {
+	maxParts := stake.MaxInputsPerSStx - 1
+	maxInputs := 3
+
+	p2pkhScript := make([]byte, 1+1+1+20+1+1)
+	lotteryScript := make([]byte, VoterLotteryPkScriptSize)
+	nullHash := &chainhash.Hash{}
+	fullSigScript := make([]byte, 1+73+1+33)
+	txInTempl := wire.NewTxIn(wire.NewOutPoint(nullHash, 0, 0), 0, fullSigScript)
+	outTempl := wire.NewTxOut(0, p2pkhScript)
+
+	for nbParts := 1; nbParts <= maxParts; nbParts++ {
+		tx := wire.NewMsgTx()
+		tx.AddTxOut(wire.NewTxOut(0, lotteryScript))
+		tx.AddTxOut(outTempl)
+
+		var totalFee, selfFee dcrutil.Amount
+		for i := 0; i < nbParts; i++ {
+			// vary the number of inputs and change outputs among participants
+			nbInputs := i%maxInputs + 1
+			hasChange := i%2 == 0
+			for j := 0; j < nbInputs; j++ {
+				tx.AddTxIn(txInTempl)
+			}
+			tx.AddTxOut(outTempl)
+			if hasChange {
+				tx.AddTxOut(outTempl)
+			}
+
+			totalFee += SplitTxParticipantFee(nbParts, nbInputs, hasChange, TxFeeRate)
+			if i == 0 {
+				selfFee = SplitTxParticipantFee(nbParts, nbInputs, hasChange, TxFeeRate)
+			}
+		}
+
+		txSize := dcrutil.Amount(tx.SerializeSize())
+		minFee := (txSize * TxFeeRate) / dcrutil.Amount(1000)
+		maxFee := minFee * 102 / 100
+		if totalFee < minFee {
+			t.Fatalf("split fee for %d participants (%s) less than minimum "+
+				"required (%s - tx size %d)", nbParts, totalFee, minFee, txSize)
+		} else if totalFee > maxFee {
+			t.Fatalf("split fee for %d participants (%s) more than maximum "+
+				"allowed (%s - tx size %d)", nbParts, totalFee, maxFee, txSize)
+		}
+
+		// a participant never pays for the full common outputs if there are
+		// other participants
+		soloFee := SplitTxParticipantFee(1, 1, true, TxFeeRate)
+		if nbParts > 1 && selfFee >= soloFee {
+			t.Fatalf("participant of a session with %d participants paying "+
+				"(%s) as much as a single participant (%s)", nbParts, selfFee,
+				soloFee)
+		}
+	}
+}
+
 func TestCheckParticipantSessionPoolFee(t *testing.T) {
 
 	nbParts := 63
@@ -70,14 +127,14 @@ func TestCheckParticipantSessionPoolFee(t *testing.T) {
 	poolFeeRate := 5.0
 
 	totalPoolFee := SessionPoolFee(nbParts, ticketPrice, blockHeight, poolFeeRate,
-		_testNetwork)
+		TxFeeRate, _testNetwork)
 
 	contribAmount := dcrutil.Amount(float64(ticketPrice) * 0.1)
 	partPoolFee := dcrutil.Amount(float64(totalPoolFee) * 0.1)
 	partFee, _ := dcrutil.NewAmount(0.001)
 
 	err := CheckParticipantSessionPoolFee(nbParts, ticketPrice, contribAmount,
-		partPoolFee, partFee, blockHeight, poolFeeRate, _testNetwork)
+		partPoolFee, partFee, blockHeight, poolFeeRate, TxFeeRate, _testNetwork)
 	if err != nil {
 		t.Fatalf("Correct participant pool fee should not return the following "+
 			"error: %v", err)
@@ -85,7 +142,7 @@ func TestCheckParticipantSessionPoolFee(t *testing.T) {
 
 	partPoolFee += 1000
 	err = CheckParticipantSessionPoolFee(nbParts, ticketPrice, contribAmount,
-		partPoolFee, partFee, blockHeight, poolFeeRate, _testNetwork)
+		partPoolFee, partFee, blockHeight, poolFeeRate, TxFeeRate, _testNetwork)
 	if err == nil {
 		t.Fatalf("Pool fee higher than contribution amount should have " +
 			"returned an error")
@@ -18,9 +18,9 @@ const (
 	RedeemPoolVotingScriptSize = 1 + 73 + 1 + 73
 )
 
-// RevocationFeeRate is the fee rate in Atoms/KB of the revocation tx for a
-// given network.
-func RevocationFeeRate(params *chaincfg.Params) dcrutil.Amount {
+// RevocationFeeRate is the fee rate in Atoms/KB of the revocation tx of a
+// session with the given (negotiated) fee rate, for a given network.
+func RevocationFeeRate(feeRate dcrutil.Amount, params *chaincfg.Params) dcrutil.Amount {
 	if params.Name == "simnet" {
 		// due to very low ticket prices in simnet, we need to use a very small
 		// revocation. This shouldn't be a problem since in simnet the
@@ -28,13 +28,14 @@ func RevocationFeeRate(params *chaincfg.Params) dcrutil.Amount {
 		return 1e4
 	}
 
-	return TxFeeRate
+	return feeRate
 }
 
 // CheckRevocation checks whether the revocation for the given ticket respects
-// the rules for split ticket buying. The ticket must have passed the
-// CheckTicket function.
-func CheckRevocation(ticket, revocation *wire.MsgTx, params *chaincfg.Params) error {
+// the rules for split ticket buying, given the fee rate negotiated for the
+// session. The ticket must have passed the CheckTicket function.
+func CheckRevocation(ticket, revocation *wire.MsgTx, sessFeeRate dcrutil.Amount,
+	params *chaincfg.Params) error {
 	// ensure this looks like a decred transaction
 	err := blockchain.CheckTransactionSanity(revocation, params)
 	if err != nil {
@@ -122,7 +123,7 @@ func CheckRevocation(ticket, revocation *wire.MsgTx, params *chaincfg.Params) er
 	}
 	fee := amountIn - amountOut
 	serializedSize := int64(revocation.SerializeSize())
-	feeRate := RevocationFeeRate(params)
+	feeRate := RevocationFeeRate(sessFeeRate, params)
 	minFee := dcrutil.Amount((serializedSize * int64(feeRate)) / 1000)
 	if fee < minFee {
 		return errors.Errorf("revocation fee (%s) less than minimum required "+
@@ -59,7 +59,7 @@ func TestCreateUnsignedRevocation(t *testing.T) {
 		ticketHash := ticket.TxHash() // the hash is actually irrelevant
 
 		revocation, err := CreateUnsignedRevocation(&ticketHash, ticket,
-			RevocationFeeRate(_testNetwork))
+			RevocationFeeRate(TxFeeRate, _testNetwork))
 		if err != nil {
 			t.Errorf("CreateUnsignedRevocation returned error on "+
 				"part %d: %v", p, err)
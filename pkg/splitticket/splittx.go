@@ -81,9 +81,12 @@ func CheckSplit(split *wire.MsgTx, utxos UtxoMap,
 }
 
 // CheckSignedSplit validates that the given signed split transaction is
-// valid according to split ticket matcher rules. Only safe to be called on
+// valid according to split ticket matcher rules and pays fees according to the
+// fee rate (in Atoms/KB) negotiated for the session. Only safe to be called on
 // split transactions that passed CheckSplit
-func CheckSignedSplit(split *wire.MsgTx, utxos UtxoMap, params *chaincfg.Params) error {
+func CheckSignedSplit(split *wire.MsgTx, utxos UtxoMap, txFeeRate dcrutil.Amount,
+	params *chaincfg.Params) error {
+
 	var totalAmountIn int64
 	for i, in := range split.TxIn {
 		utxo, hasUtxo := utxos[in.PreviousOutPoint]
@@ -122,7 +125,7 @@ func CheckSignedSplit(split *wire.MsgTx, utxos UtxoMap, params *chaincfg.Params)
 	txFee := totalAmountIn - int64(totalAmountOut)
 
 	serializedSize := int64(split.SerializeSize())
-	minFee := (serializedSize * int64(TxFeeRate)) / 1000
+	minFee := (serializedSize * int64(txFeeRate)) / 1000
 	if txFee < minFee {
 		return errors.Errorf("split tx fee (%s) less than minimum required "+
 			"amount (%s)", dcrutil.Amount(txFee), dcrutil.Amount(minFee))
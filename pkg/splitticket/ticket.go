@@ -208,8 +208,12 @@ func CheckTicket(split, ticket *wire.MsgTx, ticketPrice,
 }
 
 // CheckSignedTicket validates whether the given signed ticket can be spent
-// on the network. Only safe to be called on tickets that passed CheckTicket().
-func CheckSignedTicket(split, ticket *wire.MsgTx, params *chaincfg.Params) error {
+// on the network and pays fees according to the fee rate (in Atoms/KB)
+// negotiated for the session. Only safe to be called on tickets that passed
+// CheckTicket().
+func CheckSignedTicket(split, ticket *wire.MsgTx, txFeeRate dcrutil.Amount,
+	params *chaincfg.Params) error {
+
 	for i, in := range ticket.TxIn {
 		out := split.TxOut[in.PreviousOutPoint.Index]
 
@@ -235,7 +239,7 @@ func CheckSignedTicket(split, ticket *wire.MsgTx, params *chaincfg.Params) error
 	}
 
 	// ensure that the ticket fee being used will actually allow the ticket to be
-	// mined (fee rate much lower than 0.001 DCR/KB might block the ticket).
+	// mined (fee rate lower than the negotiated rate might block the ticket).
 	// This needs to be done after signing to verify that after accounting for
 	// the actual signatures, the ticket can be published.
 	totalAmountOut := ticket.TxOut[0].Value
@@ -246,7 +250,7 @@ func CheckSignedTicket(split, ticket *wire.MsgTx, params *chaincfg.Params) error
 	}
 	txFee := totalAmountIn - totalAmountOut
 	serializedSize := int64(ticket.SerializeSize())
-	minFee := (serializedSize * int64(TxFeeRate)) / 1000
+	minFee := (serializedSize * int64(txFeeRate)) / 1000
 	if txFee < minFee {
 		return errors.Errorf("ticket fee (%s) less than minimum required amount (%s)",
 			dcrutil.Amount(txFee), dcrutil.Amount(minFee))
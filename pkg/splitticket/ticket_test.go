@@ -35,7 +35,7 @@ func TestStopsWrongTicketFees(t *testing.T) {
 	testPartFunc := func(nbParts int) func(t *testing.T) {
 		return func(t *testing.T) {
 			t.Parallel()
-			acceptableFee := SessionParticipantFee(nbParts)
+			acceptableFee := SessionParticipantFee(nbParts, TxFeeRate)
 			partFeesToTest := []dcrutil.Amount{
 				0, 1, 10, 100, 1000, 10000, // very small fees
 				1000000, 10000000, 100000000, 1000000000, // very high fees
@@ -72,7 +72,7 @@ func TestStopsWrongPoolFees(t *testing.T) {
 			// that the service used a pool fee higher than he had agreed to.
 			delta := 0.16
 
-			partFee := SessionParticipantFee(nbParts)
+			partFee := SessionParticipantFee(nbParts, TxFeeRate)
 			data := createBaseTestData(nbParts)
 			data.fillParticipationData(ticketPrice, partFee, poolFeeRate+delta)
 			data.fillAddressesAndKeys()
@@ -121,3 +121,32 @@ func TestCheckTicketStopsWrongValueIn(t *testing.T) {
 			"error")
 	}
 }
+
+// TestCheckSignedTicketNegotiatedFeeRate tests whether CheckSignedTicket
+// validates the ticket fee against the fee rate negotiated for the session.
+func TestCheckSignedTicketNegotiatedFeeRate(t *testing.T) {
+	ticketPrice := dcrutil.Amount(200 * dcrutil.AtomsPerCoin)
+	nbParts := 10
+	feeRate := TxFeeRate * 3
+
+	data := createBaseTestData(nbParts)
+	data.fillParticipationData(ticketPrice,
+		SessionParticipantFee(nbParts, feeRate), 5.0)
+	data.fillAddressesAndKeys()
+	split, ticket := data.createTestTransactions()
+	data.signTicket(split, ticket)
+
+	err := CheckSignedTicket(split, ticket, feeRate, _testNetwork)
+	if err != nil {
+		t.Fatalf("ticket paying the negotiated fee rate should be valid: %v",
+			err)
+	}
+
+	for _, rate := range []dcrutil.Amount{TxFeeRate, feeRate * 2} {
+		err = CheckSignedTicket(split, ticket, rate, _testNetwork)
+		if err == nil {
+			t.Fatalf("ticket paying fee rate %s should not be valid when "+
+				"the negotiated rate is %s", feeRate, rate)
+		}
+	}
+}
@@ -110,15 +110,35 @@ func UtxoMapFromDcrdata(dcrdataURL string, tx *wire.MsgTx) (UtxoMap, error) {
 	return UtxoMapOutpointsFromDcrdata(dcrdataURL, outpoints)
 }
 
+// UtxoMapFromDcrdataClient is the same as UtxoMapFromDcrdata, but performs the
+// queries with the given http client (eg: one that connects through a proxy).
+func UtxoMapFromDcrdataClient(client *http.Client, dcrdataURL string,
+	tx *wire.MsgTx) (UtxoMap, error) {
+
+	outpoints := make([]*wire.OutPoint, len(tx.TxIn))
+	for i, in := range tx.TxIn {
+		outpoints[i] = &in.PreviousOutPoint
+	}
+	return UtxoMapOutpointsFromDcrdataClient(client, dcrdataURL, outpoints)
+}
+
 // UtxoMapOutpointsFromDcrdata queries the dcrdata server for the outpoints of
 // the given transaction and returns an utxo map for use in validation
 // functions.
 func UtxoMapOutpointsFromDcrdata(dcrdataURL string, outpoints []*wire.OutPoint) (UtxoMap, error) {
+	client := &http.Client{Timeout: time.Second * 10}
+	return UtxoMapOutpointsFromDcrdataClient(client, dcrdataURL, outpoints)
+}
+
+// UtxoMapOutpointsFromDcrdataClient is the same as
+// UtxoMapOutpointsFromDcrdata, but performs the queries with the given http
+// client.
+func UtxoMapOutpointsFromDcrdataClient(client *http.Client, dcrdataURL string,
+	outpoints []*wire.OutPoint) (UtxoMap, error) {
 
 	// Ideally, this should be a batched call, but dcrdata doesn't currently
 	// have one that will return the pkscript of multiple utxos.
 
-	client := http.Client{Timeout: time.Second * 10}
 	utxos := make(UtxoMap, len(outpoints))
 	respTxOut := new(dcrdatatypes.TxOut)
 
@@ -201,6 +221,14 @@ func StakeDiffChangeDistance(blockHeight uint32, params *chaincfg.Params) int32
 	return baseDist
 }
 
+// BlocksToStakeDiffChange returns the number of blocks until the next block
+// where the stake difficulty (ticket price) changes, given the current block
+// height. This is a full window when the current block is a change block.
+func BlocksToStakeDiffChange(blockHeight uint32, params *chaincfg.Params) int32 {
+	winSize := int32(params.StakeDiffWindowSize)
+	return winSize - int32(blockHeight)%winSize
+}
+
 // TargetTicketExpirationBlock calculates the expected expiration block for a
 // ticket, given the current block height and a maximum expiry value.
 //
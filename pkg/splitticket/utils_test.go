@@ -72,12 +72,12 @@ func (d *testSessionData) checkTicket(split, ticket *wire.MsgTx) error {
 
 // checkSignedTicket checks the given signed ticket against the given session data
 func (d *testSessionData) checkSignedTicket(split, ticket *wire.MsgTx) error {
-	return CheckSignedTicket(split, ticket, _testNetwork)
+	return CheckSignedTicket(split, ticket, TxFeeRate, _testNetwork)
 }
 
 // checkSignedSplit checks the given signed ticket against the given session data
 func (d *testSessionData) checkSignedSplit(split *wire.MsgTx) error {
-	return CheckSignedSplit(split, d.splitUtxoMap, _testNetwork)
+	return CheckSignedSplit(split, d.splitUtxoMap, TxFeeRate, _testNetwork)
 }
 
 // signTicket signs the ticket transaction with the keys recorded in sessionData.
@@ -231,7 +231,7 @@ func (d *testSessionData) fillParticipationData(ticketPrice,
 
 	d.partTicketFee = partTicketFee
 	d.totalPoolFee = SessionPoolFee(d.nbParts, ticketPrice,
-		int(d.currentBlockHeight), poolFeeRate, _testNetwork)
+		int(d.currentBlockHeight), poolFeeRate, TxFeeRate, _testNetwork)
 	d.ticketPrice = ticketPrice
 
 	maxAmounts := make([]dcrutil.Amount, d.nbParts)
@@ -338,7 +338,7 @@ func createBaseTestData(nbParts int) *testSessionData {
 func createStdTestData(nbParts int) *testSessionData {
 
 	ticketPrice := dcrutil.Amount(200 * dcrutil.AtomsPerCoin)
-	partTicketFee := SessionParticipantFee(nbParts)
+	partTicketFee := SessionParticipantFee(nbParts, TxFeeRate)
 
 	data := createBaseTestData(nbParts)
 	data.fillParticipationData(ticketPrice, partTicketFee, 5.0)
@@ -395,3 +395,23 @@ func TestStdTestDataCreation(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocksToStakeDiffChange(t *testing.T) {
+	winSize := uint32(_testNetwork.StakeDiffWindowSize)
+	tests := []struct {
+		height uint32
+		want   int32
+	}{
+		{winSize * 10, int32(winSize)},
+		{winSize*10 + 1, int32(winSize) - 1},
+		{winSize*11 - 1, 1},
+	}
+
+	for _, tc := range tests {
+		got := BlocksToStakeDiffChange(tc.height, _testNetwork)
+		if got != tc.want {
+			t.Errorf("unexpected distance at height %d: got %d, want %d",
+				tc.height, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package splitticket
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaximumVoteChoices is the maximum number of agenda choices a single
+	// participant may request to be used by the voter of a split ticket.
+	MaximumVoteChoices = 32
+
+	// MaximumVoteChoiceIDSize is the maximum size (in bytes) of an agenda or
+	// choice id.
+	MaximumVoteChoiceIDSize = 64
+
+	// signedMessagePrefix is the prefix used by decred wallets when signing
+	// arbitrary messages.
+	signedMessagePrefix = "Decred Signed Message:\n"
+)
+
+// VoteChoice is a single choice (ie, "yes", "no", "abstain") of an agenda that
+// a participant of a split ticket wants the voter to use.
+type VoteChoice struct {
+	AgendaID string
+	ChoiceID string
+}
+
+// VoteChoices is the list of vote preferences of a split ticket participant.
+type VoteChoices []VoteChoice
+
+// ParseVoteChoices parses a list of vote choices in the format
+// "agenda1:choice1,agenda2:choice2". An empty string returns an empty list of
+// choices.
+func ParseVoteChoices(s string) (VoteChoices, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	items := strings.Split(s, ",")
+	res := make(VoteChoices, len(items))
+	for i, item := range items {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 {
+			return nil, errors.Errorf("vote choice %d (%s) is not in the "+
+				"format agenda:choice", i, item)
+		}
+		res[i] = VoteChoice{AgendaID: parts[0], ChoiceID: parts[1]}
+	}
+
+	if err := res.Check(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func validVoteChoiceID(id string) bool {
+	if len(id) == 0 || len(id) > MaximumVoteChoiceIDSize {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9') || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Check verifies whether the list of vote choices is well formed: it must not
+// have more than MaximumVoteChoices entries, the ids must be non-empty
+// alphanumeric strings and each agenda must appear at most once.
+func (choices VoteChoices) Check() error {
+	if len(choices) > MaximumVoteChoices {
+		return errors.Errorf("too many vote choices (%d > %d)", len(choices),
+			MaximumVoteChoices)
+	}
+
+	seen := make(map[string]struct{}, len(choices))
+	for i, c := range choices {
+		if !validVoteChoiceID(c.AgendaID) {
+			return errors.Errorf("invalid agenda id in vote choice %d", i)
+		}
+		if !validVoteChoiceID(c.ChoiceID) {
+			return errors.Errorf("invalid choice id in vote choice %d", i)
+		}
+		if _, has := seen[c.AgendaID]; has {
+			return errors.Errorf("agenda %s specified multiple times",
+				c.AgendaID)
+		}
+		seen[c.AgendaID] = struct{}{}
+	}
+
+	return nil
+}
+
+// String returns the choices in the same format accepted by ParseVoteChoices.
+func (choices VoteChoices) String() string {
+	items := make([]string, len(choices))
+	for i, c := range choices {
+		items[i] = c.AgendaID + ":" + c.ChoiceID
+	}
+	return strings.Join(items, ",")
+}
+
+// LatestVoteVersion returns the highest vote version that has agendas defined
+// on the given network.
+func LatestVoteVersion(params *chaincfg.Params) uint32 {
+	var latest uint32
+	for version := range params.Deployments {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// VoteBits returns the vote bits that encode the choices for the agendas of the
+// given vote version of the network. The returned bits always approve the
+// previous block, and agendas not present in the choices are left with the
+// abstain (zero) bits.
+func (choices VoteChoices) VoteBits(voteVersion uint32,
+	params *chaincfg.Params) (uint16, error) {
+
+	voteBits := uint16(1)
+	deployments := params.Deployments[voteVersion]
+
+	for _, c := range choices {
+		found := false
+		for _, d := range deployments {
+			if d.Vote.Id != c.AgendaID {
+				continue
+			}
+
+			for _, choice := range d.Vote.Choices {
+				if choice.Id != c.ChoiceID {
+					continue
+				}
+				voteBits = voteBits&^d.Vote.Mask | choice.Bits
+				found = true
+				break
+			}
+
+			if !found {
+				return 0, errors.Errorf("choice %s does not exist for "+
+					"agenda %s", c.ChoiceID, c.AgendaID)
+			}
+			break
+		}
+
+		if !found {
+			return 0, errors.Errorf("agenda %s does not exist on vote "+
+				"version %d", c.AgendaID, voteVersion)
+		}
+	}
+
+	return voteBits, nil
+}
+
+// VoteChoicesSignMessage returns the message that needs to be signed by the
+// key of the ticket commitment address of a participant in order to attest to
+// the given vote choices. The choices are sorted by agenda id, so the order
+// in which they were specified is irrelevant.
+func VoteChoicesSignMessage(choices VoteChoices, voteAddr dcrutil.Address) string {
+	sorted := make(VoteChoices, len(choices))
+	copy(sorted, choices)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].AgendaID < sorted[j].AgendaID
+	})
+
+	return fmt.Sprintf("split ticket vote choices for %s: %s",
+		voteAddr.EncodeAddress(), sorted.String())
+}
+
+// CheckVoteChoicesSignature verifies whether the signature is a valid
+// signature of the vote choices message (see VoteChoicesSignMessage) by the
+// private key of the given commitment address.
+func CheckVoteChoicesSignature(choices VoteChoices, voteAddr,
+	commitAddr dcrutil.Address, signature []byte) error {
+
+	if err := choices.Check(); err != nil {
+		return err
+	}
+
+	msg := VoteChoicesSignMessage(choices, voteAddr)
+
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, signedMessagePrefix)
+	wire.WriteVarString(&buf, 0, msg)
+	msgHash := chainhash.HashB(buf.Bytes())
+
+	pk, wasCompressed, err := secp256k1.RecoverCompact(signature, msgHash)
+	if err != nil {
+		return errors.Wrap(err, "error recovering public key from vote "+
+			"choices signature")
+	}
+
+	var serializedPK []byte
+	if wasCompressed {
+		serializedPK = pk.SerializeCompressed()
+	} else {
+		serializedPK = pk.SerializeUncompressed()
+	}
+	recoveredAddr, err := dcrutil.NewAddressSecpPubKey(serializedPK,
+		commitAddr.Net())
+	if err != nil {
+		return errors.Wrap(err, "error creating address from recovered pubkey")
+	}
+
+	if recoveredAddr.EncodeAddress() != commitAddr.EncodeAddress() {
+		return errors.Errorf("vote choices not signed by commitment "+
+			"address %s", commitAddr.EncodeAddress())
+	}
+
+	return nil
+}
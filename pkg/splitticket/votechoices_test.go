@@ -0,0 +1,155 @@
+package splitticket
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+)
+
+func TestParseVoteChoices(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s     string
+		valid bool
+		len   int
+	}{
+		{"", true, 0},
+		{"sdiffalgorithm:yes", true, 1},
+		{" sdiffalgorithm:yes, lnsupport:no ", true, 2},
+		{"sdiffalgorithm", false, 0},
+		{"sdiffalgorithm:yes:no", false, 0},
+		{"sdiffalgorithm:yes,sdiffalgorithm:no", false, 0},
+		{":yes", false, 0},
+		{"sdiffalgorithm:", false, 0},
+		{"sdiff algorithm:yes", false, 0},
+	}
+
+	for i, tc := range tests {
+		choices, err := ParseVoteChoices(tc.s)
+		if tc.valid && err != nil {
+			t.Fatalf("case %d returned unexpected error: %v", i, err)
+		}
+		if !tc.valid && err == nil {
+			t.Fatalf("case %d should have returned an error", i)
+		}
+		if len(choices) != tc.len {
+			t.Fatalf("case %d returned %d choices (expected %d)", i,
+				len(choices), tc.len)
+		}
+	}
+}
+
+func TestCheckVoteChoicesSignature(t *testing.T) {
+	t.Parallel()
+
+	signMsg := func(key *secp256k1.PrivateKey, msg string) []byte {
+		var buf bytes.Buffer
+		wire.WriteVarString(&buf, 0, signedMessagePrefix)
+		wire.WriteVarString(&buf, 0, msg)
+		sig, err := secp256k1.SignCompact(key, chainhash.HashB(buf.Bytes()), true)
+		if err != nil {
+			t.Fatalf("error signing message: %v", err)
+		}
+		return sig
+	}
+
+	newAddr := func(key *secp256k1.PrivateKey) dcrutil.Address {
+		pk := (*secp256k1.PublicKey)(&key.PublicKey)
+		addr, err := dcrutil.NewAddressSecpPubKey(pk.SerializeCompressed(),
+			_testNetwork)
+		if err != nil {
+			t.Fatalf("error creating address: %v", err)
+		}
+		return addr
+	}
+
+	commitKey, _ := secp256k1.GeneratePrivateKey()
+	otherKey, _ := secp256k1.GeneratePrivateKey()
+	commitAddr := newAddr(commitKey)
+	voteAddr := newAddr(otherKey)
+
+	choices := VoteChoices{
+		{AgendaID: "sdiffalgorithm", ChoiceID: "yes"},
+		{AgendaID: "lnsupport", ChoiceID: "no"},
+	}
+	reordered := VoteChoices{choices[1], choices[0]}
+	sig := signMsg(commitKey, VoteChoicesSignMessage(choices, voteAddr))
+
+	err := CheckVoteChoicesSignature(choices, voteAddr, commitAddr, sig)
+	if err != nil {
+		t.Fatalf("valid signature returned error: %v", err)
+	}
+
+	err = CheckVoteChoicesSignature(reordered, voteAddr, commitAddr, sig)
+	if err != nil {
+		t.Fatalf("valid signature of reordered choices returned error: %v", err)
+	}
+
+	err = CheckVoteChoicesSignature(choices, commitAddr, commitAddr, sig)
+	if err == nil {
+		t.Fatalf("signature for a different vote address should not be valid")
+	}
+
+	err = CheckVoteChoicesSignature(choices[:1], voteAddr, commitAddr, sig)
+	if err == nil {
+		t.Fatalf("signature for different choices should not be valid")
+	}
+
+	otherSig := signMsg(otherKey, VoteChoicesSignMessage(choices, voteAddr))
+	err = CheckVoteChoicesSignature(choices, voteAddr, commitAddr, otherSig)
+	if err == nil {
+		t.Fatalf("signature by a different key should not be valid")
+	}
+}
+
+func TestVoteChoicesVoteBits(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+
+	tests := []struct {
+		choices  string
+		version  uint32
+		voteBits uint16
+		valid    bool
+	}{
+		{"", 4, 0x01, true},
+		{"sdiffalgorithm:yes", 4, 0x05, true},
+		{"sdiffalgorithm:no", 4, 0x03, true},
+		{"sdiffalgorithm:yes,lnsupport:yes", 4, 0x15, true},
+		{"lnsupport:no,sdiffalgorithm:abstain", 4, 0x09, true},
+		{"lnfeatures:yes", 5, 0x05, true},
+		{"lnfeatures:yes", 4, 0, false},
+		{"sdiffalgorithm:maybe", 4, 0, false},
+	}
+
+	for i, tc := range tests {
+		choices, err := ParseVoteChoices(tc.choices)
+		if err != nil {
+			t.Fatalf("case %d returned error on parse: %v", i, err)
+		}
+
+		voteBits, err := choices.VoteBits(tc.version, params)
+		if tc.valid && err != nil {
+			t.Fatalf("case %d returned unexpected error: %v", i, err)
+		}
+		if !tc.valid && err == nil {
+			t.Fatalf("case %d should have returned an error", i)
+		}
+		if voteBits != tc.voteBits {
+			t.Fatalf("case %d returned vote bits %x (expected %x)", i,
+				voteBits, tc.voteBits)
+		}
+	}
+
+	if LatestVoteVersion(params) != 5 {
+		t.Fatalf("unexpected latest vote version %d",
+			LatestVoteVersion(params))
+	}
+}